@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"golang_lb/client"
+	"golang_lb/server"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func printClientUsage() {
+	fmt.Println("Usage: golang_lb client [-addr URL] [-api-key KEY] <command>")
+	fmt.Println("\n📋 Commands:")
+	fmt.Println("  task [..] <text>             - Submit a task and wait for the result; --lane batch, --timeout 2s")
+	fmt.Println("  explain [..] <text>          - Submit a task and show the routing decision; takes task's flags")
+	fmt.Println("  status [--watch [1s]]        - Show all servers status, re-rendered with changes highlighted")
+	fmt.Println("  ping <id>                    - Ping a specific server")
+	fmt.Println("  state <id> <state>           - Set server state (active|paused|draining|quarantined|standby)")
+	fmt.Println("  trini <on|off|status>        - TRINI GC-aware control; trini status takes --watch [1s]")
+	fmt.Println("  trini policy <alg> <ms> [..] - Set the load balancing policy, tiebreaker and GC avoidance")
+	fmt.Println("  tune [--apply]               - Run a calibration workload and recommend a policy; --duration 30s, --rate 2")
+	fmt.Println("  events [limit]               - Show recent load balancer events")
+	fmt.Println("  watch                        - Stream load balancer events until interrupted")
+}
+
+// runClient drives a remote backend server through the client SDK and returns the exit code
+func runClient(args []string) int {
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	addr := fs.String("addr", client.DefaultBaseURL, "Backend server base URL")
+	apiKey := fs.String("api-key", "", "API key sent as X-API-Key")
+	fs.Usage = printClientUsage
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		printClientUsage()
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	c := client.New(*addr, client.WithAPIKey(*apiKey))
+	if err := runClientCommand(ctx, c, fs.Args()); err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseTaskOptions reads the lane and timeout flags of the task and explain
+// commands, which precede the task text
+func parseTaskOptions(command string, args []string) (client.TaskOptions, string, error) {
+	var opts client.TaskOptions
+	fs := flag.NewFlagSet(command, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.Lane, "lane", "", "Dispatch lane: interactive (default) or batch")
+	fs.DurationVar(&opts.Timeout, "timeout", 0, "Server-side execution timeout, at most 5s (default: the policy's)")
+	usage := fmt.Errorf("usage: %s [--lane interactive|batch] [--timeout 2s] <your_task_string>", command)
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return opts, "", usage
+	}
+
+	opts.Lane = strings.ToLower(opts.Lane)
+	if opts.Lane != "" && !server.IsValidLane(opts.Lane) {
+		return opts, "", fmt.Errorf("invalid lane %q: use interactive or batch", opts.Lane)
+	}
+	if opts.Timeout < 0 || opts.Timeout.Milliseconds() > server.MaxTaskTimeoutMs {
+		return opts, "", fmt.Errorf("invalid timeout %v: use up to %dms", opts.Timeout, server.MaxTaskTimeoutMs)
+	}
+	return opts, strings.Join(fs.Args(), " "), nil
+}
+
+func runClientCommand(ctx context.Context, c *client.Client, args []string) error {
+	command := strings.ToLower(args[0])
+	args = args[1:]
+
+	switch command {
+	case "task", "explain":
+		opts, text, err := parseTaskOptions(command, args)
+		if err != nil {
+			return err
+		}
+		opts.Explain = command == "explain"
+		response, err := c.SubmitTaskWithOptions(ctx, text, opts)
+		if err != nil {
+			return err
+		}
+		printTaskResponse(response)
+
+	case "status":
+		_, interval, watching, err := parseWatch(args)
+		if err != nil {
+			return err
+		}
+		render := func(w io.Writer) error {
+			status, err := c.Status(ctx)
+			if err != nil {
+				return err
+			}
+			printClientStatus(w, status)
+			return nil
+		}
+		if watching {
+			watch(ctx, "status", "Ctrl+C to stop", interval, render)
+			return nil
+		}
+		return render(os.Stdout)
+
+	case "ping":
+		if len(args) == 0 {
+			return fmt.Errorf("usage: ping <server_id>")
+		}
+		serverID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid server ID")
+		}
+		srv, err := c.Ping(ctx, serverID)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🏓 Ping Server %d:\n", srv.ServerID)
+		fmt.Printf("   Status: %s\n", srv.Status)
+		fmt.Printf("   Available: %v\n", srv.IsAvailable)
+		fmt.Printf("   Memory Usage: %s\n", srv.MemoryUsage)
+		fmt.Printf("   Collecting GC: %v\n", srv.IsCollectingGC)
+		fmt.Printf("   State: %s\n", srv.AdminState)
+		fmt.Printf("   Tasks Processed: %d\n", srv.TasksProcessed)
+		if srv.MaxConcurrent > 0 {
+			fmt.Printf("   Concurrent Tasks: %d/%d\n", srv.ActiveTasks, srv.MaxConcurrent)
+		}
+
+	case "state":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: state <server_id> <active|paused|draining|quarantined|standby>")
+		}
+		serverID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid server ID")
+		}
+		state := strings.ToLower(args[1])
+		if err := c.SetServerState(ctx, serverID, state); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Server %d is now %s\n", serverID, state)
+
+	case "trini":
+		return runClientTRINI(ctx, c, args)
+
+	case "tune":
+		return runClientTune(ctx, c, args)
+
+	case "events":
+		limit := 20
+		if len(args) > 0 {
+			parsed, err := strconv.Atoi(args[0])
+			if err != nil || parsed <= 0 {
+				return fmt.Errorf("invalid limit")
+			}
+			limit = parsed
+		}
+		events, err := c.Events(ctx, limit)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			printEvent(event)
+		}
+
+	case "watch":
+		fmt.Println("👀 Watching events (Ctrl+C to stop)...")
+		return c.StreamEvents(ctx, printEvent)
+
+	default:
+		printClientUsage()
+		return fmt.Errorf("unknown command: %s", command)
+	}
+	return nil
+}
+
+func runClientTRINI(ctx context.Context, c *client.Client, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: trini <on|off|status|policy>")
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "on", "off":
+		active := strings.ToLower(args[0]) == "on"
+		if err := c.SetTRINIActive(ctx, active); err != nil {
+			return err
+		}
+		if active {
+			fmt.Println("✅ TRINI GC-aware load balancing enabled")
+		} else {
+			fmt.Println("⚠️ TRINI GC-aware load balancing disabled")
+		}
+
+	case "status":
+		_, interval, watching, err := parseWatch(args[1:])
+		if err != nil {
+			return err
+		}
+		render := func(w io.Writer) error {
+			status, err := c.TRINIStatus(ctx)
+			if err != nil {
+				return err
+			}
+			printClientTRINIStatus(w, status)
+			return nil
+		}
+		if watching {
+			watch(ctx, "trini status", "Ctrl+C to stop", interval, render)
+			return nil
+		}
+		return render(os.Stdout)
+
+	case "policy":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: trini policy <algorithm> <threshold_ms> [order|confidence] [hard|soft]")
+		}
+		threshold, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid threshold value")
+		}
+		policy := server.LoadBalancingPolicy{
+			Algorithm:         strings.ToUpper(args[1]),
+			GCAware:           true,
+			MaGCThreshold:     threshold,
+			HistoryWindowSize: 30,
+		}
+		applyPolicyOptions(&policy, args[3:])
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("invalid policy: %w", err)
+		}
+		if err := c.SetPolicy(ctx, policy); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Policy set to %s (threshold %dms)\n", policy.Algorithm, policy.MaGCThreshold)
+
+	default:
+		return fmt.Errorf("unknown TRINI command: %s", args[0])
+	}
+	return nil
+}
+
+func printClientStatus(w io.Writer, status *client.Status) {
+	fmt.Fprintln(w, "📊 Load Balancer Status:")
+	fmt.Fprintf(w, "   Total Servers: %d\n", status.TotalServers)
+	for _, srv := range status.Servers {
+		fmt.Fprintf(w, "   Server %d: %s (Tasks: %d, Memory: %s)\n",
+			srv.ServerID, serverStatusLabel(srv), srv.TasksProcessed, srv.MemUsed)
+	}
+	fmt.Fprintf(w, "   Available Servers: %d/%d\n", status.AvailableServers, status.TotalServers)
+}
+
+func printClientTRINIStatus(w io.Writer, status *client.TRINIStatus) {
+	fmt.Fprintln(w, "\n🔍 TRINI Status:")
+	fmt.Fprintf(w, "   Active: %t\n", status.Active)
+	fmt.Fprintf(w, "   Monitor Interval: %s\n", status.MonitorInterval)
+	if status.MinMonitorInterval != "" {
+		fmt.Fprintf(w, "   Adaptive Interval: %s (%s-%s)\n", status.CurrentMonitorInterval, status.MinMonitorInterval, status.MaxMonitorInterval)
+	}
+	fmt.Fprintf(w, "   Analysis Interval: %s\n", status.AnalysisInterval)
+	fmt.Fprintf(w, "   Program Families: %d\n", status.ProgramFamilies)
+	fmt.Fprintln(w, "\n📊 Server Family Classifications:")
+	for _, srv := range status.Servers {
+		if srv.CurrentFamily == nil {
+			fmt.Fprintf(w, "   Server %d: Not classified\n", srv.ServerID)
+			continue
+		}
+		fmt.Fprintf(w, "   Server %d: %s\n", srv.ServerID, srv.CurrentFamily.Name)
+		if srv.LastMaGCForecast != nil {
+			fmt.Fprintf(w, "     Next MaGC in: %dms (confidence: %.2f)\n",
+				srv.LastMaGCForecast.TimeToMaGC, srv.LastMaGCForecast.Confidence)
+		}
+	}
+	fmt.Fprintln(w, "\n🔧 Current Policy:")
+	fmt.Fprintf(w, "   Algorithm: %s\n", status.CurrentPolicy.Algorithm)
+	fmt.Fprintf(w, "   GC-Aware: %t\n", status.CurrentPolicy.GCAware)
+	fmt.Fprintf(w, "   MaGC Threshold: %dms\n", status.CurrentPolicy.MaGCThreshold)
+	if status.CurrentPolicy.Tiebreaker != "" {
+		fmt.Fprintf(w, "   Tiebreaker: %s\n", status.CurrentPolicy.Tiebreaker)
+	}
+	if status.CurrentPolicy.GCAvoidance != "" {
+		fmt.Fprintf(w, "   GC Avoidance: %s\n", status.CurrentPolicy.GCAvoidance)
+	}
+}
+
+func serverStatusLabel(srv client.ServerStatus) string {
+	switch {
+	case srv.AdminState != "" && srv.AdminState != server.StateActive:
+		return "⏸️  " + strings.ToUpper(srv.AdminState[:1]) + srv.AdminState[1:]
+	case srv.IsCollectingGC:
+		return "🟡 GC Mode"
+	case !srv.IsAvailable:
+		return "🔴 Busy"
+	}
+	return "🟢 Available"
+}
+
+func printTaskResponse(response *client.TaskResponse) {
+	switch response.Status {
+	case client.TaskCompleted:
+		fmt.Printf("🎉 TASK COMPLETED: '%s' (ID: %s)\n", response.Output, response.TaskID)
+	case client.TaskRejected:
+		fmt.Printf("❌ TASK REJECTED: %s (ID: %s)\n", response.Message, response.TaskID)
+	default:
+		fmt.Printf("⏳ %s: %s\n", response.Status, response.Message)
+	}
+	if response.GCImminent > 0 {
+		fmt.Printf("⚠️ MaGC imminent on that server in %v\n", response.GCImminent)
+	}
+
+	if explanation := response.Explanation; explanation != nil {
+		fmt.Printf("🧭 Server %d chosen by %s (GC-aware: %t, attempts: %d)\n",
+			explanation.ServerID, explanation.Algorithm, explanation.GCAware, explanation.Attempts)
+		for _, skipped := range explanation.Skipped {
+			fmt.Printf("   Skipped server %d: %s\n", skipped.ServerID, skipped.Reason)
+		}
+	}
+}
+
+func printEvent(event server.Event) {
+	fmt.Printf("%s [%s] %s\n", event.Timestamp.Format(time.TimeOnly), event.Type, event.Message)
+}
@@ -0,0 +1,429 @@
+// Package client is a Go SDK for the load balancer's REST API.
+//
+// The load balancer only exposes REST (plus server-sent events for the event
+// stream), so every call here goes over HTTP/JSON.
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang_lb/server"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultBaseURL is where the backend server listens by default
+	DefaultBaseURL = "http://localhost:8080"
+	// defaultMaxRetries is how often SubmitTask retries a throttled or unavailable request
+	defaultMaxRetries = 3
+	// defaultRetryBackoff is the delay before the first retry; it doubles per attempt
+	defaultRetryBackoff = 200 * time.Millisecond
+	// maxRetryAfter is the longest Retry-After a 429 is waited out for; longer
+	// ones, such as a spent task quota, are returned to the caller
+	maxRetryAfter = 10 * time.Second
+)
+
+// Client calls the load balancer API. It is safe for concurrent use.
+type Client struct {
+	baseURL      string
+	httpClient   *http.Client
+	apiKey       string
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// Option configures a Client created by New
+type Option func(*Client)
+
+// WithHTTPClient replaces the default HTTP client, e.g. to set timeouts or TLS config
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey sends the key as X-API-Key on every request
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithRetries sets how often SubmitTask retries and the initial backoff
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// New creates a client for the API at baseURL, e.g. http://localhost:8080
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// APIError is returned when the server answers with a non-2xx status
+type APIError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // From the Retry-After header, 0 if absent
+
+	body []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("load balancer returned %d: %s", e.StatusCode, e.Message)
+}
+
+// notSentError is a connection failure before the request was written, so
+// the server cannot have acted on it
+type notSentError struct {
+	err error
+}
+
+func (e *notSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notSentError) Unwrap() error {
+	return e.err
+}
+
+// retryable reports whether a request failing with err may succeed if
+// repeated without running it twice: throttled or unavailable responses, and
+// connection failures before the request was written. A request lost after
+// it was written may have been acted on, so it is not repeated.
+func retryable(err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			return apiErr.RetryAfter <= maxRetryAfter
+		}
+		return apiErr.StatusCode == http.StatusServiceUnavailable
+	}
+	var notSent *notSentError
+	return errors.As(err, &notSent)
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
+
+// newRequest builds a request against the API, encoding body as JSON if set
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	return req, nil
+}
+
+// do sends one request and decodes the JSON response into out, if set
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	// Only a failure before the request is written is safe to repeat
+	var written atomic.Bool
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			written.Store(true)
+		},
+	}))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if !written.Load() {
+			return &notSentError{err: err}
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if receiver, ok := out.(headerReader); ok {
+		receiver.readHeaders(resp.Header)
+	}
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// headerReader is implemented by responses that carry data in headers
+type headerReader interface {
+	readHeaders(http.Header)
+}
+
+// checkResponse turns a non-2xx response into an APIError. The server reports
+// errors either as plain text or as {"error": "..."}.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := strings.TrimSpace(string(data))
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		message = body.Error
+	}
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    message,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		body:       data,
+	}
+}
+
+// withRetries runs call until it succeeds, fails permanently or retries run out
+func (c *Client) withRetries(ctx context.Context, call func() error) error {
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := call()
+		if err == nil || attempt >= c.maxRetries || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		// Wait at least as long as the server asked
+		delay := backoff
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > delay {
+			delay = apiErr.RetryAfter
+		}
+		select {
+		case <-time.After(delay):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// SubmitTask submits a task and waits for its result. Requests that are
+// throttled (429), hit an unavailable server (503) or fail to connect are
+// retried with exponential backoff, waiting at least the Retry-After a 429
+// asks for; a 429 asking for more than 10s, such as a spent quota, is
+// returned. A request whose response is lost is not resubmitted, as the task
+// may have run. A rejected or timed out task is not an error; check
+// TaskResponse.Status.
+func (c *Client) SubmitTask(ctx context.Context, task string) (*TaskResponse, error) {
+	return c.SubmitTaskWithOptions(ctx, task, TaskOptions{})
+}
+
+// SubmitTaskExplained submits a task like SubmitTask and also returns why the
+// server was chosen
+func (c *Client) SubmitTaskExplained(ctx context.Context, task string) (*TaskResponse, error) {
+	return c.SubmitTaskWithOptions(ctx, task, TaskOptions{Explain: true})
+}
+
+// SubmitTaskWithOptions submits a task like SubmitTask in the given lane and
+// with the given execution timeout
+func (c *Client) SubmitTaskWithOptions(ctx context.Context, task string, opts TaskOptions) (*TaskResponse, error) {
+	path := "/api/v1/task"
+	if opts.Explain {
+		path += "?explain=true"
+	}
+	request := taskRequest{Task: task, Type: opts.Lane, TimeoutMs: opts.Timeout.Milliseconds()}
+
+	var response TaskResponse
+	err := c.withRetries(ctx, func() error {
+		response = TaskResponse{}
+		err := c.do(ctx, http.MethodPost, path, request, &response)
+
+		// The server reports a task timeout as 408 with a regular task response
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusRequestTimeout {
+			if json.Unmarshal(apiErr.body, &response) != nil || response.Status == "" {
+				response = TaskResponse{Status: TaskTimeout, Message: "Task processing timeout"}
+			}
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// TaskTrace returns the decision and outcome of a finished task, by the
+// task ID its submission returned
+func (c *Client) TaskTrace(ctx context.Context, taskID string) (*server.TaskTrace, error) {
+	var trace server.TaskTrace
+	if err := c.do(ctx, http.MethodGet, "/api/v1/task/"+url.PathEscape(taskID)+"/trace", nil, &trace); err != nil {
+		return nil, err
+	}
+	return &trace, nil
+}
+
+// Status returns the state of every server in the pool
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.do(ctx, http.MethodGet, "/api/v1/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Ping returns the state of one server
+func (c *Client) Ping(ctx context.Context, serverID int) (*ServerStatus, error) {
+	var status ServerStatus
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/server/%d/ping", serverID), nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// SetServerState changes a server's administrative state (active, paused, draining, quarantined or standby)
+func (c *Client) SetServerState(ctx context.Context, serverID int, state string) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/api/v1/server/%d/state", serverID),
+		map[string]string{"state": state}, nil)
+}
+
+// TRINIStatus returns whether TRINI is active and how servers are classified
+func (c *Client) TRINIStatus(ctx context.Context) (*TRINIStatus, error) {
+	var status TRINIStatus
+	if err := c.do(ctx, http.MethodGet, "/api/v1/trini/status", nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// CompareServers returns GC summaries for every server over the same trailing window
+func (c *Client) CompareServers(ctx context.Context, window time.Duration) ([]server.ServerGCSummary, error) {
+	query := url.Values{}
+	query.Set("window", window.String())
+
+	var response struct {
+		Servers []server.ServerGCSummary `json:"servers"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/trini/compare?"+query.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Servers, nil
+}
+
+// SetTRINIActive enables or disables TRINI
+func (c *Client) SetTRINIActive(ctx context.Context, active bool) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/trini/toggle", map[string]bool{"active": active}, nil)
+}
+
+// SetPolicy replaces the load balancing policy
+func (c *Client) SetPolicy(ctx context.Context, policy server.LoadBalancingPolicy) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/trini/policy", policy, nil)
+}
+
+// Events returns up to limit of the most recent events, oldest first
+func (c *Client) Events(ctx context.Context, limit int) ([]server.Event, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var response struct {
+		Events []server.Event `json:"events"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/events?"+query.Encode(), nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Events, nil
+}
+
+// StreamEvents calls handle for every event recorded from now on until ctx is
+// cancelled or the server closes the stream. It returns nil when ctx ends.
+func (c *Client) StreamEvents(ctx context.Context, handle func(server.Event)) error {
+	req, err := c.newRequest(ctx, http.MethodGet, "/api/v1/events/stream", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The stream is long-lived, so the client timeout must not apply
+	streamClient := *c.httpClient
+	streamClient.Timeout = 0
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, found := strings.CutPrefix(scanner.Text(), "data: ")
+		if !found {
+			continue // Event names, comments and separators
+		}
+		var event server.Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("decode event: %w", err)
+		}
+		handle(event)
+	}
+
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
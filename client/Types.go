@@ -0,0 +1,77 @@
+package client
+
+import (
+	"golang_lb/server"
+	"net/http"
+	"time"
+)
+
+// Task outcomes reported in TaskResponse.Status
+const (
+	TaskCompleted = "completed"
+	TaskRejected  = "rejected"
+	TaskTimeout   = "timeout"
+)
+
+// TaskOptions adjusts how SubmitTaskWithOptions submits a task
+type TaskOptions struct {
+	Lane    string        // server.LaneInteractive (default) or server.LaneBatch
+	Timeout time.Duration // Server-side execution timeout, at most 5s; 0 uses the policy's task_timeout_ms
+	Explain bool          // Return why the server was chosen, see SubmitTaskExplained
+}
+
+// taskRequest is the body of a task submission
+type taskRequest struct {
+	Task      string `json:"task"`
+	Type      string `json:"type,omitempty"`
+	TimeoutMs int64  `json:"timeout_ms,omitempty"`
+}
+
+// TaskResponse is the result of submitting a task
+type TaskResponse struct {
+	Status      string                       `json:"status"`
+	Message     string                       `json:"message"`
+	TaskID      string                       `json:"task_id,omitempty"`
+	Output      string                       `json:"output,omitempty"`
+	NoServer    *server.NoServerOutcome      `json:"no_server,omitempty"`   // Set if no server was eligible at first
+	Explanation *server.SelectionExplanation `json:"explanation,omitempty"` // Only from SubmitTaskExplained
+
+	// GCImminent is how soon the server that ran the task expects a MaGC
+	// pause, from the X-GC-Imminent header; 0 if none is imminent. Follow-up
+	// requests are better sent elsewhere until it has passed.
+	GCImminent time.Duration `json:"-"`
+}
+
+// readHeaders picks up response headers that are not part of the body
+func (r *TaskResponse) readHeaders(header http.Header) {
+	if value := header.Get("X-GC-Imminent"); value != "" {
+		if remaining, err := time.ParseDuration(value); err == nil {
+			r.GCImminent = remaining
+		}
+	}
+}
+
+// ServerStatus is a server's ping result
+type ServerStatus = server.ServerStatus
+
+// Status summarizes the server pool
+type Status struct {
+	TotalServers     int            `json:"total_servers"`
+	AvailableServers int            `json:"available_servers"`
+	Servers          []ServerStatus `json:"servers"`
+}
+
+// PolicySummary is the active load balancing policy as reported by TRINI status
+type PolicySummary = server.PolicyInfo
+
+// FamilySummary is the program family a server is classified into
+type FamilySummary = server.FamilyInfo
+
+// ForecastSummary is a server's latest MaGC forecast
+type ForecastSummary = server.ForecastInfo
+
+// TRINIServer is one server's GC classification and heap state
+type TRINIServer = server.TRINIServerInfo
+
+// TRINIStatus reports whether TRINI is active and how servers are classified
+type TRINIStatus = server.TRINIStatus
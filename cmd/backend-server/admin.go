@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"golang_lb/server"
+	"net/http"
+)
+
+// AdminModeRequest switches the balancer between normal and degraded operation
+type AdminModeRequest struct {
+	Mode   string `json:"mode"`             // normal or degraded
+	Reason string `json:"reason,omitempty"` // Recorded with the mode change event
+}
+
+// getAdminMode reports the operating mode
+func (h *HTTPServer) getAdminMode(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.Mode())
+}
+
+// updateAdminMode switches the operating mode; degraded mode bypasses TRINI
+// and routes with plain round-robin
+func (h *HTTPServer) updateAdminMode(w http.ResponseWriter, r *http.Request) {
+	var req AdminModeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.lb.SetMode(req.Mode, req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Operating mode updated successfully",
+		"mode":    h.lb.Mode(),
+	})
+}
+
+// degradedGauge is 1 in degraded mode, for the lb_degraded metric
+func degradedGauge(lb *server.LoadBalancer) int {
+	if lb.Mode().Mode == server.ModeDegraded {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang_lb/server"
+)
+
+// defaultCompareWindow is used when /trini/compare is called without a window
+const defaultCompareWindow = 10 * time.Minute
+
+// compareServers returns aligned GC summaries for several servers over one window
+func (h *HTTPServer) compareServers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	ids := make([]int, 0)
+	for _, value := range splitList(query.Get("servers")) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid server ID: "+value, http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	window := defaultCompareWindow
+	if value := query.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid window duration", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	summaries, err := h.lb.CompareServers(ids, window)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"window_ms": window.Milliseconds(),
+		"servers":   summaries,
+	})
+}
+
+// exportTrainingDataset downloads the labeled GC snapshot sequences leading up
+// to recorded MaGCs, for training external forecasters
+func (h *HTTPServer) exportTrainingDataset(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := server.DatasetCSV
+	if value := query.Get("format"); value != "" {
+		if !server.IsValidDatasetFormat(value) {
+			http.Error(w, "Invalid format (use csv or jsonl)", http.StatusBadRequest)
+			return
+		}
+		format = value
+	}
+
+	ids := make([]int, 0)
+	for _, value := range splitList(query.Get("servers")) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid server ID: "+value, http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	var since time.Time
+	if value := query.Get("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid window duration", http.StatusBadRequest)
+			return
+		}
+		since = time.Now().Add(-parsed)
+	}
+
+	contentType := "text/csv"
+	if format == server.DatasetJSONL {
+		contentType = "application/x-ndjson"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename=magc-dataset."+format)
+	if err := server.WriteTrainingDataset(w, format, h.lb.TrainingDataset(ids, since)); err != nil {
+		log.Printf("Training dataset export failed: %v", err)
+	}
+}
+
+// exportGCHistory streams every server's GC snapshots over a time range in
+// one download, so analysis scripts need not fetch each server's history in turn
+func (h *HTTPServer) exportGCHistory(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format := server.DatasetCSV
+	if value := query.Get("format"); value != "" {
+		if !server.IsValidDatasetFormat(value) {
+			http.Error(w, "Invalid format (use csv or jsonl)", http.StatusBadRequest)
+			return
+		}
+		format = value
+	}
+
+	servers := h.lb.ListServers()
+	if value := query.Get("servers"); value != "" {
+		servers = servers[:0:0]
+		for _, value := range splitList(value) {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid server ID: "+value, http.StatusBadRequest)
+				return
+			}
+			srv := h.lb.GetServerByID(id)
+			if srv == nil {
+				http.Error(w, "Invalid server ID: "+value, http.StatusBadRequest)
+				return
+			}
+			servers = append(servers, srv)
+		}
+	}
+
+	now := time.Now()
+	from, err := parseHistoryPoint(query.Get("from"), now)
+	if err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseHistoryPoint(query.Get("to"), now)
+	if err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	compress, _ := strconv.ParseBool(query.Get("gzip"))
+	filename := "gc-history." + format
+	contentType := "text/csv"
+	if format == server.DatasetJSONL {
+		contentType = "application/x-ndjson"
+	}
+	if compress {
+		filename += ".gz"
+		contentType = "application/gzip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+filename)
+
+	var out io.Writer = w
+	var zipped *gzip.Writer
+	if compress {
+		zipped = gzip.NewWriter(w)
+		defer zipped.Close()
+		out = zipped
+	}
+
+	// Each server's history is copied and written on its own, so no lock is
+	// held while the client reads
+	controller := http.NewResponseController(w)
+	exporter, err := server.NewGCHistoryExporter(out, format)
+	for _, srv := range servers {
+		if err != nil {
+			break
+		}
+		err = exporter.Write(srv.ID, h.lb.StoredGCHistory(srv, from, to))
+		if zipped != nil && err == nil {
+			err = zipped.Flush()
+		}
+		controller.Flush()
+	}
+	if err != nil {
+		log.Printf("GC history export failed: %v", err)
+	}
+}
+
+// getForecastAccuracy reports how often MaGC forecasts came true and how
+// reported confidence is calibrated against those outcomes
+func (h *HTTPServer) getForecastAccuracy(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.ForecastAccuracy())
+}
+
+// getGCPressure reports each server's composite GC pressure score and its components
+func (h *HTTPServer) getGCPressure(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"servers": h.lb.GCPressures(),
+	})
+}
+
+// getPolicyEngine reports how often the external policy engine decided,
+// abstained or fell back to local selection
+func (h *HTTPServer) getPolicyEngine(w http.ResponseWriter, r *http.Request) {
+	stats, enabled := h.lb.PolicyEngineStats()
+	response := map[string]interface{}{"enabled": enabled}
+	if enabled {
+		response["engine"] = stats
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// defaultCalendarHorizon and maxCalendarHorizon bound /trini/forecast-calendar
+const (
+	defaultCalendarHorizon = 5 * time.Minute
+	maxCalendarHorizon     = 30 * time.Minute
+)
+
+// getForecastCalendar returns the merged timeline of predicted MaGC pauses
+func (h *HTTPServer) getForecastCalendar(w http.ResponseWriter, r *http.Request) {
+	horizon := defaultCalendarHorizon
+	if value := r.URL.Query().Get("horizon"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > maxCalendarHorizon {
+			http.Error(w, "Invalid horizon (must be a duration up to 30m)", http.StatusBadRequest)
+			return
+		}
+		horizon = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.ForecastCalendar(horizon))
+}
+
+// getForecastCorrelation reports clusters of servers forecast to pause
+// together and the MaGCs run early to stagger them
+func (h *HTTPServer) getForecastCorrelation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.ForecastCorrelation())
+}
+
+// getGCHistoryDiff compares two points in a server's GC history: generation
+// growth, promotion and projected exhaustion
+func (h *HTTPServer) getGCHistoryDiff(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	now := time.Now()
+	from, err := parseHistoryPoint(query.Get("from"), now)
+	if err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseHistoryPoint(query.Get("to"), now)
+	if err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	diff, err := srv.GCHistoryDiff(from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// defaultTimelineLimit and maxTimelineLimit bound a page of /server/{id}/timeline
+const (
+	defaultTimelineLimit = 100
+	maxTimelineLimit     = 1000
+)
+
+// getServerTimeline returns one server's GC events, family changes, forecast
+// updates, selections, rejections and events as a chronological feed
+func (h *HTTPServer) getServerTimeline(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	now := time.Now()
+	from, err := parseHistoryPoint(query.Get("from"), now)
+	if err != nil {
+		http.Error(w, "Invalid from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to, err := parseHistoryPoint(query.Get("to"), now)
+	if err != nil {
+		http.Error(w, "Invalid to: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultTimelineLimit
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxTimelineLimit {
+			http.Error(w, fmt.Sprintf("Invalid limit (must be 1-%d)", maxTimelineLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	offset := 0
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.Timeline(srv, from, to, offset, limit))
+}
+
+// parseHistoryPoint reads an RFC 3339 timestamp or a duration before now,
+// e.g. 5m; empty is the zero time
+func parseHistoryPoint(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		if ago < 0 {
+			return time.Time{}, fmt.Errorf("duration must not be negative")
+		}
+		return now.Add(-ago), nil
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("use an RFC 3339 time or a duration ago such as 5m")
+	}
+	return at, nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang_lb/server"
+	"net/http"
+	"strconv"
+)
+
+// getAutoscaler returns the autoscaler configuration and its last decision
+func (h *HTTPServer) getAutoscaler(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"enabled":       h.autoscaler.IsEnabled(),
+		"config":        h.autoscaler.Config(),
+		"last_decision": h.autoscaler.LastDecision(),
+		"server_count":  len(h.lb.ListServers()),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// AutoscalerRequest enables/disables the autoscaler and optionally replaces its config
+type AutoscalerRequest struct {
+	Enabled *bool                    `json:"enabled"`
+	Config  *server.AutoscalerConfig `json:"config"`
+}
+
+// updateAutoscaler enables/disables the autoscaler and optionally replaces its config
+func (h *HTTPServer) updateAutoscaler(w http.ResponseWriter, r *http.Request) {
+	var req AutoscalerRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Config != nil {
+		if err := h.autoscaler.SetConfig(*req.Config); err != nil {
+			http.Error(w, "Invalid autoscaler config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Enabled != nil {
+		h.autoscaler.SetEnabled(*req.Enabled)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Autoscaler updated successfully",
+		"enabled": h.autoscaler.IsEnabled(),
+		"config":  h.autoscaler.Config(),
+	})
+}
+
+// getAutopilot returns the synthetic load settings and how many tasks it has submitted
+func (h *HTTPServer) getAutopilot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.autopilot.Stats())
+}
+
+// AutopilotRequest enables/disables the synthetic load and optionally replaces its config
+type AutopilotRequest struct {
+	Enabled *bool                   `json:"enabled"`
+	Config  *server.AutopilotConfig `json:"config"`
+}
+
+// updateAutopilot enables/disables the synthetic load and optionally replaces its config
+func (h *HTTPServer) updateAutopilot(w http.ResponseWriter, r *http.Request) {
+	var req AutopilotRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Config != nil {
+		if err := h.autopilot.SetConfig(*req.Config); err != nil {
+			http.Error(w, "Invalid autopilot config: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.Enabled != nil {
+		h.autopilot.SetEnabled(*req.Enabled)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Autopilot updated successfully",
+		"enabled": h.autopilot.IsEnabled(),
+		"config":  h.autopilot.Config(),
+	})
+}
+
+// getEvents returns the most recent load balancer events
+func (h *HTTPServer) getEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 50 // default limit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if parsedLimit, err := strconv.Atoi(limitStr); err == nil && parsedLimit > 0 {
+			limit = parsedLimit
+		}
+	}
+
+	events := h.lb.Events(limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":  len(events),
+		"events": events,
+	})
+}
+
+// eventStreamContentType marks server-sent event responses
+const eventStreamContentType = "text/event-stream"
+
+// isEventStream reports whether a response is a long-lived event stream
+func isEventStream(w http.ResponseWriter) bool {
+	return w.Header().Get("Content-Type") == eventStreamContentType
+}
+
+// streamEvents pushes load balancer events to the client as server-sent
+// events until the client disconnects or the server shuts down
+func (h *HTTPServer) streamEvents(w http.ResponseWriter, r *http.Request) {
+	events, unsubscribe := h.lb.SubscribeEvents()
+	defer unsubscribe()
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		return // Streaming is not supported by this connection
+	}
+
+	for {
+		select {
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-h.shutdown:
+			return
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang_lb/server"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxBatchTasks bounds how many tasks one batch submission may carry
+const maxBatchTasks = 100
+
+// BatchTaskRequest submits several tasks at once to be spread across servers
+type BatchTaskRequest struct {
+	Tasks []string `json:"tasks"`
+	Type  string   `json:"type,omitempty"` // interactive (default) or batch lane
+	// Largest share of the batch one server takes; -batch-max-share when omitted
+	MaxServerShare float64 `json:"max_server_share,omitempty"`
+	// Server-side execution timeout of each task, see TaskRequest
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
+}
+
+// BatchTaskResponse holds each task's outcome, in request order, and how the
+// batch was spread
+type BatchTaskResponse struct {
+	Results   []TaskResponse        `json:"results"`
+	Placement server.BatchPlacement `json:"placement"`
+}
+
+// ServerZoneRequest sets the failure domain of a server
+type ServerZoneRequest struct {
+	Zone string `json:"zone"` // Empty clears it
+}
+
+// submitBatch dispatches a batch of tasks, capping the share of it any one
+// server or zone takes, and waits for all of them
+func (h *HTTPServer) submitBatch(w http.ResponseWriter, r *http.Request) {
+	var req BatchTaskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Tasks) == 0 || len(req.Tasks) > maxBatchTasks {
+		http.Error(w, fmt.Sprintf("A batch must have between 1 and %d tasks", maxBatchTasks), http.StatusBadRequest)
+		return
+	}
+	for _, task := range req.Tasks {
+		if task == "" {
+			http.Error(w, "Task cannot be empty", http.StatusBadRequest)
+			return
+		}
+	}
+
+	lane := server.LaneInteractive
+	if req.Type != "" {
+		lane = strings.ToLower(req.Type)
+	}
+	if !server.IsValidLane(lane) {
+		http.Error(w, fmt.Sprintf("Invalid task type %q: use interactive or batch", req.Type), http.StatusBadRequest)
+		return
+	}
+
+	share := req.MaxServerShare
+	if share == 0 {
+		share = h.config.BatchMaxShare
+	}
+	if share < 0 || share > 1 {
+		http.Error(w, "max_server_share must be in (0, 1]", http.StatusBadRequest)
+		return
+	}
+
+	timeout, err := parseTaskTimeout(req.TimeoutMs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Each task counts against the quota; those over it are answered without dispatching
+	results := make([]TaskResponse, len(req.Tasks))
+	admitted := make([]int, 0, len(req.Tasks))
+	inputs := make([]string, 0, len(req.Tasks))
+	for i, task := range req.Tasks {
+		if !isAdminRequest(r) {
+			if err := h.quotas.Reserve(rateLimitKey(r), len(task)); err != nil {
+				results[i] = TaskResponse{Status: "rejected", Message: err.Error()}
+				continue
+			}
+		}
+		admitted = append(admitted, i)
+		inputs = append(inputs, task)
+	}
+
+	explain, _ := strconv.ParseBool(r.URL.Query().Get("explain"))
+	dispatches, placement, err := h.lb.DispatchBatch(r.Context(), inputs, lane, share, timeout, explain)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for n, dispatch := range dispatches {
+		i := admitted[n]
+		if dispatch.Server == nil {
+			results[i] = TaskResponse{
+				Status:      "rejected",
+				Message:     dispatch.Response.Message,
+				TaskID:      dispatch.Response.TaskID,
+				NoServer:    dispatch.Response.NoServer,
+				Explanation: dispatch.Response.Explanation,
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, results[i] = awaitTaskResponse(dispatch.Response)
+		}()
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(BatchTaskResponse{
+		Results:   results,
+		Placement: placement,
+	})
+}
+
+// updateServerZone sets the failure domain a server counts toward when batches are spread
+func (h *HTTPServer) updateServerZone(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ServerZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	srv.SetZone(strings.TrimSpace(req.Zone))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"message":   "Server zone updated successfully",
+		"server_id": serverID,
+		"zone":      srv.Zone(),
+	})
+}
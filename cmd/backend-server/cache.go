@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// cachedResponse is one rendered response, or a render still in progress
+type cachedResponse struct {
+	ready   chan struct{} // Closed once the fields below are set
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+// ResponseCache serves recent responses of read-only endpoints again for a
+// short TTL, so dashboards polling many times a second do not each serialize
+// the fleet and contend on server mutexes. Concurrent misses for the same URL
+// wait for a single render.
+type ResponseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+// NewResponseCache creates a cache; a TTL of zero or less disables it
+func NewResponseCache(ttl time.Duration) *ResponseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &ResponseCache{ttl: ttl, entries: make(map[string]*cachedResponse)}
+}
+
+// Middleware caches successful responses by method and request URI
+func (c *ResponseCache) Middleware(next http.Handler) http.Handler {
+	if c == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Method + " " + r.URL.RequestURI()
+		now := time.Now()
+
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if ok && entry.expires.IsZero() {
+			// Another request is rendering this response
+			c.mu.Unlock()
+			<-entry.ready
+			c.write(w, entry, "HIT")
+			return
+		}
+		if ok && now.Before(entry.expires) {
+			c.mu.Unlock()
+			c.write(w, entry, "HIT")
+			return
+		}
+		entry = &cachedResponse{ready: make(chan struct{})}
+		c.entries[key] = entry
+		c.evictExpiredLocked(now)
+		c.mu.Unlock()
+
+		recorder := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		func() {
+			// Waiters must not hang if the handler panics
+			completed := false
+			defer func() {
+				if !completed {
+					recorder.status = http.StatusInternalServerError
+					recorder.body.Reset()
+				}
+				entry.status, entry.header, entry.body = recorder.status, recorder.header, recorder.body.Bytes()
+				c.mu.Lock()
+				entry.expires = time.Now().Add(c.ttl)
+				if entry.status != http.StatusOK {
+					delete(c.entries, key)
+				}
+				c.mu.Unlock()
+				close(entry.ready)
+			}()
+			next.ServeHTTP(recorder, r)
+			completed = true
+		}()
+		c.write(w, entry, "MISS")
+	})
+}
+
+// evictExpiredLocked drops stale entries so one-off query strings do not
+// accumulate; callers hold c.mu
+func (c *ResponseCache) evictExpiredLocked(now time.Time) {
+	for key, entry := range c.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// write replays a rendered response, marking whether it came from the cache
+func (c *ResponseCache) write(w http.ResponseWriter, entry *cachedResponse, result string) {
+	for name, values := range entry.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("X-Cache", result)
+	w.WriteHeader(entry.status)
+	w.Write(entry.body)
+}
+
+// responseRecorder buffers a handler's response so it can be cached
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func (rr *responseRecorder) Header() http.Header {
+	return rr.header
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if rr.wrote {
+		return
+	}
+	rr.status = code
+	rr.wrote = true
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wrote = true
+	return rr.body.Write(b)
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// defaultCapacityHorizon and maxCapacityHorizon bound /capacity; MaGCs are
+// forecast at most ten minutes ahead, so longer horizons would understate downtime
+const (
+	defaultCapacityHorizon = 5 * time.Minute
+	maxCapacityHorizon     = 10 * time.Minute
+)
+
+// getCapacity summarizes memory, utilization, forecast MaGC downtime and
+// sustainable task rate per zone and per program family
+func (h *HTTPServer) getCapacity(w http.ResponseWriter, r *http.Request) {
+	horizon := defaultCapacityHorizon
+	if value := r.URL.Query().Get("horizon"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > maxCapacityHorizon {
+			http.Error(w, "Invalid horizon (must be a duration up to 10m)", http.StatusBadRequest)
+			return
+		}
+		horizon = parsed
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.CapacityReport(horizon))
+}
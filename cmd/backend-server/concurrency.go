@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ServerConcurrencyRequest sets how many tasks a server may run at once
+type ServerConcurrencyRequest struct {
+	MaxConcurrentTasks *int `json:"max_concurrent_tasks"` // 0 removes the limit
+}
+
+// updateServerConcurrency changes a server's concurrency limit. Tasks beyond
+// the limit are rejected with a reason, and selection skips full servers.
+func (h *HTTPServer) updateServerConcurrency(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ServerConcurrencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.MaxConcurrentTasks == nil {
+		http.Error(w, "max_concurrent_tasks is required", http.StatusBadRequest)
+		return
+	}
+	if err := srv.SetMaxConcurrentTasks(*req.MaxConcurrentTasks); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":               "success",
+		"message":              "Server concurrency limit updated successfully",
+		"max_concurrent_tasks": srv.MaxConcurrentTasks(),
+		"active_tasks":         srv.ActiveTasks(),
+	})
+}
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang_lb/server"
+)
+
+// Config holds the backend-server command line configuration
+type Config struct {
+	Listeners []ListenerConfig
+	APIKeys   []string
+	// Requests per minute per client, for task submission and for all other endpoints
+	TaskRateLimit    int
+	MonitorRateLimit int
+	// Separate budget for status, metrics and health polls; 0 shares MonitorRateLimit
+	MonitoringRateLimit int
+	MonitoringExempt    []string    // Checks skipped for monitoring polls: auth, rate-limit
+	Quotas              QuotaLimits // Hourly and daily task submission quotas per client
+	TLSCert             string      // Serve HTTPS (with HTTP/2) on TCP listeners when set with TLSKey
+	TLSKey              string
+	H2C                 bool                // Accept cleartext HTTP/2 (h2c) on non-TLS listeners
+	Features            []string            // Feature flags enabled at startup
+	DataDir             string              // Enables persistence of state across restarts when set
+	SlowRequest         time.Duration       // Requests slower than this are logged and counted
+	ResponseCacheTTL    time.Duration       // How long status and families responses are served from cache, 0 disables
+	DecisionLog         string              // File recording selection decisions for replay, disabled when empty
+	YoungGenRatio       float64             // Share of each server's memory sized for the young generation
+	MaxConcurrent       int                 // Concurrent tasks allowed per server, 0 is unlimited
+	InteractiveShare    float64             // Share of each server's concurrency limit for the interactive lane
+	GCPrefetchWindow    time.Duration       // Forecast MaGCs due this soon run early on idle servers (gc_prefetch flag)
+	Warmup              server.WarmupConfig // Warm-up tasks and traffic ramp after each MaGC
+	FamilyPins          []FamilyPinConfig
+	ServerZones         map[int]string         // Failure domain per server ID
+	BatchMaxShare       float64                // Largest share of a batch one server takes unless the request says
+	Autopilot           server.AutopilotConfig // Synthetic background load
+	MonitorIntervalMin  time.Duration          // Adaptive TRINI monitoring bounds; both zero keeps the interval fixed
+	MonitorIntervalMax  time.Duration
+	AutopilotEnabled    bool                        // Generate the synthetic load from startup
+	MemoryPressure      server.MemoryPressurePolicy // When tasks that do not fit trigger a MaGC
+	SLO                 server.SLOConfig            // Objective task latency and rejections are tracked against
+	Standby             server.StandbyConfig        // When standby servers are activated under GC pressure
+	StandbyServers      []int                       // Server IDs held in standby from startup
+	PolicyEngineURL     string                      // External selection service consulted before the local algorithm, disabled when empty
+	PolicyEngineTimeout time.Duration               // Policy engine requests slower than this fall back to local selection
+	Raft                server.RaftConfig           // Configuration replication group; disabled while ID is empty
+}
+
+// FamilyPinConfig pins a server to a program family at startup
+type FamilyPinConfig struct {
+	ServerID int
+	FamilyID string
+	Duration time.Duration // 0 pins until removed
+}
+
+// Checks that -monitoring-exempt can skip for monitoring polls
+const (
+	exemptAuth      = "auth"
+	exemptRateLimit = "rate-limit"
+)
+
+// ListenerConfig describes one address the server accepts connections on
+type ListenerConfig struct {
+	Network string // tcp, tcp4, tcp6 or unix
+	Address string
+	Admin   bool // Trusted local listener: no auth or rate limiting
+}
+
+func (l ListenerConfig) String() string {
+	if l.Network == "unix" {
+		return "unix://" + l.Address
+	}
+	return l.Network + "://" + l.Address
+}
+
+// listenFlag collects repeated -listen values
+type listenFlag []string
+
+func (f *listenFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listenFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// LoadConfig parses the command line arguments
+func LoadConfig(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("backend-server", flag.ContinueOnError)
+
+	var listens listenFlag
+	port := fs.String("port", "8080", "TCP port used when no -listen address is given")
+	fs.Var(&listens, "listen", "Listen address, repeatable: host:port, tcp4://host:port, tcp6://[host]:port or unix:///path.sock")
+	apiKeys := fs.String("api-key", "", "Comma-separated API keys required as X-API-Key on TCP listeners (Unix sockets are exempt)")
+	taskRateLimit := fs.Int("task-rate-limit", 10, "Task submissions per minute per client")
+	monitorRateLimit := fs.Int("monitor-rate-limit", 120, "Monitoring/admin requests per minute per client")
+	monitoringRateLimit := fs.Int("monitoring-rate-limit", 0, "Status, metrics and health polls per minute per client, counted apart from other requests (0 shares -monitor-rate-limit)")
+	monitoringExempt := fs.String("monitoring-exempt", "", "Comma-separated checks skipped for status, metrics and health polls: auth, rate-limit")
+	quotaHourlyTasks := fs.Int64("quota-hourly-tasks", 0, "Tasks each client may submit per hour (0 is unlimited)")
+	quotaDailyTasks := fs.Int64("quota-daily-tasks", 0, "Tasks each client may submit per UTC day (0 is unlimited)")
+	quotaHourlyBytes := fs.Int64("quota-hourly-bytes", 0, "Task bytes each client may submit per hour (0 is unlimited)")
+	quotaDailyBytes := fs.Int64("quota-daily-bytes", 0, "Task bytes each client may submit per UTC day (0 is unlimited)")
+	tlsCert := fs.String("tls-cert", "", "TLS certificate file; enables HTTPS and HTTP/2 on TCP listeners")
+	tlsKey := fs.String("tls-key", "", "TLS private key file")
+	h2c := fs.Bool("h2c", false, "Accept cleartext HTTP/2 (h2c) for internal clients")
+	features := fs.String("features", "", "Comma-separated feature flags to enable (e.g. retries,hedging)")
+	slowRequest := fs.Duration("slow-request-threshold", 2*time.Second, "Log and count requests slower than this")
+	responseCacheTTL := fs.Duration("response-cache-ttl", 500*time.Millisecond, "Serve status, TRINI status and families responses from cache for this long (0 disables)")
+	dataDir := fs.String("data-dir", "", "Directory for persisted state such as execution statistics, GC history and decisions (disabled when empty)")
+	youngGenRatio := fs.Float64("young-gen-ratio", 0.5, "Share of each server's memory limit sized for the young generation (0-1, exclusive)")
+	maxConcurrent := fs.Int("max-concurrent-tasks", 0, "Concurrent tasks allowed per server; more are rejected (0 is unlimited)")
+	interactiveShare := fs.Float64("interactive-share", 0.5, "Share of each server's concurrency limit for interactive tasks; batch tasks get the rest (0-1, exclusive)")
+	warmupTasks := fs.Int("gc-warmup-tasks", 0, "Synthetic tasks each server runs after a MaGC before rejoining rotation (0 disables)")
+	warmupRamp := fs.Duration("gc-warmup-ramp", 0, "After the warm-up tasks, ramp a server's share of traffic up over this long (0 disables)")
+	gcPrefetchWindow := fs.Duration("gc-prefetch-window", 5*time.Second, "With the gc_prefetch feature, run MaGCs forecast within this window early on idle servers")
+	serverZones := fs.String("server-zones", "", "Comma-separated failure domains batches are spread across: server=zone, e.g. 1=a,2=a,3=b,4=b")
+	batchMaxShare := fs.Float64("batch-max-share", server.DefaultBatchMaxShare, "Largest share of a task batch one server takes (0-1]")
+	monitorMin := fs.Duration("monitor-interval-min", 0, "Shortest TRINI monitoring interval while heaps change quickly or a MaGC is near (0 keeps the interval fixed)")
+	monitorMax := fs.Duration("monitor-interval-max", 0, "Longest TRINI monitoring interval while heaps are stable (0 keeps the interval fixed)")
+	autopilotDefaults := server.DefaultAutopilotConfig()
+	autopilot := fs.Bool("autopilot", false, "Generate synthetic background load from startup so GC history and forecasts have data without clients")
+	autopilotRate := fs.Float64("autopilot-rate", autopilotDefaults.TasksPerSecond, "Synthetic tasks per second the autopilot submits")
+	autopilotMinSize := fs.Int("autopilot-min-size", autopilotDefaults.MinTaskSize, "Smallest synthetic task in bytes")
+	autopilotMaxSize := fs.Int("autopilot-max-size", autopilotDefaults.MaxTaskSize, "Largest synthetic task in bytes")
+	pinFamily := fs.String("pin-family", "", "Comma-separated program family pins applied at startup: server=family[:duration], e.g. 1=long-magc:2h")
+	memoryPressure := fs.String("memory-pressure", server.MemoryPressureCollect, "What a task that does not fit on a server does: collect (start a background MaGC) or wait (leave it to the GC trigger)")
+	memoryPressureMin := fs.Float64("memory-pressure-min-utilization", 0, "With -memory-pressure collect, only collect servers at least this full (0-1)")
+	sloDefaults := server.DefaultSLOConfig()
+	sloLatency := fs.Duration("slo-latency", time.Duration(sloDefaults.LatencyTargetMs)*time.Millisecond, "SLO latency target; tasks slower than this, or timing out, spend the latency error budget")
+	sloObjective := fs.Float64("slo-latency-objective", sloDefaults.LatencyObjective, "Share of tasks that must meet -slo-latency (0-1, exclusive)")
+	sloRejections := fs.Float64("slo-rejection-budget", sloDefaults.RejectionBudget, "Share of tasks that may be rejected (0-1, exclusive)")
+	standbyDefaults := server.DefaultStandbyConfig()
+	standbyServers := fs.String("standby-servers", "", "Comma-separated server IDs held in standby, taking traffic only under GC pressure, e.g. 3,4")
+	standbyMinClean := fs.Int("standby-min-clean", standbyDefaults.MinCleanServers, "Activate standby servers while fewer active servers than this are GC-clean (0 never activates them)")
+	standbyCooldown := fs.Duration("standby-cooldown", time.Duration(standbyDefaults.CooldownMs)*time.Millisecond, "Least time an activated standby server serves before returning to standby")
+	policyEngineURL := fs.String("policy-engine-url", "", "HTTP endpoint of an external policy engine that chooses servers, with local fallback (disabled when empty)")
+	policyEngineTimeout := fs.Duration("policy-engine-timeout", 50*time.Millisecond, "Fall back to local selection when the policy engine takes longer than this")
+	raftID := fs.String("raft-id", "", "This balancer's ID in a configuration replication group (disabled when empty)")
+	raftPeers := fs.String("raft-peers", "", "Comma-separated other members of the replication group: id=url, e.g. b=http://lb-b:8080,c=http://lb-c:8080")
+	raftHeartbeat := fs.Duration("raft-heartbeat", 200*time.Millisecond, "How often the replication leader contacts its peers; elections start after 5 silent heartbeats")
+	decisionLog := fs.String("decision-log", "", "File recording every selection decision for `debug replay` (disabled when empty)")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if len(listens) == 0 {
+		listens = append(listens, ":"+*port)
+	}
+
+	if *youngGenRatio <= 0 || *youngGenRatio >= 1 {
+		return nil, fmt.Errorf("-young-gen-ratio must be between 0 and 1, got %g", *youngGenRatio)
+	}
+
+	if *interactiveShare <= 0 || *interactiveShare >= 1 {
+		return nil, fmt.Errorf("-interactive-share must be between 0 and 1, got %g", *interactiveShare)
+	}
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		return nil, fmt.Errorf("-tls-cert and -tls-key must be set together")
+	}
+
+	if *taskRateLimit <= 0 || *monitorRateLimit <= 0 {
+		return nil, fmt.Errorf("rate limits must be positive")
+	}
+
+	if *monitoringRateLimit < 0 {
+		return nil, fmt.Errorf("-monitoring-rate-limit must not be negative")
+	}
+
+	exempt := splitList(*monitoringExempt)
+	for _, check := range exempt {
+		if check != exemptAuth && check != exemptRateLimit {
+			return nil, fmt.Errorf("invalid -monitoring-exempt value %q: use %s or %s", check, exemptAuth, exemptRateLimit)
+		}
+	}
+	if *monitoringRateLimit > 0 && slices.Contains(exempt, exemptRateLimit) {
+		return nil, fmt.Errorf("-monitoring-rate-limit cannot be combined with -monitoring-exempt %s", exemptRateLimit)
+	}
+
+	if *quotaHourlyTasks < 0 || *quotaDailyTasks < 0 || *quotaHourlyBytes < 0 || *quotaDailyBytes < 0 {
+		return nil, fmt.Errorf("quotas must not be negative")
+	}
+
+	if *slowRequest <= 0 {
+		return nil, fmt.Errorf("-slow-request-threshold must be positive")
+	}
+
+	if *responseCacheTTL < 0 {
+		return nil, fmt.Errorf("-response-cache-ttl must not be negative")
+	}
+
+	if *dataDir != "" && *decisionLog != "" && filepath.Clean(*decisionLog) == filepath.Join(*dataDir, "decisions.log") {
+		return nil, fmt.Errorf("-decision-log must not be the decision log -data-dir already keeps")
+	}
+
+	if *gcPrefetchWindow <= 0 {
+		return nil, fmt.Errorf("-gc-prefetch-window must be positive")
+	}
+
+	warmup := server.WarmupConfig{Tasks: *warmupTasks, Ramp: *warmupRamp}
+	if err := warmup.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid -gc-warmup-tasks or -gc-warmup-ramp: %v", err)
+	}
+
+	if *batchMaxShare <= 0 || *batchMaxShare > 1 {
+		return nil, fmt.Errorf("-batch-max-share must be in (0, 1], got %g", *batchMaxShare)
+	}
+
+	if (*monitorMin == 0) != (*monitorMax == 0) || *monitorMin < 0 || *monitorMax < *monitorMin {
+		return nil, fmt.Errorf("-monitor-interval-min and -monitor-interval-max must be set together, with min <= max")
+	}
+
+	autopilotConfig := autopilotDefaults
+	autopilotConfig.TasksPerSecond = *autopilotRate
+	autopilotConfig.MinTaskSize = *autopilotMinSize
+	autopilotConfig.MaxTaskSize = *autopilotMaxSize
+	if err := autopilotConfig.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid -autopilot-rate or task size: %v", err)
+	}
+
+	pressure := server.MemoryPressurePolicy{Mode: *memoryPressure, MinUtilization: *memoryPressureMin}
+	if err := pressure.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid -memory-pressure: %v", err)
+	}
+
+	slo := server.SLOConfig{
+		LatencyTargetMs:  sloLatency.Milliseconds(),
+		LatencyObjective: *sloObjective,
+		RejectionBudget:  *sloRejections,
+	}
+	if err := slo.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid -slo-latency, -slo-latency-objective or -slo-rejection-budget: %v", err)
+	}
+
+	standby := server.StandbyConfig{MinCleanServers: *standbyMinClean, CooldownMs: standbyCooldown.Milliseconds()}
+	if err := standby.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid -standby-min-clean or -standby-cooldown: %v", err)
+	}
+
+	if *policyEngineTimeout <= 0 {
+		return nil, fmt.Errorf("-policy-engine-timeout must be positive")
+	}
+
+	config := &Config{
+		TaskRateLimit:       *taskRateLimit,
+		MonitorRateLimit:    *monitorRateLimit,
+		MonitoringRateLimit: *monitoringRateLimit,
+		MonitoringExempt:    exempt,
+		Quotas: QuotaLimits{
+			HourlyTasks: *quotaHourlyTasks,
+			DailyTasks:  *quotaDailyTasks,
+			HourlyBytes: *quotaHourlyBytes,
+			DailyBytes:  *quotaDailyBytes,
+		},
+		TLSCert:             *tlsCert,
+		TLSKey:              *tlsKey,
+		H2C:                 *h2c,
+		DataDir:             *dataDir,
+		SlowRequest:         *slowRequest,
+		ResponseCacheTTL:    *responseCacheTTL,
+		DecisionLog:         *decisionLog,
+		YoungGenRatio:       *youngGenRatio,
+		MaxConcurrent:       *maxConcurrent,
+		InteractiveShare:    *interactiveShare,
+		GCPrefetchWindow:    *gcPrefetchWindow,
+		Warmup:              warmup,
+		BatchMaxShare:       *batchMaxShare,
+		Autopilot:           autopilotConfig,
+		MonitorIntervalMin:  *monitorMin,
+		MonitorIntervalMax:  *monitorMax,
+		AutopilotEnabled:    *autopilot,
+		ServerZones:         make(map[int]string),
+		MemoryPressure:      pressure,
+		SLO:                 slo,
+		Standby:             standby,
+		PolicyEngineURL:     *policyEngineURL,
+		PolicyEngineTimeout: *policyEngineTimeout,
+	}
+	config.APIKeys = splitList(*apiKeys)
+	config.Features = splitList(*features)
+	for _, value := range splitList(*pinFamily) {
+		pin, err := parseFamilyPin(value)
+		if err != nil {
+			return nil, err
+		}
+		config.FamilyPins = append(config.FamilyPins, pin)
+	}
+	for _, value := range splitList(*serverZones) {
+		id, zone, found := strings.Cut(value, "=")
+		serverID, err := strconv.Atoi(id)
+		if !found || zone == "" || err != nil {
+			return nil, fmt.Errorf("invalid -server-zones value %q: use server=zone", value)
+		}
+		config.ServerZones[serverID] = zone
+	}
+	for _, value := range splitList(*standbyServers) {
+		serverID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -standby-servers value %q: use a server ID", value)
+		}
+		config.StandbyServers = append(config.StandbyServers, serverID)
+	}
+	if *raftID != "" {
+		config.Raft = server.RaftConfig{
+			ID:                *raftID,
+			Peers:             make(map[string]string),
+			HeartbeatInterval: *raftHeartbeat,
+			ElectionTimeout:   5 * *raftHeartbeat,
+		}
+		// Peers share the API keys, so the first one authenticates RPCs
+		if len(config.APIKeys) > 0 {
+			config.Raft.APIKey = config.APIKeys[0]
+		}
+		for _, value := range splitList(*raftPeers) {
+			id, url, found := strings.Cut(value, "=")
+			if !found || id == "" || url == "" {
+				return nil, fmt.Errorf("invalid -raft-peers value %q: use id=url", value)
+			}
+			config.Raft.Peers[id] = url
+		}
+		if err := config.Raft.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid -raft-id, -raft-peers or -raft-heartbeat: %v", err)
+		}
+	} else if *raftPeers != "" {
+		return nil, fmt.Errorf("-raft-peers requires -raft-id")
+	}
+	for _, value := range listens {
+		listener, err := parseListenAddress(value)
+		if err != nil {
+			return nil, err
+		}
+		config.Listeners = append(config.Listeners, listener)
+	}
+
+	return config, nil
+}
+
+// parseListenAddress turns a -listen value into a ListenerConfig
+func parseListenAddress(value string) (ListenerConfig, error) {
+	network, address, found := strings.Cut(value, "://")
+	if !found {
+		network, address = "tcp", value
+	}
+
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+		return ListenerConfig{Network: network, Address: address}, nil
+	case "unix":
+		if address == "" {
+			return ListenerConfig{}, fmt.Errorf("invalid listen address %q: missing socket path", value)
+		}
+		return ListenerConfig{Network: network, Address: address, Admin: true}, nil
+	default:
+		return ListenerConfig{}, fmt.Errorf("invalid listen address %q: unsupported network %q", value, network)
+	}
+}
+
+// parseFamilyPin turns a -pin-family entry into a FamilyPinConfig
+func parseFamilyPin(value string) (FamilyPinConfig, error) {
+	id, family, found := strings.Cut(value, "=")
+	if !found || family == "" {
+		return FamilyPinConfig{}, fmt.Errorf("invalid -pin-family value %q: use server=family[:duration]", value)
+	}
+	serverID, err := strconv.Atoi(id)
+	if err != nil {
+		return FamilyPinConfig{}, fmt.Errorf("invalid -pin-family value %q: bad server ID", value)
+	}
+
+	pin := FamilyPinConfig{ServerID: serverID, FamilyID: family}
+	if family, duration, found := strings.Cut(family, ":"); found {
+		pin.FamilyID = family
+		if pin.Duration, err = time.ParseDuration(duration); err != nil || pin.Duration <= 0 {
+			return FamilyPinConfig{}, fmt.Errorf("invalid -pin-family value %q: bad duration", value)
+		}
+	}
+	return pin, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
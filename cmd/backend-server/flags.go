@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// getFeatureFlags lists all runtime feature flags
+func (h *HTTPServer) getFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"flags": h.lb.Flags.List(),
+	})
+}
+
+// FeatureFlagRequest turns a feature flag on or off
+type FeatureFlagRequest struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// updateFeatureFlag enables or disables a single feature flag
+func (h *HTTPServer) updateFeatureFlag(w http.ResponseWriter, r *http.Request) {
+	var req FeatureFlagRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.lb.SetFeatureFlag(req.Name, req.Enabled); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	status := "disabled"
+	if req.Enabled {
+		status = "enabled"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": fmt.Sprintf("Feature flag '%s' %s", req.Name, status),
+		"flags":   h.lb.Flags.List(),
+	})
+}
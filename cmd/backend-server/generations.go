@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ServerGenerationsRequest sets a young generation ratio, absolute sizes, or auto
+type ServerGenerationsRequest struct {
+	YoungGenRatio *float64 `json:"young_gen_ratio"`
+	YoungGenMax   *int     `json:"young_gen_max"`
+	OldGenMax     *int     `json:"old_gen_max"`
+	Auto          bool     `json:"auto"`
+}
+
+// updateServerGenerations changes how a server's memory is split into young
+// and old generations, which drives its MaGC forecasts. A request sets a
+// young_gen_ratio of the memory limit, both absolute sizes, or "auto" to go
+// back to the default even split.
+func (h *HTTPServer) updateServerGenerations(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ServerGenerationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	absolute := req.YoungGenMax != nil || req.OldGenMax != nil
+	switch {
+	case absolute && (req.YoungGenMax == nil || req.OldGenMax == nil):
+		http.Error(w, "young_gen_max and old_gen_max must be set together", http.StatusBadRequest)
+		return
+	case absolute && (req.YoungGenRatio != nil || req.Auto),
+		req.YoungGenRatio != nil && req.Auto:
+		http.Error(w, "Only one of young_gen_ratio, young_gen_max/old_gen_max or auto may be set", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case req.Auto:
+		srv.ResetGenerations()
+	case req.YoungGenRatio != nil:
+		err = srv.SetGenerationRatio(*req.YoungGenRatio)
+	case absolute:
+		err = srv.SetGenerationSizes(*req.YoungGenMax, *req.OldGenMax)
+	default:
+		http.Error(w, "One of young_gen_ratio, young_gen_max/old_gen_max or auto is required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":      "success",
+		"message":     "Server generations updated successfully",
+		"generations": srv.GenerationInfo(),
+	})
+}
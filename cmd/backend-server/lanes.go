@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ServerLanesRequest sets how a server splits its concurrency limit between lanes
+type ServerLanesRequest struct {
+	InteractiveShare *float64 `json:"interactive_share"` // Batch gets the rest
+}
+
+// getServerLanes reports a server's per-lane load and outcomes
+func (h *HTTPServer) getServerLanes(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"server_id":            srv.ID,
+		"max_concurrent_tasks": srv.MaxConcurrentTasks(),
+		"interactive_share":    srv.InteractiveShare(),
+		"lanes":                srv.LaneStats(),
+	})
+}
+
+// updateServerLanes changes the share of a server's concurrency limit the
+// interactive lane may use. Lanes only limit servers with a concurrency limit.
+func (h *HTTPServer) updateServerLanes(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ServerLanesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.InteractiveShare == nil {
+		http.Error(w, "interactive_share is required", http.StatusBadRequest)
+		return
+	}
+	if err := srv.SetInteractiveShare(*req.InteractiveShare); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":            "success",
+		"message":           "Server lane shares updated successfully",
+		"interactive_share": srv.InteractiveShare(),
+		"lanes":             srv.LaneStats(),
+	})
+}
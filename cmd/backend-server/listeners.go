@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// listen opens the network listener, replacing a stale Unix socket if needed
+func listen(config ListenerConfig) (net.Listener, error) {
+	if config.Network == "unix" {
+		if err := os.Remove(config.Address); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		listener, err := net.Listen("unix", config.Address)
+		if err != nil {
+			return nil, err
+		}
+		// Only the owning user and group may use the admin socket
+		if err := os.Chmod(config.Address, 0660); err != nil {
+			listener.Close()
+			return nil, err
+		}
+		return listener, nil
+	}
+
+	return net.Listen(config.Network, config.Address)
+}
+
+// listenerChain returns the middleware applied only to requests on the given listener
+func (h *HTTPServer) listenerChain(config ListenerConfig) func(http.Handler) http.Handler {
+	if config.Admin {
+		return Chain(AdminMiddleware)
+	}
+
+	regular := h.publicChain(true, RateLimitMiddleware(h.taskLimiter, h.monitorLimiter))
+
+	var limiter func(http.Handler) http.Handler
+	switch {
+	case slices.Contains(h.config.MonitoringExempt, exemptRateLimit):
+	case h.monitoringLimiter != nil:
+		limiter = h.monitoringLimiter.Middleware
+	default:
+		limiter = h.monitorLimiter.Middleware
+	}
+	monitoring := h.publicChain(!slices.Contains(h.config.MonitoringExempt, exemptAuth), limiter)
+
+	return MonitoringMiddleware(h.isMonitoringPoll, monitoring, regular)
+}
+
+// publicChain builds the CORS, auth and rate limiting chain for TCP listeners.
+// A nil limiter skips rate limiting.
+func (h *HTTPServer) publicChain(auth bool, limiter func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	// Auth runs before rate limiting so clients are limited by identity
+	middlewares := []func(http.Handler) http.Handler{CORSMiddleware}
+	if auth && len(h.config.APIKeys) > 0 {
+		middlewares = append(middlewares, AuthMiddleware(h.config.APIKeys...))
+	}
+	if limiter != nil {
+		middlewares = append(middlewares, limiter)
+	}
+	return Chain(middlewares...)
+}
+
+// isMonitoringPoll reports whether the request reads a monitoring endpoint
+func (h *HTTPServer) isMonitoringPoll(r *http.Request) bool {
+	return r.Method == http.MethodGet && h.monitoringPaths[r.URL.Path]
+}
+
+// useTLS reports whether the listener serves HTTPS (Unix admin sockets stay cleartext)
+func (h *HTTPServer) useTLS(config ListenerConfig) bool {
+	return h.config.TLSCert != "" && !config.Admin
+}
+
+// serve runs one http.Server per configured listener until ctx is cancelled
+func (h *HTTPServer) serve(ctx context.Context, handler http.Handler) error {
+	servers := make([]*http.Server, 0, len(h.config.Listeners))
+	listeners := make([]net.Listener, 0, len(h.config.Listeners))
+	// closeListeners releases the listeners opened so far when setup fails
+	closeListeners := func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	}
+
+	for _, config := range h.config.Listeners {
+		listener, err := listen(config)
+		if err != nil {
+			closeListeners()
+			return fmt.Errorf("listen on %s: %w", config, err)
+		}
+		listeners = append(listeners, listener)
+
+		srv := &http.Server{Handler: h.listenerChain(config)(handler)}
+		protocols := "HTTP/1.1"
+		switch {
+		case h.useTLS(config):
+			// ServeTLS negotiates HTTP/2 via ALPN
+			protocols = "HTTPS, HTTP/2"
+		case h.config.H2C:
+			h2s := &http2.Server{}
+			srv.Handler = h2c.NewHandler(srv.Handler, h2s)
+			if err := http2.ConfigureServer(srv, h2s); err != nil {
+				closeListeners()
+				return fmt.Errorf("configure HTTP/2 on %s: %w", config, err)
+			}
+			protocols = "HTTP/1.1, h2c"
+		}
+		servers = append(servers, srv)
+
+		access := "public"
+		if config.Admin {
+			access = "admin, no auth"
+		}
+		fmt.Printf("🔌 Listening on %s (%s, %s)\n", config, access, protocols)
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		for _, srv := range servers {
+			srv.Shutdown(shutdownCtx)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(servers))
+	for i := range servers {
+		wg.Add(1)
+		go func(srv *http.Server, listener net.Listener, config ListenerConfig) {
+			defer wg.Done()
+			var err error
+			if h.useTLS(config) {
+				err = srv.ServeTLS(listener, h.config.TLSCert, h.config.TLSKey)
+			} else {
+				err = srv.Serve(listener)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Listener %s failed: %v", listener.Addr(), err)
+				errs <- err
+			}
+		}(servers[i], listeners[i], h.config.Listeners[i])
+	}
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
@@ -1,61 +1,211 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"golang_lb/server"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"slices"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
-
-	"github.com/gorilla/mux"
 )
 
 type HTTPServer struct {
-	lb   *server.LoadBalancer
-	port string
+	lb         *server.LoadBalancer
+	autoscaler *server.Autoscaler
+	autopilot  *server.Autopilot
+	raft       *server.RaftNode // Nil unless -raft-id is set
+	// Per-client rate limiters for task submission and everything else
+	taskLimiter    *RateLimiter
+	monitorLimiter *RateLimiter
+	// Separate budget for monitoring polls; nil when they share monitorLimiter
+	monitoringLimiter *RateLimiter
+	monitoringPaths   map[string]bool // Full paths of routes marked Monitoring
+	quotas            *QuotaTracker
+	slowRequests      *SlowRequestTracker
+	responseCache     *ResponseCache // Nil when -response-cache-ttl is 0
+	metrics           *HTTPMetrics
+	decisions         *server.DecisionLog // Nil unless -decision-log is set
+	storage           *server.Storage     // Nil unless -data-dir is set
+	config            *Config
+	// Closed when the server starts shutting down, ending long-lived streams
+	shutdown <-chan struct{}
 }
 
 type TaskRequest struct {
 	Task string `json:"task"`
+	// Dispatch lane: interactive (default) or batch, which has its own share
+	// of each server's concurrency limit
+	Type string `json:"type,omitempty"`
+	// Server-side execution timeout, at most the 5s the request waits;
+	// the policy's task_timeout_ms when omitted
+	TimeoutMs int64 `json:"timeout_ms,omitempty"`
 }
 
 type TaskResponse struct {
-	Status  string `json:"status"`
-	Message string `json:"message"`
-	TaskID  string `json:"task_id,omitempty"`
-	Output  string `json:"output,omitempty"`
+	Status      string                       `json:"status"`
+	Message     string                       `json:"message"`
+	TaskID      string                       `json:"task_id,omitempty"`
+	Output      string                       `json:"output,omitempty"`
+	NoServer    *server.NoServerOutcome      `json:"no_server,omitempty"`   // How the policy's on_no_server behavior was applied
+	Explanation *server.SelectionExplanation `json:"explanation,omitempty"` // With ?explain=true
 }
 
-func NewHTTPServer(port string) *HTTPServer {
-	// Initialize load balancer with 3 servers
-	lb := &server.LoadBalancer{
-		Servers: make([]*server.Server, 0),
-	}
-
+func NewHTTPServer(ctx context.Context, config *Config) *HTTPServer {
+	// Initialize load balancer with 4 servers
+	servers := make([]*server.Server, 0)
 	for i := 1; i <= 4; i++ {
-		srv := &server.Server{
-			ID:           i,
-			LoadBalancer: lb,
-			TaskStorage:  make([]string, 0),
+		srv := server.NewServer(i, 100, 80.0) // 100 memory limit, 80% GC trigger
+		if err := srv.SetGenerationRatio(config.YoungGenRatio); err != nil {
+			log.Fatalf("Invalid -young-gen-ratio: %v", err)
+		}
+		if err := srv.SetMaxConcurrentTasks(config.MaxConcurrent); err != nil {
+			log.Fatalf("Invalid -max-concurrent-tasks: %v", err)
+		}
+		if err := srv.SetInteractiveShare(config.InteractiveShare); err != nil {
+			log.Fatalf("Invalid -interactive-share: %v", err)
 		}
-		srv.Configure(100, 80.0) // 100 memory limit, 80% GC trigger
-		srv.Start()
-		lb.Servers = append(lb.Servers, srv)
+		servers = append(servers, srv)
+	}
+	opts := []server.Option{
+		server.WithServers(servers...),
+		server.WithGCPrefetchWindow(config.GCPrefetchWindow),
+		server.WithWarmup(config.Warmup),
+		server.WithMemoryPressurePolicy(config.MemoryPressure),
+		server.WithSLO(config.SLO),
+		server.WithStandby(config.Standby),
+	}
+	var storage *server.Storage
+	if config.DataDir != "" {
+		var err error
+		if storage, err = server.NewStorage(config.DataDir); err != nil {
+			log.Fatalf("Invalid -data-dir: %v", err)
+		}
+		opts = append(opts, server.WithStore(storage))
+	}
+	var decisions *server.DecisionLog
+	if config.DecisionLog != "" {
+		var err error
+		if decisions, err = server.OpenDecisionLog(config.DecisionLog); err != nil {
+			log.Fatalf("Invalid -decision-log: %v", err)
+		}
+		opts = append(opts, server.WithDecisionLog(decisions))
+	}
+	if config.PolicyEngineURL != "" {
+		engine, err := server.NewPolicyEngine(config.PolicyEngineURL, config.PolicyEngineTimeout)
+		if err != nil {
+			log.Fatalf("Invalid -policy-engine-url: %v", err)
+		}
+		opts = append(opts, server.WithPolicyEngine(engine))
+		fmt.Printf("🧠 Consulting policy engine at %s (timeout %v)\n", config.PolicyEngineURL, config.PolicyEngineTimeout)
 	}
 
-	lb.Start()
-	time.Sleep(100 * time.Millisecond)
+	lb := server.NewLoadBalancer(opts...)
+	if err := lb.TRINI.SetAdaptiveMonitoring(config.MonitorIntervalMin, config.MonitorIntervalMax); err != nil {
+		log.Fatalf("Invalid -monitor-interval-min or -monitor-interval-max: %v", err)
+	}
+	for _, name := range config.Features {
+		if err := lb.SetFeatureFlag(name, true); err != nil {
+			log.Fatalf("Invalid -features value: %v", err)
+		}
+	}
 
-	// Start TRINI GC-aware load balancing
+	// Start the load balancer together with TRINI GC-aware load balancing
 	fmt.Println("🔍 Starting TRINI GC-aware load balancing...")
-	lb.StartTRINI()
+	if err := lb.Start(ctx); err != nil {
+		log.Fatalf("Failed to start load balancer: %v", err)
+	}
 	time.Sleep(500 * time.Millisecond)
 
+	for id, zone := range config.ServerZones {
+		srv := lb.GetServerByID(id)
+		if srv == nil {
+			log.Fatalf("Invalid -server-zones value: server %d not found", id)
+		}
+		srv.SetZone(zone)
+	}
+
+	for _, id := range config.StandbyServers {
+		srv := lb.GetServerByID(id)
+		if srv == nil {
+			log.Fatalf("Invalid -standby-servers value: server %d not found", id)
+		}
+		if err := srv.SetAdminState(server.StateStandby); err != nil {
+			log.Fatalf("Invalid -standby-servers value: %v", err)
+		}
+	}
+
+	// Configured pins replace any restored from storage
+	for _, pin := range config.FamilyPins {
+		if _, err := lb.PinFamily(pin.ServerID, pin.FamilyID, pin.Duration); err != nil {
+			log.Fatalf("Invalid -pin-family value: %v", err)
+		}
+	}
+
+	// Autoscaler starts disabled; enable it via POST /api/v1/autoscaler
+	autoscaler := server.NewAutoscaler(lb, server.DefaultAutoscalerConfig())
+	if err := autoscaler.Start(ctx); err != nil {
+		log.Fatalf("Failed to start autoscaler: %v", err)
+	}
+
+	// Autopilot starts disabled unless -autopilot; toggle it via POST /api/v1/autopilot
+	autopilot := server.NewAutopilot(lb, config.Autopilot)
+	if err := autopilot.Start(ctx); err != nil {
+		log.Fatalf("Failed to start autopilot: %v", err)
+	}
+	if config.AutopilotEnabled {
+		fmt.Printf("🤖 Autopilot generating %.1f synthetic tasks/s\n", config.Autopilot.TasksPerSecond)
+		autopilot.SetEnabled(true)
+	}
+
+	// Replication starts after the local config is in place, so a leader
+	// proposes it and a follower replaces it with the group's
+	var raft *server.RaftNode
+	if config.Raft.ID != "" {
+		var err error
+		if raft, err = server.NewRaftNode(lb, config.Raft, nil); err != nil {
+			log.Fatalf("Failed to start raft node: %v", err)
+		}
+		raft.Start(ctx)
+		fmt.Printf("🗳️  Replicating configuration as %s with %d peers\n", config.Raft.ID, len(config.Raft.Peers))
+	}
+
+	var monitoringLimiter *RateLimiter
+	if config.MonitoringRateLimit > 0 {
+		monitoringLimiter = NewRateLimiter(config.MonitoringRateLimit, time.Minute)
+	}
+
+	// Quota usage is kept with the rest of the state under -data-dir
+	var quotaStore server.Store
+	if storage != nil {
+		quotaStore = storage
+	}
+	quotas := NewQuotaTracker(config.Quotas, quotaStore)
+	if err := quotas.Restore(); err != nil {
+		log.Printf("⚠️ Could not restore quota usage: %v", err)
+	}
+
 	return &HTTPServer{
-		lb:   lb,
-		port: port,
+		lb:                lb,
+		autoscaler:        autoscaler,
+		autopilot:         autopilot,
+		raft:              raft,
+		taskLimiter:       NewRateLimiter(config.TaskRateLimit, time.Minute),
+		monitorLimiter:    NewRateLimiter(config.MonitorRateLimit, time.Minute),
+		monitoringLimiter: monitoringLimiter,
+		quotas:            quotas,
+		slowRequests:      NewSlowRequestTracker(config.SlowRequest),
+		responseCache:     NewResponseCache(config.ResponseCacheTTL),
+		metrics:           NewHTTPMetrics(),
+		decisions:         decisions,
+		storage:           storage,
+		config:            config,
 	}
 }
 
@@ -71,65 +221,203 @@ func (h *HTTPServer) submitTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	srv := h.lb.GetServerForTask(req.Task)
+	lane := server.LaneInteractive
+	if req.Type != "" {
+		lane = strings.ToLower(req.Type)
+	}
+	if !server.IsValidLane(lane) {
+		http.Error(w, fmt.Sprintf("Invalid task type %q: use interactive or batch", req.Type), http.StatusBadRequest)
+		return
+	}
+
+	timeout, err := parseTaskTimeout(req.TimeoutMs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Trusted admin listeners are not subject to quotas
+	if !isAdminRequest(r) {
+		if err := h.quotas.Reserve(rateLimitKey(r), len(req.Task)); err != nil {
+			h.rejectOverQuota(w, err)
+			return
+		}
+	}
+
+	// The decision is always captured for the slow request log, but only
+	// returned to the client when asked for
+	explain, _ := strconv.ParseBool(r.URL.Query().Get("explain"))
+	trace := requestTraceFrom(r.Context())
+	srv, response := h.lb.DispatchLaneWithTimeout(r.Context(), req.Task, lane, explain || trace != nil, timeout)
+	trace.setDecision(response.Explanation)
+	if !explain {
+		response.Explanation = nil
+	}
 	if srv == nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		json.NewEncoder(w).Encode(TaskResponse{
-			Status:  "rejected",
-			Message: "No available server",
-			TaskID:  fmt.Sprintf("task-%d", time.Now().UnixNano()),
+			Status:      "rejected",
+			Message:     response.Message,
+			TaskID:      response.TaskID,
+			NoServer:    response.NoServer,
+			Explanation: response.Explanation,
 		})
 		return
 	}
 
-	response := srv.RequestTask(req.Task)
+	statusCode, taskResponse := awaitTaskResponse(response)
+	setGCImminentHeader(w, srv, response.GCImminentWindow)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(taskResponse)
+}
+
+// taskTimeout is how long a submitted task may take before the client is told
+// it timed out; execution timeouts are bounded by the same limit
+const taskTimeout = time.Duration(server.MaxTaskTimeoutMs) * time.Millisecond
+
+// parseTaskTimeout converts a request's timeout_ms into the execution timeout
+// the server enforces; 0 leaves it to the policy
+func parseTaskTimeout(timeoutMs int64) (time.Duration, error) {
+	if timeoutMs < 0 || timeoutMs > taskTimeout.Milliseconds() {
+		return 0, fmt.Errorf("timeout_ms must be between 0 and %d", taskTimeout.Milliseconds())
+	}
+	return time.Duration(timeoutMs) * time.Millisecond, nil
+}
 
-	// Wait for result with timeout
+// awaitTaskResponse waits for a dispatched task's result and returns the HTTP
+// status and body to answer with
+func awaitTaskResponse(response server.ServiceResponse) (int, TaskResponse) {
 	select {
 	case result := <-response.ResultChan:
 		if result.Status == "rejected" {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(TaskResponse{
-				Status:  "rejected",
-				Message: "Server overloaded",
-				TaskID:  result.ID,
-			})
-		} else {
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(TaskResponse{
-				Status:  "completed",
-				Message: "Task processed successfully",
-				TaskID:  result.ID,
-				Output:  result.Output,
-			})
+			message := "Server overloaded"
+			if result.Reason != "" {
+				message = result.Reason
+			}
+			return http.StatusOK, TaskResponse{
+				Status:      "rejected",
+				Message:     message,
+				TaskID:      result.ID,
+				NoServer:    response.NoServer,
+				Explanation: response.Explanation,
+			}
 		}
-	case <-time.After(5 * time.Second):
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusRequestTimeout)
-		json.NewEncoder(w).Encode(TaskResponse{
-			Status:  "timeout",
-			Message: "Task processing timeout",
-		})
+		if result.Status == server.TaskStatusTimeout {
+			return http.StatusRequestTimeout, TaskResponse{
+				Status:      "timeout",
+				Message:     result.Reason,
+				TaskID:      result.ID,
+				NoServer:    response.NoServer,
+				Explanation: response.Explanation,
+			}
+		}
+		return http.StatusOK, TaskResponse{
+			Status:      "completed",
+			Message:     "Task processed successfully",
+			TaskID:      result.ID,
+			Output:      result.Output,
+			NoServer:    response.NoServer,
+			Explanation: response.Explanation,
+		}
+	case <-time.After(taskTimeout):
+		return http.StatusRequestTimeout, TaskResponse{
+			Status:      "timeout",
+			Message:     "Task processing timeout",
+			TaskID:      response.TaskID,
+			NoServer:    response.NoServer,
+			Explanation: response.Explanation,
+		}
+	}
+}
+
+// gcImminentHeader tells clients how soon the server that handled their
+// request is expected to pause for a MaGC, so they can send follow-up
+// requests elsewhere
+const gcImminentHeader = "X-GC-Imminent"
+
+// setGCImminentHeader adds the GC imminence header if the server's MaGC is
+// forecast within the policy's notice window
+func setGCImminentHeader(w http.ResponseWriter, srv *server.Server, window time.Duration) {
+	if window <= 0 {
+		return
+	}
+	if remaining, ok := srv.TimeToMaGC(); ok && remaining <= window {
+		w.Header().Set(gcImminentHeader, fmt.Sprintf("%dms", remaining.Milliseconds()))
 	}
 }
 
+// getStatus serves the cached fleet status, which the monitoring loop keeps
+// current. ?servers=1,3 returns some servers only, and ?fields=mem,gc,forecast
+// reads just those fields live; available_servers is then only reported with
+// the state field.
 func (h *HTTPServer) getStatus(w http.ResponseWriter, r *http.Request) {
 	status := make(map[string]interface{})
-	servers := make([]map[string]interface{}, 0)
+	servers := make([]interface{}, 0) // Ping results, or partial status maps with ?fields
+	query := r.URL.Query()
+
+	pool := h.lb.ListServers()
+	selected := pool
+	if ids := splitList(query.Get("servers")); len(ids) > 0 {
+		selected = make([]*server.Server, 0, len(ids))
+		for _, value := range ids {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid server ID: "+value, http.StatusBadRequest)
+				return
+			}
+			srv := h.lb.GetServerByID(id)
+			if srv == nil {
+				http.Error(w, "Unknown server ID: "+value, http.StatusNotFound)
+				return
+			}
+			selected = append(selected, srv)
+		}
+	}
+
+	fields := splitList(query.Get("fields"))
+	for _, field := range fields {
+		if !server.IsValidStatusField(field) {
+			http.Error(w, fmt.Sprintf("Invalid field %q: use %s", field, strings.Join(server.StatusFields, ", ")), http.StatusBadRequest)
+			return
+		}
+	}
+
+	cached := make(map[int]server.ServerStatus)
+	if len(fields) == 0 {
+		snapshot := h.lb.CachedStatus()
+		for _, result := range snapshot.Servers {
+			cached[result.ServerID] = result
+		}
+		status["updated_at"] = snapshot.UpdatedAt
+	}
 
 	availableCount := 0
-	for _, srv := range h.lb.Servers {
-		pingResult := srv.Ping()
-		if pingResult["is_available"].(bool) {
+	for _, srv := range selected {
+		if len(fields) > 0 {
+			result := srv.Status(fields)
+			if available, ok := result["is_available"].(bool); ok && available {
+				availableCount++
+			}
+			servers = append(servers, result)
+			continue
+		}
+
+		result, ok := cached[srv.ID]
+		if !ok {
+			result = srv.Ping() // Joined since the last refresh
+		}
+		if result.IsAvailable {
 			availableCount++
 		}
-		servers = append(servers, pingResult)
+		servers = append(servers, result)
 	}
 
-	status["total_servers"] = len(h.lb.Servers)
-	status["available_servers"] = availableCount
+	status["total_servers"] = len(pool)
+	if len(fields) == 0 || slices.Contains(fields, server.StatusFieldState) {
+		status["available_servers"] = availableCount
+	}
 	status["servers"] = servers
 
 	w.Header().Set("Content-Type", "application/json")
@@ -137,14 +425,17 @@ func (h *HTTPServer) getStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *HTTPServer) pingServer(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	serverID, err := strconv.Atoi(vars["id"])
-	if err != nil || serverID < 1 || serverID > len(h.lb.Servers) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
 		http.Error(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
-	srv := h.lb.Servers[serverID-1]
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
 	pingResult := srv.Ping()
 
 	w.Header().Set("Content-Type", "application/json")
@@ -153,84 +444,28 @@ func (h *HTTPServer) pingServer(w http.ResponseWriter, r *http.Request) {
 
 // TRINI monitoring endpoints
 func (h *HTTPServer) getTRINIStatus(w http.ResponseWriter, r *http.Request) {
-	if h.lb.TRINI == nil {
+	status, ok := h.lb.TRINIStatus()
+	if !ok {
 		http.Error(w, "TRINI not initialized", http.StatusServiceUnavailable)
 		return
 	}
 
-	status := map[string]interface{}{
-		"active":            h.lb.TRINI.IsActive,
-		"monitor_interval":  h.lb.TRINI.MonitorInterval.String(),
-		"analysis_interval": h.lb.TRINI.AnalysisInterval.String(),
-		"program_families":  len(h.lb.TRINI.ProgramFamilies),
-		"current_policy": map[string]interface{}{
-			"algorithm":         h.lb.CurrentPolicy.Algorithm,
-			"gc_aware":          h.lb.CurrentPolicy.GCAware,
-			"magc_threshold_ms": h.lb.CurrentPolicy.MaGCThreshold,
-			"history_window":    h.lb.CurrentPolicy.HistoryWindowSize,
-		},
-		"servers": h.getServerTRINIDetails(),
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(status)
 }
 
-func (h *HTTPServer) getServerTRINIDetails() []map[string]interface{} {
-	servers := make([]map[string]interface{}, 0)
-
-	for _, srv := range h.lb.Servers {
-		serverInfo := map[string]interface{}{
-			"server_id":          srv.ID,
-			"current_family":     nil,
-			"gc_history_count":   0,
-			"last_magc_forecast": nil,
-			"young_gen_used":     srv.YoungGenUsed,
-			"old_gen_used":       srv.OldGenUsed,
-			"young_gen_max":      srv.YoungGenMax,
-			"old_gen_max":        srv.OldGenMax,
-			"gc_count":           srv.GCCount,
-			"weights":            srv.Weights,
-		}
-
-		if srv.CurrentFamily != nil {
-			serverInfo["current_family"] = map[string]interface{}{
-				"id":                   srv.CurrentFamily.ID,
-				"name":                 srv.CurrentFamily.Name,
-				"description":          srv.CurrentFamily.Description,
-				"magc_threshold_ms":    srv.CurrentFamily.MaGCThreshold,
-				"forecast_window_size": srv.CurrentFamily.ForecastWindowSize,
-			}
-		}
-
-		serverInfo["gc_history_count"] = len(srv.GCHistory)
-
-		if srv.LastMaGCForecast != nil {
-			serverInfo["last_magc_forecast"] = map[string]interface{}{
-				"predicted_time":                srv.LastMaGCForecast.PredictedTime.Format(time.RFC3339),
-				"confidence":                    srv.LastMaGCForecast.Confidence,
-				"young_gen_threshold":           srv.LastMaGCForecast.YoungGenThreshold,
-				"time_to_magc_ms":               srv.LastMaGCForecast.TimeToMaGC,
-				"forecast_created_at":           srv.LastMaGCForecast.ForecastCreatedAt.Format(time.RFC3339),
-				"is_predicted_within_threshold": srv.IsMaGCPredicted(h.lb.CurrentPolicy.MaGCThreshold),
-			}
-		}
-
-		servers = append(servers, serverInfo)
-	}
-
-	return servers
-}
-
 func (h *HTTPServer) getGCHistory(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	serverID, err := strconv.Atoi(vars["id"])
-	if err != nil || serverID < 1 || serverID > len(h.lb.Servers) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
 		http.Error(w, "Invalid server ID", http.StatusBadRequest)
 		return
 	}
 
-	srv := h.lb.Servers[serverID-1]
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
 
 	// Get query parameters for filtering
 	limitStr := r.URL.Query().Get("limit")
@@ -264,10 +499,8 @@ func (h *HTTPServer) updateTRINIPolicy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Validate algorithm
-	validAlgorithms := map[string]bool{"RR": true, "RAN": true, "WRR": true, "WRAN": true}
-	if !validAlgorithms[policy.Algorithm] {
-		http.Error(w, "Invalid algorithm. Use RR, RAN, WRR, or WRAN", http.StatusBadRequest)
+	if err := policy.Validate(); err != nil {
+		http.Error(w, "Invalid policy: "+err.Error(), http.StatusBadRequest)
 		return
 	}
 
@@ -281,22 +514,25 @@ func (h *HTTPServer) updateTRINIPolicy(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// TRINIToggleRequest enables or disables TRINI
+type TRINIToggleRequest struct {
+	Active bool `json:"active"`
+}
+
 func (h *HTTPServer) toggleTRINI(w http.ResponseWriter, r *http.Request) {
 	if h.lb.TRINI == nil {
 		http.Error(w, "TRINI not initialized", http.StatusServiceUnavailable)
 		return
 	}
 
-	var req struct {
-		Active bool `json:"active"`
-	}
+	var req TRINIToggleRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	h.lb.TRINI.IsActive = req.Active
+	h.lb.TRINI.SetActive(req.Active)
 
 	status := "disabled"
 	if req.Active {
@@ -339,77 +575,97 @@ func (h *HTTPServer) getProgramFamilies(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
-func (h *HTTPServer) Start() {
-	r := mux.NewRouter()
-
-	// Create rate limiter (10 requests per minute)
-	rateLimiter := NewRateLimiter(10, time.Minute)
-
-	// Apply middleware chain
+// Handler returns every route with its middleware, so the API can be mounted
+// in another server or router. Authentication and rate limiting are applied
+// per listener by Start and are not included.
+func (h *HTTPServer) Handler() http.Handler {
+	// Apply middleware chain (rate limiting and auth are applied per listener)
 	middlewareChain := Chain(
 		RecoveryMiddleware,
-		LoggingMiddleware,
+		MetricsMiddleware(h.metrics),
+		SlowRequestMiddleware(h.slowRequests, h.lb),
 		CORSMiddleware,
-		rateLimiter.Middleware,
 		TRINIMonitoringMiddleware(h.lb),
 		GCForecastMiddleware(h.lb),
 		LoadBalancingDecisionMiddleware(h.lb),
-		// AuthMiddleware("your-api-key-here"), // Uncomment to enable auth
 	)
 
-	// API routes with middleware
-	api := r.PathPrefix("/api/v1").Subrouter()
-	api.Use(middlewareChain)
-	api.Use(ContentTypeMiddleware) // Only for API routes
-
-	// Original endpoints
-	api.HandleFunc("/task", h.submitTask).Methods("POST")
-	api.HandleFunc("/status", h.getStatus).Methods("GET")
-	api.HandleFunc("/server/{id}/ping", h.pingServer).Methods("GET")
-
-	// TRINI monitoring endpoints
-	api.HandleFunc("/trini/status", h.getTRINIStatus).Methods("GET")
-	api.HandleFunc("/trini/policy", h.updateTRINIPolicy).Methods("POST")
-	api.HandleFunc("/trini/toggle", h.toggleTRINI).Methods("POST")
-	api.HandleFunc("/trini/families", h.getProgramFamilies).Methods("GET")
-	api.HandleFunc("/server/{id}/gc-history", h.getGCHistory).Methods("GET")
-
-	// Health check (no middleware except basic ones)
-	healthRouter := r.PathPrefix("/health").Subrouter()
-	healthRouter.Use(Chain(RecoveryMiddleware, LoggingMiddleware))
-	healthRouter.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods("GET")
-
-	fmt.Printf("🚀 HTTP Server starting on port %s\n", h.port)
-	fmt.Println("📋 Available endpoints:")
-	fmt.Println("  POST /api/v1/task                    - Submit a task")
-	fmt.Println("  GET  /api/v1/status                  - Get system status")
-	fmt.Println("  GET  /api/v1/server/{id}/ping        - Ping specific server")
-	fmt.Println("  GET  /health                         - Health check")
-	fmt.Println("\n🔍 TRINI GC-Aware Monitoring:")
-	fmt.Println("  GET  /api/v1/trini/status            - Get TRINI status & server classifications")
-	fmt.Println("  POST /api/v1/trini/policy            - Update load balancing policy")
-	fmt.Println("  POST /api/v1/trini/toggle            - Enable/disable TRINI")
-	fmt.Println("  GET  /api/v1/trini/families          - Get program families")
-	fmt.Println("  GET  /api/v1/server/{id}/gc-history  - Get server GC history")
+	routes := h.apiRoutes()
+	h.monitoringPaths = monitoringPaths(routes)
+	r := newRouter()
+	h.registerRoutes(r, routes, middlewareChain)
+	return r
+}
+
+func (h *HTTPServer) Start(ctx context.Context) {
+	h.shutdown = ctx.Done()
+	handler := h.Handler()
+	routes := h.apiRoutes()
+
+	fmt.Printf("🚀 HTTP Server starting on %d listener(s)\n", len(h.config.Listeners))
+	printRoutes(routes)
 	fmt.Println("\n🛡️  Middleware enabled:")
-	fmt.Println("  ✅ Request logging")
+	fmt.Println("  ✅ Request logging and per-route metrics")
+	fmt.Printf("  ✅ Slow request detection (> %v)\n", h.config.SlowRequest)
 	fmt.Println("  ✅ CORS support")
-	fmt.Println("  ✅ Rate limiting (10 req/min)")
+	if h.config.ResponseCacheTTL > 0 {
+		fmt.Printf("  ✅ Response caching for status and families (%v)\n", h.config.ResponseCacheTTL)
+	}
+	fmt.Printf("  ✅ Rate limiting per client (tasks: %d req/min, other: %d req/min, TCP listeners)\n",
+		h.config.TaskRateLimit, h.config.MonitorRateLimit)
+	switch {
+	case slices.Contains(h.config.MonitoringExempt, exemptRateLimit):
+		fmt.Println("  ✅ Monitoring polls (status, metrics, health) exempt from rate limiting")
+	case h.monitoringLimiter != nil:
+		fmt.Printf("  ✅ Monitoring polls (status, metrics, health) limited separately: %d req/min\n",
+			h.config.MonitoringRateLimit)
+	}
 	fmt.Println("  ✅ Panic recovery")
 	fmt.Println("  ✅ Content-Type validation")
 	fmt.Println("  ✅ TRINI monitoring")
 	fmt.Println("  ✅ GC forecast logging")
 	fmt.Println("  ✅ Load balancing decision logging")
-	fmt.Println("  ⚠️  Authentication (disabled)")
+	if len(h.config.APIKeys) > 0 {
+		fmt.Println("  ✅ Authentication (TCP listeners)")
+		if slices.Contains(h.config.MonitoringExempt, exemptAuth) {
+			fmt.Println("  ⚠️  Monitoring polls (status, metrics, health) exempt from authentication")
+		}
+	} else {
+		fmt.Println("  ⚠️  Authentication (disabled)")
+	}
+
+	go h.quotas.Run(ctx)
+	if err := h.serve(ctx, handler); err != nil {
+		log.Fatal(err)
+	}
 
-	log.Fatal(http.ListenAndServe(":"+h.port, r))
+	fmt.Println("🛑 Shutting down load balancer...")
+	h.autoscaler.Stop()
+	h.autopilot.Stop()
+	if h.raft != nil {
+		h.raft.Stop()
+	}
+	h.lb.Stop()
+	if err := h.quotas.Persist(); err != nil {
+		log.Printf("⚠️ Could not persist quota usage: %v", err)
+	}
+	if h.decisions != nil {
+		h.decisions.Close()
+	}
+	if h.storage != nil {
+		h.storage.Close()
+	}
 }
 
 func main() {
-	port := "8080"
-	server := NewHTTPServer(port)
-	server.Start()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	config, err := LoadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := NewHTTPServer(ctx, config)
+	server.Start(ctx)
 }
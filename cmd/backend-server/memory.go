@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// MemoryPressureRequest changes when a task that does not fit triggers a MaGC;
+// omitted fields keep their current value
+type MemoryPressureRequest struct {
+	Mode           *string  `json:"mode"` // collect or wait
+	MinUtilization *float64 `json:"min_utilization"`
+}
+
+// getMemoryPressure reports the memory pressure policy
+func (h *HTTPServer) getMemoryPressure(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.MemoryPressurePolicy())
+}
+
+// updateMemoryPressure changes the memory pressure policy
+func (h *HTTPServer) updateMemoryPressure(w http.ResponseWriter, r *http.Request) {
+	var req MemoryPressureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	policy := h.lb.MemoryPressurePolicy()
+	if req.Mode != nil {
+		policy.Mode = *req.Mode
+	}
+	if req.MinUtilization != nil {
+		policy.MinUtilization = *req.MinUtilization
+	}
+	if err := h.lb.SetMemoryPressurePolicy(policy); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Memory pressure policy updated successfully",
+		"policy":  h.lb.MemoryPressurePolicy(),
+	})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"golang_lb/server"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds (seconds) of the request latency histogram
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// quietRoutes are polled frequently and are recorded in metrics but not logged
+var quietRoutes = map[string]bool{
+	"/api/v1/status":      true,
+	"/api/v1/raft/vote":   true,
+	"/api/v1/raft/append": true,
+	"/health":             true,
+	"/metrics":            true,
+}
+
+// routeKey identifies a route by method and path template
+type routeKey struct {
+	method string
+	route  string
+}
+
+// routeMetrics holds the counters for one route
+type routeMetrics struct {
+	statuses map[int]int64
+	buckets  []int64 // Cumulative counts per latencyBuckets entry
+	count    int64
+	sum      float64 // Seconds
+}
+
+// HTTPMetrics records request counts, status codes and latencies per route
+type HTTPMetrics struct {
+	mu     sync.Mutex
+	routes map[routeKey]*routeMetrics
+}
+
+// NewHTTPMetrics creates an empty metrics registry
+func NewHTTPMetrics() *HTTPMetrics {
+	return &HTTPMetrics{routes: make(map[routeKey]*routeMetrics)}
+}
+
+// observe records one request
+func (m *HTTPMetrics) observe(method, route string, status int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := routeKey{method: method, route: route}
+	metrics, exists := m.routes[key]
+	if !exists {
+		metrics = &routeMetrics{
+			statuses: make(map[int]int64),
+			buckets:  make([]int64, len(latencyBuckets)),
+		}
+		m.routes[key] = metrics
+	}
+
+	seconds := duration.Seconds()
+	metrics.statuses[status]++
+	metrics.count++
+	metrics.sum += seconds
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			metrics.buckets[i]++
+		}
+	}
+}
+
+// MetricsMiddleware records per-route metrics and logs each request, except
+// for frequently polled routes which are only counted
+func MetricsMiddleware(metrics *HTTPMetrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(wrapped, r)
+
+			duration := time.Since(start)
+			route := routeTemplate(r)
+			metrics.observe(r.Method, route, wrapped.statusCode, duration)
+
+			if !quietRoutes[route] {
+				log.Printf("%s %s %d %v", r.Method, r.URL.Path, wrapped.statusCode, duration)
+			}
+		})
+	}
+}
+
+// writePrometheus writes the HTTP metrics in the Prometheus text format
+func (m *HTTPMetrics) writePrometheus(b *strings.Builder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	keys := make([]routeKey, 0, len(m.routes))
+	for key := range m.routes {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		return keys[i].method < keys[j].method
+	})
+
+	b.WriteString("# HELP lb_http_requests_total HTTP requests by route, method and status code.\n")
+	b.WriteString("# TYPE lb_http_requests_total counter\n")
+	for _, key := range keys {
+		metrics := m.routes[key]
+		codes := make([]int, 0, len(metrics.statuses))
+		for code := range metrics.statuses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(b, "lb_http_requests_total{method=%q,route=%q,code=\"%d\"} %d\n",
+				key.method, key.route, code, metrics.statuses[code])
+		}
+	}
+
+	b.WriteString("# HELP lb_http_request_duration_seconds HTTP request latency by route and method.\n")
+	b.WriteString("# TYPE lb_http_request_duration_seconds histogram\n")
+	for _, key := range keys {
+		metrics := m.routes[key]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(b, "lb_http_request_duration_seconds_bucket{method=%q,route=%q,le=\"%s\"} %d\n",
+				key.method, key.route, strconv.FormatFloat(bound, 'g', -1, 64), metrics.buckets[i])
+		}
+		fmt.Fprintf(b, "lb_http_request_duration_seconds_bucket{method=%q,route=%q,le=\"+Inf\"} %d\n",
+			key.method, key.route, metrics.count)
+		fmt.Fprintf(b, "lb_http_request_duration_seconds_sum{method=%q,route=%q} %g\n", key.method, key.route, metrics.sum)
+		fmt.Fprintf(b, "lb_http_request_duration_seconds_count{method=%q,route=%q} %d\n", key.method, key.route, metrics.count)
+	}
+}
+
+// writeLoadBalancerMetrics writes task and server gauges in the Prometheus text format
+func writeLoadBalancerMetrics(b *strings.Builder, lb *server.LoadBalancer) {
+	stats := lb.Stats()
+	b.WriteString("# HELP lb_tasks_total Tasks by outcome.\n")
+	b.WriteString("# TYPE lb_tasks_total counter\n")
+	fmt.Fprintf(b, "lb_tasks_total{outcome=\"submitted\"} %d\n", stats.TasksSubmitted)
+	fmt.Fprintf(b, "lb_tasks_total{outcome=\"completed\"} %d\n", stats.TasksCompleted)
+	fmt.Fprintf(b, "lb_tasks_total{outcome=\"rejected\"} %d\n", stats.TasksRejected)
+	fmt.Fprintf(b, "lb_tasks_total{outcome=\"timeout\"} %d\n", stats.TasksTimedOut)
+	b.WriteString("# HELP lb_task_attempts_total Task executions sent to servers, hedges and retries included.\n")
+	b.WriteString("# TYPE lb_task_attempts_total counter\n")
+	fmt.Fprintf(b, "lb_task_attempts_total %d\n", stats.TaskAttempts)
+
+	servers := lb.ListServers()
+	b.WriteString("# HELP lb_servers Servers in the pool.\n")
+	b.WriteString("# TYPE lb_servers gauge\n")
+	fmt.Fprintf(b, "lb_servers %d\n", len(servers))
+
+	b.WriteString("# HELP lb_server_memory_utilization Memory utilization per server (0-1).\n")
+	b.WriteString("# TYPE lb_server_memory_utilization gauge\n")
+	for _, srv := range servers {
+		fmt.Fprintf(b, "lb_server_memory_utilization{server=\"%d\"} %g\n", srv.ID, srv.MemoryUtilization())
+	}
+
+	b.WriteString("# HELP lb_server_gc_pressure Composite GC pressure score per server (0-100).\n")
+	b.WriteString("# TYPE lb_server_gc_pressure gauge\n")
+	for _, srv := range servers {
+		fmt.Fprintf(b, "lb_server_gc_pressure{server=\"%d\"} %g\n", srv.ID, srv.GCPressure().Score)
+	}
+
+	b.WriteString("# HELP lb_fairness_index Jain's fairness index of recent server selections (1 is perfectly fair).\n")
+	b.WriteString("# TYPE lb_fairness_index gauge\n")
+	fmt.Fprintf(b, "lb_fairness_index %g\n", lb.Fairness().Index)
+
+	slo := lb.SLOStatus()
+	b.WriteString("# HELP lb_slo_error_budget_remaining Share of the SLO error budget left per window (negative once overspent).\n")
+	b.WriteString("# TYPE lb_slo_error_budget_remaining gauge\n")
+	for _, window := range slo.Windows {
+		fmt.Fprintf(b, "lb_slo_error_budget_remaining{slo=\"latency\",window=\"%s\"} %g\n", window.Window, window.LatencyBudgetRemaining)
+		fmt.Fprintf(b, "lb_slo_error_budget_remaining{slo=\"rejection\",window=\"%s\"} %g\n", window.Window, window.RejectionBudgetRemaining)
+	}
+	b.WriteString("# HELP lb_slo_burn_rate How fast the SLO error budget is spent per window (1 spends exactly all of it).\n")
+	b.WriteString("# TYPE lb_slo_burn_rate gauge\n")
+	for _, window := range slo.Windows {
+		fmt.Fprintf(b, "lb_slo_burn_rate{slo=\"latency\",window=\"%s\"} %g\n", window.Window, window.LatencyBurnRate)
+		fmt.Fprintf(b, "lb_slo_burn_rate{slo=\"rejection\",window=\"%s\"} %g\n", window.Window, window.RejectionBurnRate)
+	}
+
+	b.WriteString("# HELP lb_degraded Whether the balancer is in degraded mode (1) or normal operation (0).\n")
+	b.WriteString("# TYPE lb_degraded gauge\n")
+	fmt.Fprintf(b, "lb_degraded %d\n", degradedGauge(lb))
+}
+
+// getMetrics serves HTTP and load balancer metrics for Prometheus
+func (h *HTTPServer) getMetrics(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	h.metrics.writePrometheus(&b)
+	writeLoadBalancerMetrics(&b, h.lb)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
@@ -1,31 +1,17 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"golang_lb/server"
 	"log"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 )
 
-// LoggingMiddleware logs incoming requests
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		// Create a response writer wrapper to capture status code
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-
-		next.ServeHTTP(wrapped, r)
-
-		// Skip logging for status endpoint
-		if r.URL.Path != "/api/v1/status" {
-			duration := time.Since(start)
-			log.Printf("%s %s %v", r.Method, r.URL.Path, duration)
-		}
-	})
-}
-
 // responseWriter wraps http.ResponseWriter to capture status code
 type responseWriter struct {
 	http.ResponseWriter
@@ -37,12 +23,18 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to flush streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // CORSMiddleware handles Cross-Origin Resource Sharing
 func CORSMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Expose-Headers", gcImminentHeader)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -69,43 +61,141 @@ func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
 	}
 }
 
+// Allow records a request for the key and reports whether it is within the limit
+func (rl *RateLimiter) Allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+
+	// Clean old requests
+	if requests, exists := rl.requests[key]; exists {
+		var validRequests []time.Time
+		for _, reqTime := range requests {
+			if now.Sub(reqTime) < rl.window {
+				validRequests = append(validRequests, reqTime)
+			}
+		}
+		rl.requests[key] = validRequests
+	}
+
+	// Check rate limit
+	if len(rl.requests[key]) >= rl.limit {
+		return false
+	}
+
+	// Add current request
+	rl.requests[key] = append(rl.requests[key], now)
+	return true
+}
+
 func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		clientIP := r.RemoteAddr
+	return RateLimitMiddleware(rl, rl)(next)
+}
 
-		rl.mu.Lock()
-		now := time.Now()
+// RateLimitMiddleware limits task submissions and all other API calls separately,
+// keyed by the authenticated client identity or, failing that, the client IP
+func RateLimitMiddleware(taskLimiter, monitorLimiter *RateLimiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Skip rate limiting for health checks and replication heartbeats
+			if r.URL.Path == "/health" || isRaftRPC(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
 
-		// Clean old requests
-		if requests, exists := rl.requests[clientIP]; exists {
-			var validRequests []time.Time
-			for _, reqTime := range requests {
-				if now.Sub(reqTime) < rl.window {
-					validRequests = append(validRequests, reqTime)
-				}
+			limiter := monitorLimiter
+			if isTaskSubmission(r) {
+				limiter = taskLimiter
 			}
-			rl.requests[clientIP] = validRequests
-		}
 
-		// Check rate limit
-		if len(rl.requests[clientIP]) >= rl.limit {
-			rl.mu.Unlock()
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusTooManyRequests)
-			w.Write([]byte(`{"error": "Rate limit exceeded"}`))
-			return
-		}
+			if !limiter.Allow(rateLimitKey(r)) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusTooManyRequests)
+				w.Write([]byte(`{"error": "Rate limit exceeded"}`))
+				return
+			}
 
-		// Add current request
-		rl.requests[clientIP] = append(rl.requests[clientIP], now)
-		rl.mu.Unlock()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
 
-		next.ServeHTTP(w, r)
+// isTaskSubmission reports whether the request submits a task or a batch of tasks
+func isTaskSubmission(r *http.Request) bool {
+	return (r.URL.Path == "/api/v1/task" || r.URL.Path == "/api/v1/tasks") && r.Method == "POST"
+}
+
+// MonitoringMiddleware sends read-only monitoring polls through their own
+// middleware so dashboards neither need task credentials nor spend the budget
+// of other API calls; everything else goes through regular
+func MonitoringMiddleware(isMonitoring func(*http.Request) bool, monitoring, regular func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		monitoringHandler, regularHandler := monitoring(next), regular(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isMonitoring(r) {
+				monitoringHandler.ServeHTTP(w, r)
+				return
+			}
+			regularHandler.ServeHTTP(w, r)
+		})
+	}
+}
+
+// contextKey namespaces request context values set by middleware
+type contextKey string
+
+const (
+	identityContextKey contextKey = "identity"
+	adminContextKey    contextKey = "admin"
+)
+
+// clientIdentity returns the authenticated identity attached by AuthMiddleware
+func clientIdentity(r *http.Request) string {
+	identity, _ := r.Context().Value(identityContextKey).(string)
+	return identity
+}
+
+// AdminMiddleware marks requests that arrived on a trusted admin listener
+func AdminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), adminContextKey, true)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-// AuthMiddleware provides simple API key authentication
-func AuthMiddleware(apiKey string) func(http.Handler) http.Handler {
+// isAdminRequest reports whether the request arrived on an admin listener
+func isAdminRequest(r *http.Request) bool {
+	admin, _ := r.Context().Value(adminContextKey).(bool)
+	return admin
+}
+
+// rateLimitKey identifies the client for rate limiting
+func rateLimitKey(r *http.Request) string {
+	if identity := clientIdentity(r); identity != "" {
+		return identity
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// apiKeyIdentity derives a stable, non-secret identity from an API key
+func apiKeyIdentity(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// AuthMiddleware provides simple API key authentication and attaches the
+// caller's identity to the request context
+func AuthMiddleware(apiKeys ...string) func(http.Handler) http.Handler {
+	validKeys := make(map[string]bool, len(apiKeys))
+	for _, key := range apiKeys {
+		validKeys[key] = true
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Skip auth for health check
@@ -122,14 +212,15 @@ func AuthMiddleware(apiKey string) func(http.Handler) http.Handler {
 				return
 			}
 
-			if providedKey != apiKey {
+			if !validKeys[providedKey] {
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusUnauthorized)
 				w.Write([]byte(`{"error": "Invalid API key"}`))
 				return
 			}
 
-			next.ServeHTTP(w, r)
+			ctx := context.WithValue(r.Context(), identityContextKey, apiKeyIdentity(providedKey))
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -214,26 +305,31 @@ func (trw *triniResponseWriter) WriteHeader(code int) {
 	trw.ResponseWriter.WriteHeader(code)
 }
 
+func (trw *triniResponseWriter) Unwrap() http.ResponseWriter {
+	return trw.ResponseWriter
+}
+
 func logTRINIPreRequest(lb *server.LoadBalancer) {
 	if lb.TRINI == nil || !lb.TRINI.IsActive {
 		log.Printf("🔍 TRINI: Inactive - using regular load balancing")
 		return
 	}
 
+	policy := lb.GetLoadBalancingPolicy()
 	availableServers := 0
 	gcPredictedServers := 0
 
-	for _, srv := range lb.Servers {
+	for _, srv := range lb.ListServers() {
 		if srv.IsAvailable() {
 			availableServers++
-			if srv.IsMaGCPredicted(lb.CurrentPolicy.MaGCThreshold) {
+			if srv.IsMaGCPredicted(policy.MaGCThreshold) {
 				gcPredictedServers++
 			}
 		}
 	}
 
 	log.Printf("🔍 TRINI: Policy=%s, Available=%d, GC-Predicted=%d, Threshold=%dms",
-		lb.CurrentPolicy.Algorithm, availableServers, gcPredictedServers, lb.CurrentPolicy.MaGCThreshold)
+		policy.Algorithm, availableServers, gcPredictedServers, policy.MaGCThreshold)
 }
 
 func logTRINIPostRequest(lb *server.LoadBalancer, statusCode int, duration time.Duration) {
@@ -243,7 +339,7 @@ func logTRINIPostRequest(lb *server.LoadBalancer, statusCode int, duration time.
 
 	// Log server family classifications
 	familyCounts := make(map[string]int)
-	for _, srv := range lb.Servers {
+	for _, srv := range lb.ListServers() {
 		if srv.CurrentFamily != nil {
 			familyCounts[srv.CurrentFamily.Name]++
 		} else {
@@ -270,12 +366,13 @@ func GCForecastMiddleware(lb *server.LoadBalancer) func(http.Handler) http.Handl
 }
 
 func logGCForecasts(lb *server.LoadBalancer) {
-	for _, srv := range lb.Servers {
+	threshold := lb.GetLoadBalancingPolicy().MaGCThreshold
+	for _, srv := range lb.ListServers() {
 		if srv.LastMaGCForecast != nil {
 			forecast := srv.LastMaGCForecast
 			timeUntilMaGC := time.Until(forecast.PredictedTime)
 
-			if timeUntilMaGC > 0 && timeUntilMaGC.Milliseconds() <= lb.CurrentPolicy.MaGCThreshold {
+			if timeUntilMaGC > 0 && timeUntilMaGC.Milliseconds() <= threshold {
 				log.Printf("🔮 Server %d: MaGC predicted in %v (confidence: %.2f)",
 					srv.ID, timeUntilMaGC, forecast.Confidence)
 			}
@@ -291,7 +388,7 @@ func LoadBalancingDecisionMiddleware(lb *server.LoadBalancer) func(http.Handler)
 				// This will be logged by the load balancing algorithms themselves
 				// but we can add additional context here
 				if lb.TRINI != nil && lb.TRINI.IsActive {
-					log.Printf("⚖️  Using GC-aware %s algorithm", lb.CurrentPolicy.Algorithm)
+					log.Printf("⚖️  Using GC-aware %s algorithm", lb.GetLoadBalancingPolicy().Algorithm)
 				} else {
 					log.Printf("⚖️  Using regular round-robin algorithm")
 				}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		apiKey     string
+		want       string
+	}{
+		{"IPv4 caller", "203.0.113.7:51234", "", "ip:203.0.113.7"},
+		{"IPv6 caller", "[2001:db8::1]:443", "", "ip:2001:db8::1"},
+		{"address without a port", "203.0.113.7", "", "ip:203.0.113.7"},
+		{"API key", "203.0.113.7:51234", "secret-one", apiKeyIdentity("secret-one")},
+		{"same key from another address", "198.51.100.2:40000", "secret-one", apiKeyIdentity("secret-one")},
+		{"another key from the same address", "203.0.113.7:51234", "secret-two", apiKeyIdentity("secret-two")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = rateLimitKey(r)
+			}))
+			if tt.apiKey != "" {
+				handler = AuthMiddleware("secret-one", "secret-two")(handler)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/task", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.apiKey != "" {
+				r.Header.Set("X-API-Key", tt.apiKey)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("key %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if identity := apiKeyIdentity("secret-one"); len(identity) != len("key:")+12 || identity == "key:secret-one" {
+		t.Errorf("identity %q exposes the key or has the wrong length", identity)
+	}
+}
+
+func TestRateLimitMiddlewareLimitsEachClient(t *testing.T) {
+	taskLimiter := NewRateLimiter(2, time.Minute)
+	handler := AuthMiddleware("secret-one", "secret-two")(
+		RateLimitMiddleware(taskLimiter, NewRateLimiter(100, time.Minute))(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	submit := func(apiKey string) int {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/task", nil)
+		r.RemoteAddr = "203.0.113.7:51234" // Both clients behind one proxy
+		r.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	want := []struct {
+		apiKey string
+		code   int
+	}{
+		{"secret-one", http.StatusOK},
+		{"secret-one", http.StatusOK},
+		{"secret-one", http.StatusTooManyRequests},
+		{"secret-two", http.StatusOK},
+		{"secret-two", http.StatusOK},
+		{"secret-two", http.StatusTooManyRequests},
+	}
+	for i, w := range want {
+		if code := submit(w.apiKey); code != w.code {
+			t.Errorf("submission %d with %s: status %d, want %d", i, w.apiKey, code, w.code)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// openAPIVersion is the version of the API described by the spec
+const openAPIVersion = "1.0.0"
+
+// pathParamPattern matches mux path variables such as {id}
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaBuilder converts Go types to JSON schemas, collecting named struct
+// types as reusable components
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+// schema returns the JSON schema for t, referencing named structs by component
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, exists := b.components[t.Name()]; !exists {
+			b.components[t.Name()] = nil // Reserve the name so recursive types terminate
+			b.components[t.Name()] = b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t == reflect.TypeOf(time.Duration(0)) {
+			return map[string]interface{}{"type": "integer", "description": "Duration in nanoseconds"}
+		}
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	}
+	return map[string]interface{}{} // interface{} and anything else: any value
+}
+
+// structSchema describes the JSON-encoded fields of a struct, flattening embedded structs
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	b.addFields(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// addFields adds the fields of t to properties, following encoding/json's rules
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(embedded, properties)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type)
+	}
+}
+
+// jsonContent wraps a schema as an application/json media type
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schema},
+	}
+}
+
+// buildOpenAPI generates an OpenAPI 3 document from the route table
+func buildOpenAPI(routes []apiRoute) map[string]interface{} {
+	builder := &schemaBuilder{components: make(map[string]interface{})}
+	paths := make(map[string]interface{})
+	genericObject := map[string]interface{}{"type": "object"}
+
+	for _, route := range routes {
+		var parameters []interface{}
+		for _, match := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]interface{}{
+				"name":     match[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "integer"},
+			})
+		}
+		for _, param := range route.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        param.Name,
+				"in":          "query",
+				"description": param.Description,
+				"schema":      map[string]interface{}{"type": param.Type},
+			})
+		}
+
+		var content map[string]interface{}
+		switch {
+		case route.Stream:
+			content = map[string]interface{}{
+				eventStreamContentType: map[string]interface{}{"schema": builder.schema(reflect.TypeOf(route.Response))},
+			}
+		case route.Text:
+			content = map[string]interface{}{"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+		case route.Response != nil:
+			content = jsonContent(builder.schema(reflect.TypeOf(route.Response)))
+		default:
+			content = jsonContent(genericObject)
+		}
+
+		operation := map[string]interface{}{
+			"summary":     route.Summary,
+			"tags":        []string{route.Group},
+			"operationId": operationID(route),
+			"responses": map[string]interface{}{
+				"200": map[string]interface{}{"description": "Success", "content": content},
+			},
+		}
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+		if route.Request != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(builder.schema(reflect.TypeOf(route.Request))),
+			}
+		}
+
+		path := route.fullPath()
+		item, exists := paths[path].(map[string]interface{})
+		if !exists {
+			item = make(map[string]interface{})
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "GC-Aware Load Balancer API",
+			"description": "TRINI GC-aware load balancer management and task submission API",
+			"version":     openAPIVersion,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": builder.components},
+	}
+}
+
+// operationID derives a stable identifier such as get_server_id_ping
+func operationID(route apiRoute) string {
+	path := strings.NewReplacer("/api/v1", "", "{", "", "}", "", "-", "_", ".", "_").Replace(route.fullPath())
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	return strings.ToLower(route.Method) + "_" + strings.Join(parts, "_")
+}
+
+// getOpenAPI serves the OpenAPI document generated from the route table
+func (h *HTTPServer) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(buildOpenAPI(h.apiRoutes()))
+}
+
+// docsPage renders the spec with Swagger UI
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>GC-Aware Load Balancer API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// getDocs serves the Swagger UI page
+func getDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// FamilyPinRequest pins a server to a program family
+type FamilyPinRequest struct {
+	FamilyID string `json:"family_id"`
+	Duration string `json:"duration,omitempty"` // How long the pin lasts, e.g. 2h; empty pins until removed
+}
+
+// pinServerFamily assigns a server to a program family, bypassing automatic
+// classification until the pin expires or is removed
+func (h *HTTPServer) pinServerFamily(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req FamilyPinRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.FamilyID == "" {
+		http.Error(w, "family_id is required", http.StatusBadRequest)
+		return
+	}
+
+	var ttl time.Duration
+	if req.Duration != "" {
+		if ttl, err = time.ParseDuration(req.Duration); err != nil || ttl <= 0 {
+			http.Error(w, "Invalid duration", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if h.lb.GetServerByID(serverID) == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	pin, err := h.lb.PinFamily(serverID, req.FamilyID, ttl)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Program family pinned successfully",
+		"pin":     pin,
+	})
+}
+
+// unpinServerFamily hands a server's program family back to the classifier
+func (h *HTTPServer) unpinServerFamily(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	if h.lb.GetServerByID(serverID) == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.lb.UnpinFamily(serverID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Program family unpinned successfully",
+	})
+}
+
+// getFamilyPins lists the program family pins in effect
+func (h *HTTPServer) getFamilyPins(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pins": h.lb.FamilyPins(),
+	})
+}
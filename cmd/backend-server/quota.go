@@ -0,0 +1,276 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang_lb/server"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QuotaLimits caps the tasks and task bytes each client may submit per hour
+// and per day; 0 is unlimited
+type QuotaLimits struct {
+	HourlyTasks int64 `json:"hourly_tasks"`
+	DailyTasks  int64 `json:"daily_tasks"`
+	HourlyBytes int64 `json:"hourly_bytes"`
+	DailyBytes  int64 `json:"daily_bytes"`
+}
+
+// QuotaWindow is a client's usage in the current fixed hour or (UTC) day
+type QuotaWindow struct {
+	Start  time.Time `json:"start"`
+	Resets time.Time `json:"resets"`
+	Tasks  int64     `json:"tasks"`
+	Bytes  int64     `json:"bytes"`
+}
+
+// ClientUsage is a client's cumulative and windowed task submissions
+type ClientUsage struct {
+	Client     string      `json:"client"`
+	TotalTasks int64       `json:"total_tasks"`
+	TotalBytes int64       `json:"total_bytes"`
+	Rejected   int64       `json:"rejected"` // Submissions refused for exceeding a quota
+	Hourly     QuotaWindow `json:"hourly"`
+	Daily      QuotaWindow `json:"daily"`
+	Limits     QuotaLimits `json:"limits"`
+}
+
+// QuotaError describes the quota a submission would have exceeded
+type QuotaError struct {
+	Window string // hourly or daily
+	Unit   string // tasks or bytes
+	Used   int64
+	Limit  int64
+	Resets time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s %s quota exceeded: %d of %d used, resets at %s",
+		e.Window, e.Unit, e.Used, e.Limit, e.Resets.UTC().Format(time.RFC3339))
+}
+
+// quotaUsageDocument names the stored usage of every client
+const quotaUsageDocument = "quota_usage"
+
+// quotaFlushInterval is how often idle clients are evicted and usage is stored
+const quotaFlushInterval = time.Minute
+
+// QuotaTracker counts task submissions per client and enforces QuotaLimits.
+// Clients idle for a whole daily window are forgotten, totals included.
+type QuotaTracker struct {
+	mu      sync.Mutex
+	limits  QuotaLimits
+	clients map[string]*ClientUsage
+	store   server.Store // Keeps usage across restarts; nil keeps it in memory
+	now     func() time.Time
+}
+
+// NewQuotaTracker creates a tracker enforcing limits, restoring and storing
+// usage through store unless it is nil
+func NewQuotaTracker(limits QuotaLimits, store server.Store) *QuotaTracker {
+	return &QuotaTracker{
+		limits:  limits,
+		clients: make(map[string]*ClientUsage),
+		store:   store,
+		now:     time.Now,
+	}
+}
+
+// Restore loads the stored usage, keeping the windows that have not reset
+func (q *QuotaTracker) Restore() error {
+	if q.store == nil {
+		return nil
+	}
+	var usages []ClientUsage
+	if _, err := q.store.Load(quotaUsageDocument, &usages); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, usage := range usages {
+		usage.Limits = q.limits
+		q.clients[usage.Client] = &usage
+	}
+	q.evictLocked(q.now())
+	return nil
+}
+
+// Persist evicts idle clients and stores the usage of the rest
+func (q *QuotaTracker) Persist() error {
+	q.mu.Lock()
+	q.evictLocked(q.now())
+	usages := make([]ClientUsage, 0, len(q.clients))
+	for _, usage := range q.clients {
+		usages = append(usages, *usage)
+	}
+	q.mu.Unlock()
+
+	if q.store == nil {
+		return nil
+	}
+	return q.store.Save(quotaUsageDocument, usages)
+}
+
+// Run evicts idle clients and stores usage every quotaFlushInterval until ctx
+// is done
+func (q *QuotaTracker) Run(ctx context.Context) {
+	ticker := time.NewTicker(quotaFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := q.Persist(); err != nil {
+				log.Printf("⚠️ Could not persist quota usage: %v", err)
+			}
+		}
+	}
+}
+
+// evictLocked forgets clients whose daily window has reset, so keys seen once
+// do not accumulate; callers hold q.mu
+func (q *QuotaTracker) evictLocked(now time.Time) {
+	for client, usage := range q.clients {
+		if !now.Before(usage.Daily.Resets) {
+			delete(q.clients, client)
+		}
+	}
+}
+
+// Reserve charges a task of the given size to the client, or returns a
+// *QuotaError without charging it when that would exceed a quota
+func (q *QuotaTracker) Reserve(client string, bytes int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, ok := q.clients[client]
+	if !ok {
+		usage = &ClientUsage{Client: client, Limits: q.limits}
+		q.clients[client] = usage
+	}
+	rollWindows(usage, q.now())
+	size := int64(bytes)
+	checks := []struct {
+		window *QuotaWindow
+		name   string
+		tasks  int64
+		bytes  int64
+	}{
+		{&usage.Hourly, "hourly", q.limits.HourlyTasks, q.limits.HourlyBytes},
+		{&usage.Daily, "daily", q.limits.DailyTasks, q.limits.DailyBytes},
+	}
+	for _, check := range checks {
+		if check.tasks > 0 && check.window.Tasks+1 > check.tasks {
+			usage.Rejected++
+			return &QuotaError{Window: check.name, Unit: "tasks", Used: check.window.Tasks, Limit: check.tasks, Resets: check.window.Resets}
+		}
+		if check.bytes > 0 && check.window.Bytes+size > check.bytes {
+			usage.Rejected++
+			return &QuotaError{Window: check.name, Unit: "bytes", Used: check.window.Bytes, Limit: check.bytes, Resets: check.window.Resets}
+		}
+	}
+
+	usage.TotalTasks++
+	usage.TotalBytes += size
+	for _, window := range []*QuotaWindow{&usage.Hourly, &usage.Daily} {
+		window.Tasks++
+		window.Bytes += size
+	}
+	return nil
+}
+
+// Usage returns the client's usage, zero when it has not submitted anything
+func (q *QuotaTracker) Usage(client string) ClientUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	usage, ok := q.clients[client]
+	if !ok {
+		usage = &ClientUsage{Client: client, Limits: q.limits}
+	}
+	rollWindows(usage, q.now())
+	return *usage
+}
+
+// AllUsage returns the usage of every client that has submitted a task, by client
+func (q *QuotaTracker) AllUsage() []ClientUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	usages := make([]ClientUsage, 0, len(q.clients))
+	for _, usage := range q.clients {
+		rollWindows(usage, now)
+		usages = append(usages, *usage)
+	}
+	slices.SortFunc(usages, func(a, b ClientUsage) int {
+		return strings.Compare(a.Client, b.Client)
+	})
+	return usages
+}
+
+// Limits returns the configured quotas
+func (q *QuotaTracker) Limits() QuotaLimits {
+	return q.limits
+}
+
+// rollWindows starts new hourly and daily windows once now has passed the current ones
+func rollWindows(usage *ClientUsage, now time.Time) {
+	rollWindow(&usage.Hourly, now, time.Hour)
+	rollWindow(&usage.Daily, now, 24*time.Hour)
+}
+
+// rollWindow starts a new fixed window once now has passed the current one
+func rollWindow(window *QuotaWindow, now time.Time, length time.Duration) {
+	if now.Before(window.Resets) {
+		return
+	}
+	start := now.UTC().Truncate(length)
+	*window = QuotaWindow{Start: start, Resets: start.Add(length)}
+}
+
+// rejectOverQuota answers a submission that would exceed the client's quota
+func (h *HTTPServer) rejectOverQuota(w http.ResponseWriter, err error) {
+	var quotaErr *QuotaError
+	if errors.As(err, &quotaErr) {
+		retryAfter := int(math.Ceil(time.Until(quotaErr.Resets).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	json.NewEncoder(w).Encode(TaskResponse{
+		Status:  "rejected",
+		Message: err.Error(),
+	})
+}
+
+// getUsage returns the calling client's task usage and quotas. Requests on
+// the admin listener see every client, or one with ?client=.
+func (h *HTTPServer) getUsage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !isAdminRequest(r) {
+		json.NewEncoder(w).Encode(h.quotas.Usage(rateLimitKey(r)))
+		return
+	}
+
+	if client := r.URL.Query().Get("client"); client != "" {
+		json.NewEncoder(w).Encode(h.quotas.Usage(client))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"limits":  h.quotas.Limits(),
+		"clients": h.quotas.AllUsage(),
+	})
+}
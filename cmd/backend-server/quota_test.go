@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"golang_lb/server"
+	"testing"
+	"time"
+)
+
+// quotaTestStart is 15:20 UTC, so the hourly window resets at 16:00 and the
+// daily one at midnight
+var quotaTestStart = time.Date(2024, 3, 10, 15, 20, 0, 0, time.UTC)
+
+// quotaSubmission is one Reserve call made at an offset from quotaTestStart
+type quotaSubmission struct {
+	at    time.Duration
+	bytes int
+}
+
+func TestQuotaWindows(t *testing.T) {
+	limits := QuotaLimits{HourlyTasks: 2, DailyTasks: 3, HourlyBytes: 100, DailyBytes: 150}
+
+	tests := []struct {
+		name    string
+		earlier []quotaSubmission // All within quota
+		next    quotaSubmission
+		want    *QuotaError // nil when next is within quota
+	}{
+		{"within every quota", []quotaSubmission{{0, 10}}, quotaSubmission{time.Minute, 10}, nil},
+		{"hourly tasks exhausted", []quotaSubmission{{0, 10}, {time.Minute, 10}}, quotaSubmission{2 * time.Minute, 10},
+			&QuotaError{Window: "hourly", Unit: "tasks", Used: 2, Limit: 2, Resets: time.Date(2024, 3, 10, 16, 0, 0, 0, time.UTC)}},
+		{"hourly bytes exhausted", []quotaSubmission{{0, 60}}, quotaSubmission{time.Minute, 41},
+			&QuotaError{Window: "hourly", Unit: "bytes", Used: 60, Limit: 100, Resets: time.Date(2024, 3, 10, 16, 0, 0, 0, time.UTC)}},
+		{"hourly bytes filled exactly", []quotaSubmission{{0, 60}}, quotaSubmission{time.Minute, 40}, nil},
+		{"hourly window rolled", []quotaSubmission{{0, 10}, {time.Minute, 10}}, quotaSubmission{40 * time.Minute, 10}, nil},
+		{"daily tasks exhausted", []quotaSubmission{{0, 10}, {time.Minute, 10}, {time.Hour, 10}}, quotaSubmission{2 * time.Hour, 10},
+			&QuotaError{Window: "daily", Unit: "tasks", Used: 3, Limit: 3, Resets: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)}},
+		{"daily bytes exhausted", []quotaSubmission{{0, 80}, {time.Hour, 60}}, quotaSubmission{2 * time.Hour, 20},
+			&QuotaError{Window: "daily", Unit: "bytes", Used: 140, Limit: 150, Resets: time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)}},
+		{"daily window rolled", []quotaSubmission{{0, 10}, {time.Minute, 10}, {time.Hour, 10}}, quotaSubmission{9 * time.Hour, 10}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quotas := NewQuotaTracker(limits, nil)
+			reserve := func(s quotaSubmission) error {
+				quotas.now = func() time.Time { return quotaTestStart.Add(s.at) }
+				return quotas.Reserve("key:client", s.bytes)
+			}
+			for _, s := range tt.earlier {
+				if err := reserve(s); err != nil {
+					t.Fatalf("submission at +%v: %v", s.at, err)
+				}
+			}
+			before := quotas.Usage("key:client")
+
+			err := reserve(tt.next)
+			after := quotas.Usage("key:client")
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("rejected: %v", err)
+				}
+				if after.TotalTasks != before.TotalTasks+1 || after.Hourly.Tasks == 0 || after.Daily.Tasks == 0 {
+					t.Errorf("submission not charged: %+v", after)
+				}
+				return
+			}
+
+			var quotaErr *QuotaError
+			if !errors.As(err, &quotaErr) || *quotaErr != *tt.want {
+				t.Fatalf("error %v, want %v", err, tt.want)
+			}
+			if after.TotalTasks != before.TotalTasks || after.Rejected != before.Rejected+1 {
+				t.Errorf("rejected submission charged: %+v", after)
+			}
+		})
+	}
+}
+
+func TestQuotaEvictsIdleClients(t *testing.T) {
+	quotas := NewQuotaTracker(QuotaLimits{}, nil)
+	quotas.now = func() time.Time { return quotaTestStart }
+	quotas.Reserve("key:idle", 10)
+	quotas.now = func() time.Time { return quotaTestStart.Add(8 * time.Hour) }
+	quotas.Reserve("key:active", 10)
+
+	// Midnight has passed for the idle client's daily window only
+	quotas.now = func() time.Time { return quotaTestStart.Add(9 * time.Hour) }
+	quotas.Reserve("key:active", 10)
+	if err := quotas.Persist(); err != nil {
+		t.Fatal(err)
+	}
+	usages := quotas.AllUsage()
+	if len(usages) != 1 || usages[0].Client != "key:active" || usages[0].TotalTasks != 2 {
+		t.Errorf("after the idle client's day ended: %+v", usages)
+	}
+}
+
+func TestQuotaPersistsAcrossRestarts(t *testing.T) {
+	store := server.NewMemoryStore()
+	limits := QuotaLimits{HourlyTasks: 2}
+	quotas := NewQuotaTracker(limits, store)
+	quotas.now = func() time.Time { return quotaTestStart }
+	quotas.Reserve("key:client", 10)
+	quotas.Reserve("key:client", 10)
+	if err := quotas.Persist(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		at        time.Duration
+		limits    QuotaLimits
+		wantErr   bool
+		wantTotal int64
+	}{
+		{"within the hour", 10 * time.Minute, limits, true, 2},
+		{"with a raised limit", 10 * time.Minute, QuotaLimits{HourlyTasks: 3}, false, 3},
+		{"after the hour", time.Hour, limits, false, 3},
+		{"on the next day", 9 * time.Hour, limits, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restarted := NewQuotaTracker(tt.limits, store)
+			restarted.now = func() time.Time { return quotaTestStart.Add(tt.at) }
+			if err := restarted.Restore(); err != nil {
+				t.Fatal(err)
+			}
+			if err := restarted.Reserve("key:client", 10); (err != nil) != tt.wantErr {
+				t.Errorf("reserve after restart: %v, want error %v", err, tt.wantErr)
+			}
+			usage := restarted.Usage("key:client")
+			if usage.TotalTasks != tt.wantTotal || usage.Limits != tt.limits {
+				t.Errorf("restored %d tasks under %+v, want %d under %+v",
+					usage.TotalTasks, usage.Limits, tt.wantTotal, tt.limits)
+			}
+		})
+	}
+}
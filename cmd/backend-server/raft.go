@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"golang_lb/server"
+	"net/http"
+	"strings"
+)
+
+// raftDisabled answers replication endpoints on a backend outside any group
+func raftDisabled(w http.ResponseWriter) {
+	http.Error(w, "Replication is disabled: start the backend with -raft-id", http.StatusServiceUnavailable)
+}
+
+// getRaft reports this balancer's role, term and replication progress
+func (h *HTTPServer) getRaft(w http.ResponseWriter, r *http.Request) {
+	if h.raft == nil {
+		raftDisabled(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.raft.Status())
+}
+
+// raftVote answers a peer's RequestVote RPC
+func (h *HTTPServer) raftVote(w http.ResponseWriter, r *http.Request) {
+	if h.raft == nil {
+		raftDisabled(w)
+		return
+	}
+	var req server.RaftVoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.raft.HandleVote(req))
+}
+
+// raftAppend answers a leader's AppendEntries RPC
+func (h *HTTPServer) raftAppend(w http.ResponseWriter, r *http.Request) {
+	if h.raft == nil {
+		raftDisabled(w)
+		return
+	}
+	var req server.RaftAppendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.raft.HandleAppend(req))
+}
+
+// leaderOnly sends writes to replicated configuration on to the group's
+// leader: followers redirect them there, keeping method and body, or refuse
+// them while no leader is known. Without replication every write is local.
+func (h *HTTPServer) leaderOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.raft == nil || h.raft.IsLeader() {
+			next.ServeHTTP(w, r)
+			return
+		}
+		leader := h.raft.LeaderURL()
+		if leader == "" {
+			http.Error(w, "No replication leader elected; retry shortly", http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, leader+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	})
+}
+
+// isRaftRPC reports whether the request is a peer's vote or append RPC,
+// which are sent every heartbeat and so bypass rate limiting
+func isRaftRPC(r *http.Request) bool {
+	return r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/api/v1/raft/")
+}
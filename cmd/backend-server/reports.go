@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"golang_lb/server"
+	"net/http"
+)
+
+// runReportError answers a run report request that failed
+func runReportError(w http.ResponseWriter, err error) {
+	if errors.Is(err, server.ErrNoRunReportStorage) {
+		http.Error(w, "Run reports are not persisted: start the backend with -data-dir", http.StatusServiceUnavailable)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+// listRunReports lists the run reports saved in -data-dir
+func (h *HTTPServer) listRunReports(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.lb.RunReports()
+	if err != nil {
+		runReportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"reports": reports,
+		"count":   len(reports),
+	})
+}
+
+// createRunReport saves a report of the run so far
+func (h *HTTPServer) createRunReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.lb.SaveRunReport()
+	if err != nil {
+		runReportError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// getCurrentRunReport reports the run so far without saving it
+func (h *HTTPServer) getCurrentRunReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.RunReport())
+}
+
+// getRunReport returns a saved run report
+func (h *HTTPServer) getRunReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.lb.LoadRunReport(pathVar(r, "id"))
+	if err != nil {
+		runReportError(w, err)
+		return
+	}
+	if report == nil {
+		http.Error(w, "Run report not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
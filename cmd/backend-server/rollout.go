@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"golang_lb/server"
+	"net/http"
+)
+
+// getRollout returns the current or most recent blue/green policy rollout
+func (h *HTTPServer) getRollout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"current_policy": h.lb.GetLoadBalancingPolicy(),
+		"rollout":        h.lb.Rollout(),
+	})
+}
+
+// RolloutRequest stages a candidate policy, optionally with a custom rollout config
+type RolloutRequest struct {
+	Candidate server.LoadBalancingPolicy `json:"candidate"`
+	Config    *server.RolloutConfig      `json:"config"`
+}
+
+// stageRollout stages a candidate policy that receives a share of the traffic
+func (h *HTTPServer) stageRollout(w http.ResponseWriter, r *http.Request) {
+	var req RolloutRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := req.Candidate.Validate(); err != nil {
+		http.Error(w, "Invalid candidate policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config := server.DefaultRolloutConfig()
+	if req.Config != nil {
+		config = *req.Config
+	}
+
+	if err := h.lb.StageRollout(req.Candidate, config); err != nil {
+		http.Error(w, "Cannot stage rollout: "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Candidate policy staged successfully",
+		"rollout": h.lb.Rollout(),
+	})
+}
+
+// promoteRollout manually promotes the staged candidate policy
+func (h *HTTPServer) promoteRollout(w http.ResponseWriter, r *http.Request) {
+	if err := h.lb.PromoteRollout("manual promotion"); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Candidate policy promoted",
+		"rollout": h.lb.Rollout(),
+	})
+}
+
+// rollbackRollout manually abandons the staged candidate policy
+func (h *HTTPServer) rollbackRollout(w http.ResponseWriter, r *http.Request) {
+	if err := h.lb.RollbackRollout("manual rollback"); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Candidate policy rolled back",
+		"rollout": h.lb.Rollout(),
+	})
+}
@@ -0,0 +1,40 @@
+//go:build !gorillamux
+
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// stdRouter routes with net/http's method and wildcard patterns, so the
+// server builds with the standard library alone. Build with -tags gorillamux
+// to route with gorilla/mux instead.
+type stdRouter struct {
+	mux *http.ServeMux
+}
+
+func newRouter() router {
+	return &stdRouter{mux: http.NewServeMux()}
+}
+
+func (s *stdRouter) handle(method, path string, handler http.Handler) {
+	s.mux.Handle(method+" "+path, handler)
+}
+
+func (s *stdRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// pathVar returns a wildcard of the matched route path, such as {id}
+func pathVar(r *http.Request, name string) string {
+	return r.PathValue(name)
+}
+
+// routeTemplate returns the matched route path, falling back to the raw path
+func routeTemplate(r *http.Request) string {
+	if _, path, ok := strings.Cut(r.Pattern, " "); ok {
+		return path
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,37 @@
+//go:build gorillamux
+
+package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// muxRouter routes with gorilla/mux, selected with -tags gorillamux
+type muxRouter struct {
+	*mux.Router
+}
+
+func newRouter() router {
+	return muxRouter{mux.NewRouter()}
+}
+
+func (m muxRouter) handle(method, path string, handler http.Handler) {
+	m.Handle(path, handler).Methods(method)
+}
+
+// pathVar returns a variable of the matched route path, such as {id}
+func pathVar(r *http.Request, name string) string {
+	return mux.Vars(r)[name]
+}
+
+// routeTemplate returns the matched mux route template, falling back to the raw path
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
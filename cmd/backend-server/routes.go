@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"golang_lb/server"
+	"net/http"
+	"strings"
+)
+
+// queryParam documents an optional query string parameter
+type queryParam struct {
+	Name        string
+	Type        string // OpenAPI scalar type: string, integer, boolean
+	Description string
+}
+
+// apiRoute describes one endpoint. The same table registers the handlers,
+// prints the startup listing and generates the OpenAPI document, so the three
+// cannot drift apart.
+type apiRoute struct {
+	Method   string
+	Path     string // Relative to /api/v1 unless Root is set
+	Group    string
+	Summary  string
+	Handler  http.HandlerFunc
+	Root     bool         // Served outside /api/v1 with only recovery and metrics middleware
+	Query    []queryParam // Optional query parameters
+	Request  interface{}  // Zero value of the JSON request body type, if any
+	Response interface{}  // Zero value of the JSON response type; nil means a generic object
+	Text     bool         // Response is plain text rather than JSON
+	Stream   bool         // Response is a server-sent event stream of Response values
+	// Read-only endpoint polled by dashboards; -monitoring-rate-limit and
+	// -monitoring-exempt apply to it instead of the regular API limits
+	Monitoring bool
+	Cached     bool // Served from the -response-cache-ttl response cache
+	// Changes replicated configuration, so followers redirect it to the leader
+	Replicated bool
+}
+
+// fullPath returns the route path as served
+func (route apiRoute) fullPath() string {
+	if route.Root {
+		return route.Path
+	}
+	return "/api/v1" + route.Path
+}
+
+// Route groups, in listing order
+const (
+	groupCore        = "Core"
+	groupTRINI       = "TRINI GC-Aware Monitoring"
+	groupAutoscaling = "Autoscaling"
+	groupFlags       = "Feature Flags"
+	groupAdmin       = "Administration"
+	groupReplication = "Replication"
+	groupDocs        = "Documentation"
+)
+
+// routeGroups pairs each group with the emoji used in the startup listing
+var routeGroups = []struct {
+	name  string
+	emoji string
+}{
+	{groupCore, "📋"},
+	{groupTRINI, "🔍"},
+	{groupAutoscaling, "📈"},
+	{groupFlags, "🚩"},
+	{groupAdmin, "🛠️"},
+	{groupReplication, "🗳️"},
+	{groupDocs, "📖"},
+}
+
+// apiRoutes returns every endpoint served by the HTTP server
+func (h *HTTPServer) apiRoutes() []apiRoute {
+	return []apiRoute{
+		// Core endpoints
+		{Method: "POST", Path: "/task", Group: groupCore, Summary: "Submit a task", Handler: h.submitTask,
+			Query:   []queryParam{{"explain", "boolean", "Include the routing decision"}},
+			Request: TaskRequest{}, Response: TaskResponse{}},
+		{Method: "GET", Path: "/task/{id}/trace", Group: groupCore, Summary: "Decision, forecasts, queue wait, execution time and GC overlap of a finished task",
+			Handler: h.getTaskTrace, Response: server.TaskTrace{}},
+		{Method: "POST", Path: "/tasks", Group: groupCore, Summary: "Submit a batch of tasks spread across servers and zones", Handler: h.submitBatch,
+			Query:   []queryParam{{"explain", "boolean", "Include each task's routing decision"}},
+			Request: BatchTaskRequest{}, Response: BatchTaskResponse{}},
+		{Method: "GET", Path: "/usage", Group: groupCore, Summary: "Get task usage and quotas (all clients on the admin listener)", Handler: h.getUsage,
+			Query: []queryParam{{"client", "string", "Client identity, admin listener only"}}, Response: ClientUsage{}},
+		{Method: "GET", Path: "/status", Group: groupCore, Summary: "Get system status", Handler: h.getStatus, Monitoring: true, Cached: true,
+			Query: []queryParam{
+				{"servers", "string", "Comma-separated server IDs"},
+				{"fields", "string", "Comma-separated field groups (state, mem, gc, forecast, tasks); skips the ping"},
+			}},
+		{Method: "GET", Path: "/server/{id}/ping", Group: groupCore, Summary: "Ping specific server", Handler: h.pingServer,
+			Response: server.ServerStatus{}},
+		{Method: "PUT", Path: "/server/{id}/cost", Group: groupCore, Summary: "Set server cost/power score",
+			Handler: h.updateServerCost, Request: ServerCostRequest{}},
+		{Method: "PUT", Path: "/server/{id}/weight", Group: groupCore, Summary: "Set server weight or CPU multiplier",
+			Handler: h.updateServerWeight, Request: ServerWeightRequest{}},
+		{Method: "PUT", Path: "/server/{id}/zone", Group: groupCore, Summary: "Set server failure domain for batch spreading",
+			Handler: h.updateServerZone, Request: ServerZoneRequest{}, Replicated: true},
+		{Method: "PUT", Path: "/server/{id}/concurrency", Group: groupCore, Summary: "Set server concurrent task limit",
+			Handler: h.updateServerConcurrency, Request: ServerConcurrencyRequest{}},
+		{Method: "GET", Path: "/server/{id}/lanes", Group: groupCore, Summary: "Get server interactive/batch lane stats",
+			Handler: h.getServerLanes},
+		{Method: "PUT", Path: "/server/{id}/lanes", Group: groupCore, Summary: "Set server interactive lane share",
+			Handler: h.updateServerLanes, Request: ServerLanesRequest{}},
+		{Method: "PUT", Path: "/server/{id}/state", Group: groupCore, Summary: "Set server state (active|paused|draining|quarantined|standby)",
+			Handler: h.updateServerState, Request: ServerStateRequest{}},
+		{Method: "GET", Path: "/server/{id}/stats", Group: groupCore, Summary: "Get server execution statistics",
+			Handler: h.getServerStats, Response: server.ServerExecutionStats{}},
+		{Method: "GET", Path: "/upgrade", Group: groupCore, Summary: "Get rolling upgrade progress", Handler: h.getRollingUpgrade},
+		{Method: "POST", Path: "/upgrade", Group: groupCore, Summary: "Start a rolling upgrade, draining one server at a time",
+			Handler: h.startRollingUpgrade, Request: server.RollingUpgradeConfig{}},
+		{Method: "POST", Path: "/upgrade/abort", Group: groupCore, Summary: "Abort the rolling upgrade", Handler: h.abortRollingUpgrade},
+		{Method: "POST", Path: "/server/{id}/upgraded", Group: groupCore, Summary: "Report a drained server upgraded so it rejoins",
+			Handler: h.completeServerUpgrade},
+		{Method: "GET", Path: "/stats", Group: groupCore, Summary: "Get task outcome and cost statistics", Handler: h.getStats},
+		{Method: "GET", Path: "/slo", Group: groupCore, Summary: "SLO compliance and remaining error budget over 5m and 60m",
+			Handler: h.getSLO, Response: server.SLOStatus{}, Monitoring: true},
+		{Method: "PUT", Path: "/slo", Group: groupCore, Summary: "Set the latency and rejection SLO",
+			Handler: h.updateSLO, Request: SLORequest{}},
+		{Method: "GET", Path: "/reports", Group: groupCore, Summary: "List run reports saved in -data-dir", Handler: h.listRunReports},
+		{Method: "POST", Path: "/reports", Group: groupCore, Summary: "Save a report of the run so far", Handler: h.createRunReport,
+			Response: server.RunReport{}},
+		{Method: "GET", Path: "/reports/current", Group: groupCore, Summary: "Report of the run so far, without saving it",
+			Handler: h.getCurrentRunReport, Response: server.RunReport{}},
+		{Method: "GET", Path: "/reports/{id}", Group: groupCore, Summary: "Get a saved run report", Handler: h.getRunReport,
+			Response: server.RunReport{}},
+		{Method: "GET", Path: "/health", Group: groupCore, Summary: "Health check", Handler: healthCheck, Root: true, Text: true, Monitoring: true},
+		{Method: "GET", Path: "/metrics", Group: groupCore, Summary: "Prometheus metrics", Handler: h.getMetrics, Root: true, Text: true, Monitoring: true},
+
+		// TRINI monitoring endpoints
+		{Method: "GET", Path: "/trini/status", Group: groupTRINI, Summary: "Get TRINI status & server classifications",
+			Handler: h.getTRINIStatus, Response: server.TRINIStatus{}, Cached: true},
+		{Method: "POST", Path: "/trini/policy", Group: groupTRINI, Summary: "Update load balancing policy",
+			Handler: h.updateTRINIPolicy, Request: server.LoadBalancingPolicy{}, Replicated: true},
+		{Method: "POST", Path: "/trini/toggle", Group: groupTRINI, Summary: "Enable/disable TRINI",
+			Handler: h.toggleTRINI, Request: TRINIToggleRequest{}},
+		{Method: "GET", Path: "/trini/families", Group: groupTRINI, Summary: "Get program families", Handler: h.getProgramFamilies, Cached: true},
+		{Method: "GET", Path: "/trini/pins", Group: groupTRINI, Summary: "List program family pins", Handler: h.getFamilyPins},
+		{Method: "PUT", Path: "/server/{id}/family", Group: groupTRINI, Summary: "Pin server to a program family",
+			Handler: h.pinServerFamily, Request: FamilyPinRequest{}, Replicated: true},
+		{Method: "DELETE", Path: "/server/{id}/family", Group: groupTRINI, Summary: "Unpin server program family",
+			Handler: h.unpinServerFamily, Replicated: true},
+		{Method: "GET", Path: "/trini/rollout", Group: groupTRINI, Summary: "Get blue/green policy rollout state", Handler: h.getRollout},
+		{Method: "POST", Path: "/trini/rollout", Group: groupTRINI, Summary: "Stage a candidate policy",
+			Handler: h.stageRollout, Request: RolloutRequest{}},
+		{Method: "POST", Path: "/trini/rollout/promote", Group: groupTRINI, Summary: "Promote the staged candidate", Handler: h.promoteRollout, Replicated: true},
+		{Method: "POST", Path: "/trini/rollout/rollback", Group: groupTRINI, Summary: "Roll back the staged candidate", Handler: h.rollbackRollout, Replicated: true},
+		{Method: "GET", Path: "/trini/shadow", Group: groupTRINI, Summary: "Compare live decisions with the shadow policy",
+			Handler: h.getShadow, Response: server.ShadowStats{}},
+		{Method: "PUT", Path: "/trini/shadow", Group: groupTRINI, Summary: "Shadow every decision with a second policy",
+			Handler: h.updateShadow, Request: server.LoadBalancingPolicy{}},
+		{Method: "DELETE", Path: "/trini/shadow", Group: groupTRINI, Summary: "Stop shadowing decisions", Handler: h.deleteShadow},
+		{Method: "GET", Path: "/server/{id}/gc-history", Group: groupTRINI, Summary: "Get server GC history", Handler: h.getGCHistory,
+			Query: []queryParam{{"limit", "integer", "Maximum number of events"}}},
+		{Method: "GET", Path: "/server/{id}/gc-history/diff", Group: groupTRINI, Summary: "Generation growth, promotion and projected exhaustion between two points",
+			Handler: h.getGCHistoryDiff, Response: server.GCHistoryDiff{},
+			Query: []queryParam{
+				{"from", "string", "RFC 3339 time or duration ago, e.g. 5m; oldest snapshot when omitted"},
+				{"to", "string", "RFC 3339 time or duration ago; latest snapshot when omitted"},
+			}},
+		{Method: "GET", Path: "/server/{id}/timeline", Group: groupTRINI, Summary: "Chronological feed of a server's MaGCs, family changes, forecasts, selections and rejections",
+			Handler: h.getServerTimeline, Response: server.ServerTimeline{},
+			Query: []queryParam{
+				{"from", "string", "RFC 3339 time or duration ago, e.g. 10m"},
+				{"to", "string", "RFC 3339 time or duration ago"},
+				{"limit", "integer", "Entries per page (default 100, max 1000)"},
+				{"offset", "integer", "Entries to skip, e.g. next_offset of the previous page"},
+			}},
+		{Method: "PUT", Path: "/server/{id}/generations", Group: groupTRINI, Summary: "Set young/old generation sizing",
+			Handler: h.updateServerGenerations, Request: ServerGenerationsRequest{}},
+		{Method: "GET", Path: "/trini/compare", Group: groupTRINI, Summary: "Compare GC behavior across servers", Handler: h.compareServers,
+			Query: []queryParam{
+				{"servers", "string", "Comma-separated server IDs"},
+				{"window", "string", "Comparison window, e.g. 10m"},
+			}},
+		{Method: "GET", Path: "/trini/accuracy", Group: groupTRINI, Summary: "Forecast accuracy and confidence calibration curve",
+			Handler: h.getForecastAccuracy, Response: server.ForecastAccuracyReport{}},
+		{Method: "GET", Path: "/trini/memory-pressure", Group: groupTRINI, Summary: "Get when tasks that do not fit trigger a MaGC",
+			Handler: h.getMemoryPressure, Response: server.MemoryPressurePolicy{}},
+		{Method: "PUT", Path: "/trini/memory-pressure", Group: groupTRINI, Summary: "Set when tasks that do not fit trigger a MaGC",
+			Handler: h.updateMemoryPressure, Request: MemoryPressureRequest{}},
+		{Method: "GET", Path: "/trini/dataset", Group: groupTRINI, Summary: "Export labeled GC snapshots before each MaGC for training forecasters",
+			Handler: h.exportTrainingDataset, Text: true,
+			Query: []queryParam{
+				{"format", "string", "csv (default, one row per snapshot) or jsonl (one sequence per line)"},
+				{"servers", "string", "Comma-separated server IDs; all servers when omitted"},
+				{"window", "string", "Only MaGCs within this window, e.g. 1h"},
+			}},
+		{Method: "GET", Path: "/trini/gc-history/export", Group: groupTRINI, Summary: "Export every server's GC snapshots over a time range in one download",
+			Handler: h.exportGCHistory, Text: true,
+			Query: []queryParam{
+				{"format", "string", "csv (default) or jsonl, one snapshot per row or line"},
+				{"servers", "string", "Comma-separated server IDs; all servers when omitted"},
+				{"from", "string", "RFC 3339 time or duration ago, e.g. 1h; oldest snapshot when omitted"},
+				{"to", "string", "RFC 3339 time or duration ago; latest snapshot when omitted"},
+				{"gzip", "boolean", "Compress the download with gzip"},
+			}},
+		{Method: "GET", Path: "/trini/pressure", Group: groupTRINI, Summary: "Composite 0-100 GC pressure per server", Handler: h.getGCPressure},
+		{Method: "GET", Path: "/trini/policy-engine", Group: groupTRINI, Summary: "External policy engine decisions and fallbacks", Handler: h.getPolicyEngine},
+		{Method: "GET", Path: "/trini/forecast-calendar", Group: groupTRINI, Summary: "Predicted MaGC pauses across the fleet",
+			Handler: h.getForecastCalendar, Response: server.ForecastCalendar{},
+			Query: []queryParam{{"horizon", "string", "Forecast horizon, e.g. 5m"}}},
+		{Method: "GET", Path: "/trini/correlation", Group: groupTRINI, Summary: "Servers whose MaGC forecasts converge on the same window",
+			Handler: h.getForecastCorrelation, Response: server.ForecastCorrelation{}},
+
+		// Autoscaling endpoints
+		{Method: "GET", Path: "/autoscaler", Group: groupAutoscaling, Summary: "Get autoscaler config & last decision", Handler: h.getAutoscaler},
+		{Method: "POST", Path: "/autoscaler", Group: groupAutoscaling, Summary: "Enable/disable & configure autoscaler",
+			Handler: h.updateAutoscaler, Request: AutoscalerRequest{}},
+		{Method: "GET", Path: "/capacity", Group: groupAutoscaling, Summary: "Memory, utilization, forecast GC downtime and sustainable task rate per zone and family",
+			Handler: h.getCapacity, Response: server.CapacityReport{}, Monitoring: true,
+			Query: []queryParam{{"horizon", "string", "Downtime forecast horizon, e.g. 5m (at most 10m)"}}},
+		{Method: "GET", Path: "/standby", Group: groupAutoscaling, Summary: "Get warm standby config, GC-clean server count & standby servers",
+			Handler: h.getStandby, Response: server.StandbyStatus{}, Monitoring: true},
+		{Method: "PUT", Path: "/standby", Group: groupAutoscaling, Summary: "Configure when standby servers are activated under GC pressure",
+			Handler: h.updateStandby, Request: StandbyRequest{}},
+		{Method: "GET", Path: "/autopilot", Group: groupAutoscaling, Summary: "Get synthetic load generator config & task counts",
+			Handler: h.getAutopilot, Response: server.AutopilotStats{}},
+		{Method: "POST", Path: "/autopilot", Group: groupAutoscaling, Summary: "Enable/disable & configure synthetic load generator",
+			Handler: h.updateAutopilot, Request: AutopilotRequest{}},
+		{Method: "GET", Path: "/events", Group: groupAutoscaling, Summary: "Get recent load balancer events", Handler: h.getEvents,
+			Query: []queryParam{{"limit", "integer", "Maximum number of events"}}},
+		{Method: "GET", Path: "/events/stream", Group: groupAutoscaling, Summary: "Stream load balancer events (server-sent events)",
+			Handler: h.streamEvents, Response: server.Event{}, Stream: true},
+
+		// Feature flags
+		{Method: "GET", Path: "/flags", Group: groupFlags, Summary: "List feature flags", Handler: h.getFeatureFlags},
+		{Method: "POST", Path: "/flags", Group: groupFlags, Summary: "Enable/disable a feature flag",
+			Handler: h.updateFeatureFlag, Request: FeatureFlagRequest{}},
+
+		// Administration
+		{Method: "GET", Path: "/admin/mode", Group: groupAdmin, Summary: "Get operating mode (normal|degraded)",
+			Handler: h.getAdminMode, Response: server.OperatingMode{}},
+		{Method: "PUT", Path: "/admin/mode", Group: groupAdmin, Summary: "Switch to degraded mode (round-robin, no TRINI) or back",
+			Handler: h.updateAdminMode, Request: AdminModeRequest{}},
+
+		// Replication
+		{Method: "GET", Path: "/raft", Group: groupReplication, Summary: "Get Raft role, term, commit progress & peers",
+			Handler: h.getRaft, Response: server.RaftStatus{}, Monitoring: true},
+		{Method: "POST", Path: "/raft/vote", Group: groupReplication, Summary: "Raft RequestVote RPC between balancers",
+			Handler: h.raftVote, Request: server.RaftVoteRequest{}, Response: server.RaftVoteResponse{}},
+		{Method: "POST", Path: "/raft/append", Group: groupReplication, Summary: "Raft AppendEntries RPC between balancers",
+			Handler: h.raftAppend, Request: server.RaftAppendRequest{}, Response: server.RaftAppendResponse{}},
+
+		// Documentation
+		{Method: "GET", Path: "/openapi.json", Group: groupDocs, Summary: "OpenAPI specification", Handler: h.getOpenAPI, Root: true},
+		{Method: "GET", Path: "/docs", Group: groupDocs, Summary: "Swagger UI", Handler: getDocs, Root: true, Text: true},
+	}
+}
+
+// healthCheck reports that the server is up
+func healthCheck(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("OK"))
+}
+
+// monitoringPaths returns the full paths of the monitoring routes
+func monitoringPaths(routes []apiRoute) map[string]bool {
+	paths := make(map[string]bool)
+	for _, route := range routes {
+		if route.Monitoring {
+			paths[route.fullPath()] = true
+		}
+	}
+	return paths
+}
+
+// router dispatches requests to the handlers of the route table, see
+// router.go and router_mux.go
+type router interface {
+	http.Handler
+	handle(method, path string, handler http.Handler)
+}
+
+// registerRoutes adds the route table to the router. API routes get the full
+// middleware chain; root routes only get recovery and metrics.
+func (h *HTTPServer) registerRoutes(r router, routes []apiRoute, apiMiddleware func(http.Handler) http.Handler) {
+	api := Chain(apiMiddleware, ContentTypeMiddleware) // Only for API routes
+	root := Chain(RecoveryMiddleware, MetricsMiddleware(h.metrics))
+
+	for _, route := range routes {
+		var handler http.Handler = route.Handler
+		if route.Cached {
+			handler = h.responseCache.Middleware(handler)
+		}
+		if route.Replicated {
+			handler = h.leaderOnly(handler)
+		}
+		if route.Root {
+			r.handle(route.Method, route.fullPath(), root(handler))
+		} else {
+			r.handle(route.Method, route.fullPath(), api(handler))
+		}
+	}
+}
+
+// printRoutes lists the endpoints by group
+func printRoutes(routes []apiRoute) {
+	width, methodWidth := 0, 0
+	for _, route := range routes {
+		width = max(width, len(route.fullPath()))
+		methodWidth = max(methodWidth, len(route.Method))
+	}
+
+	for i, group := range routeGroups {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s %s:\n", group.emoji, group.name)
+		for _, route := range routes {
+			if route.Group != group.name {
+				continue
+			}
+			summary := route.Summary
+			if len(route.Query) > 0 {
+				names := make([]string, len(route.Query))
+				for j, param := range route.Query {
+					names[j] = param.Name
+				}
+				summary += " (?" + strings.Join(names, ", ?") + ")"
+			}
+			fmt.Printf("  %-*s %-*s - %s\n", methodWidth, route.Method, width, route.fullPath(), summary)
+		}
+	}
+}
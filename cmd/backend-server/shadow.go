@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/json"
+	"golang_lb/server"
+	"net/http"
+)
+
+// getShadow reports how live selections compare with the shadow policy
+func (h *HTTPServer) getShadow(w http.ResponseWriter, r *http.Request) {
+	stats, ok := h.lb.ShadowStats()
+	if !ok {
+		http.Error(w, "No shadow policy configured", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// updateShadow starts shadowing every selection with a second policy,
+// resetting the comparison
+func (h *HTTPServer) updateShadow(w http.ResponseWriter, r *http.Request) {
+	var policy server.LoadBalancingPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.lb.SetShadowPolicy(policy); err != nil {
+		http.Error(w, "Invalid policy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Shadow policy set successfully",
+		"policy":  policy,
+	})
+}
+
+// deleteShadow stops shadowing selections
+func (h *HTTPServer) deleteShadow(w http.ResponseWriter, r *http.Request) {
+	h.lb.ClearShadowPolicy()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Shadow policy removed",
+	})
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SLORequest changes the SLO; omitted fields keep their current value
+type SLORequest struct {
+	LatencyTargetMs  *int64   `json:"latency_target_ms"`
+	LatencyObjective *float64 `json:"latency_objective"`
+	RejectionBudget  *float64 `json:"rejection_budget"`
+}
+
+// getSLO reports SLO compliance and remaining error budget
+func (h *HTTPServer) getSLO(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.SLOStatus())
+}
+
+// updateSLO changes the latency and rejection objectives
+func (h *HTTPServer) updateSLO(w http.ResponseWriter, r *http.Request) {
+	var req SLORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	config := h.lb.SLO()
+	if req.LatencyTargetMs != nil {
+		config.LatencyTargetMs = *req.LatencyTargetMs
+	}
+	if req.LatencyObjective != nil {
+		config.LatencyObjective = *req.LatencyObjective
+	}
+	if req.RejectionBudget != nil {
+		config.RejectionBudget = *req.RejectionBudget
+	}
+	if err := h.lb.SetSLO(config); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "SLO updated successfully",
+		"slo":     h.lb.SLO(),
+	})
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"golang_lb/server"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// slowLogger writes slow request records as JSON lines
+var slowLogger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
+
+// requestTrace carries details from handlers back to the slow request detector
+type requestTrace struct {
+	mu       sync.Mutex
+	decision *server.SelectionExplanation
+}
+
+// traceContextKey stores the *requestTrace in the request context
+const traceContextKey contextKey = "request-trace"
+
+// requestTraceFrom returns the trace attached by SlowRequestMiddleware, if any
+func requestTraceFrom(ctx context.Context) *requestTrace {
+	trace, _ := ctx.Value(traceContextKey).(*requestTrace)
+	return trace
+}
+
+// setDecision records the selection decision made while serving the request
+func (t *requestTrace) setDecision(decision *server.SelectionExplanation) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.decision = decision
+}
+
+func (t *requestTrace) getDecision() *server.SelectionExplanation {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.decision
+}
+
+// SlowRequestTracker counts requests slower than a threshold
+type SlowRequestTracker struct {
+	threshold time.Duration
+
+	mu     sync.Mutex
+	total  int64
+	byPath map[string]int64
+}
+
+// NewSlowRequestTracker creates a tracker for the given latency threshold
+func NewSlowRequestTracker(threshold time.Duration) *SlowRequestTracker {
+	return &SlowRequestTracker{threshold: threshold, byPath: make(map[string]int64)}
+}
+
+// record counts one slow request
+func (t *SlowRequestTracker) record(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total++
+	t.byPath[path]++
+}
+
+// Snapshot returns the threshold and slow request counts per route
+func (t *SlowRequestTracker) Snapshot() map[string]interface{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byPath := make(map[string]int64, len(t.byPath))
+	for path, count := range t.byPath {
+		byPath[path] = count
+	}
+
+	return map[string]interface{}{
+		"threshold_ms": t.threshold.Milliseconds(),
+		"total":        t.total,
+		"by_path":      byPath,
+	}
+}
+
+// SlowRequestMiddleware logs and counts requests slower than the tracker's
+// threshold, with the selection decision and any GC pause that overlapped
+func SlowRequestMiddleware(tracker *SlowRequestTracker, lb *server.LoadBalancer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			trace := &requestTrace{}
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+			next.ServeHTTP(wrapped, r.WithContext(context.WithValue(r.Context(), traceContextKey, trace)))
+
+			end := time.Now()
+			duration := end.Sub(start)
+			if duration < tracker.threshold || isEventStream(wrapped) {
+				return
+			}
+
+			tracker.record(r.URL.Path)
+
+			pausedServers := lb.PausedServersBetween(start, end)
+			attrs := []any{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", wrapped.statusCode),
+				slog.Int64("duration_ms", duration.Milliseconds()),
+				slog.Int64("threshold_ms", tracker.threshold.Milliseconds()),
+				slog.Bool("gc_overlap", len(pausedServers) > 0),
+				slog.Any("gc_paused_servers", pausedServers),
+			}
+			if decision := trace.getDecision(); decision != nil {
+				selectedPaused := false
+				for _, id := range pausedServers {
+					selectedPaused = selectedPaused || id == decision.ServerID
+				}
+				attrs = append(attrs,
+					slog.Int("server_id", decision.ServerID),
+					slog.Bool("selected_server_paused", selectedPaused),
+					slog.Any("decision", decision),
+				)
+			}
+			slowLogger.Warn("🐢 slow request", attrs...)
+		})
+	}
+}
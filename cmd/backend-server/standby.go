@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// StandbyRequest changes when standby servers are activated; omitted fields
+// keep their current value
+type StandbyRequest struct {
+	MinCleanServers *int   `json:"min_clean_servers"`
+	CooldownMs      *int64 `json:"cooldown_ms"`
+}
+
+// getStandby reports the standby config and every standby server
+func (h *HTTPServer) getStandby(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.lb.StandbyStatus())
+}
+
+// updateStandby changes when standby servers are activated
+func (h *HTTPServer) updateStandby(w http.ResponseWriter, r *http.Request) {
+	var req StandbyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	config := h.lb.StandbyConfig()
+	if req.MinCleanServers != nil {
+		config.MinCleanServers = *req.MinCleanServers
+	}
+	if req.CooldownMs != nil {
+		config.CooldownMs = *req.CooldownMs
+	}
+	if err := h.lb.SetStandbyConfig(config); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Standby config updated successfully",
+		"standby": h.lb.StandbyStatus(),
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ServerStateRequest sets a server's administrative state
+type ServerStateRequest struct {
+	State string `json:"state"`
+}
+
+// updateServerState sets a server's administrative state (active, paused,
+// draining, quarantined or standby)
+func (h *HTTPServer) updateServerState(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ServerStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if h.lb.GetServerByID(serverID) == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.lb.SetServerState(serverID, req.State); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"message":   "Server state updated successfully",
+		"server_id": serverID,
+		"state":     req.State,
+	})
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// getStats returns aggregated task outcomes, cost accounting, learned task
+// durations and, while decisions are shadowed, the shadow policy comparison
+func (h *HTTPServer) getStats(w http.ResponseWriter, r *http.Request) {
+	stats := map[string]interface{}{
+		"tasks":         h.lb.Stats(),
+		"cost":          h.lb.CostReport(),
+		"durations":     h.lb.Durations.Estimates(),
+		"slow_requests": h.slowRequests.Snapshot(),
+		"fairness":      h.lb.Fairness(),
+	}
+	if shadow, ok := h.lb.ShadowStats(); ok {
+		stats["shadow"] = shadow
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// ServerCostRequest sets a server's cost/power score
+type ServerCostRequest struct {
+	Cost float64 `json:"cost"`
+}
+
+// updateServerCost sets the cost/power score of a server
+func (h *HTTPServer) updateServerCost(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ServerCostRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := srv.SetCost(req.Cost); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"message":   "Server cost updated successfully",
+		"server_id": serverID,
+		"cost":      srv.GetCost(),
+	})
+}
+
+// getServerStats returns a server's execution totals and rolling windows
+func (h *HTTPServer) getServerStats(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(srv.ExecutionStats())
+}
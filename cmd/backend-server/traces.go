@@ -0,0 +1,22 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// getTaskTrace links a finished task's selection decision to its outcome
+func (h *HTTPServer) getTaskTrace(w http.ResponseWriter, r *http.Request) {
+	trace, err := h.lb.TaskTrace(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if trace == nil {
+		http.Error(w, "Task trace not found (unknown task or still running)", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(trace)
+}
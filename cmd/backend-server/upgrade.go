@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"golang_lb/server"
+	"net/http"
+	"strconv"
+)
+
+// getRollingUpgrade returns the current or most recent rolling upgrade
+func (h *HTTPServer) getRollingUpgrade(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"upgrade": h.lb.RollingUpgrade(),
+	})
+}
+
+// startRollingUpgrade drains servers one at a time for upgrade; omitted
+// config fields use the defaults
+func (h *HTTPServer) startRollingUpgrade(w http.ResponseWriter, r *http.Request) {
+	config := server.DefaultRollingUpgradeConfig()
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if err := config.Validate(); err != nil {
+		http.Error(w, "Invalid upgrade config: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.lb.StartRollingUpgrade(config); err != nil {
+		http.Error(w, "Cannot start rolling upgrade: "+err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Rolling upgrade started",
+		"upgrade": h.lb.RollingUpgrade(),
+	})
+}
+
+// abortRollingUpgrade stops the rolling upgrade and restores the server being upgraded
+func (h *HTTPServer) abortRollingUpgrade(w http.ResponseWriter, r *http.Request) {
+	if err := h.lb.AbortRollingUpgrade("manual abort"); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Rolling upgrade aborted",
+		"upgrade": h.lb.RollingUpgrade(),
+	})
+}
+
+// completeServerUpgrade reports that a drained server has been upgraded and
+// can rejoin with slow start
+func (h *HTTPServer) completeServerUpgrade(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	if h.lb.GetServerByID(serverID) == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+	if err := h.lb.CompleteServerUpgrade(serverID); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    "success",
+		"message":   "Server upgrade recorded",
+		"server_id": serverID,
+	})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// ServerWeightRequest sets an explicit weight, a CPU multiplier, or auto
+type ServerWeightRequest struct {
+	Weight        *int     `json:"weight"`
+	CPUMultiplier *float64 `json:"cpu_multiplier"`
+	Auto          bool     `json:"auto"`
+}
+
+// updateServerWeight adjusts the weight used by the weighted algorithms. A
+// request sets an explicit weight, a CPU multiplier, or "auto" to go back to
+// the capacity-derived weight.
+func (h *HTTPServer) updateServerWeight(w http.ResponseWriter, r *http.Request) {
+	serverID, err := strconv.Atoi(pathVar(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	srv := h.lb.GetServerByID(serverID)
+	if srv == nil {
+		http.Error(w, "Invalid server ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ServerWeightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if req.Weight == nil && req.CPUMultiplier == nil && !req.Auto {
+		http.Error(w, "One of weight, cpu_multiplier or auto is required", http.StatusBadRequest)
+		return
+	}
+	if req.Weight != nil && req.Auto {
+		http.Error(w, "weight and auto cannot be combined", http.StatusBadRequest)
+		return
+	}
+
+	if req.CPUMultiplier != nil {
+		if err := srv.SetCPUMultiplier(*req.CPUMultiplier); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Auto {
+		srv.ResetWeight()
+	}
+	if req.Weight != nil {
+		if err := srv.SetWeight(*req.Weight); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":  "success",
+		"message": "Server weight updated successfully",
+		"weight":  srv.WeightInfo(),
+	})
+}
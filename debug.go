@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"golang_lb/server"
+	"time"
+)
+
+func printDebugUsage() {
+	fmt.Println("Usage: golang_lb debug replay -log FILE [-from TIME] [-to TIME] [-server ID] [-v]")
+	fmt.Println("\n📋 Commands:")
+	fmt.Println("  replay                       - Re-run recorded selection decisions and compare the result")
+	fmt.Println("\nTIME is RFC 3339 (2006-01-02T15:04:05Z) or a duration back from now (e.g. 15m)")
+}
+
+// runDebug runs offline debugging tools and returns the exit code
+func runDebug(args []string) int {
+	if len(args) == 0 || args[0] != "replay" {
+		printDebugUsage()
+		return 2
+	}
+
+	fs := flag.NewFlagSet("debug replay", flag.ContinueOnError)
+	logPath := fs.String("log", "", "Decision log written by the backend server's -decision-log")
+	fromFlag := fs.String("from", "", "Only replay decisions made at or after this time")
+	toFlag := fs.String("to", "", "Only replay decisions made at or before this time")
+	serverID := fs.Int("server", 0, "Only replay decisions that picked this server")
+	verbose := fs.Bool("v", false, "Show the recorded server state and skip reasons for every decision")
+	fs.Usage = printDebugUsage
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if *logPath == "" {
+		printDebugUsage()
+		return 2
+	}
+
+	now := time.Now()
+	from, err := parseReplayTime(*fromFlag, now)
+	if err != nil {
+		fmt.Printf("❌ Invalid -from: %v\n", err)
+		return 2
+	}
+	to, err := parseReplayTime(*toFlag, now)
+	if err != nil {
+		fmt.Printf("❌ Invalid -to: %v\n", err)
+		return 2
+	}
+
+	records, err := server.ReadDecisionLog(*logPath, from, to)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return 1
+	}
+	if *serverID != 0 {
+		filtered := records[:0]
+		for _, record := range records {
+			if record.ServerID == *serverID {
+				filtered = append(filtered, record)
+			}
+		}
+		records = filtered
+	}
+
+	fmt.Printf("🔁 Replaying %d decisions from %s\n", len(records), *logPath)
+	mismatches := 0
+	for _, result := range server.ReplayDecisions(records) {
+		if !result.Match {
+			mismatches++
+		}
+		printReplayResult(result, *verbose)
+	}
+
+	fmt.Printf("\n📊 %d decisions replayed, %d matched, %d mismatched\n",
+		len(records), len(records)-mismatches, mismatches)
+	if mismatches > 0 {
+		return 1
+	}
+	return 0
+}
+
+// parseReplayTime accepts an RFC 3339 timestamp or a duration before now; empty means unbounded
+func parseReplayTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return now.Add(-ago), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func serverLabel(id int) string {
+	if id == 0 {
+		return "rejected"
+	}
+	return fmt.Sprintf("server %d", id)
+}
+
+func printReplayResult(result server.ReplayResult, verbose bool) {
+	record := result.Record
+	mark := "✅"
+	if !result.Match {
+		mark = "❌"
+	}
+	fmt.Printf("%s %s %s (%d bytes, TRINI: %t, window %dms): recorded %s, replayed %s\n",
+		mark, record.Time.Format("2006-01-02 15:04:05.000"), record.Policy.Algorithm,
+		record.TaskSize, record.TRINIActive, record.PlacementWindowMs,
+		serverLabel(record.ServerID), serverLabel(result.ServerID))
+
+	if !verbose && result.Match {
+		return
+	}
+	for _, skipped := range result.Explanation.Skipped {
+		fmt.Printf("   Skipped server %d: %s\n", skipped.ServerID, skipped.Reason)
+	}
+	if result.Explanation.Fallback != "" {
+		fmt.Printf("   Fallback: %s\n", result.Explanation.Fallback)
+	}
+	for _, srv := range record.Servers {
+		forecast := "no forecast"
+		if srv.HasForecast {
+			forecast = fmt.Sprintf("MaGC in %dms (confidence %.2f)", srv.ForecastInMs, srv.Confidence)
+		}
+		fmt.Printf("   Server %d: %s, GC: %t, memory %d/%d, weight %d, %s\n",
+			srv.ID, srv.AdminState, srv.CollectingGC, srv.UsedMemory, srv.MemLimit, srv.Weight, forecast)
+	}
+}
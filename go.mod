@@ -2,4 +2,9 @@ module golang_lb
 
 go 1.23.4
 
-require github.com/gorilla/mux v1.8.1
+require (
+	github.com/gorilla/mux v1.8.1
+	golang.org/x/net v0.33.0
+)
+
+require golang.org/x/text v0.21.0 // indirect
@@ -2,8 +2,10 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"golang_lb/server"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -11,27 +13,26 @@ import (
 )
 
 func main() {
-	lb := &server.LoadBalancer{
-		Servers: make([]*server.Server, 0),
+	if len(os.Args) > 1 && os.Args[1] == "client" {
+		os.Exit(runClient(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		os.Exit(runDebug(os.Args[2:]))
 	}
 
+	servers := make([]*server.Server, 0)
 	for i := 1; i <= 3; i++ {
-		srv := &server.Server{
-			ID:           i,
-			LoadBalancer: lb,
-			TaskStorage:  make([]string, 0),
-		}
-		lb.Servers = append(lb.Servers, srv)
+		servers = append(servers, server.NewServer(i, 100, 90.0))
 	}
+	lb := server.NewLoadBalancer(server.WithServers(servers...))
 
 	// Start the load balancer
 	fmt.Println("🚀 Starting Load Balancer System...")
-	lb.Start()
-	time.Sleep(100 * time.Millisecond)
-
-	// Start TRINI GC-aware load balancing
-	fmt.Println("🔍 Starting TRINI GC-aware load balancing...")
-	lb.StartTRINI()
+	if err := lb.Start(context.Background()); err != nil {
+		fmt.Printf("❌ Failed to start load balancer: %v\n", err)
+		os.Exit(1)
+	}
+	defer lb.Stop()
 	time.Sleep(500 * time.Millisecond)
 
 	fmt.Println("✅ Load Balancer with TRINI is ready!")
@@ -76,14 +77,45 @@ func main() {
 			handlePing(lb, serverID)
 
 		case "status", "s":
-			handleStatus(lb)
+			_, interval, watching, err := parseWatch(parts[1:])
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+				continue
+			}
+			if watching {
+				watchUntilEnter(scanner, "status", interval, func(w io.Writer) error {
+					handleStatus(w, lb)
+					return nil
+				})
+				continue
+			}
+			handleStatus(os.Stdout, lb)
+
+		case "state":
+			if len(parts) < 3 {
+				fmt.Println("❌ Usage: state <server_id> <active|paused|draining|quarantined|standby>")
+				continue
+			}
+			serverID, err := strconv.Atoi(parts[1])
+			if err != nil {
+				fmt.Println("❌ Invalid server ID")
+				continue
+			}
+			if err := lb.SetServerState(serverID, strings.ToLower(parts[2])); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				continue
+			}
+			fmt.Printf("✅ Server %d is now %s\n", serverID, strings.ToLower(parts[2]))
 
 		case "trini":
 			if len(parts) < 2 {
 				fmt.Println("❌ Usage: trini <on|off|status|policy>")
 				continue
 			}
-			handleTRINI(lb, parts[1:])
+			handleTRINI(lb, scanner, parts[1:])
+
+		case "tune":
+			handleTune(lb, parts[1:])
 
 		case "help", "h":
 			printTRINIHelp()
@@ -102,16 +134,32 @@ func printTRINIHelp() {
 	fmt.Println("\n📋 Available Commands:")
 	fmt.Println("  task <text>     - Send a task to be processed (alias: t)")
 	fmt.Println("  ping <id>       - Ping a specific server (alias: p)")
-	fmt.Println("  status          - Show all servers status (alias: s)")
-	fmt.Println("  trini <cmd>     - TRINI GC-aware control (on|off|status|policy)")
+	fmt.Println("  status          - Show all servers status (alias: s); --watch [1s] re-renders it")
+	fmt.Println("  state <id> <s>  - Set server state (active|paused|draining|quarantined|standby)")
+	fmt.Println("  trini <cmd>     - TRINI GC-aware control (on|off|status|policy); trini status takes --watch [1s]")
+	fmt.Println("  tune [--apply]  - Run a calibration workload and recommend a policy; --duration 30s, --rate 2")
 	fmt.Println("  help            - Show this help message (alias: h)")
 	fmt.Println("  quit            - Exit the program (alias: q, exit)")
 	fmt.Println("\nExample: task hello world")
 	fmt.Println("Example: ping 1")
-	fmt.Println("Example: trini status")
+	fmt.Println("Example: trini status --watch 2s")
 }
 
-func handleTRINI(lb *server.LoadBalancer, args []string) {
+// watchUntilEnter re-renders a command's output until the user presses Enter
+func watchUntilEnter(scanner *bufio.Scanner, title string, interval time.Duration, render func(w io.Writer) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		scanner.Scan() // Any line, or the end of input, stops watching
+		cancel()
+	}()
+
+	watch(ctx, title, "Press Enter to stop", interval, render)
+	<-stopped
+}
+
+func handleTRINI(lb *server.LoadBalancer, scanner *bufio.Scanner, args []string) {
 	if len(args) == 0 {
 		fmt.Println("❌ Usage: trini <on|off|status|policy>")
 		return
@@ -122,7 +170,7 @@ func handleTRINI(lb *server.LoadBalancer, args []string) {
 	switch command {
 	case "on":
 		if lb.TRINI != nil {
-			lb.TRINI.IsActive = true
+			lb.TRINI.SetActive(true)
 			fmt.Println("✅ TRINI GC-aware load balancing enabled")
 		} else {
 			fmt.Println("❌ TRINI not initialized")
@@ -130,14 +178,26 @@ func handleTRINI(lb *server.LoadBalancer, args []string) {
 
 	case "off":
 		if lb.TRINI != nil {
-			lb.TRINI.IsActive = false
+			lb.TRINI.SetActive(false)
 			fmt.Println("⚠️ TRINI GC-aware load balancing disabled")
 		} else {
 			fmt.Println("❌ TRINI not initialized")
 		}
 
 	case "status":
-		showTRINIStatus(lb)
+		_, interval, watching, err := parseWatch(args[1:])
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			return
+		}
+		if watching {
+			watchUntilEnter(scanner, "trini status", interval, func(w io.Writer) error {
+				showTRINIStatus(w, lb)
+				return nil
+			})
+			return
+		}
+		showTRINIStatus(os.Stdout, lb)
 
 	case "policy":
 		if len(args) > 1 {
@@ -152,50 +212,60 @@ func handleTRINI(lb *server.LoadBalancer, args []string) {
 	}
 }
 
-func showTRINIStatus(lb *server.LoadBalancer) {
+func showTRINIStatus(w io.Writer, lb *server.LoadBalancer) {
 	if lb.TRINI == nil {
-		fmt.Println("❌ TRINI not initialized")
+		fmt.Fprintln(w, "❌ TRINI not initialized")
 		return
 	}
 
-	fmt.Println("\n🔍 TRINI Status:")
-	fmt.Printf("   Active: %t\n", lb.TRINI.IsActive)
-	fmt.Printf("   Monitor Interval: %v\n", lb.TRINI.MonitorInterval)
-	fmt.Printf("   Analysis Interval: %v\n", lb.TRINI.AnalysisInterval)
-	fmt.Printf("   Program Families: %d\n", len(lb.TRINI.ProgramFamilies))
+	fmt.Fprintln(w, "\n🔍 TRINI Status:")
+	fmt.Fprintf(w, "   Active: %t\n", lb.TRINI.IsActive)
+	fmt.Fprintf(w, "   Monitor Interval: %v\n", lb.TRINI.MonitorInterval)
+	fmt.Fprintf(w, "   Analysis Interval: %v\n", lb.TRINI.AnalysisInterval)
+	fmt.Fprintf(w, "   Program Families: %d\n", len(lb.TRINI.ProgramFamilies))
 
-	fmt.Println("\n📊 Server Family Classifications:")
+	fmt.Fprintln(w, "\n📊 Server Family Classifications:")
 	for _, server := range lb.Servers {
 		if server.CurrentFamily != nil {
-			fmt.Printf("   Server %d: %s\n", server.ID, server.CurrentFamily.Name)
+			fmt.Fprintf(w, "   Server %d: %s\n", server.ID, server.CurrentFamily.Name)
 			if server.LastMaGCForecast != nil {
 				timeToMaGC := server.LastMaGCForecast.TimeToMaGC
-				fmt.Printf("     Next MaGC in: %dms (confidence: %.2f)\n",
+				fmt.Fprintf(w, "     Next MaGC in: %dms (confidence: %.2f)\n",
 					timeToMaGC, server.LastMaGCForecast.Confidence)
 			}
 		} else {
-			fmt.Printf("   Server %d: Not classified\n", server.ID)
+			fmt.Fprintf(w, "   Server %d: Not classified\n", server.ID)
 		}
 	}
 
-	fmt.Println("\n🔧 Current Policy:")
-	fmt.Printf("   Algorithm: %s\n", lb.CurrentPolicy.Algorithm)
-	fmt.Printf("   GC-Aware: %t\n", lb.CurrentPolicy.GCAware)
-	fmt.Printf("   MaGC Threshold: %dms\n", lb.CurrentPolicy.MaGCThreshold)
+	policy := lb.GetLoadBalancingPolicy()
+	fmt.Fprintln(w, "\n🔧 Current Policy:")
+	fmt.Fprintf(w, "   Algorithm: %s\n", policy.Algorithm)
+	fmt.Fprintf(w, "   GC-Aware: %t\n", policy.GCAware)
+	fmt.Fprintf(w, "   MaGC Threshold: %dms\n", policy.MaGCThreshold)
 }
 
 func showCurrentPolicy(lb *server.LoadBalancer) {
+	policy := lb.GetLoadBalancingPolicy()
 	fmt.Println("\n🔧 Current Load Balancing Policy:")
-	fmt.Printf("   Algorithm: %s\n", lb.CurrentPolicy.Algorithm)
-	fmt.Printf("   GC-Aware: %t\n", lb.CurrentPolicy.GCAware)
-	fmt.Printf("   MaGC Threshold: %dms\n", lb.CurrentPolicy.MaGCThreshold)
-	fmt.Printf("   History Window: %d\n", lb.CurrentPolicy.HistoryWindowSize)
+	fmt.Printf("   Algorithm: %s\n", policy.Algorithm)
+	fmt.Printf("   GC-Aware: %t\n", policy.GCAware)
+	fmt.Printf("   MaGC Threshold: %dms\n", policy.MaGCThreshold)
+	fmt.Printf("   History Window: %d\n", policy.HistoryWindowSize)
+	if policy.Tiebreaker != "" {
+		fmt.Printf("   Tiebreaker: %s\n", policy.Tiebreaker)
+	}
+	if policy.GCAvoidance != "" {
+		fmt.Printf("   GC Avoidance: %s\n", policy.GCAvoidance)
+	}
 }
 
 func setPolicyFromArgs(lb *server.LoadBalancer, args []string) {
 	if len(args) < 2 {
-		fmt.Println("❌ Usage: trini policy <algorithm> <threshold_ms>")
-		fmt.Println("Algorithms: RR, RAN, WRR, WRAN")
+		fmt.Println("❌ Usage: trini policy <algorithm> <threshold_ms> [tiebreaker] [avoidance]")
+		fmt.Println("Algorithms: RR, RAN, WRR, WRAN, COST")
+		fmt.Println("Tiebreakers: order, confidence, pressure")
+		fmt.Println("GC avoidance: hard, soft")
 		return
 	}
 
@@ -212,16 +282,32 @@ func setPolicyFromArgs(lb *server.LoadBalancer, args []string) {
 		MaGCThreshold:     threshold,
 		HistoryWindowSize: 30,
 	}
+	applyPolicyOptions(&policy, args[2:])
+	if err := policy.Validate(); err != nil {
+		fmt.Printf("❌ Invalid policy: %v\n", err)
+		return
+	}
 
 	lb.SetLoadBalancingPolicy(policy)
 }
 
+// applyPolicyOptions sets the tiebreaker and GC avoidance mode from optional arguments in any order
+func applyPolicyOptions(policy *server.LoadBalancingPolicy, options []string) {
+	for _, option := range options {
+		option = strings.ToLower(option)
+		if option != "" && server.IsValidGCAvoidance(option) {
+			policy.GCAvoidance = option
+		} else {
+			policy.Tiebreaker = option // Validated with the policy
+		}
+	}
+}
+
 func handleTask(lb *server.LoadBalancer, taskInput string) {
 	fmt.Printf("📤 Sending task: '%s'\n", taskInput)
 
-	srv := lb.GetServerForTask(taskInput)
+	srv, response := lb.Dispatch(taskInput)
 	if srv != nil {
-		response := srv.RequestTask(taskInput)
 		fmt.Printf("⏳ %s - %s\n", response.Status, response.Message)
 
 		go func(resultChan chan *server.Task) {
@@ -245,39 +331,45 @@ func handlePing(lb *server.LoadBalancer, serverID int) {
 	pingResult := server.Ping()
 
 	fmt.Printf("🏓 Ping Server %d:\n", serverID)
-	fmt.Printf("   Status: %s\n", pingResult["status"])
-	fmt.Printf("   Available: %v\n", pingResult["is_available"])
-	fmt.Printf("   Memory Usage: %v\n", pingResult["mem_used"])
-	fmt.Printf("   Collecting GC: %v\n", pingResult["is_collecting_gc"])
-	fmt.Printf("   Tasks Processed: %d\n", pingResult["tasks_processed"])
-	if taskIDs, ok := pingResult["task_ids"].([]string); ok && len(taskIDs) > 0 {
-		fmt.Printf("   Recent Task IDs: %v\n", taskIDs)
+	fmt.Printf("   Status: %s\n", pingResult.Status)
+	fmt.Printf("   Available: %v\n", pingResult.IsAvailable)
+	fmt.Printf("   Memory Usage: %v\n", pingResult.MemUsed)
+	fmt.Printf("   Collecting GC: %v\n", pingResult.IsCollectingGC)
+	fmt.Printf("   State: %v\n", pingResult.AdminState)
+	fmt.Printf("   Tasks Processed: %d\n", pingResult.TasksProcessed)
+	if limit := pingResult.MaxConcurrent; limit > 0 {
+		fmt.Printf("   Concurrent Tasks: %d/%d\n", pingResult.ActiveTasks, limit)
+	}
+	if len(pingResult.TaskIDs) > 0 {
+		fmt.Printf("   Recent Task IDs: %v\n", pingResult.TaskIDs)
 	}
 }
 
-func handleStatus(lb *server.LoadBalancer) {
-	fmt.Println("📊 Load Balancer Status:")
-	fmt.Printf("   Total Servers: %d\n", len(lb.Servers))
+func handleStatus(w io.Writer, lb *server.LoadBalancer) {
+	fmt.Fprintln(w, "📊 Load Balancer Status:")
+	fmt.Fprintf(w, "   Total Servers: %d\n", len(lb.Servers))
 
 	availableCount := 0
-	for _, server := range lb.Servers {
-		pingResult := server.Ping()
-		isAvailable := pingResult["is_available"].(bool)
-		if isAvailable {
+	for _, srv := range lb.Servers {
+		pingResult := srv.Ping()
+		if pingResult.IsAvailable {
 			availableCount++
 		}
 
 		status := "🟢 Available"
-		if !isAvailable {
+		if !pingResult.IsAvailable {
 			status = "🔴 Busy"
 		}
-		if pingResult["is_collecting_gc"].(bool) {
+		if pingResult.IsCollectingGC {
 			status = "🟡 GC Mode"
 		}
+		if state := pingResult.AdminState; state != server.StateActive {
+			status = "⏸️  " + strings.ToUpper(state[:1]) + state[1:]
+		}
 
-		fmt.Printf("   Server %d: %s (Tasks: %d)\n",
-			server.ID, status, pingResult["tasks_processed"])
+		fmt.Fprintf(w, "   Server %d: %s (Tasks: %d)\n",
+			srv.ID, status, pingResult.TasksProcessed)
 	}
 
-	fmt.Printf("   Available Servers: %d/%d\n", availableCount, len(lb.Servers))
+	fmt.Fprintf(w, "   Available Servers: %d/%d\n", availableCount, len(lb.Servers))
 }
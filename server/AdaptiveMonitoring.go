@@ -0,0 +1,119 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	// volatileHeapChange is the change in a generation's occupancy, as a share
+	// of its size, between two snapshots that makes TRINI sample faster
+	volatileHeapChange = 0.10
+	// stableHeapChange is the change below which TRINI samples slower
+	stableHeapChange = 0.02
+	// imminentMonitorTicks keeps sampling fast while a MaGC is forecast
+	// within this many intervals
+	imminentMonitorTicks = 3
+)
+
+// SetAdaptiveMonitoring lets the monitoring loop shorten its interval down to
+// minInterval while heaps change quickly or a MaGC is imminent, and lengthen
+// it up to maxInterval while they are stable. Both zero keeps the interval
+// fixed at MonitorInterval. Takes effect on the next tick.
+func (t *TRINI) SetAdaptiveMonitoring(minInterval, maxInterval time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if minInterval == 0 && maxInterval == 0 {
+		t.MinMonitorInterval, t.MaxMonitorInterval = 0, 0
+		return nil
+	}
+	if minInterval <= 0 || minInterval > t.MonitorInterval {
+		return errors.New("minimum monitor interval must be positive and at most the monitor interval")
+	}
+	if maxInterval < t.MonitorInterval {
+		return errors.New("maximum monitor interval must be at least the monitor interval")
+	}
+	if t.AnalysisInterval > 0 && maxInterval > t.AnalysisInterval {
+		return errors.New("maximum monitor interval must not exceed the analysis interval")
+	}
+	t.MinMonitorInterval, t.MaxMonitorInterval = minInterval, maxInterval
+	return nil
+}
+
+// CurrentMonitorInterval returns how often the monitoring loop samples now,
+// which differs from MonitorInterval while adaptive monitoring is on
+func (t *TRINI) CurrentMonitorInterval() time.Duration {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.currentMonitorInterval > 0 {
+		return t.currentMonitorInterval
+	}
+	return t.MonitorInterval
+}
+
+// adaptMonitorInterval picks the next monitoring interval from how fast the
+// fleet's heaps are changing: halved while any server is volatile or close to
+// a forecast MaGC, grown by half while all are stable
+func (t *TRINI) adaptMonitorInterval() time.Duration {
+	current := t.CurrentMonitorInterval()
+	t.mu.RLock()
+	minInterval, maxInterval := t.MinMonitorInterval, t.MaxMonitorInterval
+	t.mu.RUnlock()
+	if (minInterval == 0 && maxInterval == 0) || !t.IsActive || t.lb.degraded() {
+		return current
+	}
+
+	volatility := 0.0
+	imminent := false
+	for _, server := range t.lb.ListServers() {
+		volatility = max(volatility, server.heapVolatility())
+		if remaining, ok := server.TimeToMaGC(); ok && remaining <= imminentMonitorTicks*current {
+			imminent = true
+		}
+	}
+
+	next := current
+	switch {
+	case imminent || volatility >= volatileHeapChange:
+		next = max(current/2, minInterval)
+	case volatility < stableHeapChange:
+		next = min(current+current/2, maxInterval)
+	}
+	next = next.Round(time.Millisecond)
+	if next != current {
+		t.lb.logf("🔍 TRINI monitor interval %v -> %v (heap volatility %.0f%%, MaGC imminent: %t)",
+			current, next, volatility*100, imminent)
+		t.mu.Lock()
+		t.currentMonitorInterval = next
+		t.mu.Unlock()
+	}
+	return next
+}
+
+// heapVolatility is the larger change in young or old generation occupancy,
+// as a share of the generation's size, between the server's last two
+// snapshots; a MaGC between them counts as fully volatile
+func (s *Server) heapVolatility() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.GCHistory)
+	if n < 2 {
+		return 0
+	}
+	prev, last := s.GCHistory[n-2], s.GCHistory[n-1]
+	if last.GCCount != prev.GCCount || last.IsCollectingGC != prev.IsCollectingGC {
+		return 1
+	}
+
+	change := 0.0
+	if last.YoungGenMax > 0 {
+		change = math.Abs(float64(last.YoungGenUsed-prev.YoungGenUsed)) / float64(last.YoungGenMax)
+	}
+	if last.OldGenMax > 0 {
+		change = max(change, math.Abs(float64(last.OldGenUsed-prev.OldGenUsed))/float64(last.OldGenMax))
+	}
+	return change
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// Administrative server states. Only active servers receive new tasks; this is
+// independent of the implicit unavailability while collecting GC tasks.
+const (
+	StateActive      = "active"      // Normal operation
+	StatePaused      = "paused"      // Temporarily taken out of rotation
+	StateDraining    = "draining"    // Finishing in-flight tasks before removal
+	StateQuarantined = "quarantined" // Excluded after misbehaving, pending investigation
+	StateStandby     = "standby"     // Warm spare, activated while too few servers are GC-clean, see StandbyConfig
+)
+
+// IsValidAdminState reports whether state is a known administrative state
+func IsValidAdminState(state string) bool {
+	switch state {
+	case StateActive, StatePaused, StateDraining, StateQuarantined, StateStandby:
+		return true
+	}
+	return false
+}
+
+// AdminState returns the server's administrative state
+func (s *Server) AdminState() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.adminStateLocked()
+}
+
+// adminStateLocked treats an unset state as active; callers hold s.mu
+func (s *Server) adminStateLocked() string {
+	if s.adminState == "" {
+		return StateActive
+	}
+	return s.adminState
+}
+
+// SetAdminState changes the server's administrative state
+func (s *Server) SetAdminState(state string) error {
+	if !IsValidAdminState(state) {
+		return fmt.Errorf("invalid state %q: use active, paused, draining, quarantined or standby", state)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.adminState = state
+	s.standbyActivatedAt = time.Time{} // An operator's choice is not undone by the standby controller
+	return nil
+}
+
+// SetServerState changes a server's administrative state and records the change as an event
+func (l *LoadBalancer) SetServerState(id int, state string) error {
+	server := l.GetServerByID(id)
+	if server == nil {
+		return fmt.Errorf("server %d not found", id)
+	}
+
+	previous := server.AdminState()
+	if err := server.SetAdminState(state); err != nil {
+		return err
+	}
+
+	if previous != state {
+		l.RecordEvent("server_state", id, "Server %d changed from %s to %s", id, previous, state)
+		l.checkStandby()
+	}
+	return nil
+}
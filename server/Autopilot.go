@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// autopilotInFlightPerServer bounds synthetic tasks in flight per server, so a
+// rate the fleet cannot keep up with does not queue work without limit
+const autopilotInFlightPerServer = 2
+
+// AutopilotConfig shapes the synthetic load the autopilot generates
+type AutopilotConfig struct {
+	TasksPerSecond float64 `json:"tasks_per_second"`
+	MinTaskSize    int     `json:"min_task_size"` // Bytes
+	MaxTaskSize    int     `json:"max_task_size"`
+	Lane           string  `json:"lane"` // Dispatch lane, batch by default so clients keep the interactive share
+}
+
+// AutopilotStats counts the synthetic tasks the autopilot has submitted
+type AutopilotStats struct {
+	Enabled   bool            `json:"enabled"`
+	Config    AutopilotConfig `json:"config"`
+	Submitted int64           `json:"submitted"`
+	Completed int64           `json:"completed"`
+	Rejected  int64           `json:"rejected"` // Rejected when dispatched or by the server
+	TimedOut  int64           `json:"timed_out"`
+	InFlight  int             `json:"in_flight"`
+	Skipped   int64           `json:"skipped"` // Tasks not sent because too many were still in flight
+	LastTask  *time.Time      `json:"last_task,omitempty"`
+}
+
+// Autopilot keeps the fleet under a steady synthetic load, so GC history,
+// family classification and forecasting have data without external clients
+type Autopilot struct {
+	mu      sync.Mutex
+	lb      *LoadBalancer
+	config  AutopilotConfig
+	enabled bool
+	stats   AutopilotStats
+	wake    chan struct{} // Re-reads the config after SetConfig or SetEnabled
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// DefaultAutopilotConfig returns a light load that fits the default servers
+func DefaultAutopilotConfig() AutopilotConfig {
+	return AutopilotConfig{
+		TasksPerSecond: 2,
+		MinTaskSize:    5,
+		MaxTaskSize:    20,
+		Lane:           LaneBatch,
+	}
+}
+
+// Validate checks the rate, task sizes and lane
+func (c AutopilotConfig) Validate() error {
+	if c.TasksPerSecond <= 0 || c.TasksPerSecond > 100 {
+		return errors.New("tasks_per_second must be in (0, 100]")
+	}
+	if c.MinTaskSize < 1 {
+		return errors.New("min_task_size must be at least 1")
+	}
+	if c.MaxTaskSize < c.MinTaskSize {
+		return errors.New("max_task_size must be >= min_task_size")
+	}
+	if !IsValidLane(c.Lane) {
+		return fmt.Errorf("invalid lane %q: use interactive or batch", c.Lane)
+	}
+	return nil
+}
+
+// NewAutopilot creates a disabled autopilot for the given load balancer
+func NewAutopilot(lb *LoadBalancer, config AutopilotConfig) *Autopilot {
+	return &Autopilot{
+		lb:     lb,
+		config: config,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Start launches the generator loop until ctx is cancelled or Stop is called
+func (a *Autopilot) Start(ctx context.Context) error {
+	a.mu.Lock()
+	if a.cancel != nil {
+		a.mu.Unlock()
+		return errors.New("autopilot already started")
+	}
+	if err := a.config.Validate(); err != nil {
+		a.mu.Unlock()
+		return err
+	}
+	ctx, a.cancel = context.WithCancel(ctx)
+	a.mu.Unlock()
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		timer := time.NewTimer(a.interval())
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-a.wake:
+				timer.Reset(a.interval())
+			case <-timer.C:
+				if a.IsEnabled() {
+					a.submit()
+				}
+				timer.Reset(a.interval())
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop terminates the generator loop and waits for tasks in flight
+func (a *Autopilot) Stop() {
+	a.mu.Lock()
+	cancel := a.cancel
+	a.cancel = nil
+	a.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	a.wg.Wait()
+}
+
+// IsEnabled reports whether the autopilot is generating load
+func (a *Autopilot) IsEnabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enabled
+}
+
+// SetEnabled turns the synthetic load on or off
+func (a *Autopilot) SetEnabled(enabled bool) {
+	a.mu.Lock()
+	changed := a.enabled != enabled
+	a.enabled = enabled
+	a.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	if enabled {
+		a.lb.RecordEvent("autopilot_started", 0, "Autopilot generating %.1f synthetic tasks/s", a.Config().TasksPerSecond)
+	} else {
+		a.lb.RecordEvent("autopilot_stopped", 0, "Autopilot stopped")
+	}
+	a.notify()
+}
+
+// Config returns the current configuration
+func (a *Autopilot) Config() AutopilotConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.config
+}
+
+// SetConfig replaces the configuration after validating it; a new rate takes
+// effect right away
+func (a *Autopilot) SetConfig(config AutopilotConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.config = config
+	a.mu.Unlock()
+	a.notify()
+	return nil
+}
+
+// Stats returns the configuration and counts of synthetic tasks
+func (a *Autopilot) Stats() AutopilotStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats := a.stats
+	stats.Enabled = a.enabled
+	stats.Config = a.config
+	return stats
+}
+
+// notify wakes the loop to pick up a changed config without blocking
+func (a *Autopilot) notify() {
+	select {
+	case a.wake <- struct{}{}:
+	default:
+	}
+}
+
+// interval is the time between synthetic tasks at the configured rate
+func (a *Autopilot) interval() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return time.Duration(float64(time.Second) / a.config.TasksPerSecond)
+}
+
+// submit dispatches one synthetic task like a client would and counts its
+// outcome once it finishes. Dispatch blocks for the server's processing
+// overhead, so it runs off the loop to keep the rate.
+func (a *Autopilot) submit() {
+	limit := autopilotInFlightPerServer * max(len(a.lb.ListServers()), 1)
+
+	a.mu.Lock()
+	if a.stats.InFlight >= limit {
+		a.stats.Skipped++
+		a.mu.Unlock()
+		return
+	}
+	config := a.config
+	now := a.lb.now()
+	a.stats.InFlight++
+	a.stats.Submitted++
+	a.stats.LastTask = &now
+	a.mu.Unlock()
+
+	size := config.MinTaskSize + rand.Intn(config.MaxTaskSize-config.MinTaskSize+1)
+	input := randomTaskInput(size)
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		var result *Task
+		if server, response := a.lb.DispatchLane(input, config.Lane, false); server != nil {
+			result = <-response.ResultChan
+		}
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		a.stats.InFlight--
+		switch {
+		case result != nil && result.Status == "completed":
+			a.stats.Completed++
+		case result != nil && result.Status == TaskStatusTimeout:
+			a.stats.TimedOut++
+		default:
+			a.stats.Rejected++
+		}
+	}()
+}
+
+// randomTaskInput returns a lowercase string of the given length
+func randomTaskInput(size int) string {
+	input := make([]byte, size)
+	for i := range input {
+		input[i] = byte('a' + rand.Intn(26))
+	}
+	return string(input)
+}
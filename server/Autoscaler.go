@@ -0,0 +1,324 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AutoscalerConfig defines the scaling bounds and triggers
+type AutoscalerConfig struct {
+	MinServers            int     `json:"min_servers"`
+	MaxServers            int     `json:"max_servers"`
+	ScaleOutUtilization   float64 `json:"scale_out_utilization"`    // Average memory utilization (0.0-1.0)
+	ScaleInUtilization    float64 `json:"scale_in_utilization"`     // Average memory utilization (0.0-1.0)
+	ScaleOutRejectionRate float64 `json:"scale_out_rejection_rate"` // Rejected/submitted tasks since last evaluation
+	ScaleOutGCPressure    float64 `json:"scale_out_gc_pressure"`    // Fraction of servers with imminent MaGC
+	ScaleOutSLOBurnRate   float64 `json:"scale_out_slo_burn_rate"`  // SLO error budget burn rate over 5m, 0 disables
+	CooldownMs            int64   `json:"cooldown_ms"`
+	IntervalMs            int64   `json:"interval_ms"`
+	ServerMemLimit        int     `json:"server_mem_limit"`
+	ServerGCPercentage    float64 `json:"server_gc_percentage"`
+}
+
+// ScalingDecision describes the outcome of one autoscaler evaluation
+type ScalingDecision struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Action        string    `json:"action"` // scale_out, scale_in, none
+	Reason        string    `json:"reason"`
+	ServerCount   int       `json:"server_count"`
+	Utilization   float64   `json:"utilization"`
+	RejectionRate float64   `json:"rejection_rate"`
+	GCPressure    float64   `json:"gc_pressure"`
+	SLOBurnRate   float64   `json:"slo_burn_rate"`
+}
+
+// Autoscaler adds and removes simulated servers based on fleet load and GC pressure
+type Autoscaler struct {
+	mu            sync.Mutex
+	lb            *LoadBalancer
+	config        AutoscalerConfig
+	enabled       bool
+	lastScaleTime time.Time
+	lastStats     LoadBalancerStats
+	lastDecision  *ScalingDecision
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// DefaultAutoscalerConfig returns conservative scaling settings
+func DefaultAutoscalerConfig() AutoscalerConfig {
+	return AutoscalerConfig{
+		MinServers:            2,
+		MaxServers:            8,
+		ScaleOutUtilization:   0.75,
+		ScaleInUtilization:    0.25,
+		ScaleOutRejectionRate: 0.2,
+		ScaleOutGCPressure:    0.5,
+		ScaleOutSLOBurnRate:   10,
+		CooldownMs:            30000,
+		IntervalMs:            5000,
+		ServerMemLimit:        100,
+		ServerGCPercentage:    80.0,
+	}
+}
+
+// Validate checks the configuration for inconsistent bounds
+func (c AutoscalerConfig) Validate() error {
+	if c.MinServers < 1 {
+		return errors.New("min_servers must be at least 1")
+	}
+	if c.MaxServers < c.MinServers {
+		return errors.New("max_servers must be >= min_servers")
+	}
+	if c.ScaleInUtilization >= c.ScaleOutUtilization {
+		return errors.New("scale_in_utilization must be below scale_out_utilization")
+	}
+	if c.ScaleOutSLOBurnRate < 0 {
+		return errors.New("scale_out_slo_burn_rate must not be negative")
+	}
+	if c.IntervalMs <= 0 {
+		return errors.New("interval_ms must be positive")
+	}
+	if c.ServerMemLimit <= 0 {
+		return errors.New("server_mem_limit must be positive")
+	}
+	if c.ServerGCPercentage <= 0 || c.ServerGCPercentage > 100 {
+		return errors.New("server_gc_percentage must be between 0 and 100")
+	}
+	return nil
+}
+
+// NewAutoscaler creates a disabled autoscaler for the given load balancer
+func NewAutoscaler(lb *LoadBalancer, config AutoscalerConfig) *Autoscaler {
+	return &Autoscaler{
+		lb:     lb,
+		config: config,
+	}
+}
+
+// Start launches the evaluation loop until ctx is cancelled or Stop is called
+func (a *Autoscaler) Start(ctx context.Context) error {
+	a.mu.Lock()
+	if a.cancel != nil {
+		a.mu.Unlock()
+		return errors.New("autoscaler already started")
+	}
+	if err := a.config.Validate(); err != nil {
+		a.mu.Unlock()
+		return err
+	}
+	ctx, a.cancel = context.WithCancel(ctx)
+	interval := time.Duration(a.config.IntervalMs) * time.Millisecond
+	a.lastStats = a.lb.Stats()
+	a.mu.Unlock()
+
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if a.IsEnabled() {
+					a.Evaluate()
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop terminates the evaluation loop
+func (a *Autoscaler) Stop() {
+	a.mu.Lock()
+	cancel := a.cancel
+	a.cancel = nil
+	a.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	a.wg.Wait()
+}
+
+// IsEnabled reports whether periodic evaluations may scale the fleet
+func (a *Autoscaler) IsEnabled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.enabled
+}
+
+// SetEnabled turns automatic scaling on or off
+func (a *Autoscaler) SetEnabled(enabled bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.enabled = enabled
+}
+
+// Config returns the current configuration
+func (a *Autoscaler) Config() AutoscalerConfig {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.config
+}
+
+// SetConfig replaces the configuration after validating it.
+// A changed interval takes effect on the next Start.
+func (a *Autoscaler) SetConfig(config AutoscalerConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config = config
+	return nil
+}
+
+// LastDecision returns the most recent evaluation result, if any
+func (a *Autoscaler) LastDecision() *ScalingDecision {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lastDecision == nil {
+		return nil
+	}
+	decision := *a.lastDecision
+	return &decision
+}
+
+// Evaluate measures the fleet and scales out or in when a trigger fires
+func (a *Autoscaler) Evaluate() ScalingDecision {
+	a.mu.Lock()
+	config := a.config
+	lastScaleTime := a.lastScaleTime
+	previous := a.lastStats
+	current := a.lb.Stats()
+	a.lastStats = current
+	a.mu.Unlock()
+
+	servers := a.lb.ListServers()
+	decision := ScalingDecision{
+		Timestamp:   a.lb.now(),
+		Action:      "none",
+		ServerCount: len(servers),
+	}
+
+	// Average memory utilization and fraction of servers with imminent MaGC,
+	// falling back to the long-horizon forecast where the short one has lapsed
+	threshold := a.lb.getCurrentMaGCThreshold()
+	predicted := 0
+	for _, server := range servers {
+		decision.Utilization += server.MemoryUtilization()
+		if server.isMaGCExpected(threshold) {
+			predicted++
+		}
+	}
+	if len(servers) > 0 {
+		decision.Utilization /= float64(len(servers))
+		decision.GCPressure = float64(predicted) / float64(len(servers))
+	}
+
+	// Rejection rate since the previous evaluation
+	submitted := current.TasksSubmitted - previous.TasksSubmitted
+	rejected := current.TasksRejected - previous.TasksRejected
+	if submitted > 0 {
+		decision.RejectionRate = float64(rejected) / float64(submitted)
+	}
+	decision.SLOBurnRate = a.lb.sloBurnRate()
+
+	cooldown := time.Duration(config.CooldownMs) * time.Millisecond
+	inCooldown := !lastScaleTime.IsZero() && decision.Timestamp.Sub(lastScaleTime) < cooldown
+
+	switch {
+	case a.lb.followsLeader():
+		decision.Reason = "the replication leader scales the pool"
+	case len(servers) < config.MinServers:
+		decision.Action = "scale_out"
+		decision.Reason = fmt.Sprintf("below minimum of %d servers", config.MinServers)
+	case len(servers) > config.MaxServers:
+		decision.Action = "scale_in"
+		decision.Reason = fmt.Sprintf("above maximum of %d servers", config.MaxServers)
+	case inCooldown:
+		decision.Reason = "cooldown"
+	case len(servers) < config.MaxServers && decision.GCPressure > config.ScaleOutGCPressure:
+		decision.Action = "scale_out"
+		decision.Reason = fmt.Sprintf("%.0f%% of servers have imminent MaGC", decision.GCPressure*100)
+	case len(servers) < config.MaxServers && decision.RejectionRate > config.ScaleOutRejectionRate:
+		decision.Action = "scale_out"
+		decision.Reason = fmt.Sprintf("rejection rate %.0f%%", decision.RejectionRate*100)
+	case len(servers) < config.MaxServers && config.ScaleOutSLOBurnRate > 0 && decision.SLOBurnRate > config.ScaleOutSLOBurnRate:
+		decision.Action = "scale_out"
+		decision.Reason = fmt.Sprintf("SLO error budget burning %.1fx", decision.SLOBurnRate)
+	case len(servers) < config.MaxServers && decision.Utilization > config.ScaleOutUtilization:
+		decision.Action = "scale_out"
+		decision.Reason = fmt.Sprintf("utilization %.0f%%", decision.Utilization*100)
+	case len(servers) > config.MinServers && decision.Utilization < config.ScaleInUtilization &&
+		decision.RejectionRate == 0 && predicted == 0 && decision.SLOBurnRate <= 1:
+		decision.Action = "scale_in"
+		decision.Reason = fmt.Sprintf("utilization %.0f%%", decision.Utilization*100)
+	}
+
+	switch decision.Action {
+	case "scale_out":
+		server, err := a.lb.AddServer(config.ServerMemLimit, config.ServerGCPercentage)
+		if err != nil {
+			decision.Action = "none"
+			decision.Reason = err.Error()
+			break
+		}
+		decision.ServerCount++
+		a.lb.RecordEvent("scale_out", server.ID, "Scaled out to %d servers: %s", decision.ServerCount, decision.Reason)
+	case "scale_in":
+		victim := a.pickScaleInCandidate(servers)
+		if victim == nil {
+			decision.Action = "none"
+			decision.Reason = "no idle server to remove"
+			break
+		}
+		if err := a.lb.RemoveServer(victim.ID); err != nil {
+			decision.Action = "none"
+			decision.Reason = err.Error()
+			break
+		}
+		decision.ServerCount--
+		a.lb.RecordEvent("scale_in", victim.ID, "Scaled in to %d servers: %s", decision.ServerCount, decision.Reason)
+	}
+
+	a.mu.Lock()
+	if decision.Action != "none" {
+		a.lastScaleTime = decision.Timestamp
+	}
+	a.lastDecision = &decision
+	a.mu.Unlock()
+
+	return decision
+}
+
+// pickScaleInCandidate chooses an idle server to remove: one an operator is
+// draining first, otherwise the newest available one. Servers with tasks in
+// flight are never chosen, so scale-in waits for a drain to finish.
+func (a *Autoscaler) pickScaleInCandidate(servers []*Server) *Server {
+	for i := len(servers) - 1; i >= 0; i-- {
+		if servers[i].AdminState() == StateDraining && servers[i].ActiveTasks() == 0 {
+			return servers[i]
+		}
+	}
+	for i := len(servers) - 1; i >= 0; i-- {
+		if servers[i].IsAvailable() && servers[i].ActiveTasks() == 0 {
+			return servers[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package server
+
+const (
+	// calibrationBins splits the raw confidence range into equal-width bins
+	calibrationBins = 10
+	// calibrationPriorWeight is how many pseudo-outcomes at the raw confidence
+	// each bin starts with, so sparse bins stay close to the raw value
+	calibrationPriorWeight = 5.0
+)
+
+// CalibrationBin compares forecasts of one raw confidence range with how
+// often they came true
+type CalibrationBin struct {
+	MinConfidence float64 `json:"min_confidence"`
+	MaxConfidence float64 `json:"max_confidence"`
+	Forecasts     int     `json:"forecasts"`
+	Accurate      int     `json:"accurate"`
+	ObservedRate  float64 `json:"observed_rate"` // Accurate / Forecasts; 0 without forecasts
+	// Confidence reported for raw confidences at the middle of the bin
+	Calibrated float64 `json:"calibrated"`
+}
+
+// ServerForecastAccuracy reports a server's scored forecasts and its calibration curve
+type ServerForecastAccuracy struct {
+	ServerID           int              `json:"server_id"`
+	ForecastsEvaluated int              `json:"forecasts_evaluated"`
+	ForecastsAccurate  int              `json:"forecasts_accurate"`
+	ForecastAccuracy   float64          `json:"forecast_accuracy"`
+	Calibration        []CalibrationBin `json:"calibration"`
+}
+
+// ForecastAccuracyReport is the forecast accuracy of every server and of the fleet
+type ForecastAccuracyReport struct {
+	ForecastsEvaluated int                      `json:"forecasts_evaluated"`
+	ForecastsAccurate  int                      `json:"forecasts_accurate"`
+	ForecastAccuracy   float64                  `json:"forecast_accuracy"`
+	Calibration        []CalibrationBin         `json:"calibration"` // All servers' outcomes pooled
+	Servers            []ServerForecastAccuracy `json:"servers"`
+}
+
+// calibrationBin returns the bin index of a raw confidence
+func calibrationBin(raw float64) int {
+	return min(max(int(raw*calibrationBins), 0), calibrationBins-1)
+}
+
+// calibrated blends a bin's observed hit rate with the raw confidence,
+// weighting the raw value as calibrationPriorWeight outcomes
+func calibrated(raw float64, forecasts, accurate int) float64 {
+	return (float64(accurate) + calibrationPriorWeight*raw) / (float64(forecasts) + calibrationPriorWeight)
+}
+
+// calibrationCurve bins scored forecasts by their raw confidence
+func calibrationCurve(outcomes []ForecastOutcome) []CalibrationBin {
+	curve := make([]CalibrationBin, calibrationBins)
+	for i := range curve {
+		curve[i].MinConfidence = float64(i) / calibrationBins
+		curve[i].MaxConfidence = float64(i+1) / calibrationBins
+	}
+
+	for _, outcome := range outcomes {
+		bin := &curve[calibrationBin(outcome.RawConfidence)]
+		bin.Forecasts++
+		if outcome.Accurate {
+			bin.Accurate++
+		}
+	}
+
+	for i := range curve {
+		bin := &curve[i]
+		if bin.Forecasts > 0 {
+			bin.ObservedRate = float64(bin.Accurate) / float64(bin.Forecasts)
+		}
+		bin.Calibrated = calibrated((bin.MinConfidence+bin.MaxConfidence)/2, bin.Forecasts, bin.Accurate)
+	}
+	return curve
+}
+
+// calibrateConfidenceLocked turns a raw forecast confidence into the observed
+// probability of forecasts like it being accurate on this server; callers hold s.mu
+func (s *Server) calibrateConfidenceLocked(raw float64) float64 {
+	bin := calibrationBin(raw)
+	forecasts, accurate := 0, 0
+	for _, outcome := range s.ForecastOutcomes {
+		if calibrationBin(outcome.RawConfidence) != bin {
+			continue
+		}
+		forecasts++
+		if outcome.Accurate {
+			accurate++
+		}
+	}
+	return calibrated(raw, forecasts, accurate)
+}
+
+// ForecastAccuracy returns the server's scored forecasts and calibration curve
+func (s *Server) ForecastAccuracy() ServerForecastAccuracy {
+	s.mu.Lock()
+	outcomes := make([]ForecastOutcome, len(s.ForecastOutcomes))
+	copy(outcomes, s.ForecastOutcomes)
+	s.mu.Unlock()
+
+	return forecastAccuracy(s.ID, outcomes)
+}
+
+// forecastAccuracy summarizes scored forecasts
+func forecastAccuracy(serverID int, outcomes []ForecastOutcome) ServerForecastAccuracy {
+	accuracy := ServerForecastAccuracy{
+		ServerID:           serverID,
+		ForecastsEvaluated: len(outcomes),
+		Calibration:        calibrationCurve(outcomes),
+	}
+	for _, outcome := range outcomes {
+		if outcome.Accurate {
+			accuracy.ForecastsAccurate++
+		}
+	}
+	if accuracy.ForecastsEvaluated > 0 {
+		accuracy.ForecastAccuracy = float64(accuracy.ForecastsAccurate) / float64(accuracy.ForecastsEvaluated)
+	}
+	return accuracy
+}
+
+// ForecastAccuracy reports every server's forecast accuracy and calibration,
+// and the fleet's with all outcomes pooled
+func (l *LoadBalancer) ForecastAccuracy() ForecastAccuracyReport {
+	report := ForecastAccuracyReport{Servers: make([]ServerForecastAccuracy, 0)}
+	pooled := make([]ForecastOutcome, 0)
+	for _, server := range l.ListServers() {
+		server.mu.Lock()
+		outcomes := make([]ForecastOutcome, len(server.ForecastOutcomes))
+		copy(outcomes, server.ForecastOutcomes)
+		server.mu.Unlock()
+
+		pooled = append(pooled, outcomes...)
+		report.Servers = append(report.Servers, forecastAccuracy(server.ID, outcomes))
+	}
+
+	fleet := forecastAccuracy(0, pooled)
+	report.ForecastsEvaluated = fleet.ForecastsEvaluated
+	report.ForecastsAccurate = fleet.ForecastsAccurate
+	report.ForecastAccuracy = fleet.ForecastAccuracy
+	report.Calibration = fleet.Calibration
+	return report
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// CapacityGroup aggregates the capacity of the servers in one zone or
+// program family, or of the whole fleet
+type CapacityGroup struct {
+	Key           string  `json:"key"` // Zone or family ID; empty for servers without one
+	Servers       int     `json:"servers"`
+	ActiveServers int     `json:"active_servers"` // In the active administrative state
+	TotalMemory   int     `json:"total_memory"`
+	UsedMemory    int     `json:"used_memory"`
+	Utilization   float64 `json:"utilization"` // Used share of the total memory
+	// Server time forecast to be spent in MaGC pauses within the horizon, and
+	// its share of the group's server time
+	GCDowntimeMs       int64   `json:"gc_downtime_ms"`
+	GCDowntimeFraction float64 `json:"gc_downtime_fraction"`
+	// Tasks per second the active servers can keep up with through the
+	// forecast pauses, each running one task at a time
+	SustainableTaskRate float64 `json:"sustainable_task_rate"`
+}
+
+// CapacityReport summarizes the fleet's capacity per zone and per program
+// family, for autoscalers and capacity planners
+type CapacityReport struct {
+	GeneratedAt    time.Time       `json:"generated_at"`
+	HorizonEnd     time.Time       `json:"horizon_end"`
+	TaskDurationMs int64           `json:"task_duration_ms"` // Mean execution time the task rates assume
+	Fleet          CapacityGroup   `json:"fleet"`
+	Zones          []CapacityGroup `json:"zones"`
+	Families       []CapacityGroup `json:"families"`
+}
+
+// serverCapacity is one server's contribution to its groups
+type serverCapacity struct {
+	zone, family string
+	active       bool
+	memLimit     int
+	usedMemory   int
+	downtime     time.Duration
+}
+
+// CapacityReport aggregates memory, utilization, forecast MaGC downtime and
+// sustainable task rate over the next horizon per zone and per family
+func (l *LoadBalancer) CapacityReport(horizon time.Duration) CapacityReport {
+	now := l.now()
+	taskDuration := l.Durations.Mean()
+	report := CapacityReport{
+		GeneratedAt:    now,
+		HorizonEnd:     now.Add(horizon),
+		TaskDurationMs: taskDuration.Milliseconds(),
+		Zones:          make([]CapacityGroup, 0),
+		Families:       make([]CapacityGroup, 0),
+	}
+
+	zones := make(map[string]*CapacityGroup)
+	families := make(map[string]*CapacityGroup)
+	for _, server := range l.ListServers() {
+		capacity := server.capacity(now, report.HorizonEnd)
+		for _, group := range []*CapacityGroup{
+			&report.Fleet,
+			capacityGroup(zones, capacity.zone),
+			capacityGroup(families, capacity.family),
+		} {
+			group.add(capacity, horizon, taskDuration)
+		}
+	}
+
+	report.Fleet.finish(horizon)
+	report.Zones = sortedCapacityGroups(zones, horizon)
+	report.Families = sortedCapacityGroups(families, horizon)
+	return report
+}
+
+// capacity measures the server's memory and the MaGC pauses forecast within [now, horizonEnd]
+func (s *Server) capacity(now, horizonEnd time.Time) serverCapacity {
+	var downtime time.Duration
+	for _, window := range s.pauseWindows(now, horizonEnd) {
+		start, end := window.Start, window.End
+		if start.Before(now) {
+			start = now
+		}
+		if end.After(horizonEnd) {
+			end = horizonEnd
+		}
+		if end.After(start) {
+			downtime += end.Sub(start)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	capacity := serverCapacity{
+		zone:       s.zone,
+		active:     s.adminStateLocked() == StateActive,
+		memLimit:   s.memLimit,
+		usedMemory: s.usedMemory,
+		downtime:   min(downtime, horizonEnd.Sub(now)),
+	}
+	if s.CurrentFamily != nil {
+		capacity.family = s.CurrentFamily.ID
+	}
+	return capacity
+}
+
+// capacityGroup returns the group for key, creating it if needed
+func capacityGroup(groups map[string]*CapacityGroup, key string) *CapacityGroup {
+	group, ok := groups[key]
+	if !ok {
+		group = &CapacityGroup{Key: key}
+		groups[key] = group
+	}
+	return group
+}
+
+// add counts one server in the group
+func (g *CapacityGroup) add(capacity serverCapacity, horizon, taskDuration time.Duration) {
+	g.Servers++
+	g.TotalMemory += capacity.memLimit
+	g.UsedMemory += capacity.usedMemory
+	g.GCDowntimeMs += capacity.downtime.Milliseconds()
+	if !capacity.active {
+		return
+	}
+	g.ActiveServers++
+	if horizon > 0 && taskDuration > 0 {
+		uptime := 1 - float64(capacity.downtime)/float64(horizon)
+		g.SustainableTaskRate += uptime / taskDuration.Seconds()
+	}
+}
+
+// finish derives the group's shares once every server was added
+func (g *CapacityGroup) finish(horizon time.Duration) {
+	if g.TotalMemory > 0 {
+		g.Utilization = float64(g.UsedMemory) / float64(g.TotalMemory)
+	}
+	if g.Servers > 0 && horizon > 0 {
+		g.GCDowntimeFraction = float64(g.GCDowntimeMs) / float64(int64(g.Servers)*horizon.Milliseconds())
+	}
+}
+
+// sortedCapacityGroups finishes the groups and orders them by key
+func sortedCapacityGroups(groups map[string]*CapacityGroup, horizon time.Duration) []CapacityGroup {
+	sorted := make([]CapacityGroup, 0, len(groups))
+	for _, group := range groups {
+		group.finish(horizon)
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
+}
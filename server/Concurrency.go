@@ -0,0 +1,89 @@
+package server
+
+import "fmt"
+
+// maxConcurrencyLimit bounds the configurable per-server concurrency limit
+const maxConcurrencyLimit = 10000
+
+// SetMaxConcurrentTasks limits how many tasks the server runs at once; 0 removes the limit
+func (s *Server) SetMaxConcurrentTasks(limit int) error {
+	if limit < 0 || limit > maxConcurrencyLimit {
+		return fmt.Errorf("max concurrent tasks must be between 0 (unlimited) and %d", maxConcurrencyLimit)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.maxConcurrentTasks = limit
+	return nil
+}
+
+// MaxConcurrentTasks returns the concurrency limit; 0 means unlimited
+func (s *Server) MaxConcurrentTasks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxConcurrentTasks
+}
+
+// ActiveTasks returns how many tasks the server is running
+func (s *Server) ActiveTasks() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activeTasks
+}
+
+// atCapacity reports whether the server cannot start another task
+func (s *Server) atCapacity() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.atCapacityLocked()
+}
+
+// atCapacityLocked is atCapacity for callers holding s.mu
+func (s *Server) atCapacityLocked() bool {
+	return s.maxConcurrentTasks > 0 && s.activeTasks >= s.maxConcurrentTasks
+}
+
+// acquireTaskSlot reserves room for one task in the lane. It fails at the
+// server's or the lane's concurrency limit and returns why, or "" on success.
+func (s *Server) acquireTaskSlot(lane string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.atCapacityLocked() {
+		return fmt.Sprintf("Server %d is at its limit of %d concurrent tasks", s.ID, s.maxConcurrentTasks)
+	}
+	state := s.laneLocked(lane)
+	if limit := s.laneLimitLocked(lane); limit > 0 && state.active >= limit {
+		return fmt.Sprintf("Server %d %s lane is at its limit of %d concurrent tasks", s.ID, lane, limit)
+	}
+	s.activeTasks++
+	state.active++
+	return ""
+}
+
+// releaseTaskSlot frees a slot taken by acquireTaskSlot
+func (s *Server) releaseTaskSlot(lane string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.activeTasks > 0 {
+		s.activeTasks--
+	}
+	if state := s.laneLocked(lane); state.active > 0 {
+		state.active--
+	}
+}
+
+// allAtCapacity reports whether every active server is at its concurrency limit
+func (l *LoadBalancer) allAtCapacity() bool {
+	servers := l.ListServers()
+	found := false
+	for _, server := range servers {
+		if server.AdminState() != StateActive {
+			continue
+		}
+		if !server.atCapacity() {
+			return false
+		}
+		found = true
+	}
+	return found
+}
@@ -0,0 +1,54 @@
+package server
+
+import "testing"
+
+func TestTaskSlots(t *testing.T) {
+	tests := []struct {
+		name        string
+		limit       int
+		acquire     []string
+		wantActive  int
+		wantRefused string // Lane of the first refused acquisition, "" if none
+	}{
+		{"unlimited", 0, []string{LaneInteractive, LaneInteractive, LaneInteractive, LaneBatch}, 4, ""},
+		{"up to the limit", 4, []string{LaneInteractive, LaneBatch, LaneInteractive, LaneBatch}, 4, ""},
+		{"past the server limit", 3, []string{LaneInteractive, LaneBatch, LaneInteractive, LaneBatch}, 3, LaneBatch},
+		{"past the lane limit", 4, []string{LaneInteractive, LaneInteractive, LaneInteractive, LaneBatch}, 3, LaneInteractive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(1, 1000, 75)
+			if err := server.SetMaxConcurrentTasks(tt.limit); err != nil {
+				t.Fatal(err)
+			}
+
+			refused := ""
+			acquired := make([]string, 0, len(tt.acquire))
+			for _, lane := range tt.acquire {
+				if reason := server.acquireTaskSlot(lane); reason == "" {
+					acquired = append(acquired, lane)
+				} else if refused == "" {
+					refused = lane
+				}
+			}
+			if got := server.ActiveTasks(); got != tt.wantActive || refused != tt.wantRefused {
+				t.Errorf("%d tasks active, first refused in %q; want %d and %q", got, refused, tt.wantActive, tt.wantRefused)
+			}
+			if atCapacity := tt.limit > 0 && tt.wantActive >= tt.limit; server.atCapacity() != atCapacity {
+				t.Errorf("at capacity %v, want %v", server.atCapacity(), atCapacity)
+			}
+
+			for _, lane := range acquired {
+				server.releaseTaskSlot(lane)
+			}
+			server.releaseTaskSlot(LaneInteractive) // An extra release must not go negative
+			if got := server.ActiveTasks(); got != 0 {
+				t.Errorf("%d tasks active after releasing every slot", got)
+			}
+		})
+	}
+
+	if err := NewServer(1, 1000, 75).SetMaxConcurrentTasks(-1); err == nil {
+		t.Error("negative limit accepted")
+	}
+}
@@ -0,0 +1,129 @@
+package server
+
+import "errors"
+
+// AlgorithmCost selects the cheapest eligible server
+const AlgorithmCost = "COST"
+
+// defaultServerCost is the relative cost/power score of a server unless configured
+const defaultServerCost = 1.0
+
+// ServerCost reports the cost configuration and accrued cost of one server
+type ServerCost struct {
+	ServerID    int     `json:"server_id"`
+	Cost        float64 `json:"cost"`
+	TasksCosted int64   `json:"tasks_costed"`
+	TotalCost   float64 `json:"total_cost"`
+}
+
+// CostReport aggregates accrued cost across the fleet
+type CostReport struct {
+	TotalCost      float64      `json:"total_cost"`
+	AvgCostPerTask float64      `json:"avg_cost_per_task"`
+	Servers        []ServerCost `json:"servers"`
+}
+
+// SetCost sets the relative cost (or power) score charged per processed task
+func (s *Server) SetCost(cost float64) error {
+	if cost < 0 {
+		return errors.New("cost cannot be negative")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Cost = cost
+	return nil
+}
+
+// GetCost returns the server's cost score
+func (s *Server) GetCost() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Cost
+}
+
+// accrueCost charges one processed task at the server's cost; callers hold s.mu
+func (s *Server) accrueCost() {
+	s.tasksCosted++
+	s.totalCost += s.Cost
+}
+
+// costSnapshot returns the server's cost accounting
+func (s *Server) costSnapshot() ServerCost {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ServerCost{
+		ServerID:    s.ID,
+		Cost:        s.Cost,
+		TasksCosted: s.tasksCosted,
+		TotalCost:   s.totalCost,
+	}
+}
+
+// CostReport returns the accrued cost per server and for the whole fleet
+func (l *LoadBalancer) CostReport() CostReport {
+	report := CostReport{Servers: make([]ServerCost, 0)}
+
+	tasks := int64(0)
+	for _, server := range l.ListServers() {
+		cost := server.costSnapshot()
+		report.Servers = append(report.Servers, cost)
+		report.TotalCost += cost.TotalCost
+		tasks += cost.TasksCosted
+	}
+	if tasks > 0 {
+		report.AvgCostPerTask = report.TotalCost / float64(tasks)
+	}
+
+	return report
+}
+
+// selectCheapest picks the lowest-cost server that is available, can fit the
+// task and (for GC-aware policies) has no MaGC predicted within the threshold.
+// Ties go to the less utilized server. If the GC constraint excludes every
+// server, the cheapest available server is used instead.
+func (l *LoadBalancer) selectCheapest(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	gcAware := policy.GCAware && l.TRINI != nil && l.TRINI.IsActive
+
+	var best, fallback *Server
+	bestCost, bestUtil := 0.0, 0.0
+	fallbackCost, fallbackUtil := 0.0, 0.0
+
+	for _, server := range l.Servers {
+		if reason := l.ineligibleReason(server, taskInput, explain); reason != "" {
+			explain.skip(server, reason)
+			continue
+		}
+
+		cost := server.GetCost()
+		util := server.MemoryUtilization()
+
+		if fallback == nil || cost < fallbackCost || (cost == fallbackCost && util < fallbackUtil) {
+			fallback, fallbackCost, fallbackUtil = server, cost, util
+		}
+
+		if gcAware && l.avoidsGC(server, policy, explain) {
+			l.logf("Server %d skipped: MaGC predicted within %dms", server.ID, policy.MaGCThreshold)
+			explain.skip(server, SkipMaGCPredicted)
+			continue
+		}
+
+		if best == nil || cost < bestCost || (cost == bestCost && util < bestUtil) {
+			best, bestCost, bestUtil = server, cost, util
+		}
+	}
+
+	if best != nil {
+		l.logf("Server %d selected (COST, cost: %.2f)", best.ID, bestCost)
+		return best
+	}
+
+	if fallback != nil {
+		l.logf("All servers have predicted MaGC, using cheapest available server %d", fallback.ID)
+		explain.fallback("No GC-safe server, used cheapest available")
+	}
+	return fallback
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Training dataset export formats
+const (
+	DatasetCSV   = "csv"   // One row per snapshot
+	DatasetJSONL = "jsonl" // One sequence per line
+)
+
+// IsValidDatasetFormat reports whether format names a training dataset export format
+func IsValidDatasetFormat(format string) bool {
+	return format == DatasetCSV || format == DatasetJSONL
+}
+
+// TrainingSnapshot is a GC snapshot labeled with the time left until the MaGC
+// that followed it
+type TrainingSnapshot struct {
+	Timestamp    time.Time `json:"timestamp"`
+	YoungGenUsed int       `json:"young_gen_used"`
+	YoungGenMax  int       `json:"young_gen_max"`
+	OldGenUsed   int       `json:"old_gen_used"`
+	OldGenMax    int       `json:"old_gen_max"`
+	TotalMemUsed int       `json:"total_mem_used"`
+	TotalMemMax  int       `json:"total_mem_max"`
+	GCCount      int       `json:"gc_count"`
+	TimeToMaGCMs int64     `json:"time_to_magc_ms"` // Label
+}
+
+// TrainingSequence is the run of snapshots a server took between two MaGCs,
+// ending with the MaGC they lead up to
+type TrainingSequence struct {
+	ServerID  int                `json:"server_id"`
+	Family    string             `json:"family"` // Program family when the MaGC started
+	MaGCStart time.Time          `json:"magc_start"`
+	MaGCPause int64              `json:"magc_pause_ms"`
+	Snapshots []TrainingSnapshot `json:"snapshots"`
+}
+
+// recordTrainingSequenceLocked labels the snapshots taken since the previous
+// MaGC with their time to the MaGC starting at start; callers hold s.mu,
+// before the MaGC is added to GCEvents
+func (s *Server) recordTrainingSequenceLocked(start, end time.Time) {
+	var since time.Time
+	if n := len(s.GCEvents); n > 0 {
+		since = s.GCEvents[n-1].EndTime
+	}
+
+	sequence := TrainingSequence{
+		ServerID:  s.ID,
+		MaGCStart: start,
+		MaGCPause: end.Sub(start).Milliseconds(),
+		Snapshots: make([]TrainingSnapshot, 0),
+	}
+	if s.CurrentFamily != nil {
+		sequence.Family = s.CurrentFamily.ID
+	}
+	for _, snapshot := range s.GCHistory {
+		if snapshot.IsCollectingGC || !snapshot.Timestamp.After(since) || snapshot.Timestamp.After(start) {
+			continue
+		}
+		sequence.Snapshots = append(sequence.Snapshots, TrainingSnapshot{
+			Timestamp:    snapshot.Timestamp,
+			YoungGenUsed: snapshot.YoungGenUsed,
+			YoungGenMax:  snapshot.YoungGenMax,
+			OldGenUsed:   snapshot.OldGenUsed,
+			OldGenMax:    snapshot.OldGenMax,
+			TotalMemUsed: snapshot.TotalMemUsed,
+			TotalMemMax:  snapshot.TotalMemMax,
+			GCCount:      snapshot.GCCount,
+			TimeToMaGCMs: start.Sub(snapshot.Timestamp).Milliseconds(),
+		})
+	}
+	if len(sequence.Snapshots) == 0 {
+		return // TRINI was not monitoring before this MaGC
+	}
+
+	s.trainingSequences = append(s.trainingSequences, sequence)
+	if len(s.trainingSequences) > maxGCEvents {
+		s.trainingSequences = s.trainingSequences[len(s.trainingSequences)-maxGCEvents:]
+	}
+}
+
+// TrainingDataset returns the recorded sequences of the given servers (all
+// when serverIDs is empty) whose MaGC started at or after since, oldest first
+func (l *LoadBalancer) TrainingDataset(serverIDs []int, since time.Time) []TrainingSequence {
+	wanted := make(map[int]bool, len(serverIDs))
+	for _, id := range serverIDs {
+		wanted[id] = true
+	}
+
+	sequences := make([]TrainingSequence, 0)
+	for _, server := range l.ListServers() {
+		if len(wanted) > 0 && !wanted[server.ID] {
+			continue
+		}
+		server.mu.Lock()
+		for _, sequence := range server.trainingSequences {
+			if !sequence.MaGCStart.Before(since) {
+				sequences = append(sequences, sequence)
+			}
+		}
+		server.mu.Unlock()
+	}
+
+	sort.SliceStable(sequences, func(i, j int) bool {
+		return sequences[i].MaGCStart.Before(sequences[j].MaGCStart)
+	})
+	return sequences
+}
+
+// WriteTrainingDataset writes the sequences in the given format. Sequences
+// are numbered in order so CSV rows can be grouped back into sequences.
+func WriteTrainingDataset(w io.Writer, format string, sequences []TrainingSequence) error {
+	if format == DatasetJSONL {
+		encoder := json.NewEncoder(w)
+		for _, sequence := range sequences {
+			if err := encoder.Encode(sequence); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{
+		"sequence", "server_id", "family", "magc_start", "magc_pause_ms", "timestamp",
+		"young_gen_used", "young_gen_max", "old_gen_used", "old_gen_max",
+		"total_mem_used", "total_mem_max", "gc_count", "time_to_magc_ms",
+	})
+	for i, sequence := range sequences {
+		for _, snapshot := range sequence.Snapshots {
+			writer.Write([]string{
+				strconv.Itoa(i + 1),
+				strconv.Itoa(sequence.ServerID),
+				sequence.Family,
+				sequence.MaGCStart.Format(time.RFC3339Nano),
+				strconv.FormatInt(sequence.MaGCPause, 10),
+				snapshot.Timestamp.Format(time.RFC3339Nano),
+				strconv.Itoa(snapshot.YoungGenUsed),
+				strconv.Itoa(snapshot.YoungGenMax),
+				strconv.Itoa(snapshot.OldGenUsed),
+				strconv.Itoa(snapshot.OldGenMax),
+				strconv.Itoa(snapshot.TotalMemUsed),
+				strconv.Itoa(snapshot.TotalMemMax),
+				strconv.Itoa(snapshot.GCCount),
+				strconv.FormatInt(snapshot.TimeToMaGCMs, 10),
+			})
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,454 @@
+package server
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// decisionLogMagic starts every decision log file; the last byte is the format version
+var decisionLogMagic = []byte("LBDL\x01")
+
+// maxDecisionFrame bounds a single encoded record so a corrupt length cannot exhaust memory
+const maxDecisionFrame = 1 << 20
+
+// DecisionServer is a server's selection-relevant state when a decision was made
+type DecisionServer struct {
+	ID            int     `json:"server_id"`
+	AdminState    string  `json:"admin_state"`
+	CollectingGC  bool    `json:"collecting_gc"`
+	UsedMemory    int     `json:"used_memory"`
+	MemLimit      int     `json:"mem_limit"`
+	Weight        int     `json:"weight"`
+	RuntimeWeight int     `json:"runtime_weight"` // Remaining weighted round-robin budget
+	Cost          float64 `json:"cost"`
+	HasForecast   bool    `json:"has_forecast"`
+	// Forecast relative to the decision time, so replay does not depend on the wall clock
+	ForecastInMs  int64   `json:"forecast_in_ms,omitempty"`
+	ForecastAgeMs int64   `json:"forecast_age_ms,omitempty"`
+	Confidence    float64 `json:"confidence,omitempty"`
+	ActiveTasks   int     `json:"active_tasks"`
+	MaxConcurrent int     `json:"max_concurrent_tasks"` // 0 is unlimited
+	// Slow start progress; both are 0 outside slow start
+	SlowStartElapsedMs int64 `json:"slow_start_elapsed_ms,omitempty"`
+	SlowStartMs        int64 `json:"slow_start_ms,omitempty"`
+}
+
+// DecisionRecord is the full input and outcome of one server selection
+type DecisionRecord struct {
+	Time     time.Time           `json:"time"`
+	TaskSize int                 `json:"task_size"` // Selection only depends on the task's size
+	Policy   LoadBalancingPolicy `json:"policy"`
+	// MaGC window after forecast-aware placement widened the policy threshold
+	PlacementWindowMs int64            `json:"placement_window_ms"`
+	PolicyArm         string           `json:"policy_arm,omitempty"`
+	TRINIActive       bool             `json:"trini_active"`
+	RRIndex           int              `json:"rr_index"` // Round-robin position before the decision
+	Retries           int              `json:"retries"`  // Selection retry budget
+	Draws             []int            `json:"draws"`    // Random numbers drawn by the algorithm
+	ServerID          int              `json:"server_id"`
+	Servers           []DecisionServer `json:"servers"`
+}
+
+// DecisionLog appends selection decisions to a compact binary file for
+// postmortem replay. Records are length-prefixed, so a log cut short by a
+// crash stays readable up to the last complete record.
+type DecisionLog struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+// OpenDecisionLog opens or creates the decision log at path for appending
+func OpenDecisionLog(path string) (*DecisionLog, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open decision log: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("open decision log: %w", err)
+	}
+	if info.Size() == 0 {
+		if _, err := file.Write(decisionLogMagic); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("write decision log header: %w", err)
+		}
+	} else if err := checkDecisionLogHeader(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &DecisionLog{file: file, path: path}, nil
+}
+
+// checkDecisionLogHeader verifies the magic bytes at the start of r
+func checkDecisionLogHeader(r io.ReaderAt) error {
+	header := make([]byte, len(decisionLogMagic))
+	if _, err := r.ReadAt(header, 0); err != nil || string(header) != string(decisionLogMagic) {
+		return errors.New("not a decision log or unsupported version")
+	}
+	return nil
+}
+
+// Path returns the log file path
+func (d *DecisionLog) Path() string {
+	return d.path
+}
+
+// Append writes one record
+func (d *DecisionLog) Append(record DecisionRecord) error {
+	payload := encodeDecision(record)
+	frame := binary.AppendUvarint(make([]byte, 0, len(payload)+binary.MaxVarintLen64), uint64(len(payload)))
+	frame = append(frame, payload...)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, err := d.file.Write(frame)
+	return err
+}
+
+// Close closes the log file
+func (d *DecisionLog) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.file.Close()
+}
+
+// ReadDecisionLog returns the records in [from, to], oldest first. A zero
+// from or to leaves that end of the range open.
+func ReadDecisionLog(path string, from, to time.Time) ([]DecisionRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open decision log: %w", err)
+	}
+	defer file.Close()
+
+	if err := checkDecisionLogHeader(file); err != nil {
+		return nil, err
+	}
+	reader := bufio.NewReader(io.NewSectionReader(file, int64(len(decisionLogMagic)), math.MaxInt64))
+
+	records := make([]DecisionRecord, 0)
+	for {
+		size, err := binary.ReadUvarint(reader)
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil || size > maxDecisionFrame {
+			return records, fmt.Errorf("decision log corrupt after %d records", len(records))
+		}
+
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return records, nil // Truncated final record, e.g. after a crash
+		}
+
+		record, err := decodeDecision(payload)
+		if err != nil {
+			return records, fmt.Errorf("decision log record %d: %w", len(records)+1, err)
+		}
+		if (!from.IsZero() && record.Time.Before(from)) || (!to.IsZero() && record.Time.After(to)) {
+			continue
+		}
+		records = append(records, record)
+	}
+}
+
+// encodeDecision serializes a record with varints for integers
+func encodeDecision(r DecisionRecord) []byte {
+	var e decisionEncoder
+	e.varint(r.Time.UnixNano())
+	e.varint(int64(r.TaskSize))
+	e.str(r.Policy.Algorithm)
+	e.boolean(r.Policy.GCAware)
+	e.varint(r.Policy.MaGCThreshold)
+	e.varint(int64(r.Policy.HistoryWindowSize))
+	e.varint(r.Policy.GCImminentHeaderMs)
+	e.varint(r.PlacementWindowMs)
+	e.str(r.PolicyArm)
+	e.boolean(r.TRINIActive)
+	e.varint(int64(r.RRIndex))
+	e.varint(int64(r.Retries))
+	e.varint(int64(len(r.Draws)))
+	for _, draw := range r.Draws {
+		e.varint(int64(draw))
+	}
+	e.varint(int64(r.ServerID))
+
+	e.varint(int64(len(r.Servers)))
+	for _, s := range r.Servers {
+		e.varint(int64(s.ID))
+		e.str(s.AdminState)
+		e.boolean(s.CollectingGC)
+		e.varint(int64(s.UsedMemory))
+		e.varint(int64(s.MemLimit))
+		e.varint(int64(s.Weight))
+		e.varint(int64(s.RuntimeWeight))
+		e.float(s.Cost)
+		e.boolean(s.HasForecast)
+		if s.HasForecast {
+			e.varint(s.ForecastInMs)
+			e.varint(s.ForecastAgeMs)
+			e.float(s.Confidence)
+		}
+	}
+
+	// Fields added after version 1 follow the servers and are optional
+	e.str(r.Policy.Tiebreaker)
+	e.str(r.Policy.GCAvoidance)
+	for _, s := range r.Servers {
+		e.varint(int64(s.ActiveTasks))
+		e.varint(int64(s.MaxConcurrent))
+	}
+	for _, s := range r.Servers {
+		e.varint(s.SlowStartElapsedMs)
+		e.varint(s.SlowStartMs)
+	}
+	return e.buf
+}
+
+// decodeDecision is the inverse of encodeDecision
+func decodeDecision(payload []byte) (DecisionRecord, error) {
+	d := decisionDecoder{buf: payload}
+	var r DecisionRecord
+	r.Time = time.Unix(0, d.varint())
+	r.TaskSize = int(d.varint())
+	r.Policy.Algorithm = d.str()
+	r.Policy.GCAware = d.boolean()
+	r.Policy.MaGCThreshold = d.varint()
+	r.Policy.HistoryWindowSize = int(d.varint())
+	r.Policy.GCImminentHeaderMs = d.varint()
+	r.PlacementWindowMs = d.varint()
+	r.PolicyArm = d.str()
+	r.TRINIActive = d.boolean()
+	r.RRIndex = int(d.varint())
+	r.Retries = int(d.varint())
+	r.Draws = make([]int, d.count())
+	for i := range r.Draws {
+		r.Draws[i] = int(d.varint())
+	}
+	r.ServerID = int(d.varint())
+
+	r.Servers = make([]DecisionServer, d.count())
+	for i := range r.Servers {
+		s := &r.Servers[i]
+		s.ID = int(d.varint())
+		s.AdminState = d.str()
+		s.CollectingGC = d.boolean()
+		s.UsedMemory = int(d.varint())
+		s.MemLimit = int(d.varint())
+		s.Weight = int(d.varint())
+		s.RuntimeWeight = int(d.varint())
+		s.Cost = d.float()
+		s.HasForecast = d.boolean()
+		if s.HasForecast {
+			s.ForecastInMs = d.varint()
+			s.ForecastAgeMs = d.varint()
+			s.Confidence = d.float()
+		}
+	}
+
+	if d.err == nil && len(d.buf) > 0 {
+		r.Policy.Tiebreaker = d.str()
+	}
+	if d.err == nil && len(d.buf) > 0 {
+		r.Policy.GCAvoidance = d.str()
+	}
+	if d.err == nil && len(d.buf) > 0 {
+		for i := range r.Servers {
+			r.Servers[i].ActiveTasks = int(d.varint())
+			r.Servers[i].MaxConcurrent = int(d.varint())
+		}
+	}
+	if d.err == nil && len(d.buf) > 0 {
+		for i := range r.Servers {
+			r.Servers[i].SlowStartElapsedMs = d.varint()
+			r.Servers[i].SlowStartMs = d.varint()
+		}
+	}
+	return r, d.err
+}
+
+// decisionEncoder appends primitive values to a buffer
+type decisionEncoder struct {
+	buf []byte
+}
+
+func (e *decisionEncoder) varint(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *decisionEncoder) str(v string) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *decisionEncoder) boolean(v bool) {
+	if v {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *decisionEncoder) float(v float64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+}
+
+// decisionDecoder reads primitive values, remembering the first error
+type decisionDecoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decisionDecoder) fail() {
+	if d.err == nil {
+		d.err = errors.New("truncated record")
+	}
+	d.buf = nil
+}
+
+func (d *decisionDecoder) varint() int64 {
+	v, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail()
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// count reads a length, bounded by the remaining bytes so corrupt input cannot over-allocate
+func (d *decisionDecoder) count() int {
+	n := d.varint()
+	if n < 0 || n > int64(len(d.buf)) {
+		d.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decisionDecoder) str() string {
+	size, n := binary.Uvarint(d.buf)
+	if n <= 0 || size > uint64(len(d.buf)-n) {
+		d.fail()
+		return ""
+	}
+	v := string(d.buf[n : n+int(size)])
+	d.buf = d.buf[n+int(size):]
+	return v
+}
+
+func (d *decisionDecoder) boolean() bool {
+	if len(d.buf) < 1 {
+		d.fail()
+		return false
+	}
+	v := d.buf[0] != 0
+	d.buf = d.buf[1:]
+	return v
+}
+
+func (d *decisionDecoder) float() float64 {
+	if len(d.buf) < 8 {
+		d.fail()
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf))
+	d.buf = d.buf[8:]
+	return v
+}
+
+// decisionSnapshot captures the selection inputs before a decision is made
+func (l *LoadBalancer) decisionSnapshot(taskInput string, policy LoadBalancingPolicy, arm string, retries int) *DecisionRecord {
+	now := l.now()
+	record := &DecisionRecord{
+		Time:              now,
+		TaskSize:          len(taskInput),
+		Policy:            policy,
+		PlacementWindowMs: l.placementPolicy(taskInput, policy).MaGCThreshold,
+		PolicyArm:         arm,
+		TRINIActive:       l.TRINI != nil && l.TRINI.IsActive,
+		Retries:           retries,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.RRIndex = l.currentServerIndex
+	record.Servers = make([]DecisionServer, 0, len(l.Servers))
+	for _, server := range l.Servers {
+		record.Servers = append(record.Servers, server.decisionState(now))
+	}
+	return record
+}
+
+// decisionState snapshots the server for a decision record
+func (s *Server) decisionState(now time.Time) DecisionServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := DecisionServer{
+		ID:            s.ID,
+		AdminState:    s.adminStateLocked(),
+		CollectingGC:  s.isCollectingGCTasks,
+		UsedMemory:    s.usedMemory,
+		MemLimit:      s.memLimit,
+		Weight:        s.weight,
+		RuntimeWeight: s.Weights,
+		Cost:          s.Cost,
+		ActiveTasks:   s.activeTasks,
+		MaxConcurrent: s.maxConcurrentTasks,
+	}
+	state.SlowStartElapsedMs, state.SlowStartMs = s.slowStartProgressLocked(now)
+	if forecast := s.LastMaGCForecast; forecast != nil {
+		state.HasForecast = true
+		state.ForecastInMs = forecast.PredictedTime.Sub(now).Milliseconds()
+		state.ForecastAgeMs = now.Sub(forecast.ForecastCreatedAt).Milliseconds()
+		state.Confidence = forecast.Confidence
+	}
+	return state
+}
+
+// recordsDecisions reports whether selection decisions are kept for replay,
+// in a decision log or the store
+func (l *LoadBalancer) recordsDecisions() bool {
+	return l.decisions != nil || l.store != nil
+}
+
+// recordDecision completes a snapshot with the outcome and appends it to the
+// log and the store. Choices of the external policy engine and degraded mode are left out,
+// as replay can only re-run the policy's local algorithm.
+func (l *LoadBalancer) recordDecision(record *DecisionRecord, explain *SelectionExplanation, server *Server) {
+	if explain != nil && (explain.PolicyEngine == EngineDecided || explain.Degraded) {
+		return
+	}
+	if explain != nil {
+		record.Draws = explain.draws
+		if explain.rrSeen {
+			record.RRIndex = explain.rrIndex
+		}
+	}
+	if server != nil {
+		record.ServerID = server.ID
+	}
+	if l.decisions != nil {
+		if err := l.decisions.Append(*record); err != nil {
+			l.logf("⚠️ Could not record decision: %v", err)
+		}
+	}
+	if l.store != nil {
+		if err := l.store.SaveDecision(*record); err != nil {
+			l.logf("⚠️ Could not store decision: %v", err)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// decisionLogRecords cover optional forecasts, random draws, rollout arms and
+// the fields added after version 1
+var decisionLogRecords = []DecisionRecord{
+	{
+		Time:     time.Unix(0, 1700000000000000000),
+		TaskSize: 11,
+		Policy:   LoadBalancingPolicy{Algorithm: "RR"},
+		Draws:    []int{},
+		ServerID: 1,
+		Servers: []DecisionServer{
+			{ID: 1, AdminState: StateActive, UsedMemory: 10, MemLimit: 100, Weight: 1, RuntimeWeight: 1},
+		},
+	},
+	{
+		Time:              time.Unix(0, 1700000001000000000),
+		TaskSize:          4096,
+		Policy:            LoadBalancingPolicy{Algorithm: "WRAN", GCAware: true, MaGCThreshold: 2000, HistoryWindowSize: 50, GCImminentHeaderMs: 500, Tiebreaker: "pressure", GCAvoidance: "soft"},
+		PlacementWindowMs: 2600,
+		PolicyArm:         armCandidate,
+		TRINIActive:       true,
+		RRIndex:           2,
+		Retries:           3,
+		Draws:             []int{7, 0, 123456},
+		ServerID:          3,
+		Servers: []DecisionServer{
+			{ID: 2, AdminState: StateDraining, CollectingGC: true, UsedMemory: 90, MemLimit: 100, Weight: 2, Cost: 1.5,
+				HasForecast: true, ForecastInMs: -250, ForecastAgeMs: 1200, Confidence: 0.875, ActiveTasks: 4, MaxConcurrent: 4},
+			{ID: 3, AdminState: StateActive, UsedMemory: 0, MemLimit: 200, Weight: 4, RuntimeWeight: 3, Cost: 0.25,
+				HasForecast: true, ForecastInMs: 9000, Confidence: 0.5, SlowStartElapsedMs: 6000, SlowStartMs: 60000},
+		},
+	},
+	{
+		Time:     time.Unix(0, 1700000002000000000),
+		TaskSize: 0,
+		Policy:   LoadBalancingPolicy{Algorithm: AlgorithmCost, GCAware: true, MaGCThreshold: 60000},
+		Draws:    []int{},
+		Servers:  []DecisionServer{},
+	},
+}
+
+// writeDecisionLog appends the records to a new log and returns its path
+func writeDecisionLog(t testing.TB, records []DecisionRecord) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "decisions.log")
+	log, err := OpenDecisionLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if err := log.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDecisionLogRoundTrip(t *testing.T) {
+	path := writeDecisionLog(t, decisionLogRecords)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []DecisionRecord
+	}{
+		{"whole log", time.Time{}, time.Time{}, decisionLogRecords},
+		{"from the second record", decisionLogRecords[1].Time, time.Time{}, decisionLogRecords[1:]},
+		{"up to the second record", time.Time{}, decisionLogRecords[1].Time, decisionLogRecords[:2]},
+		{"before every record", time.Time{}, decisionLogRecords[0].Time.Add(-time.Nanosecond), []DecisionRecord{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadDecisionLog(path, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("read %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionLogReopenAppends(t *testing.T) {
+	path := writeDecisionLog(t, decisionLogRecords[:1])
+	log, err := OpenDecisionLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Append(decisionLogRecords[1]); err != nil {
+		t.Fatal(err)
+	}
+	log.Close()
+
+	got, err := ReadDecisionLog(path, time.Time{}, time.Time{})
+	if err != nil || !reflect.DeepEqual(got, decisionLogRecords[:2]) {
+		t.Errorf("after reopening: %d records, %v", len(got), err)
+	}
+}
+
+func TestDecisionLogRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.log")
+	if err := os.WriteFile(path, []byte("LBDL\x02 from a newer version"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenDecisionLog(path); err == nil {
+		t.Error("opened a log of another version for appending")
+	}
+	if _, err := ReadDecisionLog(path, time.Time{}, time.Time{}); err == nil {
+		t.Error("read a log of another version")
+	}
+}
+
+func TestDecisionLogTruncatedFrame(t *testing.T) {
+	path := writeDecisionLog(t, decisionLogRecords)
+	full, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastFrame := len(encodeDecision(decisionLogRecords[2])) + 1 // One-byte length prefix
+
+	tests := []struct {
+		name string
+		cut  int // Bytes cut from the end of the file
+		want int // Records still readable
+	}{
+		{"last byte", 1, 2},
+		{"half the last record", lastFrame / 2, 2},
+		{"all but the length prefix", lastFrame - 1, 2},
+		{"whole last frame", lastFrame, 2},
+		{"into the second record", lastFrame + 1, 1},
+		{"down to the header", len(full) - len(decisionLogMagic), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			truncated := filepath.Join(t.TempDir(), "decisions.log")
+			if err := os.WriteFile(truncated, full[:len(full)-tt.cut], 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadDecisionLog(truncated, time.Time{}, time.Time{})
+			if err != nil {
+				t.Fatalf("a log cut short after a crash is not an error: %v", err)
+			}
+			if !reflect.DeepEqual(got, decisionLogRecords[:tt.want]) {
+				t.Errorf("read %d records, want the first %d", len(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecisionLogCorruptFrame(t *testing.T) {
+	path := writeDecisionLog(t, decisionLogRecords[:1])
+	tests := []struct {
+		name  string
+		frame []byte
+	}{
+		{"length over the frame limit", []byte{0xff, 0xff, 0xff, 0xff, 0x7f}},
+		{"record too short for its fields", []byte{2, 0x02, 0x16}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := os.ReadFile(path)
+			corrupt := filepath.Join(t.TempDir(), "decisions.log")
+			if err := os.WriteFile(corrupt, append(data, tt.frame...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadDecisionLog(corrupt, time.Time{}, time.Time{})
+			if err == nil {
+				t.Error("corrupt frame not reported")
+			}
+			if !reflect.DeepEqual(got, decisionLogRecords[:1]) {
+				t.Errorf("read %d records before the corrupt frame, want 1", len(got))
+			}
+		})
+	}
+}
+
+// FuzzReadDecisionLog appends arbitrary bytes to a valid log: reading must not
+// panic and must return every record before them intact
+func FuzzReadDecisionLog(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0x80})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 0x7f})
+	f.Add(append([]byte{byte(len(encodeDecision(decisionLogRecords[1])))}, encodeDecision(decisionLogRecords[1])...))
+	f.Add(encodeDecision(decisionLogRecords[1])[:20])
+
+	valid, err := os.ReadFile(writeDecisionLog(f, decisionLogRecords[:2]))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Fuzz(func(t *testing.T, tail []byte) {
+		path := filepath.Join(t.TempDir(), "decisions.log")
+		if err := os.WriteFile(path, append(append([]byte(nil), valid...), tail...), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := ReadDecisionLog(path, time.Time{}, time.Time{})
+		if len(got) < 2 || !reflect.DeepEqual(got[:2], decisionLogRecords[:2]) {
+			t.Fatalf("lost the records before the appended bytes: read %d, err %v", len(got), err)
+		}
+	})
+}
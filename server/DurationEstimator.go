@@ -0,0 +1,150 @@
+package server
+
+import (
+	"math/bits"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FlagForecastPlacement widens the GC-aware avoidance window to cover a
+// task's expected execution time
+const FlagForecastPlacement = "forecast_placement"
+
+const (
+	// defaultTaskDuration is assumed until tasks of a similar size have completed
+	defaultTaskDuration = time.Second
+	// durationSmoothing is the weight of the newest sample in the moving average
+	durationSmoothing = 0.2
+)
+
+// DurationEstimate is the learned execution time for one task size class
+type DurationEstimate struct {
+	MaxSize int     `json:"max_size"` // Tasks up to this many bytes
+	Samples int64   `json:"samples"`
+	AvgMs   float64 `json:"avg_ms"`
+}
+
+// DurationEstimator learns task execution times per power-of-two size class
+type DurationEstimator struct {
+	mu      sync.Mutex
+	classes map[int]*DurationEstimate
+}
+
+// NewDurationEstimator creates an estimator with no history
+func NewDurationEstimator() *DurationEstimator {
+	return &DurationEstimator{classes: make(map[int]*DurationEstimate)}
+}
+
+// sizeClass maps a task size to its power-of-two class
+func sizeClass(size int) int {
+	if size <= 0 {
+		return 0
+	}
+	return bits.Len(uint(size - 1))
+}
+
+// Observe records how long a task of the given size took
+func (e *DurationEstimator) Observe(size int, duration time.Duration) {
+	if e == nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	class := sizeClass(size)
+	ms := float64(duration.Milliseconds())
+	estimate, exists := e.classes[class]
+	if !exists {
+		e.classes[class] = &DurationEstimate{MaxSize: 1 << class, Samples: 1, AvgMs: ms}
+		return
+	}
+	estimate.Samples++
+	estimate.AvgMs += durationSmoothing * (ms - estimate.AvgMs)
+}
+
+// Estimate returns the expected execution time for a task of the given size,
+// borrowing from the nearest size class when this one has no history
+func (e *DurationEstimator) Estimate(size int) time.Duration {
+	if e == nil {
+		return defaultTaskDuration
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	class := sizeClass(size)
+	var nearest *DurationEstimate
+	nearestDistance := 0
+	for c, estimate := range e.classes {
+		distance := c - class
+		if distance < 0 {
+			distance = -distance
+		}
+		if nearest == nil || distance < nearestDistance || (distance == nearestDistance && c > class) {
+			nearest, nearestDistance = estimate, distance
+		}
+	}
+
+	if nearest == nil {
+		return defaultTaskDuration
+	}
+	return time.Duration(nearest.AvgMs * float64(time.Millisecond))
+}
+
+// Mean returns the execution time averaged over every task observed so far,
+// weighting each size class by its samples
+func (e *DurationEstimator) Mean() time.Duration {
+	if e == nil {
+		return defaultTaskDuration
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var totalMs float64
+	var samples int64
+	for _, estimate := range e.classes {
+		totalMs += estimate.AvgMs * float64(estimate.Samples)
+		samples += estimate.Samples
+	}
+	if samples == 0 {
+		return defaultTaskDuration
+	}
+	return time.Duration(totalMs / float64(samples) * float64(time.Millisecond))
+}
+
+// Estimates returns the learned durations ordered by size class
+func (e *DurationEstimator) Estimates() []DurationEstimate {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	estimates := make([]DurationEstimate, 0, len(e.classes))
+	for _, estimate := range e.classes {
+		estimates = append(estimates, *estimate)
+	}
+	sort.Slice(estimates, func(i, j int) bool { return estimates[i].MaxSize < estimates[j].MaxSize })
+	return estimates
+}
+
+// observeTaskDuration feeds a completed task's execution time to the estimator
+func (l *LoadBalancer) observeTaskDuration(size int, duration time.Duration) {
+	if l == nil {
+		return
+	}
+	l.Durations.Observe(size, duration)
+}
+
+// placementPolicy widens the policy's MaGC threshold to the task's expected
+// execution window, so servers due to pause mid-task are avoided too
+func (l *LoadBalancer) placementPolicy(taskInput string, policy LoadBalancingPolicy) LoadBalancingPolicy {
+	if !policy.GCAware || !l.Flags.Enabled(FlagForecastPlacement) {
+		return policy
+	}
+
+	if expected := l.Durations.Estimate(len(taskInput)).Milliseconds(); expected > policy.MaGCThreshold {
+		policy.MaGCThreshold = expected
+	}
+	return policy
+}
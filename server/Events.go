@@ -0,0 +1,91 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// maxEvents bounds the in-memory event log
+	maxEvents = 200
+	// eventSubscriberBuffer is how many events a slow subscriber may fall behind before events are dropped
+	eventSubscriberBuffer = 64
+)
+
+// Event records a notable occurrence in the load balancer (scaling, membership changes, ...)
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	ServerID  int       `json:"server_id,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// RecordEvent appends an event to the event log and logs it
+func (l *LoadBalancer) RecordEvent(eventType string, serverID int, format string, args ...interface{}) {
+	event := Event{
+		Timestamp: l.now(),
+		Type:      eventType,
+		ServerID:  serverID,
+		Message:   fmt.Sprintf(format, args...),
+	}
+
+	l.eventsMu.Lock()
+	if l.eventCounts == nil {
+		l.eventCounts = make(map[string]int64)
+	}
+	l.eventCounts[event.Type]++
+	l.events = append(l.events, event)
+	if len(l.events) > maxEvents {
+		l.events = l.events[len(l.events)-maxEvents:]
+	}
+	for subscriber := range l.eventSubscribers {
+		select {
+		case subscriber <- event:
+		default: // Never block recording on a slow subscriber
+		}
+	}
+	l.eventsMu.Unlock()
+
+	l.logf("📣 %s: %s", event.Type, event.Message)
+}
+
+// Events returns up to limit of the most recent events, oldest first
+func (l *LoadBalancer) Events(limit int) []Event {
+	l.eventsMu.Lock()
+	defer l.eventsMu.Unlock()
+
+	events := l.events
+	if limit > 0 && len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+
+	result := make([]Event, len(events))
+	copy(result, events)
+	return result
+}
+
+// SubscribeEvents returns a channel receiving every event recorded from now on
+// and a function that ends the subscription and closes the channel. Events are
+// dropped for subscribers that fall too far behind.
+func (l *LoadBalancer) SubscribeEvents() (<-chan Event, func()) {
+	subscriber := make(chan Event, eventSubscriberBuffer)
+
+	l.eventsMu.Lock()
+	if l.eventSubscribers == nil {
+		l.eventSubscribers = make(map[chan Event]struct{})
+	}
+	l.eventSubscribers[subscriber] = struct{}{}
+	l.eventsMu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			l.eventsMu.Lock()
+			delete(l.eventSubscribers, subscriber)
+			l.eventsMu.Unlock()
+			close(subscriber)
+		})
+	}
+	return subscriber, unsubscribe
+}
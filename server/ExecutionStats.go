@@ -0,0 +1,206 @@
+package server
+
+import (
+	"time"
+)
+
+const (
+	// statsBucketCount is how many one-minute buckets each server keeps
+	statsBucketCount = 60
+	// statsFlushInterval is how often execution stats are written to storage
+	statsFlushInterval = 30 * time.Second
+	// serverStatsDocument is the storage document holding execution stats
+	serverStatsDocument = "server-stats"
+)
+
+// statsWindows are the rolling windows reported next to the totals
+var statsWindows = []struct {
+	name    string
+	minutes int64
+}{
+	{"1m", 1},
+	{"5m", 5},
+	{"15m", 15},
+	{"60m", 60},
+}
+
+// ExecutionTotals counts the work a server has done
+type ExecutionTotals struct {
+	TasksCompleted int64 `json:"tasks_completed"`
+	TasksRejected  int64 `json:"tasks_rejected"`
+	TasksTimedOut  int64 `json:"tasks_timed_out"` // Tasks cancelled past their execution timeout
+	BytesProcessed int64 `json:"bytes_processed"`
+	ExecTimeMs     int64 `json:"exec_time_ms"`
+	GCPauses       int64 `json:"gc_pauses"`
+	GCPauseMs      int64 `json:"gc_pause_ms"`
+	GCPrefetches   int64 `json:"gc_prefetches"` // MaGCs run early on an idle server
+	GCDesyncs      int64 `json:"gc_desyncs"`    // MaGCs run early to stagger correlated servers
+	WarmupTasks    int64 `json:"warmup_tasks"`  // Synthetic tasks run after MaGCs, see WithWarmup
+}
+
+// add accumulates other into t
+func (t *ExecutionTotals) add(other ExecutionTotals) {
+	t.TasksCompleted += other.TasksCompleted
+	t.TasksRejected += other.TasksRejected
+	t.TasksTimedOut += other.TasksTimedOut
+	t.BytesProcessed += other.BytesProcessed
+	t.ExecTimeMs += other.ExecTimeMs
+	t.GCPauses += other.GCPauses
+	t.GCPauseMs += other.GCPauseMs
+	t.GCPrefetches += other.GCPrefetches
+	t.GCDesyncs += other.GCDesyncs
+	t.WarmupTasks += other.WarmupTasks
+}
+
+// since returns the totals accumulated after base was taken
+func (t ExecutionTotals) since(base ExecutionTotals) ExecutionTotals {
+	return ExecutionTotals{
+		TasksCompleted: t.TasksCompleted - base.TasksCompleted,
+		TasksRejected:  t.TasksRejected - base.TasksRejected,
+		TasksTimedOut:  t.TasksTimedOut - base.TasksTimedOut,
+		BytesProcessed: t.BytesProcessed - base.BytesProcessed,
+		ExecTimeMs:     t.ExecTimeMs - base.ExecTimeMs,
+		GCPauses:       t.GCPauses - base.GCPauses,
+		GCPauseMs:      t.GCPauseMs - base.GCPauseMs,
+		GCPrefetches:   t.GCPrefetches - base.GCPrefetches,
+		GCDesyncs:      t.GCDesyncs - base.GCDesyncs,
+		WarmupTasks:    t.WarmupTasks - base.WarmupTasks,
+	}
+}
+
+// StatsBucket holds the totals for one minute
+type StatsBucket struct {
+	Minute int64 `json:"minute"` // Unix time in minutes
+	ExecutionTotals
+}
+
+// ExecutionSummary is a set of totals with derived averages
+type ExecutionSummary struct {
+	ExecutionTotals
+	AvgExecMs float64 `json:"avg_exec_ms"`
+}
+
+// summarize derives averages from totals
+func summarize(totals ExecutionTotals) ExecutionSummary {
+	summary := ExecutionSummary{ExecutionTotals: totals}
+	if totals.TasksCompleted > 0 {
+		summary.AvgExecMs = float64(totals.ExecTimeMs) / float64(totals.TasksCompleted)
+	}
+	return summary
+}
+
+// ServerExecutionStats reports a server's lifetime totals and rolling windows
+type ServerExecutionStats struct {
+	ServerID int                         `json:"server_id"`
+	Totals   ExecutionSummary            `json:"totals"`
+	Windows  map[string]ExecutionSummary `json:"windows"`
+}
+
+// serverStatsRecord is the persisted form of a server's execution stats
+type serverStatsRecord struct {
+	Totals  ExecutionTotals `json:"totals"`
+	Buckets []StatsBucket   `json:"buckets"`
+}
+
+// recordExecutionLocked applies update to the totals and the current minute's bucket; callers hold s.mu
+func (s *Server) recordExecutionLocked(update ExecutionTotals) {
+	s.execTotals.add(update)
+
+	minute := s.now().Unix() / 60
+	if n := len(s.execBuckets); n > 0 && s.execBuckets[n-1].Minute == minute {
+		s.execBuckets[n-1].add(update)
+		return
+	}
+
+	s.execBuckets = append(s.execBuckets, StatsBucket{Minute: minute, ExecutionTotals: update})
+	if len(s.execBuckets) > statsBucketCount {
+		s.execBuckets = s.execBuckets[len(s.execBuckets)-statsBucketCount:]
+	}
+}
+
+// ExecutionStats returns the server's lifetime totals and rolling windows
+func (s *Server) ExecutionStats() ServerExecutionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := ServerExecutionStats{
+		ServerID: s.ID,
+		Totals:   summarize(s.execTotals),
+		Windows:  make(map[string]ExecutionSummary),
+	}
+
+	minute := s.now().Unix() / 60
+	for _, window := range statsWindows {
+		var totals ExecutionTotals
+		for _, bucket := range s.execBuckets {
+			if minute-bucket.Minute < window.minutes {
+				totals.add(bucket.ExecutionTotals)
+			}
+		}
+		stats.Windows[window.name] = summarize(totals)
+	}
+
+	return stats
+}
+
+// statsRecord snapshots the server's stats for persistence
+func (s *Server) statsRecord() serverStatsRecord {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets := make([]StatsBucket, len(s.execBuckets))
+	copy(buckets, s.execBuckets)
+	return serverStatsRecord{Totals: s.execTotals, Buckets: buckets}
+}
+
+// restoreStats replaces the server's stats with a persisted record
+func (s *Server) restoreStats(record serverStatsRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.execTotals = record.Totals
+	s.execBuckets = record.Buckets
+	if len(s.execBuckets) > statsBucketCount {
+		s.execBuckets = s.execBuckets[len(s.execBuckets)-statsBucketCount:]
+	}
+}
+
+// restoreServerStats loads persisted execution stats into the current servers
+func (l *LoadBalancer) restoreServerStats() error {
+	if l.store == nil {
+		return nil
+	}
+
+	records := make(map[int]serverStatsRecord)
+	found, err := l.store.Load(serverStatsDocument, &records)
+	if err != nil || !found {
+		return err
+	}
+
+	for _, server := range l.ListServers() {
+		if record, ok := records[server.ID]; ok {
+			server.restoreStats(record)
+		}
+	}
+	l.logf("💾 Restored execution stats for %d servers", len(records))
+	return nil
+}
+
+// persistServerStats writes the execution stats of all servers to storage,
+// keeping records of servers that are currently not in the pool
+func (l *LoadBalancer) persistServerStats() error {
+	if l.store == nil {
+		return nil
+	}
+
+	records := make(map[int]serverStatsRecord)
+	if _, err := l.store.Load(serverStatsDocument, &records); err != nil {
+		l.logf("⚠️ Discarding unreadable execution stats: %v", err)
+		records = make(map[int]serverStatsRecord)
+	}
+
+	for _, server := range l.ListServers() {
+		records[server.ID] = server.statsRecord()
+	}
+	return l.store.Save(serverStatsDocument, records)
+}
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// Reasons a server was passed over during selection, besides a
+// non-active administrative state which is reported as-is
+const (
+	SkipUnavailable        = "unavailable"         // Collecting GC tasks
+	SkipInsufficientMemory = "insufficient_memory" // Task does not fit
+	SkipMaGCPredicted      = "magc_predicted"      // MaGC forecast within the threshold
+	SkipAtCapacity         = "at_capacity"         // Running its maximum number of concurrent tasks
+	SkipSlowStart          = "slow_start"          // Held back while ramping up after an upgrade or warm-up
+	SkipWarmingUp          = "warming_up"          // Running warm-up tasks after a MaGC
+	SkipBatchShare         = "batch_share"         // Already took its share of the task's batch
+	SkipZoneShare          = "zone_share"          // Its zone already took its share of the task's batch
+)
+
+// SkippedServer is a server that was considered but not chosen
+type SkippedServer struct {
+	ServerID int    `json:"server_id"`
+	Reason   string `json:"reason"`
+}
+
+// ServerForecast is a server's MaGC forecast as seen when the decision was made
+type ServerForecast struct {
+	ServerID      int       `json:"server_id"`
+	PredictedTime time.Time `json:"predicted_time"`
+	TimeToMaGCMs  int64     `json:"time_to_magc_ms"`
+	Confidence    float64   `json:"confidence"`
+}
+
+// SelectionExplanation describes why a task was routed the way it was
+type SelectionExplanation struct {
+	ServerID        int    `json:"server_id,omitempty"` // Zero if the task was rejected
+	Algorithm       string `json:"algorithm"`
+	GCAware         bool   `json:"gc_aware"`
+	TRINIActive     bool   `json:"trini_active"`
+	MaGCThresholdMs int64  `json:"magc_threshold_ms"`
+	// Threshold widened to the task's expected execution time
+	PlacementWindowMs int64           `json:"placement_window_ms"`
+	PolicyArm         string          `json:"policy_arm,omitempty"` // Set while a rollout is staged
+	Tiebreaker        string          `json:"tiebreaker,omitempty"`
+	GCAvoidance       string          `json:"gc_avoidance,omitempty"`
+	Attempts          int             `json:"attempts"`
+	Fallback          string          `json:"fallback,omitempty"`
+	PolicyEngine      string          `json:"policy_engine,omitempty"` // Outcome of consulting the policy engine, see EngineDecided
+	Degraded          bool            `json:"degraded,omitempty"`      // Plain round-robin was used, see ModeDegraded
+	Skipped           []SkippedServer `json:"skipped"`
+	// Servers whose forecast was ignored for being below the confidence floor
+	Suppressed []int            `json:"suppressed_forecasts,omitempty"`
+	Forecasts  []ServerForecast `json:"forecasts"`
+
+	// Random draws made by the algorithm, kept so the decision can be replayed
+	draws     []int
+	replay    []int // Recorded draws to reuse while replaying
+	replaying bool
+	rrIndex   int // Round-robin position the first pass started from
+	rrSeen    bool
+	slowStart map[int]bool // Slow start verdict drawn for each server in this pass
+
+	engineAsked bool // The policy engine was consulted for this task, see selectExternal
+
+	spread *batchSpread // Limits of the batch the task arrived in, see DispatchBatch
+}
+
+// newSelectionExplanation starts an explanation for a decision under the given policy
+func (l *LoadBalancer) newSelectionExplanation(policy LoadBalancingPolicy, arm string) *SelectionExplanation {
+	explain := &SelectionExplanation{
+		Algorithm:       policy.Algorithm,
+		GCAware:         policy.GCAware,
+		TRINIActive:     l.TRINI != nil && l.TRINI.IsActive,
+		MaGCThresholdMs: policy.MaGCThreshold,
+		PolicyArm:       arm,
+		Tiebreaker:      policy.Tiebreaker,
+		GCAvoidance:     policy.GCAvoidance,
+		Skipped:         make([]SkippedServer, 0),
+		Forecasts:       make([]ServerForecast, 0),
+	}
+
+	for _, server := range l.ListServers() {
+		if forecast, ok := server.forecastSnapshot(); ok {
+			explain.Forecasts = append(explain.Forecasts, forecast)
+		}
+	}
+
+	return explain
+}
+
+// forecastSnapshot returns the server's current MaGC forecast, if any
+func (s *Server) forecastSnapshot() (ServerForecast, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.LastMaGCForecast == nil {
+		return ServerForecast{}, false
+	}
+	return ServerForecast{
+		ServerID:      s.ID,
+		PredictedTime: s.LastMaGCForecast.PredictedTime,
+		TimeToMaGCMs:  s.LastMaGCForecast.PredictedTime.Sub(s.now()).Milliseconds(),
+		Confidence:    s.LastMaGCForecast.Confidence,
+	}, true
+}
+
+// ineligibleReason reports why the server cannot take the task, or "" if it can
+func (s *Server) ineligibleReason(taskInput string, explain *SelectionExplanation) string {
+	if state := s.AdminState(); state != StateActive {
+		return state
+	}
+	if !s.IsAvailable() {
+		return SkipUnavailable
+	}
+	if s.isWarmingUp() {
+		return SkipWarmingUp
+	}
+	if s.atCapacity() {
+		return SkipAtCapacity
+	}
+	if !s.slowStartAdmits(explain) {
+		return SkipSlowStart
+	}
+	if !s.CanHandleTaskSize(len(taskInput)) {
+		return SkipInsufficientMemory
+	}
+	return ""
+}
+
+// attempt starts a new selection pass; only the last pass is reported
+func (e *SelectionExplanation) attempt() {
+	if e == nil {
+		return
+	}
+	e.Attempts++
+	e.Skipped = e.Skipped[:0]
+	e.Suppressed = e.Suppressed[:0]
+	e.Fallback = ""
+	e.PolicyEngine = ""
+	e.slowStart = nil
+}
+
+// engine records the outcome of consulting the policy engine
+func (e *SelectionExplanation) engine(outcome string) {
+	if e == nil {
+		return
+	}
+	e.PolicyEngine = outcome
+}
+
+// skip records that a server was passed over; the first reason per server wins
+func (e *SelectionExplanation) skip(server *Server, reason string) {
+	if e == nil {
+		return
+	}
+	for _, skipped := range e.Skipped {
+		if skipped.ServerID == server.ID {
+			return
+		}
+	}
+	e.Skipped = append(e.Skipped, SkippedServer{ServerID: server.ID, Reason: reason})
+}
+
+// suppress records that the server's forecast was too unsure to act on
+func (e *SelectionExplanation) suppress(server *Server) {
+	if e == nil || slices.Contains(e.Suppressed, server.ID) {
+		return
+	}
+	e.Suppressed = append(e.Suppressed, server.ID)
+}
+
+// intn returns a random number in [0, n) and remembers it. While replaying,
+// the recorded draws are returned instead.
+func (e *SelectionExplanation) intn(n int) int {
+	if e == nil {
+		return rand.Intn(n)
+	}
+
+	var draw int
+	switch {
+	case !e.replaying:
+		draw = rand.Intn(n)
+	case len(e.replay) > 0:
+		draw, e.replay = e.replay[0]%n, e.replay[1:]
+	}
+	e.draws = append(e.draws, draw)
+	return draw
+}
+
+// slowStartVerdict returns whether slow start admitted the server earlier in
+// this pass, so an algorithm revisiting it does not draw again
+func (e *SelectionExplanation) slowStartVerdict(serverID int) (admitted, drawn bool) {
+	if e == nil {
+		return false, false
+	}
+	admitted, drawn = e.slowStart[serverID]
+	return admitted, drawn
+}
+
+// recordSlowStart remembers the slow start verdict drawn for the server
+func (e *SelectionExplanation) recordSlowStart(serverID int, admitted bool) {
+	if e == nil {
+		return
+	}
+	if e.slowStart == nil {
+		e.slowStart = make(map[int]bool)
+	}
+	e.slowStart[serverID] = admitted
+}
+
+// roundRobinFrom notes where a round-robin pass starts. Concurrent requests
+// move the position between snapshot and selection, so the first start is
+// what a replay must begin from.
+func (e *SelectionExplanation) roundRobinFrom(index int) {
+	if e == nil || e.rrSeen {
+		return
+	}
+	e.rrIndex, e.rrSeen = index, true
+}
+
+// spreadReason reports why the task's batch, if any, has no room left on the server
+func (e *SelectionExplanation) spreadReason(server *Server) string {
+	if e == nil {
+		return ""
+	}
+	return e.spread.reason(server)
+}
+
+// fallback records that the algorithm gave up its preference
+func (e *SelectionExplanation) fallback(reason string) {
+	if e == nil {
+		return
+	}
+	e.Fallback = reason
+}
@@ -0,0 +1,139 @@
+package server
+
+const (
+	// fairnessWindowMinutes is the rolling window selection counts are compared over
+	fairnessWindowMinutes = 5
+	// fairnessMinSelections is how many selections the window needs before starvation is judged
+	fairnessMinSelections = 20
+	// starvationRatio flags servers receiving less than this fraction of their fair share
+	starvationRatio = 0.25
+)
+
+// selectionBucket counts how often a server was selected during one minute
+type selectionBucket struct {
+	Minute int64 // Unix time in minutes
+	Count  int64
+}
+
+// ServerFairness compares a server's share of recent selections with its fair share
+type ServerFairness struct {
+	ServerID   int     `json:"server_id"`
+	Selections int64   `json:"selections"`
+	Share      float64 `json:"share"`      // Fraction of the window's selections
+	FairShare  float64 `json:"fair_share"` // Equal split, or by weight for weighted algorithms
+	Starved    bool    `json:"starved"`
+}
+
+// FairnessReport summarizes how evenly traffic was spread over the rolling window
+type FairnessReport struct {
+	WindowMinutes int   `json:"window_minutes"`
+	Selections    int64 `json:"selections"`
+	// Jain's fairness index of selections relative to fair shares: 1 is
+	// perfectly fair, 1/n means one server got everything
+	Index   float64          `json:"index"`
+	Servers []ServerFairness `json:"servers"`
+}
+
+// recordSelection counts a selection of the server in the current minute
+func (s *Server) recordSelection() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recordTimelineLocked(TimelineSelected, "Selected for a task")
+	minute := s.now().Unix() / 60
+	if n := len(s.selections); n > 0 && s.selections[n-1].Minute == minute {
+		s.selections[n-1].Count++
+		return
+	}
+
+	s.selections = append(s.selections, selectionBucket{Minute: minute, Count: 1})
+	if len(s.selections) > fairnessWindowMinutes {
+		s.selections = s.selections[len(s.selections)-fairnessWindowMinutes:]
+	}
+}
+
+// recentSelections returns how often the server was selected within the fairness window
+func (s *Server) recentSelections() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	minute := s.now().Unix() / 60
+	var count int64
+	for _, bucket := range s.selections {
+		if minute-bucket.Minute < fairnessWindowMinutes {
+			count += bucket.Count
+		}
+	}
+	return count
+}
+
+// Fairness reports how evenly active servers were selected over the rolling
+// window. Servers that are paused, draining, quarantined or in standby are
+// left out, as they are not meant to receive traffic.
+func (l *LoadBalancer) Fairness() FairnessReport {
+	algorithm := l.GetLoadBalancingPolicy().Algorithm
+	weighted := algorithm == "WRR" || algorithm == "WRAN"
+
+	report := FairnessReport{WindowMinutes: fairnessWindowMinutes, Servers: make([]ServerFairness, 0)}
+	var totalWeight float64
+	weights := make([]float64, 0)
+	for _, server := range l.ListServers() {
+		if server.AdminState() != StateActive {
+			continue
+		}
+		weight := 1.0
+		if weighted {
+			weight = float64(max(server.GetWeight(), 1))
+		}
+		weights = append(weights, weight)
+		totalWeight += weight
+
+		entry := ServerFairness{ServerID: server.ID, Selections: server.recentSelections()}
+		report.Selections += entry.Selections
+		report.Servers = append(report.Servers, entry)
+	}
+
+	// Jain's index over selections normalized by fair share
+	var sum, sumSquares float64
+	for i := range report.Servers {
+		entry := &report.Servers[i]
+		entry.FairShare = weights[i] / totalWeight
+		if report.Selections > 0 {
+			entry.Share = float64(entry.Selections) / float64(report.Selections)
+		}
+		entry.Starved = report.Selections >= fairnessMinSelections && entry.Share < entry.FairShare*starvationRatio
+
+		normalized := entry.Share / entry.FairShare
+		sum += normalized
+		sumSquares += normalized * normalized
+	}
+	report.Index = 1
+	if sumSquares > 0 {
+		report.Index = sum * sum / (float64(len(report.Servers)) * sumSquares)
+	}
+	return report
+}
+
+// checkStarvation records an event for each server that became starved since
+// the last check
+func (l *LoadBalancer) checkStarvation() {
+	report := l.Fairness()
+	for _, entry := range report.Servers {
+		server := l.GetServerByID(entry.ServerID)
+		if server == nil {
+			continue
+		}
+
+		server.mu.Lock()
+		newlyStarved := entry.Starved && !server.starved
+		server.starved = entry.Starved
+		server.mu.Unlock()
+
+		if newlyStarved {
+			l.RecordEvent("server_starved", entry.ServerID,
+				"Server %d received %d of %d selections in the last %dm (%.1f%%, fair share %.1f%%)",
+				entry.ServerID, entry.Selections, report.Selections, report.WindowMinutes,
+				entry.Share*100, entry.FairShare*100)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// familyPinsDocument is the storage document holding family pins
+const familyPinsDocument = "family-pins"
+
+// FamilyPin fixes a server's program family, bypassing automatic
+// classification until it expires or is removed
+type FamilyPin struct {
+	ServerID  int       `json:"server_id"`
+	FamilyID  string    `json:"family_id"`
+	PinnedAt  time.Time `json:"pinned_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"` // Zero means the pin never expires
+}
+
+// expired reports whether the pin has run out at now
+func (p FamilyPin) expired(now time.Time) bool {
+	return !p.ExpiresAt.IsZero() && !now.Before(p.ExpiresAt)
+}
+
+// FamilyPin returns the server's family pin, if it has one that has not expired
+func (s *Server) FamilyPin() (FamilyPin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.familyPinLocked()
+}
+
+// familyPinLocked is FamilyPin for callers holding s.mu
+func (s *Server) familyPinLocked() (FamilyPin, bool) {
+	if s.familyPin == nil || s.familyPin.expired(s.now()) {
+		return FamilyPin{}, false
+	}
+	return *s.familyPin, true
+}
+
+// programFamily looks up a program family by ID
+func (t *TRINI) programFamily(id string) (*ProgramFamily, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	family, ok := t.ProgramFamilies[id]
+	return family, ok
+}
+
+// PinFamily assigns the server to the program family and keeps the automatic
+// classifier from changing it for ttl; a ttl of 0 pins it until UnpinFamily.
+// Pins are persisted when the load balancer has storage.
+func (l *LoadBalancer) PinFamily(serverID int, familyID string, ttl time.Duration) (FamilyPin, error) {
+	if ttl < 0 {
+		return FamilyPin{}, fmt.Errorf("pin duration cannot be negative")
+	}
+	server := l.GetServerByID(serverID)
+	if server == nil {
+		return FamilyPin{}, fmt.Errorf("unknown server %d", serverID)
+	}
+	family, ok := l.TRINI.programFamily(familyID)
+	if !ok {
+		return FamilyPin{}, fmt.Errorf("unknown program family %q", familyID)
+	}
+
+	pin := FamilyPin{ServerID: serverID, FamilyID: familyID, PinnedAt: l.now()}
+	if ttl > 0 {
+		pin.ExpiresAt = pin.PinnedAt.Add(ttl)
+	}
+	server.applyFamilyPin(pin, family)
+
+	if err := l.persistFamilyPins(); err != nil {
+		l.logf("⚠️ Could not persist family pins: %v", err)
+	}
+	if ttl > 0 {
+		l.RecordEvent("family_pinned", serverID, "Server %d pinned to program family '%s' for %v", serverID, familyID, ttl)
+	} else {
+		l.RecordEvent("family_pinned", serverID, "Server %d pinned to program family '%s'", serverID, familyID)
+	}
+	return pin, nil
+}
+
+// UnpinFamily hands the server's program family back to the automatic
+// classifier, which re-evaluates it on its next analysis
+func (l *LoadBalancer) UnpinFamily(serverID int) error {
+	server := l.GetServerByID(serverID)
+	if server == nil {
+		return fmt.Errorf("unknown server %d", serverID)
+	}
+
+	server.mu.Lock()
+	pinned := server.familyPin != nil
+	server.familyPin = nil
+	server.mu.Unlock()
+	if !pinned {
+		return fmt.Errorf("server %d has no family pin", serverID)
+	}
+
+	if err := l.persistFamilyPins(); err != nil {
+		l.logf("⚠️ Could not persist family pins: %v", err)
+	}
+	l.RecordEvent("family_unpinned", serverID, "Server %d program family unpinned", serverID)
+	return nil
+}
+
+// FamilyPins returns the pins in effect, ordered by server
+func (l *LoadBalancer) FamilyPins() []FamilyPin {
+	pins := make([]FamilyPin, 0)
+	for _, server := range l.ListServers() {
+		if pin, ok := server.FamilyPin(); ok {
+			pins = append(pins, pin)
+		}
+	}
+	return pins
+}
+
+// applyFamilyPin switches the server to the pinned family
+func (s *Server) applyFamilyPin(pin FamilyPin, family *ProgramFamily) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.familyPin = &pin
+	s.CurrentFamily = family
+	s.recordTimelineLocked(TimelineFamily, "Pinned to program family '%s'", family.ID)
+}
+
+// expireFamilyPins drops pins that have run out, leaving the pinned family in
+// place until the classifier next re-evaluates it
+func (l *LoadBalancer) expireFamilyPins() {
+	expired := false
+	for _, server := range l.ListServers() {
+		server.mu.Lock()
+		pin := server.familyPin
+		if pin != nil && pin.expired(server.now()) {
+			server.familyPin = nil
+		} else {
+			pin = nil
+		}
+		server.mu.Unlock()
+
+		if pin != nil {
+			expired = true
+			l.RecordEvent("family_unpinned", server.ID, "Server %d pin to program family '%s' expired", server.ID, pin.FamilyID)
+		}
+	}
+
+	if expired {
+		if err := l.persistFamilyPins(); err != nil {
+			l.logf("⚠️ Could not persist family pins: %v", err)
+		}
+	}
+}
+
+// restoreFamilyPins re-applies persisted pins that have not expired to the
+// current servers. It runs after TRINI assigned the default family.
+func (l *LoadBalancer) restoreFamilyPins() error {
+	if l.store == nil {
+		return nil
+	}
+
+	pins := make(map[int]FamilyPin)
+	found, err := l.store.Load(familyPinsDocument, &pins)
+	if err != nil || !found {
+		return err
+	}
+
+	restored := 0
+	for _, server := range l.ListServers() {
+		pin, ok := pins[server.ID]
+		if !ok || pin.expired(l.now()) {
+			continue
+		}
+		family, ok := l.TRINI.programFamily(pin.FamilyID)
+		if !ok {
+			l.logf("⚠️ Dropping pin of server %d to unknown program family '%s'", server.ID, pin.FamilyID)
+			continue
+		}
+		server.applyFamilyPin(pin, family)
+		restored++
+	}
+	if restored > 0 {
+		l.logf("💾 Restored %d program family pins", restored)
+	}
+	return nil
+}
+
+// persistFamilyPins writes the pins in effect to storage. Unlike execution
+// stats, pins of servers outside the pool are dropped: a pin is an operator
+// decision about a specific running server.
+func (l *LoadBalancer) persistFamilyPins() error {
+	if l.store == nil {
+		return nil
+	}
+
+	pins := make(map[int]FamilyPin)
+	for _, pin := range l.FamilyPins() {
+		pins[pin.ServerID] = pin
+	}
+	return l.store.Save(familyPinsDocument, pins)
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Feature flag names for experimental behaviors
+const (
+	FlagHedging          = "hedging"
+	FlagRetries          = "retries"
+	FlagAdmissionControl = "admission_control"
+
+	// Forecasters: the long-horizon MaGC forecast and the calibration of
+	// short-horizon forecast confidence against past outcomes
+	FlagLongHorizonForecast = "long_horizon_forecast"
+	FlagForecastCalibration = "forecast_calibration"
+)
+
+// FeatureFlag describes one runtime toggle
+type FeatureFlag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// FeatureFlags is a registry of runtime toggles for experimental behaviors
+type FeatureFlags struct {
+	mu    sync.RWMutex
+	flags map[string]*FeatureFlag
+}
+
+// NewFeatureFlags creates a registry with all known flags disabled
+func NewFeatureFlags() *FeatureFlags {
+	f := &FeatureFlags{flags: make(map[string]*FeatureFlag)}
+
+	f.Register(FlagHedging, "Send a duplicate of slow tasks to a second server and use the first result", false)
+	f.Register(FlagRetries, "Retry rejected tasks on another server", false)
+	f.Register(FlagAdmissionControl, "Reject tasks up front when every server has an imminent MaGC instead of falling back", false)
+	f.Register(FlagLongHorizonForecast, "Forecast MaGCs minutes ahead from their cadence, for autoscaling, GC scheduling and as a fallback when no short-horizon forecast is valid", true)
+	f.Register(FlagForecastCalibration, "Report forecast confidence as the observed accuracy of similar past forecasts rather than the raw data-quality score", true)
+	f.Register(FlagForecastPlacement, "Avoid servers with a MaGC predicted during the task's expected execution time", true)
+	f.Register(FlagGCPrefetch, "Run a forecast MaGC early on servers with no tasks in flight", false)
+	f.Register(FlagForecastDesync, "Run a MaGC early when several servers are forecast to pause at the same time", false)
+
+	return f
+}
+
+// Register adds a flag if it is not already known
+func (f *FeatureFlags) Register(name, description string, enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.flags[name]; exists {
+		return
+	}
+	f.flags[name] = &FeatureFlag{Name: name, Description: description, Enabled: enabled}
+}
+
+// Enabled reports whether the flag is on; unknown flags are off
+func (f *FeatureFlags) Enabled(name string) bool {
+	if f == nil {
+		return false
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flag, exists := f.flags[name]
+	return exists && flag.Enabled
+}
+
+// Set turns a known flag on or off
+func (f *FeatureFlags) Set(name string, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	flag, exists := f.flags[name]
+	if !exists {
+		return fmt.Errorf("unknown feature flag %q", name)
+	}
+	flag.Enabled = enabled
+	return nil
+}
+
+// List returns all flags sorted by name
+func (f *FeatureFlags) List() []FeatureFlag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flags := make([]FeatureFlag, 0, len(f.flags))
+	for _, flag := range f.flags {
+		flags = append(flags, *flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// SetFeatureFlag changes a flag and records the change as an event
+func (l *LoadBalancer) SetFeatureFlag(name string, enabled bool) error {
+	if err := l.Flags.Set(name, enabled); err != nil {
+		return err
+	}
+
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	l.RecordEvent("feature_flag", 0, "Feature flag '%s' %s", name, state)
+	return nil
+}
+
+// admitTask applies admission control: with the flag on and a GC-aware policy,
+// tasks are rejected when every server has a MaGC predicted within the
+// threshold by a forecast above the confidence floor
+func (l *LoadBalancer) admitTask(policy LoadBalancingPolicy) bool {
+	if !l.Flags.Enabled(FlagAdmissionControl) || !policy.GCAware || l.TRINI == nil || !l.TRINI.IsActive || l.degraded() {
+		return true
+	}
+
+	servers := l.ListServers()
+	for _, server := range servers {
+		if !server.IsMaGCPredicted(policy.MaGCThreshold) || server.ForecastSuppressed(policy.ConfidenceFloor) {
+			return true
+		}
+	}
+	return len(servers) == 0
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// defaultPauseEstimate is assumed for servers that have not completed a MaGC yet
+const defaultPauseEstimate = 10 * time.Second
+
+// PauseWindow is one predicted (or in-progress) MaGC on a server
+type PauseWindow struct {
+	ServerID   int       `json:"server_id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Confidence float64   `json:"confidence"`
+	InProgress bool      `json:"in_progress"`
+	Horizon    string    `json:"horizon"` // Forecast the window comes from, see HorizonShort
+}
+
+// Forecast horizons a pause window can come from
+const (
+	HorizonShort = "short" // In progress, or the short-horizon MaGC forecast
+	HorizonLong  = "long"  // The long-horizon cadence forecast
+)
+
+// CalendarSlot is a span of time during which the same set of servers is paused
+type CalendarSlot struct {
+	Start          time.Time `json:"start"`
+	End            time.Time `json:"end"`
+	ServerIDs      []int     `json:"server_ids"`
+	PausedFraction float64   `json:"paused_fraction"` // Share of the fleet paused
+}
+
+// ForecastCalendar is the fleet-wide timeline of predicted MaGC pauses
+type ForecastCalendar struct {
+	GeneratedAt  time.Time      `json:"generated_at"`
+	HorizonEnd   time.Time      `json:"horizon_end"`
+	TotalServers int            `json:"total_servers"`
+	Windows      []PauseWindow  `json:"windows"`
+	Timeline     []CalendarSlot `json:"timeline"`
+}
+
+// expectedPauseLocked estimates the next MaGC duration from past pauses; callers hold s.mu
+func (s *Server) expectedPauseLocked() time.Duration {
+	if len(s.GCEvents) > 0 {
+		total := int64(0)
+		for _, event := range s.GCEvents {
+			total += event.DurationMs
+		}
+		return time.Duration(total/int64(len(s.GCEvents))) * time.Millisecond
+	}
+	if s.MaGCDuration > 0 {
+		return time.Duration(s.MaGCDuration) * time.Millisecond
+	}
+	return defaultPauseEstimate
+}
+
+// pauseWindows returns the server's pauses overlapping [now, horizonEnd]: an
+// in-progress or short-horizon forecast MaGC first, then the long-horizon
+// forecast's MaGCs that come after it
+func (s *Server) pauseWindows(now, horizonEnd time.Time) []PauseWindow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expected := s.expectedPauseLocked()
+	windows := make([]PauseWindow, 0)
+
+	// Long-horizon MaGCs closer than half an interval to this are the same collection
+	var covered time.Time
+	forecast := s.LastMaGCForecast
+	switch {
+	case s.isCollectingGCTasks:
+		windows = append(windows, PauseWindow{
+			ServerID:   s.ID,
+			Start:      s.gcStartedAt,
+			End:        s.gcStartedAt.Add(expected),
+			Confidence: 1.0,
+			InProgress: true,
+			Horizon:    HorizonShort,
+		})
+		covered = s.gcStartedAt
+	case forecast != nil && now.Sub(forecast.ForecastCreatedAt) <= forecastValidity:
+		window := PauseWindow{
+			ServerID:   s.ID,
+			Start:      forecast.PredictedTime,
+			End:        forecast.PredictedTime.Add(expected),
+			Confidence: forecast.Confidence,
+			Horizon:    HorizonShort,
+		}
+		if !window.End.Before(now) && !window.Start.After(horizonEnd) {
+			windows = append(windows, window)
+			covered = forecast.PredictedTime
+		}
+	}
+
+	long := s.longForecastLocked()
+	if long == nil {
+		return windows
+	}
+	interval := time.Duration(long.IntervalMs) * time.Millisecond
+	for _, start := range long.PredictedTimes {
+		if !covered.IsZero() && start.Before(covered.Add(interval/2)) {
+			continue
+		}
+		window := PauseWindow{
+			ServerID:   s.ID,
+			Start:      start,
+			End:        start.Add(expected),
+			Confidence: long.Confidence,
+			Horizon:    HorizonLong,
+		}
+		if window.End.Before(now) || window.Start.After(horizonEnd) {
+			continue
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// ForecastCalendar merges the predicted MaGC windows of all servers over the
+// given horizon into a timeline of which servers are paused when
+func (l *LoadBalancer) ForecastCalendar(horizon time.Duration) ForecastCalendar {
+	now := l.now()
+	servers := l.ListServers()
+
+	calendar := ForecastCalendar{
+		GeneratedAt:  now,
+		HorizonEnd:   now.Add(horizon),
+		TotalServers: len(servers),
+		Windows:      make([]PauseWindow, 0),
+		Timeline:     make([]CalendarSlot, 0),
+	}
+
+	for _, server := range servers {
+		calendar.Windows = append(calendar.Windows, server.pauseWindows(now, calendar.HorizonEnd)...)
+	}
+	sort.Slice(calendar.Windows, func(i, j int) bool {
+		return calendar.Windows[i].Start.Before(calendar.Windows[j].Start)
+	})
+
+	calendar.Timeline = mergePauseWindows(calendar.Windows, now, calendar.HorizonEnd, len(servers))
+	return calendar
+}
+
+// mergePauseWindows sweeps the window boundaries within [from, to] and emits a
+// slot for every span where at least one server is paused
+func mergePauseWindows(windows []PauseWindow, from, to time.Time, totalServers int) []CalendarSlot {
+	boundaries := []time.Time{from, to}
+	for _, window := range windows {
+		if window.Start.After(from) && window.Start.Before(to) {
+			boundaries = append(boundaries, window.Start)
+		}
+		if window.End.After(from) && window.End.Before(to) {
+			boundaries = append(boundaries, window.End)
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	slots := make([]CalendarSlot, 0)
+	for i := 0; i+1 < len(boundaries); i++ {
+		start, end := boundaries[i], boundaries[i+1]
+		if !end.After(start) {
+			continue
+		}
+
+		ids := make([]int, 0)
+		for _, window := range windows {
+			if window.Start.Before(end) && window.End.After(start) {
+				ids = append(ids, window.ServerID)
+			}
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		sort.Ints(ids)
+
+		// Extend the previous slot when the paused set is unchanged
+		if n := len(slots); n > 0 && slots[n-1].End.Equal(start) && equalIDs(slots[n-1].ServerIDs, ids) {
+			slots[n-1].End = end
+			continue
+		}
+
+		slot := CalendarSlot{Start: start, End: end, ServerIDs: ids}
+		if totalServers > 0 {
+			slot.PausedFraction = float64(len(ids)) / float64(totalServers)
+		}
+		slots = append(slots, slot)
+	}
+
+	return slots
+}
+
+// equalIDs reports whether two sorted ID lists are identical
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
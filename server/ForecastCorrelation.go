@@ -0,0 +1,195 @@
+package server
+
+import (
+	"sort"
+	"time"
+)
+
+// FlagForecastDesync runs a MaGC early when several servers are forecast to
+// pause at the same time, so the fleet does not lose capacity all at once
+const FlagForecastDesync = "forecast_desync"
+
+const (
+	// desyncLeadTime is how soon a correlated cluster must start to be desynchronized
+	desyncLeadTime = 15 * time.Second
+
+	// maxDesyncActions is how many desynchronizations the correlation report keeps
+	maxDesyncActions = 50
+)
+
+// CorrelatedCluster is a set of servers whose forecast MaGC pauses overlap
+type CorrelatedCluster struct {
+	Start         time.Time     `json:"start"`
+	End           time.Time     `json:"end"`
+	ServerIDs     []int         `json:"server_ids"`
+	FleetFraction float64       `json:"fleet_fraction"` // Share of the fleet paused together
+	Windows       []PauseWindow `json:"windows"`
+}
+
+// Desynchronization is one MaGC run early to break up a correlated cluster
+type Desynchronization struct {
+	Timestamp     time.Time `json:"timestamp"`
+	ServerID      int       `json:"server_id"`
+	ClusterIDs    []int     `json:"cluster_ids"`
+	PredictedTime time.Time `json:"predicted_time"` // Forecast MaGC that was run early
+	LeadMs        int64     `json:"lead_ms"`        // How far ahead of the forecast it ran
+}
+
+// ForecastCorrelation reports servers whose MaGC forecasts converge on the
+// same time window and the desynchronizations made to spread them out
+type ForecastCorrelation struct {
+	GeneratedAt       time.Time           `json:"generated_at"`
+	TotalServers      int                 `json:"total_servers"`
+	Forecasting       int                 `json:"forecasting"` // Servers with a pending or in-progress MaGC
+	CorrelatedServers int                 `json:"correlated_servers"`
+	Clusters          []CorrelatedCluster `json:"clusters"`
+	Desynchronizing   bool                `json:"desynchronizing"` // FlagForecastDesync is on
+	RecentActions     []Desynchronization `json:"recent_actions"`
+}
+
+// ForecastCorrelation groups the fleet's overlapping short-horizon MaGC
+// forecasts into correlated clusters
+func (l *LoadBalancer) ForecastCorrelation() ForecastCorrelation {
+	now := l.now()
+	servers := l.ListServers()
+	windows := l.shortPauseWindows(servers, now)
+	clusters := correlatePauseWindows(windows, len(servers))
+
+	report := ForecastCorrelation{
+		GeneratedAt:     now,
+		TotalServers:    len(servers),
+		Forecasting:     len(windows),
+		Clusters:        clusters,
+		Desynchronizing: l.Flags.Enabled(FlagForecastDesync),
+	}
+	for _, cluster := range clusters {
+		report.CorrelatedServers += len(cluster.ServerIDs)
+	}
+
+	l.desyncMu.Lock()
+	report.RecentActions = append([]Desynchronization{}, l.desyncActions...)
+	l.desyncMu.Unlock()
+	return report
+}
+
+// shortPauseWindows returns every server's in-progress or short-horizon
+// forecast MaGC, ordered by start
+func (l *LoadBalancer) shortPauseWindows(servers []*Server, now time.Time) []PauseWindow {
+	windows := make([]PauseWindow, 0, len(servers))
+	for _, server := range servers {
+		for _, window := range server.pauseWindows(now, now.Add(forecastValidity)) {
+			if window.Horizon == HorizonShort {
+				windows = append(windows, window)
+			}
+		}
+	}
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+	return windows
+}
+
+// correlatePauseWindows chains windows sorted by start into clusters while
+// each one starts before the cluster ends; only clusters of two or more
+// servers are correlated
+func correlatePauseWindows(windows []PauseWindow, totalServers int) []CorrelatedCluster {
+	clusters := make([]CorrelatedCluster, 0)
+	var current *CorrelatedCluster
+
+	flush := func() {
+		if current != nil && len(current.ServerIDs) > 1 {
+			if totalServers > 0 {
+				current.FleetFraction = float64(len(current.ServerIDs)) / float64(totalServers)
+			}
+			clusters = append(clusters, *current)
+		}
+		current = nil
+	}
+
+	for _, window := range windows {
+		if current != nil && window.Start.Before(current.End) {
+			current.ServerIDs = append(current.ServerIDs, window.ServerID)
+			current.Windows = append(current.Windows, window)
+			if window.End.After(current.End) {
+				current.End = window.End
+			}
+			continue
+		}
+		flush()
+		current = &CorrelatedCluster{
+			Start:     window.Start,
+			End:       window.End,
+			ServerIDs: []int{window.ServerID},
+			Windows:   []PauseWindow{window},
+		}
+	}
+	flush()
+	return clusters
+}
+
+// desyncForecasts staggers each correlated cluster that is about to start by
+// running one member's MaGC now. The member must be idle and its pause must
+// finish before the next member's forecast MaGC; clusters with a MaGC already
+// in progress are left alone until it finishes.
+func (l *LoadBalancer) desyncForecasts() {
+	if !l.Flags.Enabled(FlagForecastDesync) {
+		return
+	}
+
+	now := l.now()
+	servers := l.ListServers()
+	for _, cluster := range correlatePauseWindows(l.shortPauseWindows(servers, now), len(servers)) {
+		if cluster.Start.Sub(now) > desyncLeadTime || clusterInProgress(cluster) {
+			continue
+		}
+
+		for i, window := range cluster.Windows[:len(cluster.Windows)-1] {
+			server := l.GetServerByID(window.ServerID)
+			if server == nil || !server.shouldPrefetchGC(desyncLeadTime) {
+				continue
+			}
+			pause := window.End.Sub(window.Start)
+			if now.Add(pause).After(cluster.Windows[i+1].Start) {
+				continue
+			}
+
+			l.runDesync(server, cluster, window, now)
+			break
+		}
+	}
+}
+
+// clusterInProgress reports whether any member of the cluster is already pausing
+func clusterInProgress(cluster CorrelatedCluster) bool {
+	for _, window := range cluster.Windows {
+		if window.InProgress {
+			return true
+		}
+	}
+	return false
+}
+
+// runDesync starts the server's MaGC ahead of its forecast and records why
+func (l *LoadBalancer) runDesync(server *Server, cluster CorrelatedCluster, window PauseWindow, now time.Time) {
+	action := Desynchronization{
+		Timestamp:     now,
+		ServerID:      server.ID,
+		ClusterIDs:    cluster.ServerIDs,
+		PredictedTime: window.Start,
+		LeadMs:        window.Start.Sub(now).Milliseconds(),
+	}
+
+	l.desyncMu.Lock()
+	l.desyncActions = append(l.desyncActions, action)
+	if len(l.desyncActions) > maxDesyncActions {
+		l.desyncActions = l.desyncActions[len(l.desyncActions)-maxDesyncActions:]
+	}
+	l.desyncMu.Unlock()
+
+	l.logf("🔀 Server %d: MaGC forecast overlaps servers %v, collecting %dms early", server.ID, cluster.ServerIDs, action.LeadMs)
+	l.RecordEvent("forecast_desync", server.ID, "MaGC run %dms early to stagger correlated servers %v", action.LeadMs, cluster.ServerIDs)
+	server.mu.Lock()
+	server.recordExecutionLocked(ExecutionTotals{GCDesyncs: 1})
+	server.mu.Unlock()
+	l.spawn(server.CollectGCTasks)
+}
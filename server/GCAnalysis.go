@@ -0,0 +1,243 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxGCEvents bounds the per-server GC event and forecast outcome logs
+const maxGCEvents = 100
+
+// GCEvent records one completed MaGC on a server
+type GCEvent struct {
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	DurationMs int64     `json:"duration_ms"`
+}
+
+// ForecastOutcome compares a MaGC forecast against what actually happened
+type ForecastOutcome struct {
+	ForecastCreatedAt time.Time `json:"forecast_created_at"`
+	PredictedTime     time.Time `json:"predicted_time"`
+	ActualTime        time.Time `json:"actual_time"` // Zero if no MaGC occurred
+	Confidence        float64   `json:"confidence"`
+	RawConfidence     float64   `json:"raw_confidence"`
+	ErrorMs           int64     `json:"error_ms"` // Actual minus predicted; negative means early
+	Accurate          bool      `json:"accurate"`
+}
+
+// ServerGCSummary summarizes GC behavior and forecast quality over a window
+type ServerGCSummary struct {
+	ServerID           int       `json:"server_id"`
+	WindowStart        time.Time `json:"window_start"`
+	WindowEnd          time.Time `json:"window_end"`
+	CurrentFamily      string    `json:"current_family"`
+	SnapshotCount      int       `json:"snapshot_count"`
+	GCCount            int       `json:"gc_count"`
+	GCPerMinute        float64   `json:"gc_per_minute"`
+	AvgPauseMs         float64   `json:"avg_pause_ms"`
+	MaxPauseMs         int64     `json:"max_pause_ms"`
+	TotalPauseMs       int64     `json:"total_pause_ms"`
+	ForecastsEvaluated int       `json:"forecasts_evaluated"`
+	ForecastsAccurate  int       `json:"forecasts_accurate"`
+	ForecastAccuracy   float64   `json:"forecast_accuracy"`
+	MeanAbsErrorMs     float64   `json:"mean_abs_error_ms"`
+}
+
+// forecastTolerance is how far an actual MaGC may be from the predicted time
+// for the forecast to count as accurate: 25% of the horizon, at least 1s
+func forecastTolerance(forecast *MaGCForecast) time.Duration {
+	tolerance := forecast.PredictedTime.Sub(forecast.ForecastCreatedAt) / 4
+	if tolerance < time.Second {
+		tolerance = time.Second
+	}
+	return tolerance
+}
+
+// recordGCEventLocked logs a completed MaGC, records the training sequence
+// leading up to it and scores the pending forecast; callers hold s.mu
+func (s *Server) recordGCEventLocked(start, end time.Time) {
+	s.recordTrainingSequenceLocked(start, end)
+	s.GCEvents = append(s.GCEvents, GCEvent{
+		StartTime:  start,
+		EndTime:    end,
+		DurationMs: end.Sub(start).Milliseconds(),
+	})
+	if len(s.GCEvents) > maxGCEvents {
+		s.GCEvents = s.GCEvents[len(s.GCEvents)-maxGCEvents:]
+	}
+
+	forecast := s.LastMaGCForecast
+	if forecast == nil || !forecast.ForecastCreatedAt.After(s.lastScoredForecast) || forecast.ForecastCreatedAt.After(start) {
+		return
+	}
+
+	errorMs := start.Sub(forecast.PredictedTime)
+	absError := errorMs
+	if absError < 0 {
+		absError = -absError
+	}
+	s.appendForecastOutcomeLocked(ForecastOutcome{
+		ForecastCreatedAt: forecast.ForecastCreatedAt,
+		PredictedTime:     forecast.PredictedTime,
+		ActualTime:        start,
+		Confidence:        forecast.Confidence,
+		RawConfidence:     forecast.RawConfidence,
+		ErrorMs:           errorMs.Milliseconds(),
+		Accurate:          absError <= forecastTolerance(forecast),
+	})
+}
+
+// scoreExpiredForecastLocked records a miss when the pending forecast's window
+// passed without any MaGC; callers hold s.mu
+func (s *Server) scoreExpiredForecastLocked(now time.Time) {
+	forecast := s.LastMaGCForecast
+	if forecast == nil || !forecast.ForecastCreatedAt.After(s.lastScoredForecast) {
+		return
+	}
+	if now.Before(forecast.PredictedTime.Add(forecastTolerance(forecast))) {
+		return
+	}
+
+	s.appendForecastOutcomeLocked(ForecastOutcome{
+		ForecastCreatedAt: forecast.ForecastCreatedAt,
+		PredictedTime:     forecast.PredictedTime,
+		Confidence:        forecast.Confidence,
+		RawConfidence:     forecast.RawConfidence,
+		ErrorMs:           now.Sub(forecast.PredictedTime).Milliseconds(),
+		Accurate:          false,
+	})
+}
+
+// appendForecastOutcomeLocked stores a scored forecast; callers hold s.mu
+func (s *Server) appendForecastOutcomeLocked(outcome ForecastOutcome) {
+	s.lastScoredForecast = outcome.ForecastCreatedAt
+	s.ForecastOutcomes = append(s.ForecastOutcomes, outcome)
+	if len(s.ForecastOutcomes) > maxGCEvents {
+		s.ForecastOutcomes = s.ForecastOutcomes[len(s.ForecastOutcomes)-maxGCEvents:]
+	}
+}
+
+// GCSummary summarizes GC frequency, pauses and forecast accuracy over the trailing window
+func (s *Server) GCSummary(window time.Duration) ServerGCSummary {
+	end := s.now()
+	return s.gcSummary(end.Add(-window), end)
+}
+
+// gcSummary summarizes the server's GC activity between start and end
+func (s *Server) gcSummary(start, end time.Time) ServerGCSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := ServerGCSummary{
+		ServerID:    s.ID,
+		WindowStart: start,
+		WindowEnd:   end,
+	}
+	if s.CurrentFamily != nil {
+		summary.CurrentFamily = s.CurrentFamily.ID
+	}
+
+	for _, snapshot := range s.GCHistory {
+		if !snapshot.Timestamp.Before(start) && !snapshot.Timestamp.After(end) {
+			summary.SnapshotCount++
+		}
+	}
+
+	for _, event := range s.GCEvents {
+		if event.StartTime.Before(start) || event.StartTime.After(end) {
+			continue
+		}
+		summary.GCCount++
+		summary.TotalPauseMs += event.DurationMs
+		if event.DurationMs > summary.MaxPauseMs {
+			summary.MaxPauseMs = event.DurationMs
+		}
+	}
+	if summary.GCCount > 0 {
+		summary.AvgPauseMs = float64(summary.TotalPauseMs) / float64(summary.GCCount)
+	}
+	if window := end.Sub(start); window > 0 {
+		summary.GCPerMinute = float64(summary.GCCount) / window.Minutes()
+	}
+
+	totalAbsError := int64(0)
+	for _, outcome := range s.ForecastOutcomes {
+		if outcome.ForecastCreatedAt.Before(start) || outcome.ForecastCreatedAt.After(end) {
+			continue
+		}
+		summary.ForecastsEvaluated++
+		if outcome.Accurate {
+			summary.ForecastsAccurate++
+		}
+		if outcome.ErrorMs < 0 {
+			totalAbsError -= outcome.ErrorMs
+		} else {
+			totalAbsError += outcome.ErrorMs
+		}
+	}
+	if summary.ForecastsEvaluated > 0 {
+		summary.ForecastAccuracy = float64(summary.ForecastsAccurate) / float64(summary.ForecastsEvaluated)
+		summary.MeanAbsErrorMs = float64(totalAbsError) / float64(summary.ForecastsEvaluated)
+	}
+
+	return summary
+}
+
+// CompareServers returns GC summaries for the given servers over the same
+// trailing window. An empty ID list compares the whole fleet.
+func (l *LoadBalancer) CompareServers(ids []int, window time.Duration) ([]ServerGCSummary, error) {
+	servers := l.ListServers()
+	if len(ids) > 0 {
+		servers = make([]*Server, 0, len(ids))
+		for _, id := range ids {
+			server := l.GetServerByID(id)
+			if server == nil {
+				return nil, fmt.Errorf("server %d not found", id)
+			}
+			servers = append(servers, server)
+		}
+	}
+
+	// Every summary covers exactly the same window so they line up
+	end := l.now()
+	start := end.Add(-window)
+
+	summaries := make([]ServerGCSummary, 0, len(servers))
+	for _, server := range servers {
+		summaries = append(summaries, server.gcSummary(start, end))
+	}
+
+	return summaries, nil
+}
+
+// pausedBetween reports whether a MaGC on the server overlapped [start, end]
+func (s *Server) pausedBetween(start, end time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.isCollectingGCTasks && s.gcStartedAt.Before(end) {
+		return true
+	}
+	for i := len(s.GCEvents) - 1; i >= 0; i-- {
+		event := s.GCEvents[i]
+		if event.EndTime.Before(start) {
+			break
+		}
+		if event.StartTime.Before(end) {
+			return true
+		}
+	}
+	return false
+}
+
+// PausedServersBetween returns the IDs of servers whose MaGC overlapped [start, end]
+func (l *LoadBalancer) PausedServersBetween(start, end time.Time) []int {
+	ids := make([]int, 0)
+	for _, server := range l.ListServers() {
+		if server.pausedBetween(start, end) {
+			ids = append(ids, server.ID)
+		}
+	}
+	return ids
+}
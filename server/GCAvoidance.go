@@ -0,0 +1,80 @@
+package server
+
+// GC avoidance modes decide what happens to servers with a MaGC predicted
+// within the policy threshold
+const (
+	AvoidanceHard = "hard" // Skip them (default)
+	AvoidanceSoft = "soft" // Penalize them by forecast confidence and imminence
+)
+
+// penaltyScale is the resolution of the soft avoidance draw
+const penaltyScale = 1000
+
+// IsValidGCAvoidance reports whether name is a supported avoidance mode; empty means AvoidanceHard
+func IsValidGCAvoidance(name string) bool {
+	return name == "" || name == AvoidanceHard || name == AvoidanceSoft
+}
+
+// gcPenalty scores a MaGC predicted within thresholdMs from 0 (none, or at
+// the edge of the window) to 1 (certain and due now): forecast confidence
+// times how far into the window the MaGC falls.
+func (s *Server) gcPenalty(thresholdMs int64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timeToMaGC, ok := s.timeToMaGCLocked()
+	if !ok || timeToMaGC.Milliseconds() > thresholdMs {
+		return 0
+	}
+	imminence := 1.0
+	if thresholdMs > 0 {
+		imminence = 1 - float64(timeToMaGC.Milliseconds())/float64(thresholdMs)
+	}
+	return min(max(s.LastMaGCForecast.Confidence*imminence, 0), 1)
+}
+
+// ForecastSuppressed reports whether the server's current MaGC forecast is
+// less confident than floor and so is ignored for GC avoidance
+func (s *Server) ForecastSuppressed(floor float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.forecastSuppressedLocked(floor)
+}
+
+// forecastSuppressedLocked implements ForecastSuppressed; callers hold s.mu
+func (s *Server) forecastSuppressedLocked(floor float64) bool {
+	if _, ok := s.timeToMaGCLocked(); !ok {
+		return false
+	}
+	return s.LastMaGCForecast.Confidence < floor
+}
+
+// avoidsGC reports whether selection should pass over server because of a
+// predicted MaGC, unless its forecast is below the policy's confidence floor.
+// Hard avoidance always does. Soft avoidance does so with a
+// probability equal to the server's penalty, so predicted servers keep a
+// share of traffic instead of starving a small fleet.
+func (l *LoadBalancer) avoidsGC(server *Server, policy LoadBalancingPolicy, explain *SelectionExplanation) bool {
+	if server.ForecastSuppressed(policy.ConfidenceFloor) {
+		explain.suppress(server)
+		return false
+	}
+	if policy.GCAvoidance != AvoidanceSoft {
+		if server.IsMaGCPredicted(policy.MaGCThreshold) {
+			l.gcAvoided.Add(1)
+			return true
+		}
+		return false
+	}
+
+	penalty := server.gcPenalty(policy.MaGCThreshold)
+	if penalty <= 0 {
+		return false
+	}
+	if explain.intn(penaltyScale) < int(penalty*penaltyScale) {
+		l.gcAvoided.Add(1)
+		return true
+	}
+	l.logf("Server %d kept despite predicted MaGC (penalty %.2f)", server.ID, penalty)
+	return false
+}
@@ -1,92 +1,124 @@
 package server
 
-import (
-	"fmt"
-	"math/rand"
-)
+import "fmt"
 
 // GC-Aware Round Robin (GC-RR)
 func (l *LoadBalancer) GetServerGCRoundRobin(taskInput string) *Server {
+	return l.gcRoundRobin(taskInput, l.GetLoadBalancingPolicy(), nil)
+}
+
+// gcRoundRobin implements GetServerGCRoundRobin for an explicit policy
+func (l *LoadBalancer) gcRoundRobin(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
+	threshold := policy.MaGCThreshold
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	if l.TRINI == nil || !l.TRINI.IsActive {
-		return l.GetServerForTask(taskInput) // Fallback to regular algorithm
+		explain.fallback("TRINI inactive, used round-robin")
+		return l.getServerRoundRobinLocked(taskInput, explain) // Fallback to regular algorithm
 	}
 
 	startIndex := l.currentServerIndex
+	explain.roundRobinFrom(startIndex)
 	fTries := 0
+	var candidates []*Server
+	var candidateIndexes []int
 
 	for fTries < len(l.Servers) {
 		serverIndex := (startIndex + fTries) % len(l.Servers)
 		server := l.Servers[serverIndex]
 
 		// Check basic availability and memory capacity
-		if !server.IsAvailable() || !server.CanHandleTaskSize(len(taskInput)) {
+		if reason := l.ineligibleReason(server, taskInput, explain); reason != "" {
+			explain.skip(server, reason)
 			fTries++
 			continue
 		}
 
 		// GC-aware check: skip if MaGC predicted within threshold
-		threshold := l.getCurrentMaGCThreshold()
-		if server.IsMaGCPredicted(threshold) {
-			fmt.Printf("Server %d skipped: MaGC predicted within %dms\n", server.ID, threshold)
+		if l.avoidsGC(server, policy, explain) {
+			l.logf("Server %d skipped: MaGC predicted within %dms", server.ID, threshold)
+			explain.skip(server, SkipMaGCPredicted)
 			fTries++
 			continue
 		}
 
 		// Server is suitable
+		if breaksTies(policy.Tiebreaker) {
+			candidates = append(candidates, server)
+			candidateIndexes = append(candidateIndexes, serverIndex)
+			fTries++
+			continue
+		}
 		l.currentServerIndex = (serverIndex + 1) % len(l.Servers)
-		fmt.Printf("Server %d selected (GC-RR)\n", server.ID)
+		l.logf("Server %d selected (GC-RR)", server.ID)
 		return server
 	}
 
+	if len(candidates) > 0 {
+		best := breakTie(candidates, threshold, policy.Tiebreaker)
+		l.currentServerIndex = (candidateIndexes[best] + 1) % len(l.Servers)
+		l.logf("Server %d selected (GC-RR, %s tiebreak)", candidates[best].ID, policy.Tiebreaker)
+		return candidates[best]
+	}
+
 	// Escape condition: all servers have predicted MaGC, fallback to regular RR
-	fmt.Println("All servers have predicted MaGC, using regular round-robin")
-	return l.GetServerForTask(taskInput)
+	l.logf("All servers have predicted MaGC, using regular round-robin")
+	explain.fallback("No GC-safe server, used round-robin")
+	return l.getServerRoundRobinLocked(taskInput, explain)
 }
 
 // GC-Aware Random (GC-RAN)
 func (l *LoadBalancer) GetServerGCRandom(taskInput string) *Server {
+	return l.gcRandom(taskInput, l.GetLoadBalancingPolicy(), nil)
+}
+
+// gcRandom implements GetServerGCRandom for an explicit policy
+func (l *LoadBalancer) gcRandom(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
+	threshold := policy.MaGCThreshold
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	if l.TRINI == nil || !l.TRINI.IsActive {
-		return l.GetServerForTask(taskInput) // Fallback to regular algorithm
+		explain.fallback("TRINI inactive, used round-robin")
+		return l.getServerRoundRobinLocked(taskInput, explain) // Fallback to regular algorithm
 	}
 
 	availableServers := make([]*Server, 0)
+	eligibleServers := make([]*Server, 0) // Including those with a predicted MaGC
 
 	// First, collect all available servers without predicted MaGC
-	threshold := l.getCurrentMaGCThreshold()
 	for _, server := range l.Servers {
-		if server.IsAvailable() && server.CanHandleTaskSize(len(taskInput)) {
-			if !server.IsMaGCPredicted(threshold) {
-				availableServers = append(availableServers, server)
-			} else {
-				fmt.Printf("Server %d skipped: MaGC predicted within %dms\n", server.ID, threshold)
-			}
+		if reason := l.ineligibleReason(server, taskInput, explain); reason != "" {
+			explain.skip(server, reason)
+			continue
+		}
+		eligibleServers = append(eligibleServers, server)
+		if !l.avoidsGC(server, policy, explain) {
+			availableServers = append(availableServers, server)
+		} else {
+			l.logf("Server %d skipped: MaGC predicted within %dms", server.ID, threshold)
+			explain.skip(server, SkipMaGCPredicted)
 		}
 	}
 
-	// If we have GC-safe servers, pick randomly
+	// If we have GC-safe servers, pick the best outlook or randomly
+	if len(availableServers) > 1 && breaksTies(policy.Tiebreaker) {
+		selectedServer := availableServers[breakTie(availableServers, threshold, policy.Tiebreaker)]
+		l.logf("Server %d selected (GC-RAN, %s tiebreak)", selectedServer.ID, policy.Tiebreaker)
+		return selectedServer
+	}
 	if len(availableServers) > 0 {
-		selectedServer := availableServers[rand.Intn(len(availableServers))]
-		fmt.Printf("Server %d selected (GC-RAN)\n", selectedServer.ID)
+		selectedServer := availableServers[explain.intn(len(availableServers))]
+		l.logf("Server %d selected (GC-RAN)", selectedServer.ID)
 		return selectedServer
 	}
 
 	// Escape condition: all servers have predicted MaGC, use regular random
-	fmt.Println("All servers have predicted MaGC, using regular random")
-	availableServers = make([]*Server, 0)
-	for _, server := range l.Servers {
-		if server.IsAvailable() && server.CanHandleTaskSize(len(taskInput)) {
-			availableServers = append(availableServers, server)
-		}
-	}
-
-	if len(availableServers) > 0 {
-		return availableServers[rand.Intn(len(availableServers))]
+	l.logf("All servers have predicted MaGC, using regular random")
+	explain.fallback("No GC-safe server, used random")
+	if len(eligibleServers) > 0 {
+		return eligibleServers[explain.intn(len(eligibleServers))]
 	}
 
 	return nil
@@ -94,11 +126,18 @@ func (l *LoadBalancer) GetServerGCRandom(taskInput string) *Server {
 
 // GC-Aware Weighted Round Robin (GC-WRR)
 func (l *LoadBalancer) GetServerGCWeightedRoundRobin(taskInput string) *Server {
+	return l.gcWeightedRoundRobin(taskInput, l.GetLoadBalancingPolicy(), nil)
+}
+
+// gcWeightedRoundRobin implements GetServerGCWeightedRoundRobin for an explicit policy
+func (l *LoadBalancer) gcWeightedRoundRobin(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
+	threshold := policy.MaGCThreshold
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	if l.TRINI == nil || !l.TRINI.IsActive {
-		return l.GetServerForTask(taskInput) // Fallback to regular algorithm
+		explain.fallback("TRINI inactive, used round-robin")
+		return l.getServerRoundRobinLocked(taskInput, explain) // Fallback to regular algorithm
 	}
 
 	// Check if all runtime weights are zero, reset if needed
@@ -117,7 +156,6 @@ func (l *LoadBalancer) GetServerGCWeightedRoundRobin(taskInput string) *Server {
 	i := 0
 	fTries := 0
 	found := false
-	threshold := l.getCurrentMaGCThreshold()
 
 	for !found && fTries < len(l.Servers) {
 		if i >= len(l.Servers) {
@@ -131,7 +169,8 @@ func (l *LoadBalancer) GetServerGCWeightedRoundRobin(taskInput string) *Server {
 			found = true
 
 			// Check availability and memory
-			if !server.IsAvailable() || !server.CanHandleTaskSize(len(taskInput)) {
+			if reason := l.ineligibleReason(server, taskInput, explain); reason != "" {
+				explain.skip(server, reason)
 				found = false
 				server.incrementRuntimeWeight()
 				i++
@@ -140,8 +179,9 @@ func (l *LoadBalancer) GetServerGCWeightedRoundRobin(taskInput string) *Server {
 			}
 
 			// GC-aware check
-			if server.IsMaGCPredicted(threshold) {
-				fmt.Printf("Server %d skipped: MaGC predicted within %dms\n", server.ID, threshold)
+			if l.avoidsGC(server, policy, explain) {
+				l.logf("Server %d skipped: MaGC predicted within %dms", server.ID, threshold)
+				explain.skip(server, SkipMaGCPredicted)
 				found = false
 				server.incrementRuntimeWeight()
 				i++
@@ -149,7 +189,7 @@ func (l *LoadBalancer) GetServerGCWeightedRoundRobin(taskInput string) *Server {
 				continue
 			}
 
-			fmt.Printf("Server %d selected (GC-WRR)\n", server.ID)
+			l.logf("Server %d selected (GC-WRR)", server.ID)
 			return server
 		} else {
 			i++
@@ -157,46 +197,55 @@ func (l *LoadBalancer) GetServerGCWeightedRoundRobin(taskInput string) *Server {
 	}
 
 	// Escape condition: fallback to regular weighted round robin
-	fmt.Println("All servers have predicted MaGC, using regular weighted round-robin")
-	return l.GetServerForTask(taskInput)
+	l.logf("All servers have predicted MaGC, using regular weighted round-robin")
+	explain.fallback("No GC-safe server, used round-robin")
+	return l.getServerRoundRobinLocked(taskInput, explain)
 }
 
 // GC-Aware Weighted Random (GC-WRAN)
 func (l *LoadBalancer) GetServerGCWeightedRandom(taskInput string) *Server {
+	return l.gcWeightedRandom(taskInput, l.GetLoadBalancingPolicy(), nil)
+}
+
+// gcWeightedRandom implements GetServerGCWeightedRandom for an explicit policy
+func (l *LoadBalancer) gcWeightedRandom(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
+	threshold := policy.MaGCThreshold
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
 	if l.TRINI == nil || !l.TRINI.IsActive {
-		return l.GetServerForTask(taskInput) // Fallback to regular algorithm
+		explain.fallback("TRINI inactive, used round-robin")
+		return l.getServerRoundRobinLocked(taskInput, explain) // Fallback to regular algorithm
 	}
 
-	threshold := l.getCurrentMaGCThreshold()
-
 	// Calculate total weight of available servers without predicted MaGC
 	totalWeight := 0
 	availableServers := make([]*Server, 0)
+	eligibleServers := make([]*Server, 0) // Including those with a predicted MaGC
 
 	for _, server := range l.Servers {
-		if server.IsAvailable() && server.CanHandleTaskSize(len(taskInput)) {
-			if !server.IsMaGCPredicted(threshold) {
-				availableServers = append(availableServers, server)
-				totalWeight += server.Weights
-			} else {
-				fmt.Printf("Server %d skipped: MaGC predicted within %dms\n", server.ID, threshold)
-			}
+		if reason := l.ineligibleReason(server, taskInput, explain); reason != "" {
+			explain.skip(server, reason)
+			continue
+		}
+		eligibleServers = append(eligibleServers, server)
+		if !l.avoidsGC(server, policy, explain) {
+			availableServers = append(availableServers, server)
+			totalWeight += server.GetWeight()
+		} else {
+			l.logf("Server %d skipped: MaGC predicted within %dms", server.ID, threshold)
+			explain.skip(server, SkipMaGCPredicted)
 		}
 	}
 
 	if totalWeight == 0 || len(availableServers) == 0 {
 		// Escape condition: fallback to regular weighted random
-		fmt.Println("All servers have predicted MaGC, using regular weighted random")
+		l.logf("All servers have predicted MaGC, using regular weighted random")
+		explain.fallback("No GC-safe server, used weighted random")
 		totalWeight = 0
-		availableServers = make([]*Server, 0)
-		for _, server := range l.Servers {
-			if server.IsAvailable() && server.CanHandleTaskSize(len(taskInput)) {
-				availableServers = append(availableServers, server)
-				totalWeight += server.Weights
-			}
+		availableServers = eligibleServers
+		for _, server := range availableServers {
+			totalWeight += server.GetWeight()
 		}
 
 		if totalWeight == 0 || len(availableServers) == 0 {
@@ -205,13 +254,13 @@ func (l *LoadBalancer) GetServerGCWeightedRandom(taskInput string) *Server {
 	}
 
 	// Weighted random selection
-	randomWeight := rand.Intn(totalWeight)
+	randomWeight := explain.intn(totalWeight)
 	currentWeight := 0
 
 	for _, server := range availableServers {
-		currentWeight += server.Weights
+		currentWeight += server.GetWeight()
 		if randomWeight < currentWeight {
-			fmt.Printf("Server %d selected (GC-WRAN)\n", server.ID)
+			l.logf("Server %d selected (GC-WRAN)", server.ID)
 			return server
 		}
 	}
@@ -226,24 +275,32 @@ func (l *LoadBalancer) GetServerGCWeightedRandom(taskInput string) *Server {
 
 // GetServerGCAware is the main entry point for GC-aware load balancing
 func (l *LoadBalancer) GetServerGCAware(taskInput string) *Server {
+	return l.selectGCAware(taskInput, l.GetLoadBalancingPolicy(), nil)
+}
+
+// selectGCAware runs the GC-aware algorithm configured by the given policy
+func (l *LoadBalancer) selectGCAware(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
 	if l.TRINI == nil || !l.TRINI.IsActive {
-		return l.GetServerForTask(taskInput)
+		explain.fallback("TRINI inactive, used round-robin")
+		return l.getServerRoundRobin(taskInput, explain)
 	}
 
-	algorithm := l.CurrentPolicy.Algorithm
+	algorithm := policy.Algorithm
 
 	switch algorithm {
 	case "RR":
-		return l.GetServerGCRoundRobin(taskInput)
+		return l.gcRoundRobin(taskInput, policy, explain)
 	case "RAN":
-		return l.GetServerGCRandom(taskInput)
+		return l.gcRandom(taskInput, policy, explain)
 	case "WRR":
-		return l.GetServerGCWeightedRoundRobin(taskInput)
+		return l.gcWeightedRoundRobin(taskInput, policy, explain)
 	case "WRAN":
-		return l.GetServerGCWeightedRandom(taskInput)
+		return l.gcWeightedRandom(taskInput, policy, explain)
+	case AlgorithmCost:
+		return l.selectCheapest(taskInput, policy, explain)
 	default:
-		fmt.Printf("Unknown algorithm %s, using GC-RR\n", algorithm)
-		return l.GetServerGCRoundRobin(taskInput)
+		l.logf("Unknown algorithm %s, using GC-RR", algorithm)
+		return l.gcRoundRobin(taskInput, policy, explain)
 	}
 }
 
@@ -276,8 +333,8 @@ func (s *Server) resetWeight(originalWeight int) {
 
 func (l *LoadBalancer) resetRuntimeWeights() {
 	for _, server := range l.Servers {
-		// Reset to default weight or based on server capacity
-		server.resetWeight(1)
+		// Refill the round-robin budget from the capacity-derived weight
+		server.resetWeight(server.GetWeight())
 	}
 }
 
@@ -287,17 +344,54 @@ func (l *LoadBalancer) getCurrentMaGCThreshold() int64 {
 		return 2000 // Default 2 seconds
 	}
 
-	return l.CurrentPolicy.MaGCThreshold
+	return l.GetLoadBalancingPolicy().MaGCThreshold
 }
 
-// SetLoadBalancingPolicy updates the current load balancing policy
-func (l *LoadBalancer) SetLoadBalancingPolicy(policy LoadBalancingPolicy) {
+// GetLoadBalancingPolicy returns the policy currently in effect
+func (l *LoadBalancer) GetLoadBalancingPolicy() LoadBalancingPolicy {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	return l.CurrentPolicy
+}
 
+// SetLoadBalancingPolicy updates the current load balancing policy
+func (l *LoadBalancer) SetLoadBalancingPolicy(policy LoadBalancingPolicy) {
+	l.mu.Lock()
+	changed := l.CurrentPolicy != policy
 	l.CurrentPolicy = policy
-	fmt.Printf("Load balancing policy updated: %s (GC-aware: %t, threshold: %dms)\n",
+	l.recordPolicyChange(policy)
+	l.logf("Load balancing policy updated: %s (GC-aware: %t, threshold: %dms)",
 		policy.Algorithm, policy.GCAware, policy.MaGCThreshold)
+	l.mu.Unlock()
+
+	if changed && l.store != nil {
+		if err := l.store.SavePolicy(policy); err != nil {
+			l.logf("⚠️ Could not persist policy: %v", err)
+		}
+	}
+}
+
+// restorePolicy puts the last persisted policy back in effect, unless it
+// no longer validates
+func (l *LoadBalancer) restorePolicy() error {
+	if l.store == nil {
+		return nil
+	}
+
+	policy, err := l.store.LoadPolicy()
+	if err != nil || policy == nil {
+		return err
+	}
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("persisted policy is invalid: %w", err)
+	}
+
+	l.mu.Lock()
+	l.CurrentPolicy = *policy
+	l.mu.Unlock()
+	l.logf("💾 Restored load balancing policy: %s (GC-aware: %t, threshold: %dms)",
+		policy.Algorithm, policy.GCAware, policy.MaGCThreshold)
+	return nil
 }
 
 // AdaptPolicy adapts the load balancing policy based on current server families
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// GenerationGrowth is how one heap generation changed between two snapshots
+// and when it fills at that pace
+type GenerationGrowth struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	Max   int `json:"max"`
+	// Net growth per second, only counting spans without a MaGC in between
+	RatePerSec float64 `json:"rate_per_sec"`
+	// Time from the later snapshot until the generation is full; -1 when it is not growing
+	ExhaustionMs int64      `json:"exhaustion_ms"`
+	ExhaustionAt *time.Time `json:"exhaustion_at,omitempty"`
+}
+
+// GCHistoryDiff compares two points in a server's GC history to show how fast
+// its heap grows, how much is promoted and when it runs out
+type GCHistoryDiff struct {
+	ServerID      int        `json:"server_id"`
+	From          GCSnapshot `json:"from"`
+	To            GCSnapshot `json:"to"`
+	ElapsedMs     int64      `json:"elapsed_ms"`
+	SnapshotCount int        `json:"snapshot_count"`
+	MaGCs         int        `json:"magcs"` // MaGCs between the two snapshots
+	// Growth rates only cover spans without a MaGC; this is their total length
+	MeasuredMs int64            `json:"measured_ms"`
+	YoungGen   GenerationGrowth `json:"young_gen"`
+	OldGen     GenerationGrowth `json:"old_gen"`
+	// Old generation growth between MaGCs, taken as objects surviving the
+	// young generation
+	PromotionRatePerSec float64 `json:"promotion_rate_per_sec"`
+	PromotionShare      float64 `json:"promotion_share"` // Share of allocations promoted
+	// Time from the later snapshot until the old generation reaches the MaGC
+	// trigger; -1 when it is not growing
+	ProjectedMaGCMs int64      `json:"projected_magc_ms"`
+	ProjectedMaGCAt *time.Time `json:"projected_magc_at,omitempty"`
+}
+
+// GCHistoryDiff analyzes the server's GC snapshots from the first at or after
+// from to the last at or before to. A zero from or to leaves that end open.
+func (s *Server) GCHistoryDiff(from, to time.Time) (GCHistoryDiff, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.gcHistoryRangeLocked(from, to)
+	if len(history) < 2 {
+		return GCHistoryDiff{}, fmt.Errorf("need at least 2 GC snapshots in range, found %d", len(history))
+	}
+
+	first, last := history[0], history[len(history)-1]
+	diff := GCHistoryDiff{
+		ServerID:      s.ID,
+		From:          first,
+		To:            last,
+		ElapsedMs:     last.Timestamp.Sub(first.Timestamp).Milliseconds(),
+		SnapshotCount: len(history),
+		MaGCs:         last.GCCount - first.GCCount,
+	}
+
+	// Sum growth over consecutive snapshots of the same MaGC cycle so that
+	// the heap being emptied does not count as shrinkage
+	var measured time.Duration
+	youngGrowth, oldGrowth, promoted, allocated := 0, 0, 0, 0
+	for i := 1; i < len(history); i++ {
+		prev, next := history[i-1], history[i]
+		if next.GCCount != prev.GCCount || !next.LastMaGCTime.Equal(prev.LastMaGCTime) {
+			continue
+		}
+		measured += next.Timestamp.Sub(prev.Timestamp)
+		youngGrowth += next.YoungGenUsed - prev.YoungGenUsed
+		oldGrowth += next.OldGenUsed - prev.OldGenUsed
+		if grown := next.OldGenUsed - prev.OldGenUsed; grown > 0 {
+			promoted += grown
+		}
+		if grown := next.TotalMemUsed - prev.TotalMemUsed; grown > 0 {
+			allocated += grown
+		}
+	}
+	diff.MeasuredMs = measured.Milliseconds()
+
+	seconds := measured.Seconds()
+	diff.YoungGen = generationGrowth(first.YoungGenUsed, last.YoungGenUsed, last.YoungGenMax, youngGrowth, seconds, last.Timestamp)
+	diff.OldGen = generationGrowth(first.OldGenUsed, last.OldGenUsed, last.OldGenMax, oldGrowth, seconds, last.Timestamp)
+	if seconds > 0 {
+		diff.PromotionRatePerSec = float64(promoted) / seconds
+	}
+	if allocated > 0 {
+		diff.PromotionShare = min(float64(promoted)/float64(allocated), 1)
+	}
+
+	diff.ProjectedMaGCMs = -1
+	trigger := float64(last.OldGenMax) * s.gcPercentage
+	if rate := diff.OldGen.RatePerSec; rate > 0 && last.OldGenMax > 0 {
+		remaining := max(trigger-float64(last.OldGenUsed), 0)
+		diff.ProjectedMaGCMs = int64(remaining / rate * 1000)
+		at := last.Timestamp.Add(time.Duration(diff.ProjectedMaGCMs) * time.Millisecond)
+		diff.ProjectedMaGCAt = &at
+	}
+	return diff, nil
+}
+
+// generationGrowth reports a generation's growth rate and when it fills
+func generationGrowth(start, end, maxSize, growth int, seconds float64, at time.Time) GenerationGrowth {
+	result := GenerationGrowth{Start: start, End: end, Max: maxSize, ExhaustionMs: -1}
+	if seconds <= 0 {
+		return result
+	}
+
+	result.RatePerSec = float64(growth) / seconds
+	if result.RatePerSec > 0 {
+		remaining := max(maxSize-end, 0)
+		result.ExhaustionMs = int64(float64(remaining) / result.RatePerSec * 1000)
+		exhaustion := at.Add(time.Duration(result.ExhaustionMs) * time.Millisecond)
+		result.ExhaustionAt = &exhaustion
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// GCHistoryRange returns a copy of the server's GC snapshots taken between
+// from and to, inclusive; a zero from or to leaves that end open
+func (s *Server) GCHistoryRange(from, to time.Time) []GCSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.gcHistoryRangeLocked(from, to)
+}
+
+// gcHistoryRangeLocked is GCHistoryRange for callers holding s.mu
+func (s *Server) gcHistoryRangeLocked(from, to time.Time) []GCSnapshot {
+	history := make([]GCSnapshot, 0, len(s.GCHistory))
+	for _, snapshot := range s.GCHistory {
+		if !from.IsZero() && snapshot.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && snapshot.Timestamp.After(to) {
+			continue
+		}
+		history = append(history, snapshot)
+	}
+	return history
+}
+
+// StoredGCHistory returns the server's GC snapshots between from and to from
+// the store, which keeps more than the last 100 and survives restarts. Without
+// a store, or if it cannot be read, it falls back to the in-memory history.
+func (l *LoadBalancer) StoredGCHistory(server *Server, from, to time.Time) []GCSnapshot {
+	if l.store == nil {
+		return server.GCHistoryRange(from, to)
+	}
+	history, err := l.store.LoadHistory(server.ID, from, to)
+	if err != nil {
+		l.logf("⚠️ Could not load stored GC history of server %d: %v", server.ID, err)
+		return server.GCHistoryRange(from, to)
+	}
+	return history
+}
+
+// ExportedSnapshot is a GC snapshot tagged with the server that took it
+type ExportedSnapshot struct {
+	ServerID int `json:"server_id"`
+	GCSnapshot
+}
+
+// GCHistoryExporter writes the GC history of several servers as one CSV or
+// JSONL stream (DatasetCSV or DatasetJSONL), one row or line per snapshot
+type GCHistoryExporter struct {
+	format  string
+	encoder *json.Encoder
+	writer  *csv.Writer
+}
+
+// NewGCHistoryExporter starts an export in the given format, writing the CSV
+// header right away
+func NewGCHistoryExporter(w io.Writer, format string) (*GCHistoryExporter, error) {
+	exporter := &GCHistoryExporter{format: format}
+	if format == DatasetJSONL {
+		exporter.encoder = json.NewEncoder(w)
+		return exporter, nil
+	}
+
+	exporter.writer = csv.NewWriter(w)
+	exporter.writer.Write([]string{
+		"server_id", "timestamp", "young_gen_used", "young_gen_max", "old_gen_used", "old_gen_max",
+		"total_mem_used", "total_mem_max", "gc_count", "last_magc_time", "magc_duration_ms", "is_collecting_gc",
+	})
+	exporter.writer.Flush()
+	return exporter, exporter.writer.Error()
+}
+
+// Write appends one server's snapshots to the export
+func (e *GCHistoryExporter) Write(serverID int, snapshots []GCSnapshot) error {
+	if e.format == DatasetJSONL {
+		for _, snapshot := range snapshots {
+			if err := e.encoder.Encode(ExportedSnapshot{ServerID: serverID, GCSnapshot: snapshot}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, snapshot := range snapshots {
+		e.writer.Write([]string{
+			strconv.Itoa(serverID),
+			snapshot.Timestamp.Format(time.RFC3339Nano),
+			strconv.Itoa(snapshot.YoungGenUsed),
+			strconv.Itoa(snapshot.YoungGenMax),
+			strconv.Itoa(snapshot.OldGenUsed),
+			strconv.Itoa(snapshot.OldGenMax),
+			strconv.Itoa(snapshot.TotalMemUsed),
+			strconv.Itoa(snapshot.TotalMemMax),
+			strconv.Itoa(snapshot.GCCount),
+			snapshot.LastMaGCTime.Format(time.RFC3339Nano),
+			strconv.FormatInt(snapshot.MaGCDuration, 10),
+			strconv.FormatBool(snapshot.IsCollectingGC),
+		})
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
@@ -0,0 +1,52 @@
+package server
+
+import "time"
+
+// FlagGCPrefetch runs a forecast MaGC early on servers that are idle, so the
+// pause does not land in the middle of a request
+const FlagGCPrefetch = "gc_prefetch"
+
+// defaultGCPrefetchWindow is how soon a forecast MaGC must be due to be prefetched
+const defaultGCPrefetchWindow = 5 * time.Second
+
+// shouldPrefetchGC reports whether the server is idle and its MaGC is forecast
+// within the window
+func (s *Server) shouldPrefetchGC(window time.Duration) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replay || s.isCollectingGCTasks || s.activeTasks > 0 || s.usedMemory == 0 {
+		return false
+	}
+	if s.adminStateLocked() != StateActive || s.LastMaGCForecast == nil {
+		return false
+	}
+	timeToMaGC := s.LastMaGCForecast.PredictedTime.Sub(s.now())
+	return timeToMaGC > 0 && timeToMaGC <= window
+}
+
+// prefetchGC starts the MaGC of every idle server whose forecast MaGC is due
+// within the prefetch window. The server is unavailable only for the pause
+// itself, which now happens while no request is waiting on it.
+func (l *LoadBalancer) prefetchGC() {
+	if !l.Flags.Enabled(FlagGCPrefetch) {
+		return
+	}
+
+	window := l.gcPrefetchWindow
+	if window <= 0 {
+		window = defaultGCPrefetchWindow
+	}
+
+	for _, server := range l.ListServers() {
+		if !server.shouldPrefetchGC(window) {
+			continue
+		}
+
+		l.logf("🧹 Server %d: MaGC forecast within %v and no tasks in flight, collecting now", server.ID, window)
+		server.mu.Lock()
+		server.recordExecutionLocked(ExecutionTotals{GCPrefetches: 1})
+		server.mu.Unlock()
+		l.spawn(server.CollectGCTasks)
+	}
+}
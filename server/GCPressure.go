@@ -0,0 +1,106 @@
+package server
+
+import "time"
+
+const (
+	// gcPressureSnapshots is how many recent snapshots old generation growth is measured over
+	gcPressureSnapshots = 10
+	// gcPressureReference is the MaGC interval assumed until the long-horizon
+	// forecast knows the server's cadence, and the span imminence is scored over
+	gcPressureReference = time.Minute
+
+	// Component weights of the composite score; they sum to 1
+	gcPressureGrowthWeight    = 0.3
+	gcPressureAgeWeight       = 0.3
+	gcPressureImminenceWeight = 0.4
+)
+
+// GCPressure is a server's composite 0-100 GC pressure score and the 0-1
+// components it is made of. The monitoring loop refreshes it while TRINI is active.
+type GCPressure struct {
+	ServerID int     `json:"server_id"`
+	Score    float64 `json:"score"`
+	// Share of the old generation filled per minute, capped at 1
+	Growth float64 `json:"growth"`
+	// Time since the last MaGC relative to the usual interval, capped at 1
+	Age float64 `json:"age"`
+	// Forecast confidence times how close the next MaGC is
+	Imminence float64   `json:"imminence"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// updateGCPressure recomputes the server's GC pressure from its latest snapshots and forecasts
+func (s *Server) updateGCPressure() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	pressure := GCPressure{ServerID: s.ID, UpdatedAt: now}
+
+	// Old generation growth since the last MaGC, within the recent snapshots
+	history := s.GCHistory
+	if len(history) > gcPressureSnapshots {
+		history = history[len(history)-gcPressureSnapshots:]
+	}
+	if n := len(history); n > 1 && s.OldGenMax > 0 {
+		first, last := 0, history[n-1]
+		for i := n - 2; i >= 0 && history[i].LastMaGCTime.Equal(last.LastMaGCTime); i-- {
+			first = i
+		}
+		elapsed := last.Timestamp.Sub(history[first].Timestamp)
+		growth := last.OldGenUsed - history[first].OldGenUsed
+		if elapsed > 0 && growth > 0 {
+			perMinute := float64(growth) / float64(s.OldGenMax) * float64(time.Minute) / float64(elapsed)
+			pressure.Growth = min(perMinute, 1)
+		}
+	}
+
+	// Time since the last MaGC against the server's own cadence
+	reference := gcPressureReference
+	long := s.longForecastLocked()
+	if long != nil && long.IntervalMs > 0 {
+		reference = time.Duration(long.IntervalMs) * time.Millisecond
+	}
+	since := s.LastMaGCTime
+	if since.IsZero() && len(s.GCHistory) > 0 {
+		since = s.GCHistory[0].Timestamp
+	}
+	if !since.IsZero() {
+		pressure.Age = min(max(float64(now.Sub(since))/float64(reference), 0), 1)
+	}
+
+	// Imminence from the short-horizon forecast, else the long-horizon one
+	if timeToMaGC, ok := s.timeToMaGCLocked(); ok {
+		pressure.Imminence = s.LastMaGCForecast.Confidence * imminence(timeToMaGC)
+	} else if long != nil {
+		pressure.Imminence = long.Confidence * imminence(long.NextMaGC.Sub(now))
+	}
+
+	pressure.Score = 100 * (gcPressureGrowthWeight*pressure.Growth +
+		gcPressureAgeWeight*pressure.Age +
+		gcPressureImminenceWeight*pressure.Imminence)
+	s.gcPressure = pressure
+}
+
+// imminence scores a MaGC due now as 1, falling to 0 at gcPressureReference away
+func imminence(timeToMaGC time.Duration) float64 {
+	return min(max(1-float64(timeToMaGC)/float64(gcPressureReference), 0), 1)
+}
+
+// GCPressure returns the server's latest GC pressure
+func (s *Server) GCPressure() GCPressure {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pressure := s.gcPressure
+	pressure.ServerID = s.ID
+	return pressure
+}
+
+// GCPressures returns the latest GC pressure of every server, ordered by server
+func (l *LoadBalancer) GCPressures() []GCPressure {
+	pressures := make([]GCPressure, 0)
+	for _, server := range l.ListServers() {
+		pressures = append(pressures, server.GCPressure())
+	}
+	return pressures
+}
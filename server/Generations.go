@@ -0,0 +1,112 @@
+package server
+
+import "fmt"
+
+// defaultYoungGenRatio is the share of the memory limit sized for the young generation
+const defaultYoungGenRatio = 0.5
+
+// GenerationSizing reports how a server's heap is split into generations
+type GenerationSizing struct {
+	ServerID      int     `json:"server_id"`
+	YoungGenMax   int     `json:"young_gen_max"`
+	OldGenMax     int     `json:"old_gen_max"`
+	YoungGenRatio float64 `json:"young_gen_ratio"`
+	Absolute      bool    `json:"absolute"` // Sizes were set explicitly instead of derived from the ratio
+}
+
+// refreshGenerationsLocked derives the generation sizes from the memory limit
+// unless they were set explicitly; callers hold s.mu. Explicit sizes that no
+// longer fit a reduced memory limit fall back to the ratio.
+func (s *Server) refreshGenerationsLocked() {
+	if s.youngGenRatio == 0 {
+		s.youngGenRatio = defaultYoungGenRatio
+	}
+	if s.generationOverride && s.YoungGenMax+s.OldGenMax > s.memLimit {
+		s.generationOverride = false
+	}
+	if !s.generationOverride {
+		s.YoungGenMax = int(float64(s.memLimit) * s.youngGenRatio)
+		s.OldGenMax = s.memLimit - s.YoungGenMax
+	}
+
+	// Usage cannot exceed a shrunken generation
+	s.YoungGenUsed = min(s.YoungGenUsed, s.YoungGenMax)
+	s.OldGenUsed = min(s.OldGenUsed, s.OldGenMax)
+}
+
+// oldGenTrigger is the old generation usage at which a MaGC starts
+func (s *Server) oldGenTrigger() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return float64(s.OldGenMax) * s.gcPercentage
+}
+
+// shouldCollectLocked reports whether occupancy warrants a MaGC. Like a
+// generational collector, it watches the old generation, which is what MaGA
+// forecasts; servers without generation sizing fall back to total memory.
+// Callers hold s.mu.
+func (s *Server) shouldCollectLocked() bool {
+	if s.OldGenMax > 0 {
+		return float64(s.OldGenUsed) >= float64(s.OldGenMax)*s.gcPercentage
+	}
+	return float64(s.usedMemory) >= float64(s.memLimit)*s.gcPercentage
+}
+
+// SetGenerationRatio sizes the young generation as a fraction of the memory
+// limit, leaving the rest to the old generation
+func (s *Server) SetGenerationRatio(youngRatio float64) error {
+	if youngRatio <= 0 || youngRatio >= 1 {
+		return fmt.Errorf("young generation ratio must be between 0 and 1, got %g", youngRatio)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.youngGenRatio = youngRatio
+	s.generationOverride = false
+	s.refreshGenerationsLocked()
+	return nil
+}
+
+// SetGenerationSizes sets absolute generation sizes, which must fit in the memory limit
+func (s *Server) SetGenerationSizes(youngGenMax, oldGenMax int) error {
+	if youngGenMax <= 0 || oldGenMax <= 0 {
+		return fmt.Errorf("generation sizes must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if youngGenMax+oldGenMax > s.memLimit {
+		return fmt.Errorf("generation sizes %d+%d exceed the memory limit %d", youngGenMax, oldGenMax, s.memLimit)
+	}
+	s.YoungGenMax = youngGenMax
+	s.OldGenMax = oldGenMax
+	s.generationOverride = true
+	s.refreshGenerationsLocked()
+	return nil
+}
+
+// ResetGenerations goes back to the default even split of the memory limit
+func (s *Server) ResetGenerations() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.youngGenRatio = defaultYoungGenRatio
+	s.generationOverride = false
+	s.refreshGenerationsLocked()
+}
+
+// GenerationInfo returns the server's generation sizing
+func (s *Server) GenerationInfo() GenerationSizing {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ratio := s.youngGenRatio
+	if ratio == 0 {
+		ratio = defaultYoungGenRatio
+	}
+	return GenerationSizing{
+		ServerID:      s.ID,
+		YoungGenMax:   s.YoungGenMax,
+		OldGenMax:     s.OldGenMax,
+		YoungGenRatio: ratio,
+		Absolute:      s.generationOverride,
+	}
+}
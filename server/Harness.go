@@ -0,0 +1,351 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Invariants the harness checks; a violation names the one it broke
+const (
+	// InvariantSelectedEligible: selection never returns a server that is out
+	// of the pool, collecting or not active, nor one too full for the task
+	// while no other submission could have filled it
+	InvariantSelectedEligible = "selected_eligible"
+	// InvariantWeightsNonNegative: configured weights stay within bounds and
+	// weighted round-robin budgets never drop below zero
+	InvariantWeightsNonNegative = "weights_non_negative"
+	// InvariantMemoryWithinLimit: used memory and each generation stay
+	// between zero and their limits
+	InvariantMemoryWithinLimit = "memory_within_limit"
+)
+
+// HarnessOp is one kind of step the harness applies
+type HarnessOp uint8
+
+const (
+	HarnessSubmit       HarnessOp = iota // Select a server for a task of Arg-derived size and admit it there
+	HarnessStartGC                       // Begin a MaGC on the Target server
+	HarnessEndGC                         // Finish the Target server's MaGC
+	HarnessAddServer                     // Add a server with an Arg-derived memory limit
+	HarnessRemoveServer                  // Remove the Target server
+	HarnessAdminState                    // Move the Target server to the Arg-th administrative state
+	HarnessSetWeight                     // Override the Target server's weight from Arg
+	HarnessForecast                      // Forecast a MaGC on the Target server Arg*10ms ahead
+	HarnessAdvance                       // Advance the clock by Arg*10ms
+	HarnessAlgorithm                     // Switch to the Arg-th algorithm and avoidance mode
+	harnessOpCount
+)
+
+// harnessStepSize is how many input bytes DecodeHarnessSteps uses per step
+const harnessStepSize = 3
+
+// harnessAlgorithms are the algorithms HarnessAlgorithm cycles through
+var harnessAlgorithms = []string{"RR", "RAN", "WRR", "WRAN", AlgorithmCost}
+
+// harnessAdminStates are the states HarnessAdminState cycles through
+var harnessAdminStates = []string{StateActive, StatePaused, StateDraining, StateQuarantined, StateStandby}
+
+// HarnessStep is one operation with its raw operands. Target picks a server
+// by position in the pool, wrapping around, so any value is valid.
+type HarnessStep struct {
+	Op     HarnessOp
+	Target int
+	Arg    int
+}
+
+// DecodeHarnessSteps turns arbitrary bytes, such as fuzzer input, into
+// steps, three bytes each; a trailing partial step is ignored
+func DecodeHarnessSteps(data []byte) []HarnessStep {
+	steps := make([]HarnessStep, 0, len(data)/harnessStepSize)
+	for i := 0; i+harnessStepSize <= len(data); i += harnessStepSize {
+		steps = append(steps, HarnessStep{
+			Op:     HarnessOp(data[i] % byte(harnessOpCount)),
+			Target: int(data[i+1]),
+			Arg:    int(data[i+2]),
+		})
+	}
+	return steps
+}
+
+// InvariantViolation describes one broken invariant
+type InvariantViolation struct {
+	Invariant string `json:"invariant"`
+	ServerID  int    `json:"server_id"`
+	Detail    string `json:"detail"`
+}
+
+func (v InvariantViolation) String() string {
+	return fmt.Sprintf("%s (server %d): %s", v.Invariant, v.ServerID, v.Detail)
+}
+
+// harnessClock is a Clock the harness advances by hand
+type harnessClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *harnessClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *harnessClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Harness drives a load balancer through sequences of task submissions, GC
+// events and membership changes and checks selection invariants after every
+// step. Its servers skip the simulated latency and MaGCs begin and end on
+// command, so long sequences run in milliseconds; fuzz targets feed it
+// random bytes through DecodeHarnessSteps.
+//
+// RunConcurrent applies steps from several goroutines. Submissions run
+// alongside each other, while steps that change availability or membership
+// run alone, so whether a selected server was eligible stays well defined.
+type Harness struct {
+	LB *LoadBalancer
+
+	clock      *harnessClock
+	steps      sync.RWMutex // Held shared by submissions and exclusively by every other step
+	concurrent bool
+
+	mu         sync.Mutex
+	policy     LoadBalancingPolicy
+	gcStarts   map[*Server]time.Time
+	violations []InvariantViolation
+}
+
+// NewHarness creates a harness with a pool of servers with 1000 bytes of
+// memory each, balanced GC-aware round-robin
+func NewHarness(servers int) *Harness {
+	h := &Harness{
+		clock:    &harnessClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		gcStarts: make(map[*Server]time.Time),
+	}
+
+	pool := make([]*Server, 0, servers)
+	for id := 1; id <= servers; id++ {
+		server := NewServer(id, 1000, 75)
+		server.replay = true
+		pool = append(pool, server)
+	}
+	h.LB = NewLoadBalancer(
+		WithServers(pool...),
+		WithClock(h.clock),
+		WithLogger(log.New(io.Discard, "", 0)),
+	)
+	for _, server := range pool {
+		server.initializeTRINI(h.LB.TRINI.DefaultFamily)
+	}
+	h.policy = h.LB.CurrentPolicy
+	return h
+}
+
+// Run applies the steps in order and returns every violation found
+func (h *Harness) Run(steps []HarnessStep) []InvariantViolation {
+	for _, step := range steps {
+		h.Apply(step)
+	}
+	return h.Violations()
+}
+
+// RunConcurrent applies the steps from workers goroutines, dealing them out
+// in turn, and returns every violation found
+func (h *Harness) RunConcurrent(steps []HarnessStep, workers int) []InvariantViolation {
+	h.concurrent = workers > 1
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < len(steps); i += workers {
+				h.Apply(steps[i])
+			}
+		}(w)
+	}
+	wg.Wait()
+	h.CheckInvariants()
+	return h.Violations()
+}
+
+// Apply runs one step and checks the invariants it may have broken
+func (h *Harness) Apply(step HarnessStep) {
+	if step.Op == HarnessSubmit {
+		h.steps.RLock()
+		defer h.steps.RUnlock()
+		h.submit(1 + step.Arg*4)
+		return
+	}
+
+	h.steps.Lock()
+	defer h.steps.Unlock()
+
+	switch step.Op {
+	case HarnessAddServer:
+		if server, err := h.LB.AddServer(100+step.Arg*8, 75); err == nil {
+			server.mu.Lock()
+			server.replay = true
+			server.mu.Unlock()
+		}
+	case HarnessAdvance:
+		h.clock.advance(time.Duration(step.Arg*10) * time.Millisecond)
+	case HarnessAlgorithm:
+		h.mu.Lock()
+		h.policy.Algorithm = harnessAlgorithms[step.Arg%len(harnessAlgorithms)]
+		h.policy.GCAvoidance = AvoidanceHard
+		if step.Arg/len(harnessAlgorithms)%2 == 1 {
+			h.policy.GCAvoidance = AvoidanceSoft
+		}
+		h.mu.Unlock()
+	default:
+		if server := h.target(step.Target); server != nil {
+			h.applyToServer(step, server)
+		}
+	}
+	h.CheckInvariants()
+}
+
+// target returns the server at position index in the pool, wrapping around
+func (h *Harness) target(index int) *Server {
+	servers := h.LB.ListServers()
+	if len(servers) == 0 {
+		return nil
+	}
+	return servers[index%len(servers)]
+}
+
+// applyToServer runs a step aimed at one server
+func (h *Harness) applyToServer(step HarnessStep, server *Server) {
+	switch step.Op {
+	case HarnessStartGC:
+		if start, ok := server.beginMaGC(); ok {
+			h.mu.Lock()
+			h.gcStarts[server] = start
+			h.mu.Unlock()
+		}
+	case HarnessEndGC:
+		h.mu.Lock()
+		start, ok := h.gcStarts[server]
+		delete(h.gcStarts, server)
+		h.mu.Unlock()
+		if ok {
+			server.finishMaGC(start)
+		}
+	case HarnessRemoveServer:
+		h.LB.RemoveServer(server.ID)
+	case HarnessAdminState:
+		server.SetAdminState(harnessAdminStates[step.Arg%len(harnessAdminStates)])
+	case HarnessSetWeight:
+		server.SetWeight(1 + step.Arg%maxServerWeight)
+	case HarnessForecast:
+		now := h.clock.Now()
+		server.mu.Lock()
+		server.LastMaGCForecast = &MaGCForecast{
+			PredictedTime:     now.Add(time.Duration(step.Arg*10) * time.Millisecond),
+			Confidence:        0.9,
+			ForecastCreatedAt: now,
+		}
+		server.mu.Unlock()
+	}
+}
+
+// submit selects a server for a task of taskSize bytes and admits it there
+// the way a server worker does
+func (h *Harness) submit(taskSize int) {
+	h.mu.Lock()
+	policy := h.policy
+	h.mu.Unlock()
+
+	taskInput := strings.Repeat("x", taskSize)
+	server := h.LB.selectServer(taskInput, policy, nil)
+	if server == nil {
+		return
+	}
+
+	if reason := h.ineligible(server, taskSize); reason != "" {
+		h.violate(InvariantSelectedEligible, server.ID, "selected for a task of %d bytes while %s", taskSize, reason)
+		return
+	}
+	server.admitTask(taskSize)
+	h.checkServer(server)
+}
+
+// ineligible reports why a selected server should not have been, or ""
+func (h *Harness) ineligible(server *Server, taskSize int) string {
+	if h.LB.GetServerByID(server.ID) != server {
+		return "out of the pool"
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	switch {
+	case server.isCollectingGCTasks:
+		return "collecting"
+	case server.adminStateLocked() != StateActive:
+		return server.adminStateLocked()
+	case !h.concurrent && server.usedMemory+taskSize > server.memLimit:
+		return fmt.Sprintf("%d of %d bytes were used", server.usedMemory, server.memLimit)
+	}
+	return ""
+}
+
+// CheckInvariants checks the weight and memory invariants on every server
+func (h *Harness) CheckInvariants() {
+	for _, server := range h.LB.ListServers() {
+		h.checkServer(server)
+	}
+}
+
+// checkServer checks the weight and memory invariants on one server
+func (h *Harness) checkServer(server *Server) {
+	server.mu.Lock()
+	weight, budget := server.weight, server.Weights
+	used, limit := server.usedMemory, server.memLimit
+	young, youngMax := server.YoungGenUsed, server.YoungGenMax
+	old, oldMax := server.OldGenUsed, server.OldGenMax
+	server.mu.Unlock()
+
+	if weight < 1 || weight > maxServerWeight {
+		h.violate(InvariantWeightsNonNegative, server.ID, "weight %d outside 1-%d", weight, maxServerWeight)
+	}
+	if budget < 0 {
+		h.violate(InvariantWeightsNonNegative, server.ID, "round-robin budget %d", budget)
+	}
+	if used < 0 || used > limit {
+		h.violate(InvariantMemoryWithinLimit, server.ID, "%d of %d bytes used", used, limit)
+	}
+	if young < 0 || young > youngMax {
+		h.violate(InvariantMemoryWithinLimit, server.ID, "young generation %d of %d bytes used", young, youngMax)
+	}
+	if old < 0 || old > oldMax {
+		h.violate(InvariantMemoryWithinLimit, server.ID, "old generation %d of %d bytes used", old, oldMax)
+	}
+}
+
+// violate records a broken invariant
+func (h *Harness) violate(invariant string, serverID int, format string, args ...interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.violations = append(h.violations, InvariantViolation{
+		Invariant: invariant,
+		ServerID:  serverID,
+		Detail:    fmt.Sprintf(format, args...),
+	})
+}
+
+// Violations returns the violations found so far
+func (h *Harness) Violations() []InvariantViolation {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	violations := make([]InvariantViolation, len(h.violations))
+	copy(violations, h.violations)
+	return violations
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// harnessSeeds are sequences worth keeping in every fuzz corpus: GC in the
+// middle of a burst, membership churn and weighted algorithms near capacity
+var harnessSeeds = [][]byte{
+	{0, 0, 200, 0, 1, 200, 1, 0, 0, 0, 0, 200, 0, 1, 200, 2, 0, 0, 0, 0, 200},
+	{3, 0, 10, 0, 0, 250, 4, 0, 0, 0, 0, 250, 3, 0, 255, 0, 0, 250, 4, 1, 0},
+	{9, 0, 2, 6, 0, 0, 0, 0, 240, 0, 1, 240, 0, 2, 240, 0, 3, 240, 0, 0, 240},
+	{9, 0, 3, 7, 0, 50, 7, 1, 50, 8, 0, 10, 0, 0, 100, 5, 2, 1, 0, 0, 100},
+}
+
+func FuzzSelectionInvariants(f *testing.F) {
+	for _, seed := range harnessSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, violation := range NewHarness(4).Run(DecodeHarnessSteps(data)) {
+			t.Error(violation)
+		}
+	})
+}
+
+func FuzzConcurrentSelectionInvariants(f *testing.F) {
+	for _, seed := range harnessSeeds {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, violation := range NewHarness(4).RunConcurrent(DecodeHarnessSteps(data), 4) {
+			t.Error(violation)
+		}
+	})
+}
+
+func TestSelectionInvariantsRandomSequences(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		data := make([]byte, 900)
+		rand.New(rand.NewSource(seed)).Read(data)
+		steps := DecodeHarnessSteps(data)
+
+		for _, violation := range NewHarness(4).Run(steps) {
+			t.Errorf("seed %d: %v", seed, violation)
+		}
+		for _, violation := range NewHarness(4).RunConcurrent(steps, 8) {
+			t.Errorf("seed %d, concurrent: %v", seed, violation)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package server
+
+import "time"
+
+// Hooks are callbacks for library users who want custom metrics or
+// accounting. Any field may be nil. Callbacks run synchronously on the
+// balancer's goroutines without its locks held, so they must return quickly.
+type Hooks struct {
+	// OnSelect runs once a task's server is chosen; server is nil if the task was rejected
+	OnSelect func(taskInput string, server *Server, policy LoadBalancingPolicy)
+	// OnDispatch runs when a server accepts a task, including hedges and retries
+	OnDispatch func(taskInput string, server *Server)
+	// OnComplete runs when a server finishes, rejects (Task.Status is "rejected")
+	// or times out (TaskStatusTimeout) a task
+	OnComplete func(server *Server, task *Task, elapsed time.Duration)
+	// OnGCStart runs when a server begins a MaGC
+	OnGCStart func(server *Server)
+	// OnGCEnd runs when a server's MaGC ends
+	OnGCEnd func(server *Server, pause time.Duration)
+}
+
+// AddHooks registers callbacks in addition to any already registered
+func (l *LoadBalancer) AddHooks(hooks Hooks) {
+	l.hooksMu.Lock()
+	defer l.hooksMu.Unlock()
+	l.hooks = append(l.hooks, hooks)
+}
+
+// registeredHooks returns the current callbacks; nil-safe for detached servers
+func (l *LoadBalancer) registeredHooks() []Hooks {
+	if l == nil {
+		return nil
+	}
+	l.hooksMu.RLock()
+	defer l.hooksMu.RUnlock()
+	return l.hooks
+}
+
+func (l *LoadBalancer) onSelect(taskInput string, server *Server, policy LoadBalancingPolicy) {
+	for _, hooks := range l.registeredHooks() {
+		if hooks.OnSelect != nil {
+			hooks.OnSelect(taskInput, server, policy)
+		}
+	}
+}
+
+func (l *LoadBalancer) onDispatch(taskInput string, server *Server) {
+	for _, hooks := range l.registeredHooks() {
+		if hooks.OnDispatch != nil {
+			hooks.OnDispatch(taskInput, server)
+		}
+	}
+}
+
+func (l *LoadBalancer) onComplete(server *Server, task *Task, elapsed time.Duration) {
+	for _, hooks := range l.registeredHooks() {
+		if hooks.OnComplete != nil {
+			hooks.OnComplete(server, task, elapsed)
+		}
+	}
+}
+
+func (l *LoadBalancer) onGCStart(server *Server) {
+	for _, hooks := range l.registeredHooks() {
+		if hooks.OnGCStart != nil {
+			hooks.OnGCStart(server)
+		}
+	}
+}
+
+func (l *LoadBalancer) onGCEnd(server *Server, pause time.Duration) {
+	for _, hooks := range l.registeredHooks() {
+		if hooks.OnGCEnd != nil {
+			hooks.OnGCEnd(server, pause)
+		}
+	}
+}
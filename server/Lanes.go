@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Dispatch lanes. Each server splits its concurrency limit between the two so
+// that large batch tasks cannot take every slot from latency-sensitive ones.
+const (
+	LaneInteractive = "interactive"
+	LaneBatch       = "batch"
+)
+
+// Lanes lists every dispatch lane, in reporting order
+var Lanes = []string{LaneInteractive, LaneBatch}
+
+// defaultInteractiveShare is the share of the concurrency limit the interactive lane may use
+const defaultInteractiveShare = 0.5
+
+// IsValidLane reports whether lane names a dispatch lane
+func IsValidLane(lane string) bool {
+	return lane == LaneInteractive || lane == LaneBatch
+}
+
+// LaneStats reports one lane's load and outcomes on a server
+type LaneStats struct {
+	Lane      string `json:"lane"`
+	Active    int    `json:"active"`
+	Limit     int    `json:"limit"` // 0 is unlimited
+	Completed int64  `json:"completed"`
+	Rejected  int64  `json:"rejected"`
+	// Mean time from request to result of completed tasks
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// laneState is a lane's running counters on a server
+type laneState struct {
+	active    int
+	completed int64
+	rejected  int64
+	latencyMs int64
+}
+
+// laneLocked returns the lane's counters, creating them on first use; callers hold s.mu
+func (s *Server) laneLocked(lane string) *laneState {
+	if s.lanes == nil {
+		s.lanes = make(map[string]*laneState, len(Lanes))
+	}
+	state, ok := s.lanes[lane]
+	if !ok {
+		state = &laneState{}
+		s.lanes[lane] = state
+	}
+	return state
+}
+
+// SetInteractiveShare sets the share of the concurrency limit the interactive
+// lane may use; the batch lane may use the rest. Each lane gets at least one slot.
+func (s *Server) SetInteractiveShare(share float64) error {
+	if share <= 0 || share >= 1 {
+		return fmt.Errorf("interactive share must be between 0 and 1, got %g", share)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.interactiveShare = share
+	return nil
+}
+
+// InteractiveShare returns the share of the concurrency limit reserved for the interactive lane
+func (s *Server) InteractiveShare() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.interactiveShareLocked()
+}
+
+func (s *Server) interactiveShareLocked() float64 {
+	if s.interactiveShare == 0 {
+		return defaultInteractiveShare
+	}
+	return s.interactiveShare
+}
+
+// laneLimitLocked returns how many tasks the lane may run at once; 0 means
+// unlimited. Lane limits round up, so together they may exceed the server's
+// limit, which still caps the total.
+func (s *Server) laneLimitLocked(lane string) int {
+	if s.maxConcurrentTasks == 0 {
+		return 0
+	}
+	share := s.interactiveShareLocked()
+	if lane == LaneBatch {
+		share = 1 - share
+	}
+	return max(1, int(math.Ceil(float64(s.maxConcurrentTasks)*share)))
+}
+
+// recordLaneOutcomeLocked counts a finished task against its lane; callers hold s.mu
+func (s *Server) recordLaneOutcomeLocked(lane string, completed bool, elapsed time.Duration) {
+	state := s.laneLocked(lane)
+	if !completed {
+		state.rejected++
+		return
+	}
+	state.completed++
+	state.latencyMs += elapsed.Milliseconds()
+}
+
+// LaneStats returns the load and outcomes of every lane
+func (s *Server) LaneStats() []LaneStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.laneStatsLocked()
+}
+
+func (s *Server) laneStatsLocked() []LaneStats {
+	stats := make([]LaneStats, 0, len(Lanes))
+	for _, lane := range Lanes {
+		state := s.laneLocked(lane)
+		entry := LaneStats{
+			Lane:      lane,
+			Active:    state.active,
+			Limit:     s.laneLimitLocked(lane),
+			Completed: state.completed,
+			Rejected:  state.rejected,
+		}
+		if state.completed > 0 {
+			entry.AvgLatencyMs = float64(state.latencyMs) / float64(state.completed)
+		}
+		stats = append(stats, entry)
+	}
+	return stats
+}
@@ -1,25 +1,165 @@
 package server
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
 
-func (l *LoadBalancer) Start() {
-	l.TaskQueue = make(chan string)
+// Start validates the configuration, launches the task dispatcher, prepares
+// the servers and starts TRINI. All goroutines run until ctx is cancelled or
+// Stop is called.
+func (l *LoadBalancer) Start(ctx context.Context) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	if l.cancel != nil {
+		l.mu.Unlock()
+		return errors.New("load balancer already started")
+	}
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.ctx = ctx
+	if l.TaskQueue == nil {
+		l.TaskQueue = make(chan string)
+	}
+	queue := l.TaskQueue
+	l.mu.Unlock()
+
+	for _, s := range l.Servers {
+		s.Start()
+	}
+
+	if err := l.restoreServerStats(); err != nil {
+		l.logf("⚠️ Could not restore execution stats: %v", err)
+	}
+	if err := l.restorePolicy(); err != nil {
+		l.logf("⚠️ Could not restore load balancing policy: %v", err)
+	}
+	l.beginRun()
+	if l.store != nil {
+		l.wg.Add(1)
+		go l.statsFlushLoop(ctx)
+	}
+	l.wg.Add(1)
+	go l.housekeepingLoop(ctx)
 
+	l.wg.Add(1)
 	go func() {
-		for task := range l.TaskQueue {
-			server := l.GetServerForTask(task)
-			if server != nil {
-				server.RequestTask(task)
-			} else {
-				fmt.Printf("❌ No server can handle task: '%s'\n", task)
+		defer l.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case task := <-queue:
+				if server, _ := l.Dispatch(task); server == nil {
+					l.logf("❌ No server can handle task: '%s'", task)
+				}
 			}
 		}
 	}()
-	for i := range l.Servers {
-		go l.Servers[i].Start()
+
+	if l.TRINI != nil {
+		if err := l.TRINI.Start(ctx); err != nil {
+			l.Stop()
+			return err
+		}
+		// TRINI assigns every server the default family first
+		if err := l.restoreFamilyPins(); err != nil {
+			l.logf("⚠️ Could not restore family pins: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// Stop cancels all balancer goroutines (including TRINI) and waits for them to exit
+func (l *LoadBalancer) Stop() {
+	l.mu.Lock()
+	cancel := l.cancel
+	l.cancel = nil
+	l.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	if l.TRINI != nil {
+		l.TRINI.Stop()
+	}
+	l.AbortRollingUpgrade("load balancer stopped") // Its orchestrator is waited for below
+	l.wg.Wait()
+
+	if err := l.persistServerStats(); err != nil {
+		l.logf("⚠️ Could not persist execution stats: %v", err)
+	}
+	if l.store != nil {
+		if _, err := l.saveRunReport(true); err != nil {
+			l.logf("⚠️ Could not save run report: %v", err)
+		}
+	}
+}
+
+// statsFlushLoop periodically writes execution stats to storage
+func (l *LoadBalancer) statsFlushLoop(ctx context.Context) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(statsFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.persistServerStats(); err != nil {
+				l.logf("⚠️ Could not persist execution stats: %v", err)
+			}
+		}
 	}
 }
 
+// housekeepingLoop keeps the status snapshot fresh, flags starved servers,
+// expires family pins, reports SLO error budget changes and activates or
+// deactivates standby servers. It runs whether or not TRINI is active, so
+// pausing TRINI can stop its own loops.
+func (l *LoadBalancer) housekeepingLoop(ctx context.Context) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(housekeepingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.refreshStatus()
+			l.checkStarvation()
+			l.expireFamilyPins()
+			l.checkSLO()
+			l.checkStandby()
+		}
+	}
+}
+
+// spawn runs fn in a goroutine tracked by Stop
+func (l *LoadBalancer) spawn(fn func()) {
+	if l == nil {
+		go fn()
+		return
+	}
+
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		fn()
+	}()
+}
+
 // Legacy method for backward compatibility (when task size unknown)
 func (l *LoadBalancer) GetServer() *Server {
 	return l.GetServerForTask("")
@@ -27,37 +167,481 @@ func (l *LoadBalancer) GetServer() *Server {
 
 // New method that considers both availability and memory capacity
 func (l *LoadBalancer) GetServerForTask(taskInput string) *Server {
+	l.tasksSubmitted.Add(1)
+
+	server := l.selectServer(taskInput, l.GetLoadBalancingPolicy(), nil)
+	if server == nil {
+		l.recordRejection(RejectNoServer)
+	}
+	return server
+}
+
+// Dispatch selects a server for the task and submits it. The response's
+// ResultChan delivers the task result; a nil server means no server was
+// eligible and the task was rejected.
+func (l *LoadBalancer) Dispatch(taskInput string) (*Server, ServiceResponse) {
+	return l.DispatchLane(taskInput, LaneInteractive, false)
+}
+
+// DispatchExplained is Dispatch with the response's Explanation describing
+// how the server was chosen
+func (l *LoadBalancer) DispatchExplained(taskInput string) (*Server, ServiceResponse) {
+	return l.DispatchLane(taskInput, LaneInteractive, true)
+}
+
+// DispatchLane is Dispatch for a task in the given lane, see LaneBatch. The
+// chosen server rejects the task if that lane is full; hedges and retries
+// stay in the lane.
+func (l *LoadBalancer) DispatchLane(taskInput string, lane string, explained bool) (*Server, ServiceResponse) {
+	return l.dispatch(context.Background(), taskInput, lane, explained, 0, nil)
+}
+
+// DispatchLaneWithTimeout is DispatchLane for a task the server cancels once
+// it has run for timeout; 0 uses the policy's task_timeout_ms. A task waiting
+// for a server under the wait or block behaviors is rejected once ctx is done.
+func (l *LoadBalancer) DispatchLaneWithTimeout(ctx context.Context, taskInput string, lane string, explained bool, timeout time.Duration) (*Server, ServiceResponse) {
+	return l.dispatch(ctx, taskInput, lane, explained, timeout, nil)
+}
+
+// dispatch is DispatchLaneWithTimeout for a task that may belong to a batch
+// being spread across servers, see DispatchBatch
+func (l *LoadBalancer) dispatch(ctx context.Context, taskInput string, lane string, explained bool, timeout time.Duration, spread *batchSpread) (*Server, ServiceResponse) {
+	if !IsValidLane(lane) {
+		return nil, ServiceResponse{Status: "rejected", Message: fmt.Sprintf("Unknown lane %q", lane)}
+	}
+
+	start := l.now()
+	l.tasksSubmitted.Add(1)
+
+	policy, arm := l.policyForDispatch()
+	if timeout <= 0 {
+		timeout = time.Duration(policy.TaskTimeoutMs) * time.Millisecond
+	}
+
+	// Every task's trace keeps the decision, and recorded decisions need the
+	// random draws, which the explanation collects
+	explain := l.newSelectionExplanation(policy, arm)
+	explain.spread = spread
+	var reported *SelectionExplanation
+	if explained {
+		reported = explain
+	}
+	trace := TaskTrace{ID: l.newTaskID(), Lane: lane, TaskSize: len(taskInput), SubmittedAt: start, Decision: explain}
+
+	if !l.admitTask(policy) {
+		l.recordRejection(RejectAdmissionControl)
+		l.recordRolloutOutcome(arm, false, 0)
+		explain.fallback("Rejected by admission control")
+		response := ServiceResponse{
+			Status:      "rejected",
+			Message:     "Admission control: all servers have imminent MaGC",
+			TaskID:      trace.ID,
+			Explanation: reported,
+		}
+		l.traceTask(trace, &Task{Status: "rejected", Reason: RejectAdmissionControl})
+		return nil, response
+	}
+
+	retries := 0
+	if l.Flags.Enabled(FlagRetries) {
+		retries = maxTaskRetries
+	}
+
+	// Batch limits are not part of the snapshot, so batch decisions are
+	// neither recorded for replay nor shadowed
+	var record, shadowRecord *DecisionRecord
+	var shadow *shadowState
+	if l.recordsDecisions() && spread == nil {
+		record = l.decisionSnapshot(taskInput, policy, arm, retries)
+	}
+	if spread == nil {
+		shadowRecord, shadow = l.shadowSnapshot(taskInput, retries)
+	}
+	server, retries := l.selectWithRetries(taskInput, policy, explain, retries)
+	if spread.limitedSelection() && server == nil {
+		// Every server under its batch share was ineligible: exceed the
+		// share rather than reject the task
+		explain.spread = nil
+		server, retries = l.selectWithRetries(taskInput, policy, explain, retries)
+		if server != nil {
+			spread.placement.Overflow++
+			explain.fallback("No eligible server under its batch share, exceeded it")
+		}
+	}
+	var noServer *NoServerOutcome
+	if server == nil {
+		var outcome NoServerOutcome
+		server, outcome = l.awaitEligibleServer(ctx, taskInput, policy, explain)
+		noServer, trace.NoServer = &outcome, &outcome
+		if outcome.Found {
+			// The snapshots predate the wait, so neither replay nor the
+			// shadow policy could reach the same server
+			record, shadowRecord = nil, nil
+		}
+	}
+	spread.place(server)
+	if record != nil {
+		l.recordDecision(record, explain, server)
+	}
+	if shadowRecord != nil {
+		l.compareShadow(shadowRecord, shadow, server, policy.MaGCThreshold)
+	}
+	l.onSelect(taskInput, server, policy)
+	if server == nil {
+		reason, message := RejectNoServer, "No available server"
+		if l.allAtCapacity() {
+			reason, message = RejectAllAtCapacity, "All servers are at their concurrency limit"
+		}
+		l.recordRejection(reason)
+		l.recordRolloutOutcome(arm, false, 0)
+		l.traceTask(trace, &Task{Status: "rejected", Reason: reason})
+		return nil, ServiceResponse{
+			Status:      "rejected",
+			Message:     message,
+			TaskID:      trace.ID,
+			NoServer:    noServer,
+			Explanation: reported,
+		}
+	}
+
+	server.recordSelection()
+	if explain != nil {
+		explain.ServerID = server.ID
+	}
+
+	// Attempts still running once the task is answered are cancelled, so a
+	// losing hedge stops holding its server's memory and concurrency slot
+	attempts, cancelAttempts := context.WithCancel(context.Background())
+	response := l.sendAttempt(attempts, server, taskInput, lane, timeout)
+	response.TaskID = trace.ID
+	response.NoServer = noServer
+	response.Explanation = reported
+	response.GCImminentWindow = time.Duration(policy.GCImminentHeaderMs) * time.Millisecond
+	upstream := response.ResultChan
+	resultChan := make(chan *Task, 1)
+	response.ResultChan = resultChan
+
+	l.spawn(func() {
+		defer cancelAttempts()
+		result := l.awaitResult(attempts, taskInput, lane, policy, server, upstream, retries, timeout)
+		elapsed := l.now().Sub(start)
+		l.recordTaskOutcome(result, elapsed)
+		completed := result != nil && result.Status == "completed"
+		l.recordRolloutOutcome(arm, completed, elapsed)
+		if result != nil {
+			// Answer with the ID the trace is kept under, whichever attempt won
+			traced := *result
+			traced.ID = trace.ID
+			result = &traced
+		}
+		l.traceTask(trace, result)
+		resultChan <- result
+	})
+
+	return server, response
+}
+
+// maxTaskRetries bounds re-dispatches of a rejected task when retries are enabled
+const maxTaskRetries = 2
+
+// hedgeDelay is how long a task may run before a hedged duplicate is sent;
+// tests shorten it
+var hedgeDelay = 2 * time.Second
+
+// awaitResult waits for the first completed result, hedging slow tasks to a
+// second server and retrying rejected or timed out tasks when those feature
+// flags are on. Hedges and retries are sent under ctx, which the caller
+// cancels once the task is answered.
+func (l *LoadBalancer) awaitResult(ctx context.Context, taskInput string, lane string, policy LoadBalancingPolicy, server *Server, upstream chan *Task, retries int, timeout time.Duration) *Task {
+	// Room for the original attempt, one hedge and every retry so forwarders never block
+	results := make(chan *Task, 2+retries)
+	forward := func(ch chan *Task) {
+		l.spawn(func() { results <- <-ch })
+	}
+	forward(upstream)
+	pending := 1
+
+	var hedge <-chan time.Time
+	if l.Flags.Enabled(FlagHedging) {
+		timer := time.NewTimer(hedgeDelay)
+		defer timer.Stop()
+		hedge = timer.C
+	}
+
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			if backup := l.selectServer(taskInput, policy, nil); backup != nil && backup != server {
+				l.logf("Task '%s' slow on server %d, hedging to server %d", taskInput, server.ID, backup.ID)
+				forward(l.sendAttempt(ctx, backup, taskInput, lane, timeout).ResultChan)
+				pending++
+			}
+
+		case result := <-results:
+			pending--
+			if result != nil && result.Status == "completed" {
+				return result
+			}
+
+			if retries > 0 {
+				retries--
+				if retry := l.selectServer(taskInput, policy, nil); retry != nil {
+					l.logf("Task '%s' not completed, retrying on server %d", taskInput, retry.ID)
+					forward(l.sendAttempt(ctx, retry, taskInput, lane, timeout).ResultChan)
+					pending++
+					continue
+				}
+			}
+
+			if pending == 0 {
+				return result
+			}
+		}
+	}
+}
+
+// sendAttempt sends one execution of the task to the server; the original,
+// every hedge and every retry count as attempts of the one task
+func (l *LoadBalancer) sendAttempt(ctx context.Context, server *Server, taskInput string, lane string, timeout time.Duration) ServiceResponse {
+	l.taskAttempts.Add(1)
+	return server.requestTask(ctx, taskInput, lane, timeout)
+}
+
+// selectWithRetries runs selection passes until a server is found or the
+// retry budget is spent, and returns the remaining budget
+func (l *LoadBalancer) selectWithRetries(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation, retries int) (*Server, int) {
+	explain.attempt()
+	server := l.selectServer(taskInput, policy, explain)
+	for server == nil && retries > 0 {
+		retries--
+		explain.attempt()
+		server = l.selectServer(taskInput, policy, explain)
+	}
+	return server, retries
+}
+
+// selectServer picks a server using the given policy, recording the
+// decision in explain when it is non-nil
+func (l *LoadBalancer) selectServer(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
+	// Degraded mode ignores the policy altogether
+	if l.degraded() {
+		if explain != nil {
+			explain.Degraded = true
+		}
+		return l.getServerRoundRobin(taskInput, explain)
+	}
+
+	policy = l.placementPolicy(taskInput, policy)
+	if explain != nil {
+		explain.PlacementWindowMs = policy.MaGCThreshold
+	}
+
+	// An external policy engine decides first when one is configured
+	if server := l.selectExternal(taskInput, policy, explain); server != nil {
+		return server
+	}
+
+	// Cost-aware selection applies its own GC constraint
+	if policy.Algorithm == AlgorithmCost {
+		return l.selectCheapest(taskInput, policy, explain)
+	}
+
 	// If TRINI is active and policy is GC-aware, use GC-aware selection
-	if l.TRINI != nil && l.TRINI.IsActive && l.CurrentPolicy.GCAware {
-		return l.GetServerGCAware(taskInput)
+	if l.TRINI != nil && l.TRINI.IsActive && policy.GCAware {
+		return l.selectGCAware(taskInput, policy, explain)
 	}
 
 	// Otherwise use regular round-robin
-	return l.getServerRoundRobin(taskInput)
+	return l.getServerRoundRobin(taskInput, explain)
 }
 
 // getServerRoundRobin implements the original round-robin algorithm
-func (l *LoadBalancer) getServerRoundRobin(taskInput string) *Server {
+func (l *LoadBalancer) getServerRoundRobin(taskInput string, explain *SelectionExplanation) *Server {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
+	return l.getServerRoundRobinLocked(taskInput, explain)
+}
+
+// getServerRoundRobinLocked is getServerRoundRobin for callers already holding l.mu
+func (l *LoadBalancer) getServerRoundRobinLocked(taskInput string, explain *SelectionExplanation) *Server {
 	startIndex := l.currentServerIndex
+	explain.roundRobinFrom(startIndex)
 	for i := 0; i < len(l.Servers); i++ {
 		serverIndex := (startIndex + i) % len(l.Servers)
 		server := l.Servers[serverIndex]
 
 		// Check both availability and memory capacity
-		if server.IsAvailable() && server.CanHandleTaskSize(len(taskInput)) {
-			fmt.Printf("Server %d is available and can handle task (round-robin)\n", server.ID)
+		switch reason := l.ineligibleReason(server, taskInput, explain); reason {
+		case "":
+			l.logf("Server %d is available and can handle task (round-robin)", server.ID)
 			l.currentServerIndex = (serverIndex + 1) % len(l.Servers)
 			return server
-		} else if !server.IsAvailable() {
-			fmt.Printf("Server %d is busy/unavailable\n", server.ID)
-		} else {
-			fmt.Printf("Server %d is available but memory full\n", server.ID)
+		case SkipUnavailable:
+			l.logf("Server %d is busy/unavailable", server.ID)
+			explain.skip(server, reason)
+		default:
+			l.logf("Server %d is available but memory full", server.ID)
+			explain.skip(server, reason)
+		}
+	}
+
+	l.logf("No server can handle this task")
+	return nil
+}
+
+// Rejection reasons of tasks no server accepted; tasks a server rejects are
+// counted under its skip reason, see SkipUnavailable
+const (
+	RejectAdmissionControl = "admission_control" // Every server had an imminent MaGC
+	RejectNoServer         = "no_server"         // No server was eligible
+	RejectAllAtCapacity    = "all_at_capacity"   // Every server was at its concurrency limit
+)
+
+// Stats returns the aggregated task outcome counters
+func (l *LoadBalancer) Stats() LoadBalancerStats {
+	stats := LoadBalancerStats{
+		TasksSubmitted:   l.tasksSubmitted.Load(),
+		TaskAttempts:     l.taskAttempts.Load(),
+		TasksCompleted:   l.tasksCompleted.Load(),
+		TasksRejected:    l.tasksRejected.Load(),
+		TasksTimedOut:    l.tasksTimedOut.Load(),
+		RejectionReasons: make(map[string]int64),
+		GCAvoided:        l.gcAvoided.Load(),
+		GCHit:            l.gcHit.Load(),
+	}
+
+	l.rejectionsMu.Lock()
+	for reason, count := range l.rejections {
+		stats.RejectionReasons[reason] = count
+	}
+	l.rejectionsMu.Unlock()
+	return stats
+}
+
+// recordTaskOutcome counts a dispatched task once, under the outcome of the
+// attempt that answered it, however many hedges and retries it took
+func (l *LoadBalancer) recordTaskOutcome(task *Task, elapsed time.Duration) {
+	switch {
+	case task == nil:
+		return
+	case task.Status == "completed":
+		l.recordTaskCompleted(elapsed)
+	case task.Status == TaskStatusTimeout:
+		l.recordTaskTimeout()
+	default:
+		l.recordRejection(task.Reason)
+	}
+}
+
+// recordTaskCompleted counts a task completed after elapsed
+func (l *LoadBalancer) recordTaskCompleted(elapsed time.Duration) {
+	if l == nil {
+		return
+	}
+	l.tasksCompleted.Add(1)
+	l.recordSLOOutcome(true, l.isSlow(elapsed), false)
+}
+
+// recordRejection counts a rejected task under its reason
+func (l *LoadBalancer) recordRejection(reason string) {
+	if l == nil {
+		return
+	}
+	l.tasksRejected.Add(1)
+	l.recordSLOOutcome(false, false, true)
+
+	l.rejectionsMu.Lock()
+	defer l.rejectionsMu.Unlock()
+	if l.rejections == nil {
+		l.rejections = make(map[string]int64)
+	}
+	l.rejections[reason]++
+}
+
+// ListServers returns a snapshot of the current server pool
+func (l *LoadBalancer) ListServers() []*Server {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	servers := make([]*Server, len(l.Servers))
+	copy(servers, l.Servers)
+	return servers
+}
+
+// GetServerByID returns the server with the given ID, or nil if it is not in the pool
+func (l *LoadBalancer) GetServerByID(id int) *Server {
+	for _, server := range l.ListServers() {
+		if server.ID == id {
+			return server
 		}
 	}
+	return nil
+}
+
+// AddServer creates a new server, prepares it for TRINI and adds it to the pool
+func (l *LoadBalancer) AddServer(memLimit int, gcPercentage float64) (*Server, error) {
+	return l.addServer(0, memLimit, gcPercentage)
+}
+
+// addServer is AddServer with the server's ID chosen by the caller; 0 picks
+// the next free one
+func (l *LoadBalancer) addServer(id int, memLimit int, gcPercentage float64) (*Server, error) {
+	if err := validateServerConfig(memLimit, gcPercentage/100.0); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	nextID := 1
+	for _, s := range l.Servers {
+		if s.ID == id {
+			l.mu.Unlock()
+			return nil, fmt.Errorf("server %d already exists", id)
+		}
+		if s.ID >= nextID {
+			nextID = s.ID + 1
+		}
+	}
+	if id > 0 {
+		nextID = id
+	}
+	server := NewServer(nextID, memLimit, gcPercentage)
+	server.attach(l)
+	server.Start()
+	if l.TRINI != nil {
+		server.initializeTRINI(l.TRINI.DefaultFamily)
+	}
+	l.Servers = append(l.Servers, server)
+	l.mu.Unlock()
+
+	l.refreshStatus()
+	l.RecordEvent("server_added", server.ID, "Server %d added to the pool", server.ID)
+	return server, nil
+}
+
+// RemoveServer removes the server with the given ID from the pool
+func (l *LoadBalancer) RemoveServer(id int) error {
+	l.mu.Lock()
+	index := -1
+	for i, s := range l.Servers {
+		if s.ID == id {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		l.mu.Unlock()
+		return fmt.Errorf("server %d not found", id)
+	}
+
+	l.Servers = append(l.Servers[:index], l.Servers[index+1:]...)
+	if l.currentServerIndex >= len(l.Servers) {
+		l.currentServerIndex = 0
+	}
+	l.mu.Unlock()
 
-	fmt.Println("No server can handle this task")
+	l.refreshStatus()
+	l.RecordEvent("server_removed", id, "Server %d removed from the pool", id)
 	return nil
 }
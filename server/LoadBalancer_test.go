@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestTaskOutcomesCountOncePerTask(t *testing.T) {
+	defer func(delay time.Duration) { hedgeDelay = delay }(hedgeDelay)
+	hedgeDelay = 100 * time.Millisecond
+
+	tests := []struct {
+		name          string
+		flag          string
+		timeout       time.Duration
+		want          LoadBalancerStats
+		wantCompleted int64 // Executions the servers completed
+		wantCancelled int   // Attempts cancelled once another answered
+	}{
+		{"retried past the timeout", FlagRetries, 50 * time.Millisecond,
+			LoadBalancerStats{TasksSubmitted: 1, TaskAttempts: 1 + maxTaskRetries, TasksTimedOut: 1}, 0, 0},
+		{"hedged", FlagHedging, 0,
+			LoadBalancerStats{TasksSubmitted: 1, TaskAttempts: 2, TasksCompleted: 1}, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			servers := []*Server{NewServer(1, 100, 80), NewServer(2, 100, 80)}
+			lb := NewLoadBalancer(WithServers(servers...), WithLogger(log.New(io.Discard, "", 0)))
+			if err := lb.Flags.Set(tt.flag, true); err != nil {
+				t.Fatal(err)
+			}
+
+			_, resp := lb.DispatchLaneWithTimeout(context.Background(), "payload", LaneInteractive, false, tt.timeout)
+			<-resp.ResultChan
+			lb.wg.Wait()
+
+			stats := lb.Stats()
+			if stats.TasksSubmitted != tt.want.TasksSubmitted || stats.TaskAttempts != tt.want.TaskAttempts ||
+				stats.TasksCompleted != tt.want.TasksCompleted || stats.TasksRejected != tt.want.TasksRejected ||
+				stats.TasksTimedOut != tt.want.TasksTimedOut {
+				t.Errorf("stats %+v, want %+v", stats, tt.want)
+			}
+
+			var used int
+			var completed int64
+			var cancelled int
+			for _, server := range servers {
+				server.mu.Lock()
+				used += server.usedMemory
+				completed += server.execTotals.TasksCompleted
+				for _, entry := range server.timeline {
+					if entry.Kind == TimelineCancelled {
+						cancelled++
+					}
+				}
+				server.mu.Unlock()
+			}
+			if want := int(tt.wantCompleted) * len("payload"); used != want {
+				t.Errorf("servers hold %d bytes, want %d: a cancelled attempt kept its memory", used, want)
+			}
+			if completed != tt.wantCompleted || cancelled != tt.wantCancelled {
+				t.Errorf("servers completed %d and cancelled %d attempts, want %d and %d",
+					completed, cancelled, tt.wantCompleted, tt.wantCancelled)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// longForecastHorizon is how far ahead the long-horizon forecast predicts MaGCs
+	longForecastHorizon = 10 * time.Minute
+	// longForecastEvents is how many recent MaGCs the cadence is measured over
+	longForecastEvents = 20
+	// longForecastValidity is how long a long-horizon forecast is trusted after it was made
+	longForecastValidity = 2 * time.Minute
+	// longForecastMaxTimes bounds the predicted MaGCs listed for fast-collecting servers
+	longForecastMaxTimes = 100
+)
+
+// LongHorizonForecast predicts a server's MaGCs minutes ahead from the cadence
+// of its past collections. Selection uses the short-horizon MaGCForecast; this
+// one feeds autoscaling and GC scheduling.
+type LongHorizonForecast struct {
+	NextMaGC          time.Time   `json:"next_magc"`
+	PredictedTimes    []time.Time `json:"predicted_times"` // MaGC starts expected within the horizon, oldest first
+	IntervalMs        int64       `json:"interval_ms"`     // Mean time between MaGC starts
+	ExpectedPauseMs   int64       `json:"expected_pause_ms"`
+	Confidence        float64     `json:"confidence"`
+	HorizonEnd        time.Time   `json:"horizon_end"`
+	ForecastCreatedAt time.Time   `json:"forecast_created_at"`
+}
+
+// generateLongHorizonForecastLocked projects the server's MaGC cadence over the
+// long horizon; callers hold s.mu. It needs at least three completed MaGCs.
+func (s *Server) generateLongHorizonForecastLocked(now time.Time) *LongHorizonForecast {
+	events := s.GCEvents
+	if len(events) > longForecastEvents {
+		events = events[len(events)-longForecastEvents:]
+	}
+	if len(events) < 3 {
+		return nil
+	}
+
+	intervals := make([]float64, 0, len(events)-1)
+	var mean float64
+	for i := 1; i < len(events); i++ {
+		interval := float64(events[i].StartTime.Sub(events[i-1].StartTime).Milliseconds())
+		intervals = append(intervals, interval)
+		mean += interval
+	}
+	mean /= float64(len(intervals))
+	if mean <= 0 {
+		return nil
+	}
+
+	var variance float64
+	for _, interval := range intervals {
+		variance += (interval - mean) * (interval - mean)
+	}
+	variance /= float64(len(intervals))
+
+	// Regular cadence and more samples both raise confidence
+	regularity := max(0, 1-math.Sqrt(variance)/mean)
+	samples := min(float64(len(intervals))/10, 1)
+
+	interval := time.Duration(mean) * time.Millisecond
+	forecast := &LongHorizonForecast{
+		IntervalMs:        interval.Milliseconds(),
+		ExpectedPauseMs:   s.expectedPauseLocked().Milliseconds(),
+		Confidence:        min(max(regularity*samples, 0), 1),
+		HorizonEnd:        now.Add(longForecastHorizon),
+		ForecastCreatedAt: now,
+		PredictedTimes:    make([]time.Time, 0),
+	}
+
+	// An overdue MaGC is expected any moment now
+	next := events[len(events)-1].StartTime.Add(interval)
+	if next.Before(now) {
+		next = now
+	}
+	forecast.NextMaGC = next
+	for at := next; !at.After(forecast.HorizonEnd) && len(forecast.PredictedTimes) < longForecastMaxTimes; at = at.Add(interval) {
+		forecast.PredictedTimes = append(forecast.PredictedTimes, at)
+	}
+	return forecast
+}
+
+// LongHorizonForecast returns a copy of the server's long-horizon forecast, or
+// nil if it has none that is still valid
+func (s *Server) LongHorizonForecast() *LongHorizonForecast {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	forecast := s.longForecastLocked()
+	if forecast == nil {
+		return nil
+	}
+	copied := *forecast
+	copied.PredictedTimes = append([]time.Time(nil), forecast.PredictedTimes...)
+	return &copied
+}
+
+// longForecastLocked returns the long-horizon forecast if it is still valid; callers hold s.mu
+func (s *Server) longForecastLocked() *LongHorizonForecast {
+	forecast := s.LongMaGCForecast
+	if forecast == nil || s.now().Sub(forecast.ForecastCreatedAt) > longForecastValidity {
+		return nil
+	}
+	return forecast
+}
+
+// isMaGCExpected reports whether a MaGC is due within thresholdMs. The
+// short-horizon forecast decides when it is valid; otherwise the long-horizon
+// forecast's next MaGC is used.
+func (s *Server) isMaGCExpected(thresholdMs int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timeToMaGC, ok := s.timeToMaGCLocked(); ok {
+		return timeToMaGC.Milliseconds() <= thresholdMs
+	}
+	forecast := s.longForecastLocked()
+	return forecast != nil && forecast.NextMaGC.Sub(s.now()).Milliseconds() <= thresholdMs
+}
@@ -0,0 +1,101 @@
+package server
+
+import "fmt"
+
+// Memory pressure modes decide what happens when a task does not fit in a
+// server's free memory. Servers only report the failed fit; the balancer
+// applies the policy.
+const (
+	MemoryPressureCollect = "collect" // Collect in the background when that would let the task fit (default)
+	MemoryPressureWait    = "wait"    // Leave collection to the occupancy trigger, forecasts and prefetching
+)
+
+// IsValidMemoryPressure reports whether name is a supported memory pressure mode
+func IsValidMemoryPressure(name string) bool {
+	return name == MemoryPressureCollect || name == MemoryPressureWait
+}
+
+// MemoryPressurePolicy decides when an allocation failure triggers a MaGC
+type MemoryPressurePolicy struct {
+	Mode string `json:"mode"`
+	// Collect only when at least this share of memory is in use, so a mostly
+	// empty heap is not paused for a single large task
+	MinUtilization float64 `json:"min_utilization"`
+}
+
+// DefaultMemoryPressurePolicy collects whenever a task does not fit
+func DefaultMemoryPressurePolicy() MemoryPressurePolicy {
+	return MemoryPressurePolicy{Mode: MemoryPressureCollect}
+}
+
+// Validate checks the mode and utilization bound
+func (p MemoryPressurePolicy) Validate() error {
+	if !IsValidMemoryPressure(p.Mode) {
+		return fmt.Errorf("invalid memory pressure mode %q: use collect or wait", p.Mode)
+	}
+	if p.MinUtilization < 0 || p.MinUtilization > 1 {
+		return fmt.Errorf("minimum utilization must be between 0 and 1, got %g", p.MinUtilization)
+	}
+	return nil
+}
+
+// SetMemoryPressurePolicy changes how allocation failures are handled
+func (l *LoadBalancer) SetMemoryPressurePolicy(policy MemoryPressurePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	l.memoryPressure.Store(&policy)
+	return nil
+}
+
+// MemoryPressurePolicy returns how allocation failures are handled
+func (l *LoadBalancer) MemoryPressurePolicy() MemoryPressurePolicy {
+	if policy := l.memoryPressure.Load(); policy != nil {
+		return *policy
+	}
+	return DefaultMemoryPressurePolicy()
+}
+
+// ineligibleReason is Server.ineligibleReason with allocation failures passed
+// to the memory pressure policy and the limits of the task's batch applied.
+// Selection may hold l.mu.
+func (l *LoadBalancer) ineligibleReason(server *Server, taskInput string, explain *SelectionExplanation) string {
+	reason := server.ineligibleReason(taskInput, explain)
+	switch reason {
+	case SkipUnavailable:
+		l.gcAvoided.Add(1)
+	case SkipInsufficientMemory:
+		l.allocationFailed(server, len(taskInput))
+	}
+	if reason == "" {
+		reason = explain.spreadReason(server)
+	}
+	return reason
+}
+
+// allocationFailed starts a background MaGC on the server when the memory
+// pressure policy calls for one
+func (l *LoadBalancer) allocationFailed(server *Server, taskSize int) {
+	policy := l.MemoryPressurePolicy()
+	if policy.Mode == MemoryPressureWait || !server.collectsForAllocation(taskSize, policy.MinUtilization) {
+		return
+	}
+
+	l.logf("🧹 Server %d: task of size %d does not fit, collecting", server.ID, taskSize)
+	l.spawn(server.CollectGCTasks)
+}
+
+// collectsForAllocation reports whether a MaGC would let a task of taskSize
+// fit and the heap is at least minUtilization full
+func (s *Server) collectsForAllocation(taskSize int, minUtilization float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.replay || s.isCollectingGCTasks || s.usedMemory == 0 {
+		return false
+	}
+	if taskSize > s.memLimit {
+		return false // Does not fit even in an empty heap
+	}
+	return float64(s.usedMemory) >= float64(s.memLimit)*minUtilization
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// Operating modes. Degraded mode is a kill switch for the adaptive machinery:
+// selection falls back to plain round-robin, TRINI stops collecting snapshots
+// and computing forecasts, and forecast-driven extras (admission control, the
+// policy engine, shadowing, GC prefetching) are bypassed.
+const (
+	ModeNormal   = "normal"
+	ModeDegraded = "degraded"
+)
+
+// IsValidMode reports whether mode is a known operating mode
+func IsValidMode(mode string) bool {
+	return mode == ModeNormal || mode == ModeDegraded
+}
+
+// OperatingMode is the balancer's operating mode and why it was entered
+type OperatingMode struct {
+	Mode   string    `json:"mode"`
+	Reason string    `json:"reason,omitempty"`
+	Since  time.Time `json:"since"`
+}
+
+// SetMode switches the operating mode and records the change as an event
+func (l *LoadBalancer) SetMode(mode, reason string) error {
+	if !IsValidMode(mode) {
+		return fmt.Errorf("invalid mode %q: use normal or degraded", mode)
+	}
+
+	previous := l.Mode()
+	l.mode.Store(&OperatingMode{Mode: mode, Reason: reason, Since: l.now()})
+	if previous.Mode != mode {
+		if reason == "" {
+			reason = "no reason given"
+		}
+		l.RecordEvent("mode_changed", 0, "Switched from %s to %s mode: %s", previous.Mode, mode, reason)
+	}
+	return nil
+}
+
+// Mode returns the current operating mode
+func (l *LoadBalancer) Mode() OperatingMode {
+	if mode := l.mode.Load(); mode != nil {
+		return *mode
+	}
+	return OperatingMode{Mode: ModeNormal}
+}
+
+// degraded reports whether the adaptive machinery is switched off
+func (l *LoadBalancer) degraded() bool {
+	mode := l.mode.Load()
+	return mode != nil && mode.Mode == ModeDegraded
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// No-server behaviors decide what happens to a task when selection finds no
+// eligible server
+const (
+	NoServerFailFast = "fail_fast" // Reject the task at once (default)
+	NoServerWait     = "wait"      // Wait for the earliest forecast MaGC to finish and select again, up to the deadline
+	NoServerBlock    = "block"     // Keep selecting until a server frees up or the deadline passes, with bounded capacity
+)
+
+const (
+	// defaultNoServerWait is the deadline of waiting and blocking tasks when
+	// the policy sets none
+	defaultNoServerWait = 2 * time.Second
+
+	// defaultBlockCapacity is how many tasks may block at once when the
+	// policy sets no capacity
+	defaultBlockCapacity = 20
+
+	// blockPollInterval is how often a blocked task selects again
+	blockPollInterval = 250 * time.Millisecond
+
+	// gcEndMargin is how long after a forecast MaGC end a waiting task selects
+	// again, so the server has returned to rotation
+	gcEndMargin = 50 * time.Millisecond
+)
+
+// IsValidNoServerBehavior reports whether name is a supported no-server
+// behavior; empty means NoServerFailFast
+func IsValidNoServerBehavior(name string) bool {
+	return name == "" || name == NoServerFailFast || name == NoServerWait || name == NoServerBlock
+}
+
+// NoServerOutcome reports how a task fared after selection first found no
+// eligible server
+type NoServerOutcome struct {
+	Behavior string `json:"behavior"` // fail_fast, wait or block
+	WaitedMs int64  `json:"waited_ms"`
+	Found    bool   `json:"found"`            // A server became eligible before the deadline
+	Reason   string `json:"reason,omitempty"` // Why waiting ended without a server
+}
+
+// noServerDeadline is how long the policy lets a task wait or block
+func (p LoadBalancingPolicy) noServerDeadline() time.Duration {
+	if p.NoServerWaitMs > 0 {
+		return time.Duration(p.NoServerWaitMs) * time.Millisecond
+	}
+	return defaultNoServerWait
+}
+
+// blockCapacity is how many tasks the policy lets block at once
+func (p LoadBalancingPolicy) blockCapacity() int64 {
+	if p.BlockCapacity > 0 {
+		return int64(p.BlockCapacity)
+	}
+	return defaultBlockCapacity
+}
+
+// awaitEligibleServer applies the policy's no-server behavior to a task
+// selection found no server for. It returns the server selected once one
+// became eligible, or nil if the task is to be rejected. Waiting ends early
+// when ctx is done or the balancer stops.
+func (l *LoadBalancer) awaitEligibleServer(ctx context.Context, taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) (*Server, NoServerOutcome) {
+	switch policy.OnNoServer {
+	case NoServerWait:
+		ctx, cancel := l.withLifetime(ctx)
+		defer cancel()
+		return l.waitForGCEnd(ctx, taskInput, policy, explain)
+	case NoServerBlock:
+		ctx, cancel := l.withLifetime(ctx)
+		defer cancel()
+		return l.blockForServer(ctx, taskInput, policy, explain)
+	default:
+		return nil, NoServerOutcome{Behavior: NoServerFailFast}
+	}
+}
+
+// lifetime returns a context done once the balancer stops; it never ends for
+// a balancer that was not started or a detached server
+func (l *LoadBalancer) lifetime() context.Context {
+	if l == nil {
+		return context.Background()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ctx == nil {
+		return context.Background()
+	}
+	return l.ctx
+}
+
+// withLifetime returns a context that is also done once the balancer stops
+func (l *LoadBalancer) withLifetime(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(l.lifetime(), cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// sleepCtx sleeps for d, returning false if ctx is done first
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// waitForGCEnd sleeps until the earliest forecast or in-progress MaGC should
+// have finished and selects again, for as long as one ends before the deadline
+func (l *LoadBalancer) waitForGCEnd(ctx context.Context, taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) (*Server, NoServerOutcome) {
+	start := l.now()
+	deadline := start.Add(policy.noServerDeadline())
+	outcome := NoServerOutcome{Behavior: NoServerWait}
+
+	for {
+		now := l.now()
+		end, ok := l.nextGCEnd(now)
+		if !ok || end.Add(gcEndMargin).After(deadline) {
+			outcome.Reason = "No MaGC forecast to finish before the deadline"
+			break
+		}
+
+		if !sleepCtx(ctx, end.Add(gcEndMargin).Sub(now)) {
+			outcome.Reason = "Cancelled while waiting for a MaGC to finish"
+			break
+		}
+		explain.attempt()
+		if server := l.selectServer(taskInput, policy, explain); server != nil {
+			outcome.Found = true
+			outcome.WaitedMs = l.now().Sub(start).Milliseconds()
+			explain.fallback(fmt.Sprintf("No eligible server, waited %dms for a MaGC to finish", outcome.WaitedMs))
+			return server, outcome
+		}
+	}
+
+	outcome.WaitedMs = l.now().Sub(start).Milliseconds()
+	return nil, outcome
+}
+
+// nextGCEnd returns when the earliest in-progress or short-horizon forecast
+// MaGC still running after now should finish
+func (l *LoadBalancer) nextGCEnd(now time.Time) (time.Time, bool) {
+	var earliest time.Time
+	for _, window := range l.shortPauseWindows(l.ListServers(), now) {
+		if window.End.After(now) && (earliest.IsZero() || window.End.Before(earliest)) {
+			earliest = window.End
+		}
+	}
+	return earliest, !earliest.IsZero()
+}
+
+// blockForServer selects again every blockPollInterval until a server is
+// eligible or the deadline passes. Tasks beyond the policy's block capacity
+// are rejected at once rather than piling up.
+func (l *LoadBalancer) blockForServer(ctx context.Context, taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) (*Server, NoServerOutcome) {
+	outcome := NoServerOutcome{Behavior: NoServerBlock}
+	if l.blockedTasks.Add(1) > policy.blockCapacity() {
+		l.blockedTasks.Add(-1)
+		outcome.Reason = "Block capacity full"
+		return nil, outcome
+	}
+	defer l.blockedTasks.Add(-1)
+
+	start := l.now()
+	deadline := start.Add(policy.noServerDeadline())
+	for l.now().Add(blockPollInterval).Before(deadline) {
+		if !sleepCtx(ctx, blockPollInterval) {
+			outcome.WaitedMs = l.now().Sub(start).Milliseconds()
+			outcome.Reason = "Cancelled while blocked for a server"
+			return nil, outcome
+		}
+		explain.attempt()
+		if server := l.selectServer(taskInput, policy, explain); server != nil {
+			outcome.Found = true
+			outcome.WaitedMs = l.now().Sub(start).Milliseconds()
+			explain.fallback(fmt.Sprintf("No eligible server, blocked %dms until one freed up", outcome.WaitedMs))
+			return server, outcome
+		}
+	}
+
+	outcome.WaitedMs = l.now().Sub(start).Milliseconds()
+	outcome.Reason = "No server became eligible before the deadline"
+	return nil, outcome
+}
+
+// BlockedTasks returns how many tasks are blocked waiting for a server
+func (l *LoadBalancer) BlockedTasks() int64 {
+	return l.blockedTasks.Load()
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// newNoServerTestBalancer returns a balancer whose only server is paused, or
+// in a MaGC of pauseMs when that is set, so selection finds nothing eligible
+// until the returned resume is called
+func newNoServerTestBalancer(t *testing.T, pauseMs int64) (*LoadBalancer, func()) {
+	t.Helper()
+	server := NewServer(1, 100, 80)
+	lb := NewLoadBalancer(WithServers(server), WithLogger(log.New(io.Discard, "", 0)))
+	if pauseMs > 0 {
+		server.mu.Lock()
+		server.GCEvents = []GCEvent{{DurationMs: pauseMs}}
+		server.isCollectingGCTasks, server.gcStartedAt = true, time.Now()
+		server.mu.Unlock()
+		return lb, func() {
+			server.mu.Lock()
+			server.isCollectingGCTasks = false
+			server.mu.Unlock()
+		}
+	}
+
+	if err := server.SetAdminState(StatePaused); err != nil {
+		t.Fatal(err)
+	}
+	return lb, func() { server.SetAdminState(StateActive) }
+}
+
+func TestNoServerOutcomes(t *testing.T) {
+	tests := []struct {
+		name      string
+		behavior  string
+		pauseMs   int64         // Length of the server's MaGC; 0 pauses the server instead
+		blocked   int64         // Tasks already blocked
+		resume    time.Duration // When the server is resumed; 0 leaves it paused
+		cancel    time.Duration // When the caller gives up; 0 never
+		wantFound bool
+		want      string
+		maxWait   time.Duration
+	}{
+		{"fail fast", NoServerFailFast, 0, 0, 0, 0, false, "", 50 * time.Millisecond},
+		{"wait without a MaGC", NoServerWait, 0, 0, 0, 0, false, "No MaGC forecast to finish before the deadline", 50 * time.Millisecond},
+		{"wait for a MaGC past the deadline", NoServerWait, 10000, 0, 0, 0, false, "No MaGC forecast to finish before the deadline", 50 * time.Millisecond},
+		{"wait for a MaGC to finish", NoServerWait, 300, 0, 250 * time.Millisecond, 0, true, "", 1000 * time.Millisecond},
+		{"wait cancelled by the caller", NoServerWait, 300, 0, 0, 100 * time.Millisecond, false, "Cancelled while waiting for a MaGC to finish", 500 * time.Millisecond},
+		{"block until resumed", NoServerBlock, 0, 0, 300 * time.Millisecond, 0, true, "", 1500 * time.Millisecond},
+		{"block past the deadline", NoServerBlock, 0, 0, 0, 0, false, "No server became eligible before the deadline", 1500 * time.Millisecond},
+		{"block cancelled by the caller", NoServerBlock, 0, 0, 0, 100 * time.Millisecond, false, "Cancelled while blocked for a server", 500 * time.Millisecond},
+		{"block capacity full", NoServerBlock, 0, defaultBlockCapacity, 0, 0, false, "Block capacity full", 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb, resume := newNoServerTestBalancer(t, tt.pauseMs)
+			lb.blockedTasks.Store(tt.blocked)
+			policy := lb.GetLoadBalancingPolicy()
+			policy.OnNoServer = tt.behavior
+			policy.NoServerWaitMs = 1000
+
+			if tt.resume > 0 {
+				time.AfterFunc(tt.resume, resume)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.cancel > 0 {
+				time.AfterFunc(tt.cancel, cancel)
+			}
+
+			start := time.Now()
+			got, outcome := lb.awaitEligibleServer(ctx, "task", policy, lb.newSelectionExplanation(policy, ""))
+			elapsed := time.Since(start)
+
+			if (got != nil) != tt.wantFound || outcome.Found != tt.wantFound {
+				t.Errorf("found server %v (outcome %v), want %v", got != nil, outcome.Found, tt.wantFound)
+			}
+			if outcome.Behavior != tt.behavior || outcome.Reason != tt.want {
+				t.Errorf("outcome %s: %q, want %s: %q", outcome.Behavior, outcome.Reason, tt.behavior, tt.want)
+			}
+			if elapsed > tt.maxWait {
+				t.Errorf("took %v, want at most %v", elapsed, tt.maxWait)
+			}
+			if blocked := lb.BlockedTasks(); blocked != tt.blocked {
+				t.Errorf("%d tasks left blocked, want %d", blocked, tt.blocked)
+			}
+		})
+	}
+}
+
+func TestNoServerBlockEndsOnStop(t *testing.T) {
+	lb, _ := newNoServerTestBalancer(t, 0)
+	if err := lb.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	policy := lb.GetLoadBalancingPolicy()
+	policy.OnNoServer = NoServerBlock
+	policy.NoServerWaitMs = 3000
+
+	done := make(chan NoServerOutcome, 1)
+	go func() {
+		_, outcome := lb.awaitEligibleServer(context.Background(), "task", policy, nil)
+		done <- outcome
+	}()
+	time.Sleep(100 * time.Millisecond)
+	lb.Stop()
+
+	select {
+	case outcome := <-done:
+		if outcome.Reason != "Cancelled while blocked for a server" {
+			t.Errorf("blocked task ended with %q", outcome.Reason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked task still waiting after the balancer stopped")
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Clock abstracts the time source used by the balancer and its servers
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default Clock backed by the system time
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// defaultLogger mirrors the previous stdout output of the package
+var defaultLogger = log.New(os.Stdout, "", 0)
+
+// Option configures a LoadBalancer created by NewLoadBalancer
+type Option func(*LoadBalancer)
+
+// WithServers attaches the given servers to the load balancer
+func WithServers(servers ...*Server) Option {
+	return func(l *LoadBalancer) {
+		l.Servers = append(l.Servers, servers...)
+	}
+}
+
+// WithPolicy sets the initial load balancing policy
+func WithPolicy(policy LoadBalancingPolicy) Option {
+	return func(l *LoadBalancer) {
+		l.CurrentPolicy = policy
+	}
+}
+
+// WithTRINI uses an existing TRINI instance instead of creating a new one
+func WithTRINI(trini *TRINI) Option {
+	return func(l *LoadBalancer) {
+		l.TRINI = trini
+	}
+}
+
+// WithClock overrides the time source (useful for deterministic tests)
+func WithClock(clock Clock) Option {
+	return func(l *LoadBalancer) {
+		l.clock = clock
+	}
+}
+
+// WithLogger redirects the balancer and server log output
+func WithLogger(logger *log.Logger) Option {
+	return func(l *LoadBalancer) {
+		l.logger = logger
+	}
+}
+
+// WithFeatureFlags uses an existing feature flag registry
+func WithFeatureFlags(flags *FeatureFlags) Option {
+	return func(l *LoadBalancer) {
+		l.Flags = flags
+	}
+}
+
+// WithStore persists execution statistics, family pins, run reports, GC
+// history, the policy and selection decisions in the given store
+func WithStore(store Store) Option {
+	return func(l *LoadBalancer) {
+		l.store = store
+	}
+}
+
+// WithStorage persists state in a storage directory.
+//
+// Deprecated: use WithStore, which also accepts other Store implementations.
+func WithStorage(storage *Storage) Option {
+	return WithStore(storage)
+}
+
+// WithHooks registers callbacks for selection, dispatch, completion and MaGCs
+func WithHooks(hooks Hooks) Option {
+	return func(l *LoadBalancer) {
+		l.hooks = append(l.hooks, hooks)
+	}
+}
+
+// WithDecisionLog records every selection decision for later replay
+func WithDecisionLog(decisions *DecisionLog) Option {
+	return func(l *LoadBalancer) {
+		l.decisions = decisions
+	}
+}
+
+// WithPolicyEngine consults the external policy engine before the local
+// selection algorithm
+func WithPolicyEngine(engine *PolicyEngine) Option {
+	return func(l *LoadBalancer) {
+		l.policyEngine = engine
+	}
+}
+
+// WithGCPrefetchWindow sets how soon a forecast MaGC must be due for the
+// gc_prefetch feature flag to run it early on an idle server
+func WithGCPrefetchWindow(window time.Duration) Option {
+	return func(l *LoadBalancer) {
+		l.gcPrefetchWindow = window
+	}
+}
+
+// WithWarmup runs warm-up tasks on each server after its MaGC, keeping it out
+// of rotation meanwhile, then ramps its traffic back up. An invalid config is
+// ignored.
+func WithWarmup(config WarmupConfig) Option {
+	return func(l *LoadBalancer) {
+		if config.Validate() == nil {
+			l.warmup = config
+		}
+	}
+}
+
+// WithMemoryPressurePolicy sets when a task that does not fit triggers a MaGC.
+// An invalid policy is ignored in favor of the default.
+func WithMemoryPressurePolicy(policy MemoryPressurePolicy) Option {
+	return func(l *LoadBalancer) {
+		if policy.Validate() == nil {
+			l.memoryPressure.Store(&policy)
+		}
+	}
+}
+
+// WithStandby sets when standby servers are activated under GC pressure. An
+// invalid config is ignored in favor of the default, which never activates them.
+func WithStandby(config StandbyConfig) Option {
+	return func(l *LoadBalancer) {
+		if config.Validate() == nil {
+			l.standby.Store(&config)
+		}
+	}
+}
+
+// WithSLO sets the objective task outcomes are measured against. An invalid
+// objective is ignored in favor of the default.
+func WithSLO(config SLOConfig) Option {
+	return func(l *LoadBalancer) {
+		if config.Validate() == nil {
+			l.sloConfig.Store(&config)
+		}
+	}
+}
+
+// NewLoadBalancer creates a fully initialized load balancer.
+// Unless overridden, TRINI is created with its default families and the
+// initial policy is taken from the default family.
+func NewLoadBalancer(opts ...Option) *LoadBalancer {
+	l := &LoadBalancer{
+		Servers:   make([]*Server, 0),
+		TaskQueue: make(chan string),
+		clock:     realClock{},
+		logger:    defaultLogger,
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	if l.TRINI == nil {
+		l.TRINI = NewTRINI()
+	}
+	if l.Flags == nil {
+		l.Flags = NewFeatureFlags()
+	}
+	if l.Durations == nil {
+		l.Durations = NewDurationEstimator()
+	}
+	l.TRINI.lb = l
+	l.mode.Store(&OperatingMode{Mode: ModeNormal, Since: l.now()})
+	l.taskIDEpoch = l.now().Unix()
+	if l.CurrentPolicy.Algorithm == "" {
+		l.CurrentPolicy = l.TRINI.DefaultFamily.Policy
+	}
+
+	for _, s := range l.Servers {
+		s.attach(l)
+	}
+
+	return l
+}
+
+// attach binds a server to its load balancer and fills in defaults
+func (s *Server) attach(l *LoadBalancer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.LoadBalancer = l
+	if s.TaskStorage == nil {
+		s.TaskStorage = make([]string, 0)
+	}
+	if s.Cost == 0 {
+		s.Cost = defaultServerCost
+	}
+	s.refreshWeightLocked()
+}
+
+// now returns the current time from the configured clock
+func (l *LoadBalancer) now() time.Time {
+	if l == nil || l.clock == nil {
+		return time.Now()
+	}
+	return l.clock.Now()
+}
+
+// logf writes a log line through the configured logger
+func (l *LoadBalancer) logf(format string, args ...interface{}) {
+	if l == nil || l.logger == nil {
+		defaultLogger.Printf(format, args...)
+		return
+	}
+	l.logger.Printf(format, args...)
+}
+
+// now returns the current time from the owning load balancer's clock
+func (s *Server) now() time.Time {
+	return s.LoadBalancer.now()
+}
+
+// logf writes a log line through the owning load balancer's logger
+func (s *Server) logf(format string, args ...interface{}) {
+	s.LoadBalancer.logf(format, args...)
+}
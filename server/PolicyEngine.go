@@ -0,0 +1,297 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PolicyEngine consults an external HTTP service for selection decisions, so
+// policies such as learned models can be developed outside this codebase.
+// The service receives the eligible servers and returns one of them; when it
+// abstains, errors, times out or picks an ineligible server, the local
+// algorithm decides instead. The engine is asked once per task, and not at
+// all for engineCooldown after engineFailureLimit consecutive failures.
+type PolicyEngine struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+
+	decisions   atomic.Int64
+	abstentions atomic.Int64
+	fallbacks   atomic.Int64
+	bypassed    atomic.Int64
+
+	mu          sync.Mutex
+	lastError   string
+	lastErrorAt time.Time
+	failures    int       // Consecutive failures
+	openUntil   time.Time // The engine is bypassed until then
+}
+
+const (
+	// engineFailureLimit is how many consecutive failures open the circuit
+	engineFailureLimit = 3
+
+	// engineCooldown is how long selection stays local once the circuit
+	// opens, after which the engine is tried again
+	engineCooldown = 10 * time.Second
+)
+
+// Outcomes of consulting the policy engine, as reported in explanations
+const (
+	EngineDecided   = "decided"   // The engine chose the server
+	EngineAbstained = "abstained" // The engine left the choice to the local algorithm
+	EngineFallback  = "fallback"  // The engine failed or chose an ineligible server
+	EngineBypassed  = "bypassed"  // The engine failed repeatedly and is not asked until its cooldown ends
+)
+
+// PolicyEngineCandidate is an eligible server as sent to the policy engine
+type PolicyEngineCandidate struct {
+	ServerID          int     `json:"server_id"`
+	MemoryUtilization float64 `json:"memory_utilization"`
+	ActiveTasks       int     `json:"active_tasks"`
+	MaxConcurrent     int     `json:"max_concurrent_tasks"` // 0 is unlimited
+	Weight            int     `json:"weight"`
+	Cost              float64 `json:"cost"`
+	GCPressure        float64 `json:"gc_pressure"`
+	// Forecast MaGC; TimeToMaGCMs is -1 without a valid forecast
+	TimeToMaGCMs       int64   `json:"time_to_magc_ms"`
+	ForecastConfidence float64 `json:"forecast_confidence"`
+}
+
+// PolicyEngineRequest is the body POSTed to the policy engine for each selection
+type PolicyEngineRequest struct {
+	TaskSize        int                     `json:"task_size"`
+	Algorithm       string                  `json:"algorithm"` // Local algorithm used as the fallback
+	GCAware         bool                    `json:"gc_aware"`
+	MaGCThresholdMs int64                   `json:"magc_threshold_ms"`
+	Candidates      []PolicyEngineCandidate `json:"candidates"`
+}
+
+// PolicyEngineResponse is the policy engine's decision; a zero ServerID abstains
+type PolicyEngineResponse struct {
+	ServerID int `json:"server_id"`
+}
+
+// PolicyEngineStats reports how the policy engine's decisions were used
+type PolicyEngineStats struct {
+	URL         string     `json:"url"`
+	TimeoutMs   int64      `json:"timeout_ms"`
+	Decisions   int64      `json:"decisions"`   // Selections the engine made
+	Abstentions int64      `json:"abstentions"` // Selections the engine left to the local algorithm
+	Fallbacks   int64      `json:"fallbacks"`   // Failed or invalid engine responses
+	Bypassed    int64      `json:"bypassed"`    // Selections made locally while the circuit was open
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	OpenUntil   *time.Time `json:"open_until,omitempty"` // Set while the engine is bypassed
+}
+
+// NewPolicyEngine creates a client for the policy service at rawURL. Requests
+// that take longer than timeout fall back to local selection.
+func NewPolicyEngine(rawURL string, timeout time.Duration) (*PolicyEngine, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("policy engine URL must be an http or https URL, got %q", rawURL)
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("policy engine timeout must be positive")
+	}
+	return &PolicyEngine{
+		url:     rawURL,
+		timeout: timeout,
+		client:  &http.Client{},
+	}, nil
+}
+
+// decide asks the engine to choose among the candidates
+func (e *PolicyEngine) decide(request PolicyEngineRequest) (int, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("policy engine returned %s", resp.Status)
+	}
+
+	var decision PolicyEngineResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return 0, fmt.Errorf("invalid policy engine response: %w", err)
+	}
+	return decision.ServerID, nil
+}
+
+// fail counts a fallback and remembers why, opening the circuit after
+// engineFailureLimit in a row
+func (e *PolicyEngine) fail(err error, now time.Time) {
+	e.fallbacks.Add(1)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastError = err.Error()
+	e.lastErrorAt = now
+	e.failures++
+	if e.failures >= engineFailureLimit {
+		e.openUntil = now.Add(engineCooldown)
+	}
+}
+
+// succeed closes the circuit after a usable response
+func (e *PolicyEngine) succeed() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.failures = 0
+	e.openUntil = time.Time{}
+}
+
+// bypass reports whether the circuit is open at now. Once the cooldown ends
+// the engine is asked again, and one more failure reopens the circuit.
+func (e *PolicyEngine) bypass(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.openUntil.IsZero() {
+		return false
+	}
+	if now.Before(e.openUntil) {
+		return true
+	}
+	e.openUntil = time.Time{}
+	e.failures = engineFailureLimit - 1
+	return false
+}
+
+// Stats returns the engine's decision counters and last error
+func (e *PolicyEngine) Stats() PolicyEngineStats {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := PolicyEngineStats{
+		URL:         e.url,
+		TimeoutMs:   e.timeout.Milliseconds(),
+		Decisions:   e.decisions.Load(),
+		Abstentions: e.abstentions.Load(),
+		Fallbacks:   e.fallbacks.Load(),
+		Bypassed:    e.bypassed.Load(),
+		LastError:   e.lastError,
+	}
+	if !e.lastErrorAt.IsZero() {
+		at := e.lastErrorAt
+		stats.LastErrorAt = &at
+	}
+	if !e.openUntil.IsZero() {
+		until := e.openUntil
+		stats.OpenUntil = &until
+	}
+	return stats
+}
+
+// PolicyEngineStats returns the policy engine's stats, or false when
+// selection does not consult one
+func (l *LoadBalancer) PolicyEngineStats() (PolicyEngineStats, bool) {
+	if l.policyEngine == nil {
+		return PolicyEngineStats{}, false
+	}
+	return l.policyEngine.Stats(), true
+}
+
+// policyEngineCandidate captures the state of an eligible server for the engine
+func policyEngineCandidate(server *Server) PolicyEngineCandidate {
+	candidate := PolicyEngineCandidate{
+		ServerID:          server.ID,
+		MemoryUtilization: server.MemoryUtilization(),
+		ActiveTasks:       server.ActiveTasks(),
+		MaxConcurrent:     server.MaxConcurrentTasks(),
+		Weight:            server.GetWeight(),
+		Cost:              server.GetCost(),
+		GCPressure:        server.GCPressure().Score,
+		TimeToMaGCMs:      -1,
+	}
+	if forecast, ok := server.forecastSnapshot(); ok && forecast.TimeToMaGCMs >= 0 {
+		candidate.TimeToMaGCMs = forecast.TimeToMaGCMs
+		candidate.ForecastConfidence = forecast.Confidence
+	}
+	return candidate
+}
+
+// selectExternal asks the policy engine for a server. It returns nil when the
+// engine abstains or fails, leaving the decision to the local algorithm.
+// A task asks the engine once, on its first selection pass with eligible
+// servers; later passes, hedges and retries, which carry no explanation, and
+// replayed decisions use the local algorithm.
+func (l *LoadBalancer) selectExternal(taskInput string, policy LoadBalancingPolicy, explain *SelectionExplanation) *Server {
+	engine := l.policyEngine
+	if engine == nil || explain == nil || explain.replaying || explain.engineAsked {
+		return nil
+	}
+
+	request := PolicyEngineRequest{
+		TaskSize:        len(taskInput),
+		Algorithm:       policy.Algorithm,
+		GCAware:         policy.GCAware,
+		MaGCThresholdMs: policy.MaGCThreshold,
+		Candidates:      make([]PolicyEngineCandidate, 0),
+	}
+	eligible := make(map[int]*Server)
+	for _, server := range l.ListServers() {
+		if l.ineligibleReason(server, taskInput, explain) != "" {
+			continue
+		}
+		eligible[server.ID] = server
+		request.Candidates = append(request.Candidates, policyEngineCandidate(server))
+	}
+	if len(request.Candidates) == 0 {
+		return nil
+	}
+
+	explain.engineAsked = true
+	if engine.bypass(l.now()) {
+		engine.bypassed.Add(1)
+		explain.engine(EngineBypassed)
+		return nil
+	}
+
+	serverID, err := engine.decide(request)
+	if err != nil {
+		engine.fail(err, l.now())
+		l.logf("⚠️ Policy engine failed, using local selection: %v", err)
+		explain.engine(EngineFallback)
+		return nil
+	}
+	if serverID == 0 {
+		engine.succeed()
+		engine.abstentions.Add(1)
+		explain.engine(EngineAbstained)
+		return nil
+	}
+	server, ok := eligible[serverID]
+	if !ok {
+		engine.fail(fmt.Errorf("policy engine chose ineligible server %d", serverID), l.now())
+		l.logf("⚠️ Policy engine chose ineligible server %d, using local selection", serverID)
+		explain.engine(EngineFallback)
+		return nil
+	}
+
+	engine.succeed()
+	engine.decisions.Add(1)
+	explain.engine(EngineDecided)
+	l.logf("Server %d selected (policy engine)", server.ID)
+	return server
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// engineTestClock is a Clock the test moves by hand
+type engineTestClock struct {
+	now time.Time
+}
+
+func (c *engineTestClock) Now() time.Time {
+	return c.now
+}
+
+func TestPolicyEngineAskedOncePerTask(t *testing.T) {
+	var calls atomic.Int64
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"server_id": 0}`))
+	}))
+	defer service.Close()
+
+	engine, err := NewPolicyEngine(service.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb := NewLoadBalancer(WithServers(NewServer(1, 100, 80)), WithPolicyEngine(engine), WithLogger(log.New(io.Discard, "", 0)))
+
+	explain := lb.newSelectionExplanation(lb.GetLoadBalancingPolicy(), "")
+	if server, _ := lb.selectWithRetries("task", lb.GetLoadBalancingPolicy(), explain, 0); server == nil {
+		t.Fatal("no server selected")
+	}
+	for i := 0; i < 3; i++ {
+		explain.attempt()
+		lb.selectServer("task", lb.GetLoadBalancingPolicy(), explain)
+	}
+	lb.selectServer("task", lb.GetLoadBalancingPolicy(), nil)
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("engine asked %d times for one task, want 1", got)
+	}
+}
+
+func TestPolicyEngineCircuitBreaker(t *testing.T) {
+	var calls atomic.Int64
+	var failing atomic.Bool
+	failing.Store(true)
+	service := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			http.Error(w, "model unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"server_id": 1}`))
+	}))
+	defer service.Close()
+
+	engine, err := NewPolicyEngine(service.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &engineTestClock{now: time.Unix(1700000000, 0)}
+	lb := NewLoadBalancer(WithServers(NewServer(1, 100, 80)), WithPolicyEngine(engine),
+		WithClock(clock), WithLogger(log.New(io.Discard, "", 0)))
+
+	// select runs one task's selection and returns the engine outcome
+	selectTask := func() string {
+		explain := lb.newSelectionExplanation(lb.GetLoadBalancingPolicy(), "")
+		lb.selectWithRetries("task", lb.GetLoadBalancingPolicy(), explain, 0)
+		return explain.PolicyEngine
+	}
+
+	for i := 0; i < engineFailureLimit; i++ {
+		if outcome := selectTask(); outcome != EngineFallback {
+			t.Fatalf("task %d: engine outcome %q, want %q", i, outcome, EngineFallback)
+		}
+	}
+	if outcome := selectTask(); outcome != EngineBypassed || calls.Load() != engineFailureLimit {
+		t.Fatalf("after %d failures: outcome %q with %d calls, want %q without another call",
+			engineFailureLimit, outcome, calls.Load(), EngineBypassed)
+	}
+	if stats := engine.Stats(); stats.OpenUntil == nil || stats.Bypassed != 1 {
+		t.Errorf("stats while open: %+v", stats)
+	}
+
+	// One failure after the cooldown reopens the circuit
+	clock.now = clock.now.Add(engineCooldown)
+	if outcome := selectTask(); outcome != EngineFallback {
+		t.Errorf("after the cooldown: outcome %q, want %q", outcome, EngineFallback)
+	}
+	if outcome := selectTask(); outcome != EngineBypassed {
+		t.Errorf("after failing again: outcome %q, want %q", outcome, EngineBypassed)
+	}
+
+	// A decision after the next cooldown closes it
+	failing.Store(false)
+	clock.now = clock.now.Add(engineCooldown)
+	for i := 0; i < 2; i++ {
+		if outcome := selectTask(); outcome != EngineDecided {
+			t.Errorf("recovered engine, task %d: outcome %q, want %q", i, outcome, EngineDecided)
+		}
+	}
+	if stats := engine.Stats(); stats.OpenUntil != nil {
+		t.Errorf("circuit still open until %v", stats.OpenUntil)
+	}
+}
@@ -0,0 +1,773 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// raftDocument is the storage document holding the node's term, vote and log
+	raftDocument = "raft"
+
+	// Defaults for RaftConfig
+	defaultRaftHeartbeat       = 200 * time.Millisecond
+	defaultRaftElectionTimeout = time.Second
+
+	// maxRaftLogEntries is how many applied entries the log keeps before
+	// compacting to the last of them; every entry holds the whole config, so
+	// older ones are never needed to rebuild it. Entries carry TRINI's GC
+	// history and the log is persisted whole, so it is kept short.
+	maxRaftLogEntries = 16
+
+	// maxRaftAppendEntries bounds the entries sent in one AppendEntries
+	maxRaftAppendEntries = 16
+)
+
+// Roles a RaftNode can be in
+const (
+	RaftFollower  = "follower"
+	RaftCandidate = "candidate"
+	RaftLeader    = "leader"
+)
+
+// RaftConfig names this balancer and its peers in a replication group
+type RaftConfig struct {
+	ID     string            `json:"id"`    // Unique within the group
+	Peers  map[string]string `json:"peers"` // Other members' IDs to their base URLs, such as http://lb-b:8080
+	APIKey string            `json:"-"`     // Sent as X-API-Key to peers that require one
+
+	HeartbeatInterval time.Duration `json:"heartbeat_interval"`
+	// Least time without a leader before an election; each node waits a
+	// random time up to twice as long, so elections rarely split
+	ElectionTimeout time.Duration `json:"election_timeout"`
+}
+
+// Validate checks the node ID, the peer URLs and the timing
+func (c RaftConfig) Validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("raft node ID must not be empty")
+	}
+	for id, rawURL := range c.Peers {
+		if id == "" || id == c.ID {
+			return fmt.Errorf("raft peer ID must be non-empty and differ from the node's own, got %q", id)
+		}
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("raft peer %s URL must be an http or https URL, got %q", id, rawURL)
+		}
+	}
+	if c.HeartbeatInterval < 0 || c.ElectionTimeout < 0 {
+		return fmt.Errorf("raft heartbeat and election timeout must not be negative")
+	}
+	if c.HeartbeatInterval > 0 && c.ElectionTimeout > 0 && c.ElectionTimeout < 2*c.HeartbeatInterval {
+		return fmt.Errorf("raft election timeout %v must be at least twice the heartbeat %v", c.ElectionTimeout, c.HeartbeatInterval)
+	}
+	return nil
+}
+
+// RaftEntry is one log entry; each holds the whole replicated config, so
+// applying the last committed entry brings a balancer fully up to date
+type RaftEntry struct {
+	Index  int64            `json:"index"`
+	Term   int64            `json:"term"`
+	Config ReplicatedConfig `json:"config"`
+}
+
+// RaftVoteRequest asks a peer for its vote in an election
+type RaftVoteRequest struct {
+	Term         int64  `json:"term"`
+	CandidateID  string `json:"candidate_id"`
+	LastLogIndex int64  `json:"last_log_index"`
+	LastLogTerm  int64  `json:"last_log_term"`
+}
+
+// RaftVoteResponse grants or refuses a vote
+type RaftVoteResponse struct {
+	Term    int64 `json:"term"`
+	Granted bool  `json:"granted"`
+}
+
+// RaftAppendRequest replicates entries, or asserts leadership when empty.
+// Snapshot carries the leader's compacted base when the follower lags behind it.
+type RaftAppendRequest struct {
+	Term         int64       `json:"term"`
+	LeaderID     string      `json:"leader_id"`
+	PrevLogIndex int64       `json:"prev_log_index"`
+	PrevLogTerm  int64       `json:"prev_log_term"`
+	Snapshot     *RaftEntry  `json:"snapshot,omitempty"`
+	Entries      []RaftEntry `json:"entries,omitempty"`
+	LeaderCommit int64       `json:"leader_commit"`
+}
+
+// RaftAppendResponse acknowledges an AppendEntries; on a log mismatch
+// LastIndex tells the leader where the follower's log ends
+type RaftAppendResponse struct {
+	Term      int64 `json:"term"`
+	Success   bool  `json:"success"`
+	LastIndex int64 `json:"last_index"`
+}
+
+// RaftPeerStatus is the leader's view of one peer's replication
+type RaftPeerStatus struct {
+	ID         string     `json:"id"`
+	URL        string     `json:"url"`
+	MatchIndex int64      `json:"match_index"` // Highest entry known to be replicated; leader only
+	LastError  string     `json:"last_error,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+}
+
+// RaftStatus reports the node's role, term and log progress
+type RaftStatus struct {
+	ID          string           `json:"id"`
+	Role        string           `json:"role"`
+	Term        int64            `json:"term"`
+	LeaderID    string           `json:"leader_id,omitempty"`
+	LeaderURL   string           `json:"leader_url,omitempty"` // Empty when this node leads or no leader is known
+	CommitIndex int64            `json:"commit_index"`
+	LastApplied int64            `json:"last_applied"`
+	LastIndex   int64            `json:"last_index"`
+	Peers       []RaftPeerStatus `json:"peers"`
+}
+
+// RaftTransport carries RPCs to peers, identified by their base URLs
+type RaftTransport interface {
+	RequestVote(ctx context.Context, peerURL, apiKey string, request RaftVoteRequest) (RaftVoteResponse, error)
+	AppendEntries(ctx context.Context, peerURL, apiKey string, request RaftAppendRequest) (RaftAppendResponse, error)
+}
+
+// raftState is what a node persists: its term, vote, log and how much of
+// the log it knows committed, so a restart reapplies the last committed config
+type raftState struct {
+	Term        int64       `json:"term"`
+	VotedFor    string      `json:"voted_for,omitempty"`
+	Log         []RaftEntry `json:"log"`
+	CommitIndex int64       `json:"commit_index"`
+}
+
+// raftPeer is the leader's replication progress to one peer
+type raftPeer struct {
+	url        string
+	nextIndex  int64
+	matchIndex int64
+	inFlight   bool // An AppendEntries is outstanding, so heartbeats do not pile up
+	lastError  string
+	lastSeen   time.Time
+}
+
+// RaftNode replicates the load balancer's critical configuration (policy,
+// server pool, program families and pins) and TRINI's adaptive state (GC
+// history, scored forecasts and current forecasts) across a group of
+// balancers, so a standby that takes over resumes with identical adaptive
+// state rather than re-learning from an empty TRINI. As that state changes
+// with every snapshot, the leader proposes an entry about once per TRINI
+// monitoring interval.
+//
+// It implements the core of Raft: randomized elections, a replicated log and
+// commitment by majority. The leader appends an entry holding the whole
+// config whenever its config changes, and followers apply each committed
+// entry. Because every entry is complete, the log compacts to its last
+// applied entry instead of keeping snapshots. Membership is fixed by
+// RaftConfig; a group needs three members to elect a leader when one fails.
+//
+// While following, the balancer's own classifier and autoscaler leave the
+// replicated config alone; writes to it belong on the leader.
+type RaftNode struct {
+	lb        *LoadBalancer
+	config    RaftConfig
+	transport RaftTransport
+
+	mu              sync.Mutex
+	role            string
+	term            int64
+	votedFor        string
+	leaderID        string
+	log             []RaftEntry // log[0] is the compacted base, index 0 in a fresh log
+	commitIndex     int64
+	lastApplied     int64
+	peers           map[string]*raftPeer
+	lastContact     time.Time // Last heartbeat from a leader, or vote granted
+	electionTimeout time.Duration
+
+	applyMu sync.Mutex // Serializes applying committed entries
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRaftNode creates the replication node of lb, restoring its term, vote
+// and log from lb's storage if it has any. Zero timings take their defaults,
+// and a nil transport talks to peers' /api/v1/raft endpoints over HTTP.
+func NewRaftNode(lb *LoadBalancer, config RaftConfig, transport RaftTransport) (*RaftNode, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.HeartbeatInterval == 0 {
+		config.HeartbeatInterval = defaultRaftHeartbeat
+	}
+	if config.ElectionTimeout == 0 {
+		config.ElectionTimeout = max(defaultRaftElectionTimeout, 5*config.HeartbeatInterval)
+	}
+	if transport == nil {
+		transport = NewHTTPRaftTransport(config.ElectionTimeout)
+	}
+
+	n := &RaftNode{
+		lb:        lb,
+		config:    config,
+		transport: transport,
+		role:      RaftFollower,
+		log:       []RaftEntry{{}},
+		peers:     make(map[string]*raftPeer, len(config.Peers)),
+	}
+	for id, peerURL := range config.Peers {
+		n.peers[id] = &raftPeer{url: strings.TrimSuffix(peerURL, "/")}
+	}
+
+	if lb.store != nil {
+		var state raftState
+		found, err := lb.store.Load(raftDocument, &state)
+		if err != nil {
+			return nil, fmt.Errorf("could not restore raft state: %w", err)
+		}
+		if found && len(state.Log) > 0 {
+			n.term, n.votedFor, n.log = state.Term, state.VotedFor, state.Log
+			// The base was applied before it was compacted, so it is committed
+			n.commitIndex = min(max(state.CommitIndex, n.log[0].Index), n.lastIndexLocked())
+		}
+	}
+	return n, nil
+}
+
+// Start applies the restored committed config, if any, and runs elections and
+// replication until ctx is cancelled or Stop is called
+func (n *RaftNode) Start(ctx context.Context) {
+	ctx, n.cancel = context.WithCancel(ctx)
+	n.lb.raft.Store(n)
+
+	n.mu.Lock()
+	n.lastContact = time.Now()
+	n.electionTimeout = n.randomElectionTimeout()
+	if n.commitIndex > 0 {
+		committed := n.log[n.commitIndex-n.log[0].Index]
+		n.lastApplied = committed.Index
+		n.mu.Unlock()
+		n.lb.applyReplicatedConfig(committed.Config)
+		n.lb.logf("💾 Restored replicated configuration %d", committed.Index)
+	} else {
+		n.mu.Unlock()
+	}
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		ticker := time.NewTicker(n.config.HeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				n.tick(ctx)
+			}
+		}
+	}()
+	n.lb.logf("🗳️ Raft node %s started with %d peers", n.config.ID, len(n.peers))
+}
+
+// Stop ends elections and replication; the balancer keeps the last applied config
+func (n *RaftNode) Stop() {
+	if n.cancel != nil {
+		n.cancel()
+	}
+	n.wg.Wait()
+	n.lb.raft.CompareAndSwap(n, nil)
+}
+
+// IsLeader reports whether this node leads the group
+func (n *RaftNode) IsLeader() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role == RaftLeader
+}
+
+// LeaderURL returns the base URL of the current leader, or "" when this
+// node leads or no leader is known
+func (n *RaftNode) LeaderURL() string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if peer, ok := n.peers[n.leaderID]; ok {
+		return peer.url
+	}
+	return ""
+}
+
+// Status reports the node's role, term, log progress and peers
+func (n *RaftNode) Status() RaftStatus {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	status := RaftStatus{
+		ID:          n.config.ID,
+		Role:        n.role,
+		Term:        n.term,
+		LeaderID:    n.leaderID,
+		CommitIndex: n.commitIndex,
+		LastApplied: n.lastApplied,
+		LastIndex:   n.lastIndexLocked(),
+		Peers:       make([]RaftPeerStatus, 0, len(n.peers)),
+	}
+	if peer, ok := n.peers[n.leaderID]; ok {
+		status.LeaderURL = peer.url
+	}
+	for id, peer := range n.peers {
+		peerStatus := RaftPeerStatus{ID: id, URL: peer.url, LastError: peer.lastError}
+		if n.role == RaftLeader {
+			peerStatus.MatchIndex = peer.matchIndex
+		}
+		if !peer.lastSeen.IsZero() {
+			lastSeen := peer.lastSeen
+			peerStatus.LastSeen = &lastSeen
+		}
+		status.Peers = append(status.Peers, peerStatus)
+	}
+	sort.Slice(status.Peers, func(i, j int) bool { return status.Peers[i].ID < status.Peers[j].ID })
+	return status
+}
+
+// HandleVote answers a peer's RequestVote
+func (n *RaftNode) HandleVote(request RaftVoteRequest) RaftVoteResponse {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if request.Term > n.term {
+		n.stepDownLocked(request.Term)
+	}
+	response := RaftVoteResponse{Term: n.term}
+	if request.Term < n.term || (n.votedFor != "" && n.votedFor != request.CandidateID) {
+		return response
+	}
+
+	// Only a candidate whose log is at least as up to date can be elected
+	lastTerm, lastIndex := n.lastTermLocked(), n.lastIndexLocked()
+	if request.LastLogTerm < lastTerm || (request.LastLogTerm == lastTerm && request.LastLogIndex < lastIndex) {
+		return response
+	}
+
+	n.votedFor = request.CandidateID
+	n.lastContact = time.Now()
+	n.persistLocked()
+	response.Granted = true
+	return response
+}
+
+// HandleAppend answers a leader's AppendEntries and applies what it committed
+func (n *RaftNode) HandleAppend(request RaftAppendRequest) RaftAppendResponse {
+	n.mu.Lock()
+	if request.Term < n.term {
+		defer n.mu.Unlock()
+		return RaftAppendResponse{Term: n.term, LastIndex: n.lastIndexLocked()}
+	}
+	if request.Term > n.term || n.role != RaftFollower {
+		n.stepDownLocked(request.Term)
+	}
+	if n.leaderID != request.LeaderID {
+		n.leaderID = request.LeaderID
+		n.lb.logf("🗳️ Following raft leader %s in term %d", request.LeaderID, n.term)
+	}
+	n.lastContact = time.Now()
+
+	changed := false
+	if request.Snapshot != nil && request.Snapshot.Index > n.log[0].Index {
+		n.installSnapshotLocked(*request.Snapshot)
+		changed = true
+	}
+
+	response := RaftAppendResponse{Term: n.term}
+	base := n.log[0].Index
+	switch {
+	case request.PrevLogIndex > n.lastIndexLocked():
+		response.LastIndex = n.lastIndexLocked()
+	case request.PrevLogIndex >= base && n.termAtLocked(request.PrevLogIndex) != request.PrevLogTerm:
+		// Conflicting entries from the mismatch on are dropped when the leader retries
+		response.LastIndex = request.PrevLogIndex - 1
+	default:
+		for _, entry := range request.Entries {
+			if entry.Index <= base {
+				continue // Already compacted, so committed and identical
+			}
+			if entry.Index <= n.lastIndexLocked() {
+				if n.termAtLocked(entry.Index) == entry.Term {
+					continue
+				}
+				n.log = n.log[:entry.Index-base]
+			}
+			n.log = append(n.log, entry)
+			changed = true
+		}
+		response.Success = true
+		response.LastIndex = n.lastIndexLocked()
+
+		lastNew := request.PrevLogIndex + int64(len(request.Entries))
+		if commit := min(request.LeaderCommit, lastNew); commit > n.commitIndex {
+			n.commitIndex = commit
+			changed = true
+		}
+	}
+	if changed {
+		n.persistLocked()
+	}
+	n.mu.Unlock()
+
+	n.applyCommitted()
+	return response
+}
+
+// tick runs one heartbeat interval: the leader proposes its config if it
+// changed and replicates; others start an election once the leader is silent
+func (n *RaftNode) tick(ctx context.Context) {
+	n.mu.Lock()
+	role := n.role
+	silent := time.Since(n.lastContact) >= n.electionTimeout
+	n.mu.Unlock()
+
+	switch {
+	case role == RaftLeader:
+		n.propose()
+		n.applyCommitted()
+		n.replicate(ctx)
+	case silent:
+		n.startElection(ctx)
+	}
+}
+
+// startElection becomes a candidate for the next term and asks every peer
+// for its vote, becoming leader on a majority
+func (n *RaftNode) startElection(ctx context.Context) {
+	n.mu.Lock()
+	n.role = RaftCandidate
+	n.term++
+	n.votedFor = n.config.ID
+	n.leaderID = ""
+	n.lastContact = time.Now()
+	n.electionTimeout = n.randomElectionTimeout()
+	n.persistLocked()
+	term := n.term
+	request := RaftVoteRequest{
+		Term:         term,
+		CandidateID:  n.config.ID,
+		LastLogIndex: n.lastIndexLocked(),
+		LastLogTerm:  n.lastTermLocked(),
+	}
+	peers := make(map[string]string, len(n.peers))
+	for id, peer := range n.peers {
+		peers[id] = peer.url
+	}
+	n.mu.Unlock()
+
+	votes, quorum := 1, (len(peers)+1)/2+1
+	if votes >= quorum {
+		n.becomeLeader(term)
+		return
+	}
+
+	var votesMu sync.Mutex
+	for id, peerURL := range peers {
+		n.wg.Add(1)
+		go func(id, peerURL string) {
+			defer n.wg.Done()
+			rpcCtx, cancel := context.WithTimeout(ctx, n.config.ElectionTimeout)
+			defer cancel()
+			response, err := n.transport.RequestVote(rpcCtx, peerURL, n.config.APIKey, request)
+			n.recordContact(id, err)
+			if err != nil {
+				return
+			}
+
+			n.mu.Lock()
+			if response.Term > n.term {
+				n.stepDownLocked(response.Term)
+			}
+			n.mu.Unlock()
+			if !response.Granted {
+				return
+			}
+
+			votesMu.Lock()
+			votes++
+			won := votes == quorum
+			votesMu.Unlock()
+			if won {
+				n.becomeLeader(term)
+			}
+		}(id, peerURL)
+	}
+}
+
+// becomeLeader takes the lead for term unless the node has moved on since.
+// It first applies the newest entry in its log, which holds everything the
+// group may have committed, so its proposals never roll back a committed config.
+func (n *RaftNode) becomeLeader(term int64) {
+	n.mu.Lock()
+	latest := n.log[len(n.log)-1]
+	n.mu.Unlock()
+	n.applyEntry(latest)
+
+	n.mu.Lock()
+	if n.term != term || n.role != RaftCandidate {
+		n.mu.Unlock()
+		return
+	}
+	n.role = RaftLeader
+	n.leaderID = n.config.ID
+	lastIndex := n.lastIndexLocked()
+	for _, peer := range n.peers {
+		peer.nextIndex = lastIndex + 1
+		peer.matchIndex = 0
+		peer.inFlight = false
+	}
+	n.mu.Unlock()
+	n.lb.RecordEvent("raft_leader", 0, "Raft node %s elected leader for term %d", n.config.ID, term)
+}
+
+// propose appends the balancer's config to the log when it differs from the
+// last entry, and in any case once per term so earlier entries can commit
+func (n *RaftNode) propose() {
+	config := n.lb.ReplicatedConfig()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.role != RaftLeader {
+		return
+	}
+	last := n.log[len(n.log)-1]
+	if last.Index > 0 && last.Term == n.term && last.Config.equal(config) {
+		return
+	}
+	n.log = append(n.log, RaftEntry{Index: last.Index + 1, Term: n.term, Config: config})
+	n.persistLocked()
+	n.advanceCommitLocked()
+}
+
+// replicate sends each peer without an outstanding request the entries it lacks
+func (n *RaftNode) replicate(ctx context.Context) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for id, peer := range n.peers {
+		if peer.inFlight {
+			continue
+		}
+		peer.inFlight = true
+		request := n.appendRequestLocked(peer)
+		n.wg.Add(1)
+		go func(id string, peer *raftPeer) {
+			defer n.wg.Done()
+			rpcCtx, cancel := context.WithTimeout(ctx, n.config.ElectionTimeout)
+			defer cancel()
+			response, err := n.transport.AppendEntries(rpcCtx, peer.url, n.config.APIKey, request)
+			n.recordContact(id, err)
+			n.handleAppendResponse(peer, request, response, err)
+		}(id, peer)
+	}
+}
+
+// appendRequestLocked builds the AppendEntries for a peer from its nextIndex,
+// with the compacted base when the peer needs entries before it
+func (n *RaftNode) appendRequestLocked(peer *raftPeer) RaftAppendRequest {
+	request := RaftAppendRequest{Term: n.term, LeaderID: n.config.ID, LeaderCommit: n.commitIndex}
+	base := n.log[0]
+	next := peer.nextIndex
+	if next <= base.Index {
+		snapshot := base
+		request.Snapshot = &snapshot
+		next = base.Index + 1
+	}
+	request.PrevLogIndex = next - 1
+	request.PrevLogTerm = n.termAtLocked(next - 1)
+	entries := n.log[next-base.Index:]
+	if len(entries) > maxRaftAppendEntries {
+		entries = entries[:maxRaftAppendEntries]
+	}
+	request.Entries = append([]RaftEntry(nil), entries...)
+	return request
+}
+
+// handleAppendResponse advances or backs off the peer's progress and
+// commits what a majority now holds
+func (n *RaftNode) handleAppendResponse(peer *raftPeer, request RaftAppendRequest, response RaftAppendResponse, err error) {
+	n.mu.Lock()
+	peer.inFlight = false
+	if err != nil || n.role != RaftLeader || n.term != request.Term {
+		n.mu.Unlock()
+		return
+	}
+	if response.Term > n.term {
+		n.stepDownLocked(response.Term)
+		n.mu.Unlock()
+		return
+	}
+
+	if response.Success {
+		peer.matchIndex = max(peer.matchIndex, request.PrevLogIndex+int64(len(request.Entries)))
+		peer.nextIndex = peer.matchIndex + 1
+		n.advanceCommitLocked()
+	} else {
+		peer.nextIndex = max(min(peer.nextIndex-1, response.LastIndex+1), 1)
+	}
+	n.mu.Unlock()
+
+	n.applyCommitted()
+}
+
+// advanceCommitLocked commits the newest entry of the current term that a
+// majority holds; entries of earlier terms commit along with it
+func (n *RaftNode) advanceCommitLocked() {
+	for index := n.lastIndexLocked(); index > n.commitIndex; index-- {
+		if n.termAtLocked(index) != n.term {
+			break
+		}
+		replicas := 1
+		for _, peer := range n.peers {
+			if peer.matchIndex >= index {
+				replicas++
+			}
+		}
+		if replicas > (len(n.peers)+1)/2 {
+			n.commitIndex = index
+			n.persistLocked()
+			return
+		}
+	}
+}
+
+// applyCommitted applies the newest committed entry if it was not yet; the
+// leader's own entries came from its config, so it only marks them applied
+func (n *RaftNode) applyCommitted() {
+	n.mu.Lock()
+	if n.commitIndex <= n.lastApplied {
+		n.mu.Unlock()
+		return
+	}
+	entry := n.log[n.commitIndex-n.log[0].Index]
+	leading := n.role == RaftLeader
+	if leading {
+		n.lastApplied = entry.Index
+		n.compactLocked()
+	}
+	n.mu.Unlock()
+
+	if !leading {
+		n.applyEntry(entry)
+	}
+}
+
+// applyEntry applies an entry's config unless a later one already was
+func (n *RaftNode) applyEntry(entry RaftEntry) {
+	n.applyMu.Lock()
+	defer n.applyMu.Unlock()
+
+	n.mu.Lock()
+	applied := entry.Index <= n.lastApplied
+	n.mu.Unlock()
+	if applied {
+		return
+	}
+
+	n.lb.applyReplicatedConfig(entry.Config)
+
+	n.mu.Lock()
+	if entry.Index > n.lastApplied {
+		n.lastApplied = entry.Index
+		n.compactLocked()
+	}
+	leaderID := n.leaderID
+	n.mu.Unlock()
+	n.lb.RecordEvent("config_replicated", 0, "Applied replicated configuration %d from raft leader %s", entry.Index, leaderID)
+}
+
+// compactLocked drops applied entries once there are too many, keeping the
+// last applied one as the new base
+func (n *RaftNode) compactLocked() {
+	base := n.log[0].Index
+	if n.lastApplied-base <= maxRaftLogEntries || n.lastApplied > n.lastIndexLocked() {
+		return
+	}
+	n.log = append([]RaftEntry(nil), n.log[n.lastApplied-base:]...)
+	n.persistLocked()
+}
+
+// installSnapshotLocked replaces the log up to the snapshot with it, keeping
+// later entries that agree with it
+func (n *RaftNode) installSnapshotLocked(snapshot RaftEntry) {
+	base := n.log[0].Index
+	if snapshot.Index <= n.lastIndexLocked() && n.termAtLocked(snapshot.Index) == snapshot.Term {
+		n.log = append([]RaftEntry{snapshot}, n.log[snapshot.Index-base+1:]...)
+	} else {
+		n.log = []RaftEntry{snapshot}
+	}
+	n.commitIndex = max(n.commitIndex, snapshot.Index)
+}
+
+// stepDownLocked follows in term, forgetting the vote when the term is new
+func (n *RaftNode) stepDownLocked(term int64) {
+	if term > n.term {
+		n.term = term
+		n.votedFor = ""
+		n.leaderID = ""
+		n.persistLocked()
+	}
+	if n.role != RaftFollower {
+		n.lb.logf("🗳️ Raft node %s stepping down to follower in term %d", n.config.ID, n.term)
+	}
+	n.role = RaftFollower
+	n.lastContact = time.Now()
+}
+
+// recordContact remembers whether the peer answered
+func (n *RaftNode) recordContact(id string, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	peer := n.peers[id]
+	if err != nil {
+		peer.lastError = err.Error()
+		return
+	}
+	peer.lastError = ""
+	peer.lastSeen = time.Now()
+}
+
+// persistLocked saves the term, vote, log and commit index when the balancer has storage
+func (n *RaftNode) persistLocked() {
+	if n.lb.store == nil {
+		return
+	}
+	state := raftState{Term: n.term, VotedFor: n.votedFor, Log: n.log, CommitIndex: n.commitIndex}
+	if err := n.lb.store.Save(raftDocument, state); err != nil {
+		n.lb.logf("⚠️ Could not persist raft state: %v", err)
+	}
+}
+
+// lastIndexLocked returns the index of the last entry in the log
+func (n *RaftNode) lastIndexLocked() int64 {
+	return n.log[len(n.log)-1].Index
+}
+
+// lastTermLocked returns the term of the last entry in the log
+func (n *RaftNode) lastTermLocked() int64 {
+	return n.log[len(n.log)-1].Term
+}
+
+// termAtLocked returns the term of the entry at index, which must be within the log
+func (n *RaftNode) termAtLocked(index int64) int64 {
+	return n.log[index-n.log[0].Index].Term
+}
+
+// randomElectionTimeout picks an election timeout between one and two times the configured one
+func (n *RaftNode) randomElectionTimeout() time.Duration {
+	return n.config.ElectionTimeout + time.Duration(rand.Int63n(int64(n.config.ElectionTimeout)))
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// HTTPRaftTransport sends Raft RPCs as JSON POSTs to peers' /api/v1/raft/vote
+// and /api/v1/raft/append endpoints
+type HTTPRaftTransport struct {
+	client *http.Client
+}
+
+// NewHTTPRaftTransport creates a transport whose requests give up after timeout
+func NewHTTPRaftTransport(timeout time.Duration) *HTTPRaftTransport {
+	return &HTTPRaftTransport{client: &http.Client{Timeout: timeout}}
+}
+
+// RequestVote asks the peer at peerURL for its vote
+func (t *HTTPRaftTransport) RequestVote(ctx context.Context, peerURL, apiKey string, request RaftVoteRequest) (RaftVoteResponse, error) {
+	var response RaftVoteResponse
+	err := t.post(ctx, peerURL+"/api/v1/raft/vote", apiKey, request, &response)
+	return response, err
+}
+
+// AppendEntries replicates entries to the peer at peerURL
+func (t *HTTPRaftTransport) AppendEntries(ctx context.Context, peerURL, apiKey string, request RaftAppendRequest) (RaftAppendResponse, error) {
+	var response RaftAppendResponse
+	err := t.post(ctx, peerURL+"/api/v1/raft/append", apiKey, request, &response)
+	return response, err
+}
+
+// post sends request as JSON and decodes the peer's answer into response
+func (t *HTTPRaftTransport) post(ctx context.Context, url, apiKey string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("raft peer returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return fmt.Errorf("invalid raft peer response: %w", err)
+	}
+	return nil
+}
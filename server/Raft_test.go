@@ -0,0 +1,415 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryRaftTransport delivers RPCs straight to the nodes of one test group;
+// nodes marked down fail every RPC sent to them
+type memoryRaftTransport struct {
+	mu    sync.Mutex
+	nodes map[string]*RaftNode
+	down  map[string]bool
+}
+
+func (t *memoryRaftTransport) node(peerURL string) (*RaftNode, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	node, ok := t.nodes[peerURL]
+	if !ok || t.down[peerURL] {
+		return nil, fmt.Errorf("%s unreachable", peerURL)
+	}
+	return node, nil
+}
+
+func (t *memoryRaftTransport) setDown(id string, down bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.down[raftTestURL(id)] = down
+}
+
+func (t *memoryRaftTransport) RequestVote(ctx context.Context, peerURL, apiKey string, request RaftVoteRequest) (RaftVoteResponse, error) {
+	node, err := t.node(peerURL)
+	if err != nil {
+		return RaftVoteResponse{}, err
+	}
+	return node.HandleVote(request), nil
+}
+
+func (t *memoryRaftTransport) AppendEntries(ctx context.Context, peerURL, apiKey string, request RaftAppendRequest) (RaftAppendResponse, error) {
+	node, err := t.node(peerURL)
+	if err != nil {
+		return RaftAppendResponse{}, err
+	}
+	return node.HandleAppend(request), nil
+}
+
+func raftTestURL(id string) string {
+	return "http://" + id
+}
+
+// newRaftTestBalancer creates a load balancer with two servers that logs nowhere
+func newRaftTestBalancer(store Store) *LoadBalancer {
+	opts := []Option{
+		WithServers(NewServer(1, 100, 80), NewServer(2, 100, 80)),
+		WithLogger(log.New(io.Discard, "", 0)),
+	}
+	if store != nil {
+		opts = append(opts, WithStore(store))
+	}
+	lb := NewLoadBalancer(opts...)
+	for _, server := range lb.ListServers() {
+		server.initializeTRINI(lb.TRINI.DefaultFamily)
+	}
+	return lb
+}
+
+// newRaftTestGroup creates one node per ID, all peers of each other, that are
+// driven by calling their election and replication steps directly
+func newRaftTestGroup(t *testing.T, ids ...string) (map[string]*RaftNode, *memoryRaftTransport) {
+	t.Helper()
+	transport := &memoryRaftTransport{nodes: make(map[string]*RaftNode), down: make(map[string]bool)}
+	nodes := make(map[string]*RaftNode, len(ids))
+	for _, id := range ids {
+		config := RaftConfig{ID: id, Peers: make(map[string]string)}
+		for _, peer := range ids {
+			if peer != id {
+				config.Peers[peer] = raftTestURL(peer)
+			}
+		}
+		lb := newRaftTestBalancer(nil)
+		node, err := NewRaftNode(lb, config, transport)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lb.raft.Store(node)
+		nodes[id] = node
+		transport.nodes[raftTestURL(id)] = node
+	}
+	return nodes, transport
+}
+
+// elect runs an election on the node and waits for every vote
+func elect(n *RaftNode) {
+	n.startElection(context.Background())
+	n.wg.Wait()
+}
+
+// heartbeat has the leader propose its config and replicate once, waiting for every peer
+func heartbeat(n *RaftNode) {
+	n.propose()
+	n.applyCommitted()
+	n.replicate(context.Background())
+	n.wg.Wait()
+}
+
+// raftRole returns the node's role and term
+func raftRole(n *RaftNode) (string, int64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.role, n.term
+}
+
+func TestRaftElection(t *testing.T) {
+	tests := []struct {
+		name string
+		down []string
+		want string
+	}{
+		{"all peers up", nil, RaftLeader},
+		{"one of three down", []string{"c"}, RaftLeader},
+		{"two of three down", []string{"b", "c"}, RaftCandidate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, transport := newRaftTestGroup(t, "a", "b", "c")
+			for _, id := range tt.down {
+				transport.setDown(id, true)
+			}
+			elect(nodes["a"])
+
+			if role, term := raftRole(nodes["a"]); role != tt.want || term != 1 {
+				t.Errorf("got %s in term %d, want %s in term 1", role, term, tt.want)
+			}
+			if tt.want == RaftLeader && !nodes["a"].IsLeader() {
+				t.Error("IsLeader is false for the elected node")
+			}
+		})
+	}
+}
+
+func TestRaftSplitVoteRetriesInNextTerm(t *testing.T) {
+	nodes, transport := newRaftTestGroup(t, "a", "b", "c")
+
+	// c already voted for b in term 1, and b is unreachable
+	if response := nodes["c"].HandleVote(RaftVoteRequest{Term: 1, CandidateID: "b"}); !response.Granted {
+		t.Fatal("c refused its first vote")
+	}
+	transport.setDown("b", true)
+
+	elect(nodes["a"])
+	if role, term := raftRole(nodes["a"]); role != RaftCandidate || term != 1 {
+		t.Fatalf("got %s in term %d after a split vote, want candidate in term 1", role, term)
+	}
+
+	elect(nodes["a"])
+	if role, term := raftRole(nodes["a"]); role != RaftLeader || term != 2 {
+		t.Fatalf("got %s in term %d after retrying, want leader in term 2", role, term)
+	}
+}
+
+func TestRaftVoteRequiresUpToDateLog(t *testing.T) {
+	nodes, _ := newRaftTestGroup(t, "a", "b")
+	nodes["b"].HandleAppend(RaftAppendRequest{
+		Term: 1, LeaderID: "a",
+		Entries: []RaftEntry{{Index: 1, Term: 1}, {Index: 2, Term: 1}},
+	})
+
+	tests := []struct {
+		name    string
+		request RaftVoteRequest
+		granted bool
+	}{
+		{"shorter log", RaftVoteRequest{Term: 2, CandidateID: "c", LastLogIndex: 1, LastLogTerm: 1}, false},
+		{"older last term", RaftVoteRequest{Term: 3, CandidateID: "c", LastLogIndex: 5, LastLogTerm: 0}, false},
+		{"same log", RaftVoteRequest{Term: 4, CandidateID: "c", LastLogIndex: 2, LastLogTerm: 1}, true},
+		{"second candidate in the same term", RaftVoteRequest{Term: 4, CandidateID: "d", LastLogIndex: 9, LastLogTerm: 4}, false},
+	}
+	for _, tt := range tests {
+		if response := nodes["b"].HandleVote(tt.request); response.Granted != tt.granted {
+			t.Errorf("%s: granted %t, want %t", tt.name, response.Granted, tt.granted)
+		}
+	}
+}
+
+func TestRaftStepsDownOnHigherTerm(t *testing.T) {
+	tests := []struct {
+		name    string
+		observe func(nodes map[string]*RaftNode)
+		term    int64
+	}{
+		{"append from a newer leader", func(nodes map[string]*RaftNode) {
+			nodes["a"].HandleAppend(RaftAppendRequest{Term: 5, LeaderID: "b"})
+		}, 5},
+		{"vote request in a newer term", func(nodes map[string]*RaftNode) {
+			nodes["a"].HandleVote(RaftVoteRequest{Term: 6, CandidateID: "b", LastLogIndex: 99, LastLogTerm: 99})
+		}, 6},
+		{"append response from a peer in a newer term", func(nodes map[string]*RaftNode) {
+			nodes["b"].mu.Lock()
+			nodes["b"].term = 7
+			nodes["b"].mu.Unlock()
+			heartbeat(nodes["a"])
+		}, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, _ := newRaftTestGroup(t, "a", "b", "c")
+			elect(nodes["a"])
+			if !nodes["a"].IsLeader() {
+				t.Fatal("a was not elected")
+			}
+
+			tt.observe(nodes)
+			if role, term := raftRole(nodes["a"]); role != RaftFollower || term != tt.term {
+				t.Errorf("got %s in term %d, want follower in term %d", role, term, tt.term)
+			}
+		})
+	}
+}
+
+func TestRaftAppendTruncatesConflictingEntries(t *testing.T) {
+	nodes, _ := newRaftTestGroup(t, "a", "b")
+	follower := nodes["b"]
+	follower.HandleAppend(RaftAppendRequest{
+		Term: 1, LeaderID: "a",
+		Entries: []RaftEntry{{Index: 1, Term: 1}, {Index: 2, Term: 1}, {Index: 3, Term: 1}},
+	})
+
+	// A mismatch at the previous entry is refused with where the log ends
+	response := follower.HandleAppend(RaftAppendRequest{Term: 2, LeaderID: "c", PrevLogIndex: 3, PrevLogTerm: 2})
+	if response.Success || response.LastIndex != 2 {
+		t.Errorf("mismatched append: got success %t, last index %d, want refusal with 2", response.Success, response.LastIndex)
+	}
+	response = follower.HandleAppend(RaftAppendRequest{Term: 2, LeaderID: "c", PrevLogIndex: 9, PrevLogTerm: 2})
+	if response.Success || response.LastIndex != 3 {
+		t.Errorf("append past the log: got success %t, last index %d, want refusal with 3", response.Success, response.LastIndex)
+	}
+
+	// The new leader's entry replaces the conflicting ones from index 2 on
+	response = follower.HandleAppend(RaftAppendRequest{
+		Term: 2, LeaderID: "c", PrevLogIndex: 1, PrevLogTerm: 1,
+		Entries: []RaftEntry{{Index: 2, Term: 2}},
+	})
+	if !response.Success || response.LastIndex != 2 {
+		t.Fatalf("got success %t, last index %d, want success with 2", response.Success, response.LastIndex)
+	}
+	follower.mu.Lock()
+	defer follower.mu.Unlock()
+	if len(follower.log) != 3 || follower.termAtLocked(1) != 1 || follower.termAtLocked(2) != 2 {
+		t.Errorf("log after truncation: %+v", follower.log)
+	}
+}
+
+func TestRaftReplicatesConfig(t *testing.T) {
+	nodes, _ := newRaftTestGroup(t, "a", "b", "c")
+	leader, follower := nodes["a"], nodes["b"]
+	elect(leader)
+
+	policy := leader.lb.CurrentPolicy
+	policy.Algorithm, policy.MaGCThreshold = "WRR", 3300
+	leader.lb.SetLoadBalancingPolicy(policy)
+	if _, err := leader.lb.AddServer(200, 70); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := leader.lb.PinFamily(2, "long-magc", 0); err != nil {
+		t.Fatal(err)
+	}
+	leader.lb.GetServerByID(1).SetZone("z1")
+
+	// The first heartbeat commits the entry, the second tells followers so
+	heartbeat(leader)
+	heartbeat(leader)
+
+	want := leader.lb.ReplicatedConfig()
+	if got := follower.lb.ReplicatedConfig(); !got.equal(want) {
+		t.Errorf("follower config %+v, want %+v", got, want)
+	}
+	if !follower.lb.followsLeader() || leader.lb.followsLeader() {
+		t.Error("only the follower should follow the leader")
+	}
+
+	// Removing a server on the leader removes it on followers
+	if err := leader.lb.RemoveServer(3); err != nil {
+		t.Fatal(err)
+	}
+	heartbeat(leader)
+	heartbeat(leader)
+	if follower.lb.GetServerByID(3) != nil {
+		t.Error("server 3 is still on the follower")
+	}
+}
+
+func TestRaftFailoverKeepsTRINIState(t *testing.T) {
+	nodes, transport := newRaftTestGroup(t, "a", "b", "c")
+	leader, standby := nodes["a"], nodes["b"]
+	elect(leader)
+
+	learned := leader.lb.GetServerByID(1)
+	for i := 0; i < 3; i++ {
+		learned.collectGCSnapshot()
+	}
+	magc := selectionTestTime.Add(-time.Minute)
+	learned.mu.Lock()
+	learned.GCEvents = []GCEvent{{StartTime: magc, EndTime: magc.Add(time.Second), DurationMs: 1000}}
+	learned.ForecastOutcomes = []ForecastOutcome{{ForecastCreatedAt: magc.Add(-time.Minute), PredictedTime: magc, ActualTime: magc, Confidence: 0.8, Accurate: true}}
+	learned.LastMaGCForecast = &MaGCForecast{PredictedTime: selectionTestTime, Confidence: 0.7, TimeToMaGC: 60000, ForecastCreatedAt: magc}
+	learned.LongMaGCForecast = &LongHorizonForecast{NextMaGC: selectionTestTime, PredictedTimes: []time.Time{selectionTestTime}, IntervalMs: 60000, ForecastCreatedAt: magc}
+	learned.mu.Unlock()
+
+	heartbeat(leader)
+	heartbeat(leader)
+	transport.setDown("a", true)
+	elect(standby)
+	if role, _ := raftRole(standby); role != RaftLeader {
+		t.Fatalf("standby is %s after the leader failed", role)
+	}
+
+	want := ReplicatedConfig{Servers: []ReplicatedServer{learned.replicated()}}
+	resumed := standby.lb.GetServerByID(1)
+	if got := (ReplicatedConfig{Servers: []ReplicatedServer{resumed.replicated()}}); !got.equal(want) {
+		t.Errorf("new leader resumed with %+v, want %+v", got.Servers[0], want.Servers[0])
+	}
+	resumed.mu.Lock()
+	lastScored := resumed.lastScoredForecast
+	resumed.mu.Unlock()
+	if !lastScored.Equal(magc.Add(-time.Minute)) {
+		t.Errorf("last scored forecast %v, want the replicated outcome's", lastScored)
+	}
+}
+
+func TestRaftCompactsAndInstallsSnapshot(t *testing.T) {
+	nodes, transport := newRaftTestGroup(t, "a", "b", "c")
+	leader, lagging := nodes["a"], nodes["c"]
+	transport.setDown("c", true)
+	elect(leader)
+
+	policy := leader.lb.CurrentPolicy
+	for i := 0; i < maxRaftLogEntries+10; i++ {
+		policy.MaGCThreshold = int64(1000 + i*10)
+		leader.lb.SetLoadBalancingPolicy(policy)
+		heartbeat(leader)
+	}
+
+	leader.mu.Lock()
+	base, entries := leader.log[0].Index, len(leader.log)
+	request := leader.appendRequestLocked(leader.peers["c"])
+	leader.mu.Unlock()
+	if base == 0 || entries > maxRaftLogEntries+1 {
+		t.Fatalf("log not compacted: base %d, %d entries", base, entries)
+	}
+	if request.Snapshot == nil || request.Snapshot.Index != base || request.PrevLogIndex != base {
+		t.Fatalf("append to a peer behind the base: snapshot %+v, prev index %d, want the base at %d",
+			request.Snapshot, request.PrevLogIndex, base)
+	}
+
+	transport.setDown("c", false)
+	heartbeat(leader)
+	heartbeat(leader)
+
+	lagging.mu.Lock()
+	lagBase, lagApplied := lagging.log[0].Index, lagging.lastApplied
+	lagging.mu.Unlock()
+	if lagBase != base {
+		t.Errorf("lagging follower's base is %d, want the snapshot at %d", lagBase, base)
+	}
+	if got := lagging.lb.CurrentPolicy.MaGCThreshold; got != policy.MaGCThreshold || lagApplied == 0 {
+		t.Errorf("lagging follower applied %d with threshold %d, want threshold %d", lagApplied, got, policy.MaGCThreshold)
+	}
+}
+
+func TestRaftRestoresFromStore(t *testing.T) {
+	store := NewMemoryStore()
+	transport := &memoryRaftTransport{nodes: make(map[string]*RaftNode), down: make(map[string]bool)}
+
+	lb := newRaftTestBalancer(store)
+	node, err := NewRaftNode(lb, RaftConfig{ID: "a"}, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb.raft.Store(node)
+	elect(node)
+	if !node.IsLeader() {
+		t.Fatal("a single node did not elect itself")
+	}
+
+	policy := lb.CurrentPolicy
+	policy.Algorithm, policy.MaGCThreshold = "WRAN", 2500
+	lb.SetLoadBalancingPolicy(policy)
+	lb.GetServerByID(2).SetZone("z2")
+	heartbeat(node)
+	status := node.Status()
+
+	restoredLB := newRaftTestBalancer(store)
+	restored, err := NewRaftNode(restoredLB, RaftConfig{ID: "a"}, transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.Start(context.Background())
+	defer restored.Stop()
+
+	got := restored.Status()
+	if got.Term != status.Term || got.CommitIndex != status.CommitIndex || got.LastApplied != status.CommitIndex {
+		t.Errorf("restored term %d, commit %d, applied %d; want term %d, commit and applied %d",
+			got.Term, got.CommitIndex, got.LastApplied, status.Term, status.CommitIndex)
+	}
+	if restoredLB.CurrentPolicy != policy || restoredLB.GetServerByID(2).Zone() != "z2" {
+		t.Errorf("restored policy %+v and zone %q, want %+v and z2",
+			restoredLB.CurrentPolicy, restoredLB.GetServerByID(2).Zone(), policy)
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"io"
+	"log"
+	"strings"
+	"time"
+)
+
+// fixedClock always reports the same time, pinning replays to the decision time
+type fixedClock struct {
+	t time.Time
+}
+
+func (c fixedClock) Now() time.Time {
+	return c.t
+}
+
+// ReplayResult compares a recorded decision with re-running the algorithm on its inputs
+type ReplayResult struct {
+	Record      DecisionRecord        `json:"record"`
+	ServerID    int                   `json:"replayed_server_id"` // Zero if the replay rejected the task
+	Match       bool                  `json:"match"`
+	Explanation *SelectionExplanation `json:"explanation"`
+}
+
+// ReplayDecision rebuilds the recorded pool on a throwaway load balancer and
+// runs the same selection again. Replay servers skip the simulated latency and
+// GC side effects, and random draws are taken from the record, so a healthy
+// algorithm reproduces the recorded choice exactly.
+func ReplayDecision(record DecisionRecord) ReplayResult {
+	servers := make([]*Server, 0, len(record.Servers))
+	for _, state := range record.Servers {
+		servers = append(servers, replayServer(state, record.Time))
+	}
+
+	// Placement was resolved when recording; replay the resulting window as-is
+	flags := NewFeatureFlags()
+	flags.Set(FlagForecastPlacement, false)
+
+	policy := record.Policy
+	policy.MaGCThreshold = record.PlacementWindowMs
+
+	lb := NewLoadBalancer(
+		WithServers(servers...),
+		WithPolicy(policy),
+		WithClock(fixedClock{t: record.Time}),
+		WithLogger(log.New(io.Discard, "", 0)),
+		WithFeatureFlags(flags),
+	)
+	lb.TRINI.IsActive = record.TRINIActive
+	if len(servers) > 0 {
+		lb.currentServerIndex = record.RRIndex % len(servers)
+	}
+
+	explain := lb.newSelectionExplanation(record.Policy, record.PolicyArm)
+	explain.PlacementWindowMs = record.PlacementWindowMs
+	explain.replaying = true
+	explain.replay = record.Draws
+
+	// Only the task's size influences selection
+	taskInput := strings.Repeat("x", record.TaskSize)
+	server, _ := lb.selectWithRetries(taskInput, policy, explain, record.Retries)
+
+	result := ReplayResult{Record: record, Explanation: explain}
+	if server != nil {
+		result.ServerID = server.ID
+		explain.ServerID = server.ID
+	}
+	result.Match = result.ServerID == record.ServerID
+	return result
+}
+
+// ReplayDecisions replays every record in order
+func ReplayDecisions(records []DecisionRecord) []ReplayResult {
+	results := make([]ReplayResult, len(records))
+	for i, record := range records {
+		results[i] = ReplayDecision(record)
+	}
+	return results
+}
+
+// replayServer recreates a server from its recorded state
+func replayServer(state DecisionServer, at time.Time) *Server {
+	server := NewServer(state.ID, state.MemLimit, 100)
+	server.replay = true
+	server.adminState = state.AdminState
+	server.isCollectingGCTasks = state.CollectingGC
+	server.usedMemory = state.UsedMemory
+	server.weight = state.Weight
+	server.weightOverride = true // Keep the recorded weight through attach
+	server.Weights = state.RuntimeWeight
+	server.Cost = state.Cost
+	server.activeTasks = state.ActiveTasks
+	server.maxConcurrentTasks = state.MaxConcurrent
+	if state.SlowStartMs > 0 {
+		server.slowStartFrom = at.Add(-time.Duration(state.SlowStartElapsedMs) * time.Millisecond)
+		server.slowStartUntil = server.slowStartFrom.Add(time.Duration(state.SlowStartMs) * time.Millisecond)
+	}
+	if state.HasForecast {
+		server.LastMaGCForecast = &MaGCForecast{
+			PredictedTime:     at.Add(time.Duration(state.ForecastInMs) * time.Millisecond),
+			ForecastCreatedAt: at.Add(-time.Duration(state.ForecastAgeMs) * time.Millisecond),
+			Confidence:        state.Confidence,
+		}
+	}
+	return server
+}
@@ -0,0 +1,247 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"sort"
+)
+
+// ReplicatedConfig is the configuration a replication group keeps identical
+// on every balancer: the policy, the server pool, each server's program
+// family and pin, and the GC history and forecasts TRINI adapts from.
+// Program family definitions are built in, so only their IDs are replicated.
+type ReplicatedConfig struct {
+	Policy  LoadBalancingPolicy `json:"policy"`
+	Servers []ReplicatedServer  `json:"servers"`
+}
+
+// ReplicatedServer is one server's replicated configuration
+type ReplicatedServer struct {
+	ID          int        `json:"id"`
+	MemoryLimit int        `json:"memory_limit"`
+	GCPercent   float64    `json:"gc_percent"`
+	Zone        string     `json:"zone,omitempty"`
+	FamilyID    string     `json:"family_id,omitempty"`
+	Pin         *FamilyPin `json:"pin,omitempty"`
+
+	// TRINI's adaptive state: snapshots and MaGCs it classifies and forecasts
+	// from, scored forecasts it calibrates against and its current forecasts
+	GCHistory        []GCSnapshot         `json:"gc_history,omitempty"`
+	GCEvents         []GCEvent            `json:"gc_events,omitempty"`
+	ForecastOutcomes []ForecastOutcome    `json:"forecast_outcomes,omitempty"`
+	Forecast         *MaGCForecast        `json:"forecast,omitempty"`
+	LongForecast     *LongHorizonForecast `json:"long_forecast,omitempty"`
+}
+
+// equal reports whether both configs encode the same; times lose their
+// monotonic readings in the log, so struct comparison would not do
+func (c ReplicatedConfig) equal(other ReplicatedConfig) bool {
+	a, errA := json.Marshal(c)
+	b, errB := json.Marshal(other)
+	return errA == nil && errB == nil && bytes.Equal(a, b)
+}
+
+// ReplicatedConfig captures the configuration replicated to the group, with
+// servers ordered by ID
+func (l *LoadBalancer) ReplicatedConfig() ReplicatedConfig {
+	l.mu.Lock()
+	config := ReplicatedConfig{Policy: l.CurrentPolicy}
+	l.mu.Unlock()
+
+	config.Servers = make([]ReplicatedServer, 0)
+	for _, server := range l.ListServers() {
+		config.Servers = append(config.Servers, server.replicated())
+	}
+	sort.Slice(config.Servers, func(i, j int) bool { return config.Servers[i].ID < config.Servers[j].ID })
+	return config
+}
+
+// replicated captures the server's replicated configuration
+func (s *Server) replicated() ReplicatedServer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replicated := ReplicatedServer{
+		ID:          s.ID,
+		MemoryLimit: s.memLimit,
+		GCPercent:   s.gcPercentage * 100.0,
+		Zone:        s.zone,
+	}
+	if s.CurrentFamily != nil {
+		replicated.FamilyID = s.CurrentFamily.ID
+	}
+	if pin, ok := s.familyPinLocked(); ok {
+		replicated.Pin = &pin
+	}
+
+	replicated.GCHistory = slices.Clone(s.GCHistory)
+	replicated.GCEvents = slices.Clone(s.GCEvents)
+	replicated.ForecastOutcomes = slices.Clone(s.ForecastOutcomes)
+	if s.LastMaGCForecast != nil {
+		forecast := *s.LastMaGCForecast
+		replicated.Forecast = &forecast
+	}
+	if s.LongMaGCForecast != nil {
+		forecast := *s.LongMaGCForecast
+		forecast.PredictedTimes = slices.Clone(forecast.PredictedTimes)
+		replicated.LongForecast = &forecast
+	}
+	return replicated
+}
+
+// applyReplicatedConfig brings the policy, pool, families and pins in line
+// with config as committed by the group's leader. Parts that no longer
+// validate, such as a family this build does not know, are skipped and
+// logged rather than failing the rest.
+func (l *LoadBalancer) applyReplicatedConfig(config ReplicatedConfig) {
+	if err := config.Policy.Validate(); err != nil {
+		l.logf("⚠️ Skipping replicated policy: %v", err)
+	} else {
+		l.mu.Lock()
+		changed := l.CurrentPolicy != config.Policy
+		l.mu.Unlock()
+		if changed {
+			l.SetLoadBalancingPolicy(config.Policy)
+		}
+	}
+
+	wanted := make(map[int]bool, len(config.Servers))
+	for _, replicated := range config.Servers {
+		wanted[replicated.ID] = true
+	}
+	for _, server := range l.ListServers() {
+		if !wanted[server.ID] {
+			if err := l.RemoveServer(server.ID); err != nil {
+				l.logf("⚠️ Could not remove server %d: %v", server.ID, err)
+			}
+		}
+	}
+
+	pinsChanged := false
+	for _, replicated := range config.Servers {
+		server := l.GetServerByID(replicated.ID)
+		if server == nil {
+			var err error
+			server, err = l.addServer(replicated.ID, replicated.MemoryLimit, replicated.GCPercent)
+			if err != nil {
+				l.logf("⚠️ Could not add replicated server %d: %v", replicated.ID, err)
+				continue
+			}
+		}
+		if l.applyReplicatedServer(server, replicated) {
+			pinsChanged = true
+		}
+	}
+
+	if pinsChanged {
+		if err := l.persistFamilyPins(); err != nil {
+			l.logf("⚠️ Could not persist family pins: %v", err)
+		}
+	}
+}
+
+// applyReplicatedServer configures one server as replicated and reports
+// whether its pin changed
+func (l *LoadBalancer) applyReplicatedServer(server *Server, replicated ReplicatedServer) bool {
+	current := server.replicated()
+	server.applyReplicatedGCState(replicated)
+	if current.MemoryLimit != replicated.MemoryLimit || current.GCPercent != replicated.GCPercent {
+		if err := validateServerConfig(replicated.MemoryLimit, replicated.GCPercent/100.0); err != nil {
+			l.logf("⚠️ Skipping replicated configuration of server %d: %v", server.ID, err)
+		} else {
+			server.Configure(replicated.MemoryLimit, replicated.GCPercent)
+		}
+	}
+	if current.Zone != replicated.Zone {
+		server.SetZone(replicated.Zone)
+	}
+
+	switch {
+	case replicated.Pin != nil:
+		if current.Pin != nil && current.Pin.same(*replicated.Pin) {
+			return false
+		}
+		family, ok := l.replicatedFamily(replicated.Pin.FamilyID)
+		if !ok {
+			l.logf("⚠️ Skipping replicated pin of server %d to unknown program family '%s'", server.ID, replicated.Pin.FamilyID)
+			return false
+		}
+		server.applyFamilyPin(*replicated.Pin, family)
+		return true
+	case current.Pin != nil:
+		server.mu.Lock()
+		server.familyPin = nil
+		server.mu.Unlock()
+		l.applyReplicatedFamily(server, current.FamilyID, replicated.FamilyID)
+		return true
+	default:
+		l.applyReplicatedFamily(server, current.FamilyID, replicated.FamilyID)
+		return false
+	}
+}
+
+// applyReplicatedGCState replaces the server's GC history and forecasts with
+// the leader's, so taking over leadership resumes adapting from them. The
+// last scored forecast is the newest outcome's, so none is scored twice.
+func (s *Server) applyReplicatedGCState(replicated ReplicatedServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.GCHistory = append(make([]GCSnapshot, 0, 100), replicated.GCHistory...)
+	s.GCEvents = slices.Clone(replicated.GCEvents)
+	s.ForecastOutcomes = slices.Clone(replicated.ForecastOutcomes)
+	if n := len(s.ForecastOutcomes); n > 0 {
+		s.lastScoredForecast = s.ForecastOutcomes[n-1].ForecastCreatedAt
+	}
+	s.LastMaGCForecast, s.LongMaGCForecast = nil, nil
+	if replicated.Forecast != nil {
+		forecast := *replicated.Forecast
+		s.LastMaGCForecast = &forecast
+	}
+	if replicated.LongForecast != nil {
+		forecast := *replicated.LongForecast
+		forecast.PredictedTimes = slices.Clone(forecast.PredictedTimes)
+		s.LongMaGCForecast = &forecast
+	}
+}
+
+// same reports whether both pins fix the same family over the same period
+func (p FamilyPin) same(other FamilyPin) bool {
+	return p.ServerID == other.ServerID && p.FamilyID == other.FamilyID &&
+		p.PinnedAt.Equal(other.PinnedAt) && p.ExpiresAt.Equal(other.ExpiresAt)
+}
+
+// applyReplicatedFamily moves the server to the leader's classification of it
+func (l *LoadBalancer) applyReplicatedFamily(server *Server, currentID, familyID string) {
+	if familyID == "" || familyID == currentID {
+		return
+	}
+	family, ok := l.replicatedFamily(familyID)
+	if !ok {
+		l.logf("⚠️ Skipping replicated program family '%s' of server %d", familyID, server.ID)
+		return
+	}
+	server.mu.Lock()
+	server.CurrentFamily = family
+	server.recordTimelineLocked(TimelineFamily, "Replicated program family '%s'", familyID)
+	server.mu.Unlock()
+}
+
+// replicatedFamily looks up a program family named in a replicated config
+func (l *LoadBalancer) replicatedFamily(id string) (*ProgramFamily, bool) {
+	if l.TRINI == nil {
+		return nil, false
+	}
+	return l.TRINI.programFamily(id)
+}
+
+// followsLeader reports whether another balancer in the replication group
+// leads it, so the configuration it replicates must not change locally
+func (l *LoadBalancer) followsLeader() bool {
+	if l == nil {
+		return false
+	}
+	node := l.raft.Load()
+	return node != nil && !node.IsLeader()
+}
@@ -0,0 +1,225 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// ServerStatus is a server's ping result
+type ServerStatus struct {
+	ServerID       int     `json:"server_id"`
+	Status         string  `json:"status"`
+	IsAvailable    bool    `json:"is_available"`
+	IsCollectingGC bool    `json:"is_collecting_gc"`
+	AdminState     string  `json:"admin_state"`
+	Zone           string  `json:"zone,omitempty"`
+	MemUsed        string  `json:"mem_used"`
+	TasksProcessed int     `json:"tasks_processed"`
+	Cost           float64 `json:"cost"`
+	Weight         int     `json:"weight"`
+	ActiveTasks    int     `json:"active_tasks"`
+	MaxConcurrent  int     `json:"max_concurrent_tasks"` // 0 is unlimited
+	// Interactive and batch lane load and outcomes
+	Lanes       []LaneStats `json:"lanes"`
+	TaskIDs     []string    `json:"task_ids"`
+	MemoryUsage string      `json:"memory_usage"`
+}
+
+// PolicyInfo is the active load balancing policy as reported by TRINI status
+type PolicyInfo struct {
+	Algorithm     string `json:"algorithm"`
+	GCAware       bool   `json:"gc_aware"`
+	MaGCThreshold int64  `json:"magc_threshold_ms"`
+	HistoryWindow int    `json:"history_window"`
+	// Notice window for the X-GC-Imminent header; 0 when disabled
+	GCImminentHeaderMs int64 `json:"gc_imminent_header_ms"`
+	// Tiebreaker among GC-safe servers; empty means the algorithm's own order
+	Tiebreaker string `json:"tiebreaker,omitempty"`
+	// hard or soft GC avoidance; empty means hard
+	GCAvoidance string `json:"gc_avoidance,omitempty"`
+	// Forecasts below this confidence are ignored; 0 trusts every forecast
+	ConfidenceFloor float64 `json:"confidence_floor"`
+	// Server-side task execution timeout; 0 when tasks may run indefinitely
+	TaskTimeoutMs int64 `json:"task_timeout_ms"`
+	// What happens to a task no server is eligible for; empty means fail_fast
+	OnNoServer     string `json:"on_no_server,omitempty"`
+	NoServerWaitMs int64  `json:"no_server_wait_ms,omitempty"`
+	BlockCapacity  int    `json:"block_capacity,omitempty"`
+}
+
+// FamilyInfo is the program family a server is classified into
+type FamilyInfo struct {
+	ID                 string `json:"id"`
+	Name               string `json:"name"`
+	Description        string `json:"description"`
+	MaGCThreshold      int64  `json:"magc_threshold_ms"`
+	ForecastWindowSize int    `json:"forecast_window_size"`
+}
+
+// ForecastInfo is a server's latest short-horizon MaGC forecast
+type ForecastInfo struct {
+	PredictedTime              time.Time `json:"predicted_time"`
+	Confidence                 float64   `json:"confidence"`     // Calibrated against past outcomes
+	RawConfidence              float64   `json:"raw_confidence"` // Before calibration
+	YoungGenThreshold          int       `json:"young_gen_threshold"`
+	TimeToMaGC                 int64     `json:"time_to_magc_ms"`
+	ForecastCreatedAt          time.Time `json:"forecast_created_at"`
+	IsPredictedWithinThreshold bool      `json:"is_predicted_within_threshold"`
+}
+
+// TRINIServerInfo is one server's GC classification and heap state
+type TRINIServerInfo struct {
+	ServerID         int                  `json:"server_id"`
+	CurrentFamily    *FamilyInfo          `json:"current_family"`
+	GCHistoryCount   int                  `json:"gc_history_count"`
+	LastMaGCForecast *ForecastInfo        `json:"last_magc_forecast"`
+	LongMaGCForecast *LongHorizonForecast `json:"long_magc_forecast,omitempty"`
+	FamilyPin        *FamilyPin           `json:"family_pin,omitempty"`
+	YoungGenUsed     int                  `json:"young_gen_used"`
+	OldGenUsed       int                  `json:"old_gen_used"`
+	YoungGenMax      int                  `json:"young_gen_max"`
+	OldGenMax        int                  `json:"old_gen_max"`
+	GCCount          int                  `json:"gc_count"`
+	Weights          int                  `json:"weights"`
+	// The forecast is below the policy's confidence floor and ignored
+	ForecastSuppressed bool `json:"forecast_suppressed"`
+}
+
+// TRINIStatus reports whether TRINI is active and how servers are classified
+type TRINIStatus struct {
+	Active          bool   `json:"active"`
+	MonitorInterval string `json:"monitor_interval"`
+	// Interval the monitoring loop samples at now, and its bounds when adaptive
+	CurrentMonitorInterval string            `json:"current_monitor_interval"`
+	MinMonitorInterval     string            `json:"min_monitor_interval,omitempty"`
+	MaxMonitorInterval     string            `json:"max_monitor_interval,omitempty"`
+	AnalysisInterval       string            `json:"analysis_interval"`
+	ProgramFamilies        int               `json:"program_families"`
+	CurrentPolicy          PolicyInfo        `json:"current_policy"`
+	Servers                []TRINIServerInfo `json:"servers"`
+	Rollout                *PolicyRollout    `json:"rollout"`
+	// Servers whose forecasts are ignored for being below the confidence floor
+	SuppressedForecasts int `json:"suppressed_forecasts"`
+}
+
+// pingResultLocked builds the Ping result; callers hold s.mu
+func (s *Server) pingResultLocked() ServerStatus {
+	utilization := float64(s.usedMemory) / float64(s.memLimit) * 100
+	return ServerStatus{
+		ServerID:       s.ID,
+		Status:         "online",
+		IsAvailable:    !s.isCollectingGCTasks && s.adminStateLocked() == StateActive,
+		IsCollectingGC: s.isCollectingGCTasks,
+		AdminState:     s.adminStateLocked(),
+		Zone:           s.zone,
+		MemUsed:        fmt.Sprintf("%.1f%%", utilization),
+		TasksProcessed: len(s.TaskStorage),
+		Cost:           s.Cost,
+		Weight:         s.weight,
+		ActiveTasks:    s.activeTasks,
+		MaxConcurrent:  s.maxConcurrentTasks,
+		Lanes:          s.laneStatsLocked(),
+		TaskIDs:        s.TaskStorage,
+		MemoryUsage:    fmt.Sprintf("%d/%d (%.1f%%)", s.usedMemory, s.memLimit, utilization),
+	}
+}
+
+// triniInfo reports the server's classification, forecasts and heap state
+// under the given policy
+func (s *Server) triniInfo(policy LoadBalancingPolicy) TRINIServerInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info := TRINIServerInfo{
+		ServerID:         s.ID,
+		GCHistoryCount:   len(s.GCHistory),
+		LongMaGCForecast: s.longForecastLocked(),
+		YoungGenUsed:     s.YoungGenUsed,
+		OldGenUsed:       s.OldGenUsed,
+		YoungGenMax:      s.YoungGenMax,
+		OldGenMax:        s.OldGenMax,
+		GCCount:          s.GCCount,
+		Weights:          s.Weights,
+
+		ForecastSuppressed: s.forecastSuppressedLocked(policy.ConfidenceFloor),
+	}
+
+	if family := s.CurrentFamily; family != nil {
+		info.CurrentFamily = &FamilyInfo{
+			ID:                 family.ID,
+			Name:               family.Name,
+			Description:        family.Description,
+			MaGCThreshold:      family.MaGCThreshold,
+			ForecastWindowSize: family.ForecastWindowSize,
+		}
+	}
+
+	if forecast := s.LastMaGCForecast; forecast != nil {
+		timeToMaGC, ok := s.timeToMaGCLocked()
+		info.LastMaGCForecast = &ForecastInfo{
+			PredictedTime:              forecast.PredictedTime,
+			Confidence:                 forecast.Confidence,
+			RawConfidence:              forecast.RawConfidence,
+			YoungGenThreshold:          forecast.YoungGenThreshold,
+			TimeToMaGC:                 forecast.TimeToMaGC,
+			ForecastCreatedAt:          forecast.ForecastCreatedAt,
+			IsPredictedWithinThreshold: ok && timeToMaGC.Milliseconds() <= policy.MaGCThreshold,
+		}
+	}
+
+	if long := info.LongMaGCForecast; long != nil {
+		copied := *long
+		copied.PredictedTimes = append([]time.Time(nil), long.PredictedTimes...)
+		info.LongMaGCForecast = &copied
+	}
+
+	if pin, ok := s.familyPinLocked(); ok {
+		info.FamilyPin = &pin
+	}
+	return info
+}
+
+// TRINIStatus reports TRINI's state, the active policy and every server's
+// classification. It reports false when TRINI is not initialized.
+func (l *LoadBalancer) TRINIStatus() (TRINIStatus, bool) {
+	if l.TRINI == nil {
+		return TRINIStatus{}, false
+	}
+
+	policy := l.GetLoadBalancingPolicy()
+	status := TRINIStatus{
+		Active:           l.TRINI.IsActive,
+		MonitorInterval:  l.TRINI.MonitorInterval.String(),
+		AnalysisInterval: l.TRINI.AnalysisInterval.String(),
+		ProgramFamilies:  len(l.TRINI.ProgramFamilies),
+		CurrentPolicy: PolicyInfo{
+			Algorithm:          policy.Algorithm,
+			GCAware:            policy.GCAware,
+			MaGCThreshold:      policy.MaGCThreshold,
+			HistoryWindow:      policy.HistoryWindowSize,
+			GCImminentHeaderMs: policy.GCImminentHeaderMs,
+			Tiebreaker:         policy.Tiebreaker,
+			GCAvoidance:        policy.GCAvoidance,
+			ConfidenceFloor:    policy.ConfidenceFloor,
+			TaskTimeoutMs:      policy.TaskTimeoutMs,
+			OnNoServer:         policy.OnNoServer,
+			NoServerWaitMs:     policy.NoServerWaitMs,
+			BlockCapacity:      policy.BlockCapacity,
+		},
+		Servers: make([]TRINIServerInfo, 0),
+		Rollout: l.Rollout(),
+	}
+	status.CurrentMonitorInterval = l.TRINI.CurrentMonitorInterval().String()
+	if l.TRINI.MinMonitorInterval > 0 {
+		status.MinMonitorInterval = l.TRINI.MinMonitorInterval.String()
+		status.MaxMonitorInterval = l.TRINI.MaxMonitorInterval.String()
+	}
+	for _, server := range l.ListServers() {
+		info := server.triniInfo(policy)
+		if info.ForecastSuppressed {
+			status.SuppressedForecasts++
+		}
+		status.Servers = append(status.Servers, info)
+	}
+	return status, true
+}
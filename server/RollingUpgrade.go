@@ -0,0 +1,431 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Rolling upgrade states
+const (
+	UpgradeRunning   = "running"
+	UpgradeCompleted = "completed"
+	UpgradeAborted   = "aborted"
+)
+
+// Steps each server goes through during a rolling upgrade
+const (
+	UpgradeStepPending   = "pending"
+	UpgradeStepWaiting   = "waiting" // Holding off while the rest of the fleet is under GC pressure
+	UpgradeStepDraining  = "draining"
+	UpgradeStepReady     = "ready" // Drained; waiting for the upgrade to be reported done
+	UpgradeStepSlowStart = "slow_start"
+	UpgradeStepDone      = "done"
+)
+
+const (
+	// upgradePollInterval is how often the orchestrator rechecks drain and GC conditions
+	upgradePollInterval = 250 * time.Millisecond
+	// upgradeWebhookTimeout bounds each readiness notification
+	upgradeWebhookTimeout = 5 * time.Second
+	// slowStartMinShare is the share of selections a server admits when slow start begins
+	slowStartMinShare = 0.1
+
+	// slowStartScale is the range of the draw deciding whether slow start admits a selection
+	slowStartScale = 1000
+)
+
+// RollingUpgradeConfig controls how servers are taken out for upgrade
+type RollingUpgradeConfig struct {
+	ServerIDs      []int   `json:"server_ids"`            // Upgrade order; all servers in pool order when empty
+	WebhookURL     string  `json:"webhook_url,omitempty"` // Receives an UpgradeNotice when a server is ready
+	SlowStartMs    int64   `json:"slow_start_ms"`         // Ramp from 10% to full traffic after the upgrade; 0 disables
+	DrainTimeoutMs int64   `json:"drain_timeout_ms"`      // Abort when in-flight tasks take longer; 0 waits indefinitely
+	MaxGCPressure  float64 `json:"max_gc_pressure"`       // Do not drain while the rest of the fleet averages more
+}
+
+// UpgradeStep is one server's progress through a rolling upgrade
+type UpgradeStep struct {
+	ServerID    int        `json:"server_id"`
+	State       string     `json:"state"`
+	PriorState  string     `json:"prior_state"` // Administrative state restored afterwards
+	DrainedAt   *time.Time `json:"drained_at,omitempty"`
+	UpgradedAt  *time.Time `json:"upgraded_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// RollingUpgrade drains, upgrades and restores servers one at a time
+type RollingUpgrade struct {
+	Config     RollingUpgradeConfig `json:"config"`
+	State      string               `json:"state"`
+	Reason     string               `json:"reason,omitempty"`
+	Steps      []UpgradeStep        `json:"steps"`
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+
+	cancel   context.CancelFunc
+	upgraded chan int // Server IDs reported upgraded
+}
+
+// UpgradeNotice is the body POSTed to the webhook when a server is ready for upgrade
+type UpgradeNotice struct {
+	ServerID  int       `json:"server_id"`
+	State     string    `json:"state"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// DefaultRollingUpgradeConfig upgrades every server with a 30 second slow start
+func DefaultRollingUpgradeConfig() RollingUpgradeConfig {
+	return RollingUpgradeConfig{
+		SlowStartMs:    30000,
+		DrainTimeoutMs: 60000,
+		MaxGCPressure:  60,
+	}
+}
+
+// Validate checks the timings and pressure bound
+func (c RollingUpgradeConfig) Validate() error {
+	if c.SlowStartMs < 0 {
+		return errors.New("slow_start_ms cannot be negative")
+	}
+	if c.DrainTimeoutMs < 0 {
+		return errors.New("drain_timeout_ms cannot be negative")
+	}
+	if c.MaxGCPressure <= 0 || c.MaxGCPressure > 100 {
+		return errors.New("max_gc_pressure must be between 0 and 100")
+	}
+	return nil
+}
+
+// StartRollingUpgrade begins draining servers one at a time. Each drained
+// server waits for CompleteServerUpgrade before it rejoins with slow start.
+func (l *LoadBalancer) StartRollingUpgrade(config RollingUpgradeConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	ids := config.ServerIDs
+	if len(ids) == 0 {
+		for _, server := range l.ListServers() {
+			ids = append(ids, server.ID)
+		}
+	}
+	steps := make([]UpgradeStep, 0, len(ids))
+	for _, id := range ids {
+		server := l.GetServerByID(id)
+		if server == nil {
+			return fmt.Errorf("server %d not found", id)
+		}
+		steps = append(steps, UpgradeStep{ServerID: id, State: UpgradeStepPending, PriorState: server.AdminState()})
+	}
+	if len(steps) == 0 {
+		return errors.New("no servers to upgrade")
+	}
+
+	l.upgradeMu.Lock()
+	defer l.upgradeMu.Unlock()
+	if l.upgrade != nil && l.upgrade.State == UpgradeRunning {
+		return errors.New("a rolling upgrade is already in progress")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	upgrade := &RollingUpgrade{
+		Config:    config,
+		State:     UpgradeRunning,
+		Steps:     steps,
+		StartedAt: l.now(),
+		cancel:    cancel,
+		upgraded:  make(chan int, 1),
+	}
+	l.upgrade = upgrade
+
+	l.RecordEvent("upgrade_started", 0, "Rolling upgrade of %d servers started", len(steps))
+	l.spawn(func() { l.runRollingUpgrade(ctx, upgrade) })
+	return nil
+}
+
+// RollingUpgrade returns a copy of the current or most recent rolling upgrade, or nil if none
+func (l *LoadBalancer) RollingUpgrade() *RollingUpgrade {
+	l.upgradeMu.Lock()
+	defer l.upgradeMu.Unlock()
+
+	if l.upgrade == nil {
+		return nil
+	}
+	upgrade := *l.upgrade
+	upgrade.Steps = append([]UpgradeStep(nil), l.upgrade.Steps...)
+	return &upgrade
+}
+
+// CompleteServerUpgrade reports that a server waiting in the ready step has
+// been upgraded, so it can rejoin the pool
+func (l *LoadBalancer) CompleteServerUpgrade(id int) error {
+	l.upgradeMu.Lock()
+	upgrade := l.upgrade
+	if upgrade == nil || upgrade.State != UpgradeRunning {
+		l.upgradeMu.Unlock()
+		return errors.New("no rolling upgrade in progress")
+	}
+	ready := false
+	for _, step := range upgrade.Steps {
+		if step.ServerID == id && step.State == UpgradeStepReady {
+			ready = true
+		}
+	}
+	l.upgradeMu.Unlock()
+
+	if !ready {
+		return fmt.Errorf("server %d is not waiting for an upgrade", id)
+	}
+	select {
+	case upgrade.upgraded <- id:
+		return nil
+	default:
+		return fmt.Errorf("upgrade of server %d was already reported", id)
+	}
+}
+
+// AbortRollingUpgrade stops the rolling upgrade; the server being upgraded
+// returns to its prior state without slow start
+func (l *LoadBalancer) AbortRollingUpgrade(reason string) error {
+	l.upgradeMu.Lock()
+	defer l.upgradeMu.Unlock()
+
+	if l.upgrade == nil || l.upgrade.State != UpgradeRunning {
+		return errors.New("no rolling upgrade in progress")
+	}
+	l.finishUpgradeLocked(l.upgrade, UpgradeAborted, reason)
+	return nil
+}
+
+// finishUpgradeLocked ends the upgrade and stops its orchestrator; callers hold upgradeMu
+func (l *LoadBalancer) finishUpgradeLocked(upgrade *RollingUpgrade, state, reason string) {
+	now := l.now()
+	upgrade.State = state
+	upgrade.Reason = reason
+	upgrade.FinishedAt = &now
+	upgrade.cancel()
+
+	if state == UpgradeCompleted {
+		l.RecordEvent("upgrade_completed", 0, "Rolling upgrade completed: %s", reason)
+	} else {
+		l.RecordEvent("upgrade_aborted", 0, "Rolling upgrade aborted: %s", reason)
+	}
+}
+
+// setUpgradeStep moves a server to the next step and timestamps it
+func (l *LoadBalancer) setUpgradeStep(upgrade *RollingUpgrade, i int, state string) {
+	l.upgradeMu.Lock()
+	defer l.upgradeMu.Unlock()
+
+	now := l.now()
+	step := &upgrade.Steps[i]
+	step.State = state
+	switch state {
+	case UpgradeStepReady:
+		step.DrainedAt = &now
+	case UpgradeStepSlowStart:
+		step.UpgradedAt = &now
+	case UpgradeStepDone:
+		step.CompletedAt = &now
+	}
+}
+
+// runRollingUpgrade upgrades the servers in order until done or aborted
+func (l *LoadBalancer) runRollingUpgrade(ctx context.Context, upgrade *RollingUpgrade) {
+	for i, step := range upgrade.Steps {
+		server := l.GetServerByID(step.ServerID)
+		if server == nil {
+			l.setUpgradeStep(upgrade, i, UpgradeStepDone) // Removed from the pool meanwhile
+			continue
+		}
+		if err := l.upgradeServer(ctx, upgrade, i, server); err != nil {
+			l.SetServerState(server.ID, step.PriorState)
+			l.upgradeMu.Lock()
+			if upgrade.State == UpgradeRunning {
+				l.finishUpgradeLocked(upgrade, UpgradeAborted, err.Error())
+			}
+			l.upgradeMu.Unlock()
+			return
+		}
+	}
+
+	l.upgradeMu.Lock()
+	defer l.upgradeMu.Unlock()
+	if upgrade.State == UpgradeRunning {
+		l.finishUpgradeLocked(upgrade, UpgradeCompleted, fmt.Sprintf("%d servers upgraded", len(upgrade.Steps)))
+	}
+}
+
+// upgradeServer drains one server, waits for its upgrade and brings it back
+func (l *LoadBalancer) upgradeServer(ctx context.Context, upgrade *RollingUpgrade, i int, server *Server) error {
+	config := upgrade.Config
+	ticker := time.NewTicker(upgradePollInterval)
+	defer ticker.Stop()
+
+	// Taking capacity away just before other servers pause would leave too few
+	// to absorb their traffic
+	l.setUpgradeStep(upgrade, i, UpgradeStepWaiting)
+	for !l.upgradeSafe(server, config.MaxGCPressure) {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	l.setUpgradeStep(upgrade, i, UpgradeStepDraining)
+	if err := l.SetServerState(server.ID, StateDraining); err != nil {
+		return err
+	}
+	deadline := l.now().Add(time.Duration(config.DrainTimeoutMs) * time.Millisecond)
+	for server.ActiveTasks() > 0 {
+		if config.DrainTimeoutMs > 0 && l.now().After(deadline) {
+			return fmt.Errorf("server %d did not drain within %dms", server.ID, config.DrainTimeoutMs)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	l.setUpgradeStep(upgrade, i, UpgradeStepReady)
+	l.RecordEvent("upgrade_ready", server.ID, "Server %d drained and ready for upgrade", server.ID)
+	if config.WebhookURL != "" {
+		l.spawn(func() { l.notifyUpgradeReady(config.WebhookURL, server.ID) })
+	}
+	for waiting := true; waiting; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case id := <-upgrade.upgraded:
+			waiting = id != server.ID
+		}
+	}
+
+	l.setUpgradeStep(upgrade, i, UpgradeStepSlowStart)
+	server.startSlowStart(time.Duration(config.SlowStartMs) * time.Millisecond)
+	if err := l.SetServerState(server.ID, upgrade.Steps[i].PriorState); err != nil {
+		return err
+	}
+	l.RecordEvent("upgrade_rejoined", server.ID, "Server %d upgraded, rejoining with %dms slow start", server.ID, config.SlowStartMs)
+
+	// Upgrade the next server only once this one takes full traffic again
+	for server.inSlowStart() {
+		select {
+		case <-ctx.Done():
+			server.startSlowStart(0)
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	l.setUpgradeStep(upgrade, i, UpgradeStepDone)
+	return nil
+}
+
+// upgradeSafe reports whether the rest of the fleet can absorb the server's
+// traffic: none of the other active servers is collecting or expects a MaGC
+// within the policy threshold, and their mean GC pressure is below maxPressure
+func (l *LoadBalancer) upgradeSafe(target *Server, maxPressure float64) bool {
+	threshold := l.GetLoadBalancingPolicy().MaGCThreshold
+	var total float64
+	others := 0
+	for _, server := range l.ListServers() {
+		if server == target || server.AdminState() != StateActive {
+			continue
+		}
+		if !server.IsAvailable() || server.isMaGCExpected(threshold) {
+			return false
+		}
+		total += server.GCPressure().Score
+		others++
+	}
+	return others == 0 || total/float64(others) < maxPressure
+}
+
+// notifyUpgradeReady POSTs an UpgradeNotice; failures are logged, the
+// upgrade can still be reported through the API
+func (l *LoadBalancer) notifyUpgradeReady(url string, serverID int) {
+	body, err := json.Marshal(UpgradeNotice{ServerID: serverID, State: UpgradeStepReady, Timestamp: l.now()})
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), upgradeWebhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		l.logf("⚠️ Upgrade webhook failed for server %d: %v", serverID, err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		l.logf("⚠️ Upgrade webhook failed for server %d: %v", serverID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		l.logf("⚠️ Upgrade webhook for server %d returned %s", serverID, resp.Status)
+	}
+}
+
+// startSlowStart ramps the server's share of selections up over duration; 0 ends slow start
+func (s *Server) startSlowStart(duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.slowStartFrom = s.now()
+	s.slowStartUntil = s.slowStartFrom.Add(duration)
+}
+
+// inSlowStart reports whether the server is still ramping up
+func (s *Server) inSlowStart() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.now().Before(s.slowStartUntil)
+}
+
+// slowStartAdmits reports whether a selection may use the server. During slow
+// start it admits a share growing linearly from slowStartMinShare to all. The
+// draw goes through explain, so a replay makes the same choice, and is made
+// once per selection pass however often the algorithm revisits the server.
+func (s *Server) slowStartAdmits(explain *SelectionExplanation) bool {
+	s.mu.Lock()
+	elapsedMs, durationMs := s.slowStartProgressLocked(s.now())
+	s.mu.Unlock()
+
+	if durationMs == 0 {
+		return true
+	}
+	if admitted, drawn := explain.slowStartVerdict(s.ID); drawn {
+		return admitted
+	}
+	admitted := explain.intn(slowStartScale) < slowStartShare(elapsedMs, durationMs)
+	explain.recordSlowStart(s.ID, admitted)
+	return admitted
+}
+
+// slowStartProgressLocked returns how far into its slow start the server is
+// at now, in milliseconds of the total; both are 0 outside slow start.
+// Callers hold s.mu.
+func (s *Server) slowStartProgressLocked(now time.Time) (elapsedMs, durationMs int64) {
+	if !now.Before(s.slowStartUntil) {
+		return 0, 0
+	}
+	durationMs = max(s.slowStartUntil.Sub(s.slowStartFrom).Milliseconds(), 1)
+	elapsedMs = min(max(now.Sub(s.slowStartFrom).Milliseconds(), 0), durationMs)
+	return elapsedMs, durationMs
+}
+
+// slowStartShare is how many selections out of slowStartScale a server
+// admits elapsedMs into a slow start of durationMs
+func slowStartShare(elapsedMs, durationMs int64) int {
+	minShare := int64(slowStartMinShare * slowStartScale)
+	return int(minShare + (slowStartScale-minShare)*elapsedMs/durationMs)
+}
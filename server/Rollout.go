@@ -0,0 +1,244 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Rollout states
+const (
+	RolloutStaged     = "staged"
+	RolloutPromoted   = "promoted"
+	RolloutRolledBack = "rolled_back"
+)
+
+// Rollout traffic arms
+const (
+	armBaseline  = "baseline"
+	armCandidate = "candidate"
+)
+
+// RolloutConfig controls how a candidate policy is evaluated
+type RolloutConfig struct {
+	TrafficShare          float64 `json:"traffic_share"`            // Fraction of tasks routed with the candidate (0.0-1.0)
+	MinSamples            int64   `json:"min_samples"`              // Tasks per arm before deciding
+	MaxRejectionRateDelta float64 `json:"max_rejection_rate_delta"` // Allowed rejection rate increase over baseline
+	MaxLatencyRatio       float64 `json:"max_latency_ratio"`        // Allowed candidate/baseline average latency
+}
+
+// RolloutArmStats aggregates task outcomes for one side of a rollout
+type RolloutArmStats struct {
+	Tasks          int64   `json:"tasks"`
+	Completed      int64   `json:"completed"`
+	Rejected       int64   `json:"rejected"`
+	TotalLatencyMs int64   `json:"total_latency_ms"`
+	RejectionRate  float64 `json:"rejection_rate"`
+	AvgLatencyMs   float64 `json:"avg_latency_ms"`
+}
+
+// PolicyRollout tracks a blue/green comparison between the current and a candidate policy
+type PolicyRollout struct {
+	Baseline       LoadBalancingPolicy `json:"baseline"`
+	Candidate      LoadBalancingPolicy `json:"candidate"`
+	Config         RolloutConfig       `json:"config"`
+	State          string              `json:"state"`
+	Reason         string              `json:"reason,omitempty"`
+	StartedAt      time.Time           `json:"started_at"`
+	DecidedAt      time.Time           `json:"decided_at"`
+	BaselineStats  RolloutArmStats     `json:"baseline_stats"`
+	CandidateStats RolloutArmStats     `json:"candidate_stats"`
+}
+
+// DefaultRolloutConfig returns a cautious 10% canary configuration
+func DefaultRolloutConfig() RolloutConfig {
+	return RolloutConfig{
+		TrafficShare:          0.1,
+		MinSamples:            20,
+		MaxRejectionRateDelta: 0.05,
+		MaxLatencyRatio:       1.5,
+	}
+}
+
+// Validate checks the rollout thresholds
+func (c RolloutConfig) Validate() error {
+	if c.TrafficShare <= 0 || c.TrafficShare >= 1 {
+		return errors.New("traffic_share must be between 0 and 1")
+	}
+	if c.MinSamples < 1 {
+		return errors.New("min_samples must be at least 1")
+	}
+	if c.MaxRejectionRateDelta < 0 {
+		return errors.New("max_rejection_rate_delta cannot be negative")
+	}
+	if c.MaxLatencyRatio < 1 {
+		return errors.New("max_latency_ratio must be at least 1")
+	}
+	return nil
+}
+
+// record adds one task outcome to the arm
+func (a *RolloutArmStats) record(completed bool, latency time.Duration) {
+	a.Tasks++
+	if completed {
+		a.Completed++
+		a.TotalLatencyMs += latency.Milliseconds()
+		a.AvgLatencyMs = float64(a.TotalLatencyMs) / float64(a.Completed)
+	} else {
+		a.Rejected++
+	}
+	a.RejectionRate = float64(a.Rejected) / float64(a.Tasks)
+}
+
+// StageRollout starts routing a share of traffic through the candidate policy
+func (l *LoadBalancer) StageRollout(candidate LoadBalancingPolicy, config RolloutConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	baseline := l.GetLoadBalancingPolicy()
+
+	l.rolloutMu.Lock()
+	defer l.rolloutMu.Unlock()
+
+	if l.rollout != nil && l.rollout.State == RolloutStaged {
+		return errors.New("a rollout is already in progress")
+	}
+
+	l.rollout = &PolicyRollout{
+		Baseline:  baseline,
+		Candidate: candidate,
+		Config:    config,
+		State:     RolloutStaged,
+		StartedAt: l.now(),
+	}
+
+	l.RecordEvent("rollout_staged", 0, "Staged policy %s (threshold: %dms) with %.0f%% of traffic",
+		candidate.Algorithm, candidate.MaGCThreshold, config.TrafficShare*100)
+	return nil
+}
+
+// Rollout returns a copy of the current or most recent rollout, or nil if none
+func (l *LoadBalancer) Rollout() *PolicyRollout {
+	l.rolloutMu.Lock()
+	defer l.rolloutMu.Unlock()
+
+	if l.rollout == nil {
+		return nil
+	}
+	rollout := *l.rollout
+	return &rollout
+}
+
+// PromoteRollout makes the staged candidate the current policy
+func (l *LoadBalancer) PromoteRollout(reason string) error {
+	return l.concludeRollout(RolloutPromoted, reason)
+}
+
+// RollbackRollout abandons the staged candidate and keeps the baseline policy
+func (l *LoadBalancer) RollbackRollout(reason string) error {
+	return l.concludeRollout(RolloutRolledBack, reason)
+}
+
+// concludeRollout finishes the staged rollout and, once rolloutMu is
+// released, puts a promoted candidate in effect
+func (l *LoadBalancer) concludeRollout(state string, reason string) error {
+	l.rolloutMu.Lock()
+	err := l.concludeRolloutLocked(state, reason)
+	candidate := l.rollout
+	l.rolloutMu.Unlock()
+
+	if err == nil && state == RolloutPromoted {
+		l.SetLoadBalancingPolicy(candidate.Candidate)
+	}
+	return err
+}
+
+// concludeRolloutLocked marks the staged rollout decided; callers hold
+// rolloutMu and apply a promoted candidate after releasing it
+func (l *LoadBalancer) concludeRolloutLocked(state string, reason string) error {
+	if l.rollout == nil || l.rollout.State != RolloutStaged {
+		return errors.New("no rollout in progress")
+	}
+
+	l.rollout.State = state
+	l.rollout.Reason = reason
+	l.rollout.DecidedAt = l.now()
+
+	if state == RolloutPromoted {
+		l.RecordEvent("rollout_promoted", 0, "Promoted policy %s: %s", l.rollout.Candidate.Algorithm, reason)
+	} else {
+		l.RecordEvent("rollout_rolled_back", 0, "Rolled back policy %s: %s", l.rollout.Candidate.Algorithm, reason)
+	}
+	return nil
+}
+
+// policyForDispatch chooses the policy for the next task and the rollout arm it belongs to
+func (l *LoadBalancer) policyForDispatch() (LoadBalancingPolicy, string) {
+	l.rolloutMu.Lock()
+	rollout := l.rollout
+	if rollout == nil || rollout.State != RolloutStaged {
+		l.rolloutMu.Unlock()
+		return l.GetLoadBalancingPolicy(), ""
+	}
+	defer l.rolloutMu.Unlock()
+
+	if rand.Float64() < rollout.Config.TrafficShare {
+		return rollout.Candidate, armCandidate
+	}
+	return rollout.Baseline, armBaseline
+}
+
+// recordRolloutOutcome attributes a task outcome to a rollout arm and
+// promotes or rolls back once both arms have enough samples
+func (l *LoadBalancer) recordRolloutOutcome(arm string, completed bool, latency time.Duration) {
+	if arm == "" {
+		return
+	}
+
+	l.rolloutMu.Lock()
+	rollout := l.rollout
+	if rollout == nil || rollout.State != RolloutStaged {
+		l.rolloutMu.Unlock()
+		return
+	}
+
+	if arm == armCandidate {
+		rollout.CandidateStats.record(completed, latency)
+	} else {
+		rollout.BaselineStats.record(completed, latency)
+	}
+
+	state, reason := rollout.verdict()
+	if state != "" {
+		l.concludeRolloutLocked(state, reason)
+	}
+	l.rolloutMu.Unlock()
+
+	if state == RolloutPromoted {
+		l.SetLoadBalancingPolicy(rollout.Candidate)
+	}
+}
+
+// verdict decides a staged rollout once both arms have enough samples,
+// returning an empty state while it is undecided
+func (r *PolicyRollout) verdict() (string, string) {
+	baseline := r.BaselineStats
+	candidate := r.CandidateStats
+	if baseline.Tasks < r.Config.MinSamples || candidate.Tasks < r.Config.MinSamples {
+		return "", ""
+	}
+
+	if candidate.RejectionRate > baseline.RejectionRate+r.Config.MaxRejectionRateDelta {
+		return RolloutRolledBack, fmt.Sprintf("rejection rate %.1f%% vs baseline %.1f%%",
+			candidate.RejectionRate*100, baseline.RejectionRate*100)
+	}
+
+	if baseline.AvgLatencyMs > 0 && candidate.AvgLatencyMs > baseline.AvgLatencyMs*r.Config.MaxLatencyRatio {
+		return RolloutRolledBack, fmt.Sprintf("average latency %.0fms vs baseline %.0fms",
+			candidate.AvgLatencyMs, baseline.AvgLatencyMs)
+	}
+
+	return RolloutPromoted, fmt.Sprintf("candidate within thresholds after %d tasks", candidate.Tasks)
+}
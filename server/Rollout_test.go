@@ -0,0 +1,109 @@
+package server
+
+import (
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// rolloutOutcome is one task outcome recorded against a rollout arm
+type rolloutOutcome struct {
+	arm       string
+	completed bool
+	latencyMs int64
+}
+
+// completedOutcomes returns n completed tasks on the arm taking latencyMs each
+func completedOutcomes(arm string, n int, latencyMs int64) []rolloutOutcome {
+	outcomes := make([]rolloutOutcome, n)
+	for i := range outcomes {
+		outcomes[i] = rolloutOutcome{arm: arm, completed: true, latencyMs: latencyMs}
+	}
+	return outcomes
+}
+
+func TestRolloutConcludesAtThresholds(t *testing.T) {
+	config := RolloutConfig{TrafficShare: 0.5, MinSamples: 4, MaxRejectionRateDelta: 0.25, MaxLatencyRatio: 1.5}
+	rejected := rolloutOutcome{arm: armCandidate}
+
+	tests := []struct {
+		name     string
+		outcomes []rolloutOutcome
+		want     string
+	}{
+		{"candidate below min samples",
+			append(completedOutcomes(armBaseline, 4, 100), completedOutcomes(armCandidate, 3, 100)...),
+			RolloutStaged},
+		{"rejection rate at the allowed delta",
+			append(completedOutcomes(armBaseline, 4, 100), append(completedOutcomes(armCandidate, 3, 100), rejected)...),
+			RolloutPromoted},
+		{"rejection rate over the allowed delta",
+			append(completedOutcomes(armBaseline, 4, 100), append(completedOutcomes(armCandidate, 2, 100), rejected, rejected)...),
+			RolloutRolledBack},
+		{"latency at the allowed ratio",
+			append(completedOutcomes(armBaseline, 4, 100), completedOutcomes(armCandidate, 4, 150)...),
+			RolloutPromoted},
+		{"latency over the allowed ratio",
+			append(completedOutcomes(armBaseline, 4, 100), completedOutcomes(armCandidate, 4, 151)...),
+			RolloutRolledBack},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lb := NewLoadBalancer(WithServers(NewServer(1, 100, 80)), WithLogger(log.New(io.Discard, "", 0)))
+			baseline := lb.GetLoadBalancingPolicy()
+			candidate := baseline
+			candidate.MaGCThreshold = baseline.MaGCThreshold + 500
+			if err := lb.StageRollout(candidate, config); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, outcome := range tt.outcomes {
+				lb.recordRolloutOutcome(outcome.arm, outcome.completed, time.Duration(outcome.latencyMs)*time.Millisecond)
+			}
+
+			rollout := lb.Rollout()
+			if rollout.State != tt.want {
+				t.Fatalf("rollout is %s (%s), want %s", rollout.State, rollout.Reason, tt.want)
+			}
+			want := baseline
+			if tt.want == RolloutPromoted {
+				want = candidate
+			}
+			if got := lb.GetLoadBalancingPolicy(); got != want {
+				t.Errorf("current threshold is %dms, want %dms", got.MaGCThreshold, want.MaGCThreshold)
+			}
+			if policy, arm := lb.policyForDispatch(); tt.want != RolloutStaged && (arm != "" || policy != want) {
+				t.Errorf("concluded rollout still dispatches to arm %q", arm)
+			}
+		})
+	}
+}
+
+func TestPolicyReadsDuringPolicyChanges(t *testing.T) {
+	lb := NewLoadBalancer(WithServers(NewServer(1, 100, 80)), WithLogger(log.New(io.Discard, "", 0)))
+	policies := [2]LoadBalancingPolicy{lb.GetLoadBalancingPolicy(), lb.GetLoadBalancingPolicy()}
+	policies[1].Algorithm, policies[1].MaGCThreshold = "WRR", policies[0].MaGCThreshold+500
+
+	// Readers of the current policy; run with -race to catch unlocked reads
+	readers := map[string]func(){
+		"dispatch policy": func() { lb.policyForDispatch() },
+		"MaGC threshold":  func() { lb.getCurrentMaGCThreshold() },
+		"fairness":        func() { lb.Fairness() },
+		"TRINI status":    func() { lb.TRINIStatus() },
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			lb.SetLoadBalancingPolicy(policies[i%2])
+		}
+	}()
+	for _, read := range readers {
+		for i := 0; i < 200; i++ {
+			read()
+		}
+	}
+	<-done
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// runReportPrefix names the storage documents holding run reports
+	runReportPrefix = "run-report-"
+
+	// runReportIDFormat is the layout of run and report IDs
+	runReportIDFormat = "20060102-150405"
+
+	// maxPolicyChanges bounds the policy changes kept for the run report
+	maxPolicyChanges = 100
+)
+
+// ErrNoRunReportStorage is returned when saving or reading run reports
+// without a store configured, see WithStore
+var ErrNoRunReportStorage = errors.New("run reports need a store")
+
+// validRunReportID matches the IDs of saved run reports
+var validRunReportID = regexp.MustCompile(`^\d{8}-\d{6}$`)
+
+// PolicyChange is one replacement of the load balancing policy
+type PolicyChange struct {
+	Timestamp     time.Time `json:"timestamp"`
+	Algorithm     string    `json:"algorithm"`
+	GCAware       bool      `json:"gc_aware"`
+	MaGCThreshold int64     `json:"magc_threshold_ms"`
+}
+
+// RunGCSummary counts the MaGCs of a run and how traffic fared around them
+type RunGCSummary struct {
+	Pauses         int64 `json:"pauses"`
+	PauseMs        int64 `json:"pause_ms"`
+	Avoided        int64 `json:"avoided"` // Servers skipped because they were in or forecast to start a MaGC
+	Hit            int64 `json:"hit"`     // Tasks in flight on, or sent to, a server when its MaGC started
+	Prefetched     int64 `json:"prefetched"`
+	Desynchronized int64 `json:"desynchronized"`
+}
+
+// RunReport summarizes a load balancer run from Start until the report was
+// made, so experiments with different policies can be compared
+type RunReport struct {
+	ID            string              `json:"id"`     // When the report was made
+	RunID         string              `json:"run_id"` // When the run started
+	StartedAt     time.Time           `json:"started_at"`
+	EndedAt       time.Time           `json:"ended_at"`
+	DurationMs    int64               `json:"duration_ms"`
+	Final         bool                `json:"final"` // Made when the load balancer stopped rather than on demand
+	Servers       int                 `json:"servers"`
+	Tasks         LoadBalancerStats   `json:"tasks"`
+	Execution     ExecutionTotals     `json:"execution"` // Work done during the run by servers still in the pool
+	GC            RunGCSummary        `json:"gc"`
+	Policy        LoadBalancingPolicy `json:"policy"` // In effect when the report was made
+	PolicyChanges []PolicyChange      `json:"policy_changes"`
+	Events        map[string]int64    `json:"events"` // Events recorded during the run, by type
+}
+
+// RunReportInfo identifies a saved run report
+type RunReportInfo struct {
+	ID    string `json:"id"`
+	RunID string `json:"run_id"`
+	Final bool   `json:"final"`
+}
+
+// beginRun marks the start of the run and snapshots each server's execution
+// totals, so restored stats are not counted as work done during the run
+func (l *LoadBalancer) beginRun() {
+	baseline := make(map[int]ExecutionTotals)
+	for _, server := range l.ListServers() {
+		baseline[server.ID] = server.executionTotals()
+	}
+
+	l.runMu.Lock()
+	defer l.runMu.Unlock()
+	l.runStartedAt = l.now()
+	l.runBaseline = baseline
+}
+
+// recordPolicyChange keeps the policy change for the run report
+func (l *LoadBalancer) recordPolicyChange(policy LoadBalancingPolicy) {
+	l.runMu.Lock()
+	defer l.runMu.Unlock()
+
+	l.policyChanges = append(l.policyChanges, PolicyChange{
+		Timestamp:     l.now(),
+		Algorithm:     policy.Algorithm,
+		GCAware:       policy.GCAware,
+		MaGCThreshold: policy.MaGCThreshold,
+	})
+	if len(l.policyChanges) > maxPolicyChanges {
+		l.policyChanges = l.policyChanges[len(l.policyChanges)-maxPolicyChanges:]
+	}
+}
+
+// recordGCHit counts tasks caught by a MaGC
+func (l *LoadBalancer) recordGCHit(tasks int) {
+	if l == nil || tasks <= 0 {
+		return
+	}
+	l.gcHit.Add(int64(tasks))
+}
+
+// executionTotals returns the server's lifetime execution totals
+func (s *Server) executionTotals() ExecutionTotals {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.execTotals
+}
+
+// RunReport summarizes the run so far
+func (l *LoadBalancer) RunReport() RunReport {
+	return l.runReport(false)
+}
+
+// runReport builds the run report; final marks the one made on Stop
+func (l *LoadBalancer) runReport(final bool) RunReport {
+	now := l.now()
+	servers := l.ListServers()
+
+	l.mu.Lock()
+	policy := l.CurrentPolicy
+	l.mu.Unlock()
+
+	l.runMu.Lock()
+	started := l.runStartedAt
+	baseline := l.runBaseline
+	changes := append([]PolicyChange{}, l.policyChanges...)
+	l.runMu.Unlock()
+	if started.IsZero() {
+		started = now
+	}
+
+	report := RunReport{
+		ID:            now.Format(runReportIDFormat),
+		RunID:         started.Format(runReportIDFormat),
+		StartedAt:     started,
+		EndedAt:       now,
+		DurationMs:    now.Sub(started).Milliseconds(),
+		Final:         final,
+		Servers:       len(servers),
+		Tasks:         l.Stats(),
+		Policy:        policy,
+		PolicyChanges: changes,
+		Events:        make(map[string]int64),
+	}
+
+	for _, server := range servers {
+		report.Execution.add(server.executionTotals().since(baseline[server.ID]))
+	}
+	report.GC = RunGCSummary{
+		Pauses:         report.Execution.GCPauses,
+		PauseMs:        report.Execution.GCPauseMs,
+		Avoided:        report.Tasks.GCAvoided,
+		Hit:            report.Tasks.GCHit,
+		Prefetched:     report.Execution.GCPrefetches,
+		Desynchronized: report.Execution.GCDesyncs,
+	}
+
+	l.eventsMu.Lock()
+	for eventType, count := range l.eventCounts {
+		report.Events[eventType] = count
+	}
+	l.eventsMu.Unlock()
+
+	return report
+}
+
+// SaveRunReport makes a run report and saves it to storage
+func (l *LoadBalancer) SaveRunReport() (RunReport, error) {
+	return l.saveRunReport(false)
+}
+
+// saveRunReport implements SaveRunReport; final marks the report made on Stop
+func (l *LoadBalancer) saveRunReport(final bool) (RunReport, error) {
+	report := l.runReport(final)
+	if l.store == nil {
+		return report, ErrNoRunReportStorage
+	}
+	if err := l.store.Save(runReportPrefix+report.ID, report); err != nil {
+		return report, err
+	}
+	l.logf("📝 Saved run report %s", report.ID)
+	return report, nil
+}
+
+// RunReports lists the saved run reports, oldest first
+func (l *LoadBalancer) RunReports() ([]RunReportInfo, error) {
+	if l.store == nil {
+		return nil, ErrNoRunReportStorage
+	}
+
+	names, err := l.store.List(runReportPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]RunReportInfo, 0, len(names))
+	for _, name := range names {
+		id := strings.TrimPrefix(name, runReportPrefix)
+		if !validRunReportID.MatchString(id) {
+			continue
+		}
+		report, err := l.LoadRunReport(id)
+		if err != nil {
+			l.logf("⚠️ Skipping unreadable run report %s: %v", id, err)
+			continue
+		}
+		if report == nil {
+			continue // Removed since it was listed
+		}
+		reports = append(reports, RunReportInfo{ID: report.ID, RunID: report.RunID, Final: report.Final})
+	}
+	return reports, nil
+}
+
+// LoadRunReport reads a saved run report. It returns nil without an error if
+// there is no report with that ID.
+func (l *LoadBalancer) LoadRunReport(id string) (*RunReport, error) {
+	if l.store == nil {
+		return nil, ErrNoRunReportStorage
+	}
+	if !validRunReportID.MatchString(id) {
+		return nil, nil // Not a name SaveRunReport would have used
+	}
+
+	var report RunReport
+	found, err := l.store.Load(runReportPrefix+id, &report)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// sloBucketCount is how many minutes of task outcomes are kept for SLO windows
+const sloBucketCount = 60
+
+// sloWindows are the rolling windows SLO compliance is reported over; the
+// last one decides whether the error budget is exhausted
+var sloWindows = []struct {
+	name    string
+	minutes int64
+}{
+	{"5m", 5},
+	{"60m", 60},
+}
+
+// SLOConfig is the service level objective task outcomes are measured against
+type SLOConfig struct {
+	LatencyTargetMs  int64   `json:"latency_target_ms"` // Tasks completing slower than this, or timing out, are slow
+	LatencyObjective float64 `json:"latency_objective"` // Share of tasks that ran that must not be slow, e.g. 0.99
+	RejectionBudget  float64 `json:"rejection_budget"`  // Share of tasks that may be rejected, e.g. 0.01
+}
+
+// DefaultSLOConfig expects 99% of tasks under 2s and under 1% rejections
+func DefaultSLOConfig() SLOConfig {
+	return SLOConfig{
+		LatencyTargetMs:  2000,
+		LatencyObjective: 0.99,
+		RejectionBudget:  0.01,
+	}
+}
+
+// Validate checks the latency target and that both objectives leave a budget
+func (c SLOConfig) Validate() error {
+	if c.LatencyTargetMs <= 0 || c.LatencyTargetMs > maxMaGCThreshold {
+		return fmt.Errorf("latency target must be between 1 and %dms, got %d", maxMaGCThreshold, c.LatencyTargetMs)
+	}
+	if c.LatencyObjective <= 0 || c.LatencyObjective >= 1 {
+		return fmt.Errorf("latency objective must be between 0 and 1 (exclusive), got %g", c.LatencyObjective)
+	}
+	if c.RejectionBudget <= 0 || c.RejectionBudget >= 1 {
+		return fmt.Errorf("rejection budget must be between 0 and 1 (exclusive), got %g", c.RejectionBudget)
+	}
+	return nil
+}
+
+// sloBucket counts task outcomes in one minute
+type sloBucket struct {
+	minute   int64 // Unix time in minutes
+	ran      int64 // Completed or timed out
+	slow     int64
+	rejected int64
+}
+
+// SLOWindow is SLO compliance over one rolling window. Burn rates compare
+// the share of bad tasks with what the objective allows: 1 spends exactly the
+// error budget, and the remaining budget goes negative once it is overspent.
+type SLOWindow struct {
+	Window                   string  `json:"window"`
+	Tasks                    int64   `json:"tasks"` // Completed, timed out or rejected
+	Slow                     int64   `json:"slow"`
+	Rejected                 int64   `json:"rejected"`
+	LatencyCompliance        float64 `json:"latency_compliance"` // Share of tasks that ran that were not slow
+	RejectionRate            float64 `json:"rejection_rate"`
+	LatencyBurnRate          float64 `json:"latency_burn_rate"`
+	RejectionBurnRate        float64 `json:"rejection_burn_rate"`
+	LatencyBudgetRemaining   float64 `json:"latency_budget_remaining"`
+	RejectionBudgetRemaining float64 `json:"rejection_budget_remaining"`
+	Met                      bool    `json:"met"`
+}
+
+// burnRate is the faster of the window's latency and rejection burn rates
+func (w SLOWindow) burnRate() float64 {
+	return max(w.LatencyBurnRate, w.RejectionBurnRate)
+}
+
+// SLOStatus reports compliance with the SLO over each window
+type SLOStatus struct {
+	Config    SLOConfig   `json:"config"`
+	Windows   []SLOWindow `json:"windows"`
+	Exhausted bool        `json:"exhausted"` // An error budget is overspent over the longest window
+}
+
+// SetSLO changes the objective task outcomes are measured against; outcomes
+// already recorded are judged by the new latency target only from now on
+func (l *LoadBalancer) SetSLO(config SLOConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	l.sloConfig.Store(&config)
+	return nil
+}
+
+// SLO returns the objective task outcomes are measured against
+func (l *LoadBalancer) SLO() SLOConfig {
+	if config := l.sloConfig.Load(); config != nil {
+		return *config
+	}
+	return DefaultSLOConfig()
+}
+
+// recordSLOOutcome counts a task outcome in the current minute's bucket
+func (l *LoadBalancer) recordSLOOutcome(ran, slow, rejected bool) {
+	minute := l.now().Unix() / 60
+
+	l.sloMu.Lock()
+	defer l.sloMu.Unlock()
+
+	if n := len(l.sloBuckets); n == 0 || l.sloBuckets[n-1].minute != minute {
+		l.sloBuckets = append(l.sloBuckets, sloBucket{minute: minute})
+		if len(l.sloBuckets) > sloBucketCount {
+			l.sloBuckets = l.sloBuckets[len(l.sloBuckets)-sloBucketCount:]
+		}
+	}
+	bucket := &l.sloBuckets[len(l.sloBuckets)-1]
+	if ran {
+		bucket.ran++
+	}
+	if slow {
+		bucket.slow++
+	}
+	if rejected {
+		bucket.rejected++
+	}
+}
+
+// isSlow reports whether a task that took elapsed misses the latency target
+func (l *LoadBalancer) isSlow(elapsed time.Duration) bool {
+	return elapsed.Milliseconds() > l.SLO().LatencyTargetMs
+}
+
+// SLOStatus reports compliance and remaining error budget over each window
+func (l *LoadBalancer) SLOStatus() SLOStatus {
+	config := l.SLO()
+	minute := l.now().Unix() / 60
+
+	l.sloMu.Lock()
+	buckets := make([]sloBucket, len(l.sloBuckets))
+	copy(buckets, l.sloBuckets)
+	l.sloMu.Unlock()
+
+	status := SLOStatus{Config: config, Windows: make([]SLOWindow, 0, len(sloWindows))}
+	for _, window := range sloWindows {
+		var total sloBucket
+		for _, bucket := range buckets {
+			if minute-bucket.minute < window.minutes {
+				total.ran += bucket.ran
+				total.slow += bucket.slow
+				total.rejected += bucket.rejected
+			}
+		}
+		status.Windows = append(status.Windows, sloWindow(window.name, total, config))
+	}
+
+	longest := status.Windows[len(status.Windows)-1]
+	status.Exhausted = longest.LatencyBudgetRemaining < 0 || longest.RejectionBudgetRemaining < 0
+	return status
+}
+
+// sloWindow judges one window's outcomes against the SLO
+func sloWindow(name string, total sloBucket, config SLOConfig) SLOWindow {
+	window := SLOWindow{
+		Window:            name,
+		Tasks:             total.ran + total.rejected,
+		Slow:              total.slow,
+		Rejected:          total.rejected,
+		LatencyCompliance: 1,
+	}
+	if total.ran > 0 {
+		window.LatencyCompliance = 1 - float64(total.slow)/float64(total.ran)
+		window.LatencyBurnRate = (1 - window.LatencyCompliance) / (1 - config.LatencyObjective)
+	}
+	if window.Tasks > 0 {
+		window.RejectionRate = float64(total.rejected) / float64(window.Tasks)
+		window.RejectionBurnRate = window.RejectionRate / config.RejectionBudget
+	}
+	window.LatencyBudgetRemaining = 1 - window.LatencyBurnRate
+	window.RejectionBudgetRemaining = 1 - window.RejectionBurnRate
+	window.Met = window.LatencyCompliance >= config.LatencyObjective && window.RejectionRate <= config.RejectionBudget
+	return window
+}
+
+// sloBurnRate is how fast the shortest window spends the faster-burning
+// error budget, for the autoscaler
+func (l *LoadBalancer) sloBurnRate() float64 {
+	return l.SLOStatus().Windows[0].burnRate()
+}
+
+// checkSLO records an event when an error budget becomes overspent over the
+// longest window and when it recovers
+func (l *LoadBalancer) checkSLO() {
+	status := l.SLOStatus()
+
+	l.sloMu.Lock()
+	changed := status.Exhausted != l.sloExhausted
+	l.sloExhausted = status.Exhausted
+	l.sloMu.Unlock()
+	if !changed {
+		return
+	}
+
+	longest := status.Windows[len(status.Windows)-1]
+	if status.Exhausted {
+		l.RecordEvent("slo_budget_exhausted", 0, "SLO error budget spent over %s: %.2f%% of tasks slow, %.2f%% rejected",
+			longest.Window, (1-longest.LatencyCompliance)*100, longest.RejectionRate*100)
+	} else {
+		l.RecordEvent("slo_budget_restored", 0, "SLO error budget available again over %s", longest.Window)
+	}
+}
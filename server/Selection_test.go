@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+// selectionTestTime is when every selection test's clock stands
+var selectionTestTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// selectionAlgorithms are the algorithms every eligibility test runs
+var selectionAlgorithms = []string{"RR", "RAN", "WRR", "WRAN", AlgorithmCost}
+
+// newSelectionTestBalancer returns a GC-aware balancer with TRINI active over
+// servers that skip the simulated latency. With magcImminent every server has
+// a MaGC forecast within the threshold, so the GC-aware algorithms fall back.
+func newSelectionTestBalancer(servers int, magcImminent bool) (*LoadBalancer, []*Server) {
+	pool := make([]*Server, 0, servers)
+	for id := 1; id <= servers; id++ {
+		server := NewServer(id, 1000, 75)
+		server.replay = true
+		pool = append(pool, server)
+	}
+	lb := NewLoadBalancer(
+		WithServers(pool...),
+		WithClock(fixedClock{t: selectionTestTime}),
+		WithLogger(log.New(io.Discard, "", 0)),
+	)
+	lb.TRINI.IsActive = true
+	for _, server := range pool {
+		server.initializeTRINI(lb.TRINI.DefaultFamily)
+		if magcImminent {
+			server.LastMaGCForecast = &MaGCForecast{
+				PredictedTime:     selectionTestTime.Add(100 * time.Millisecond),
+				Confidence:        0.9,
+				ForecastCreatedAt: selectionTestTime,
+			}
+		}
+	}
+	return lb, pool
+}
+
+// selectionCounts runs selections under the algorithm and counts how often
+// each server was picked; zero counts no server
+func selectionCounts(lb *LoadBalancer, algorithm string, selections int) map[int]int {
+	policy := lb.GetLoadBalancingPolicy()
+	policy.Algorithm = algorithm
+	policy.GCAware = true
+
+	counts := make(map[int]int)
+	for i := 0; i < selections; i++ {
+		explain := lb.newSelectionExplanation(policy, "")
+		if server := lb.selectServer("task", policy, explain); server != nil {
+			counts[server.ID]++
+		} else {
+			counts[0]++
+		}
+	}
+	return counts
+}
+
+// forEachSelectionCase runs test for every algorithm, with servers GC-safe
+// and with a MaGC imminent on all of them
+func forEachSelectionCase(t *testing.T, test func(t *testing.T, algorithm string, magcImminent bool)) {
+	for _, algorithm := range selectionAlgorithms {
+		for _, magcImminent := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s/magc_imminent=%v", algorithm, magcImminent), func(t *testing.T) {
+				test(t, algorithm, magcImminent)
+			})
+		}
+	}
+}
+
+func TestSelectionSkipsWarmingUpServers(t *testing.T) {
+	forEachSelectionCase(t, func(t *testing.T, algorithm string, magcImminent bool) {
+		lb, servers := newSelectionTestBalancer(3, magcImminent)
+		servers[1].warmingUp = true
+
+		counts := selectionCounts(lb, algorithm, 30)
+		if counts[servers[1].ID] > 0 || counts[0] > 0 {
+			t.Errorf("picks by server %v, want none for warming up server %d and none rejected", counts, servers[1].ID)
+		}
+	})
+}
+
+func TestWarmUpStopsWithBalancer(t *testing.T) {
+	server := NewServer(1, 1000, 75)
+	lb := NewLoadBalancer(WithServers(server), WithLogger(log.New(io.Discard, "", 0)))
+	if err := lb.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	server.mu.Lock()
+	server.warmingUp = true
+	server.mu.Unlock()
+
+	lb.spawn(func() { server.warmUp(lb.lifetime(), WarmupConfig{Tasks: 20, Ramp: time.Minute}) })
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	lb.Stop()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stopping waited %v for 20 warm-up tasks", elapsed)
+	}
+	if server.isWarmingUp() || server.inSlowStart() {
+		t.Error("interrupted warm-up left the server out of rotation or ramping")
+	}
+}
+
+func TestSelectionSkipsServersAtCapacity(t *testing.T) {
+	forEachSelectionCase(t, func(t *testing.T, algorithm string, magcImminent bool) {
+		lb, servers := newSelectionTestBalancer(3, magcImminent)
+		for _, server := range servers {
+			server.maxConcurrentTasks = 2
+		}
+		servers[0].activeTasks = 2
+
+		counts := selectionCounts(lb, algorithm, 30)
+		if counts[servers[0].ID] > 0 || counts[0] > 0 {
+			t.Errorf("picks by server %v, want none for server %d at capacity and none rejected", counts, servers[0].ID)
+		}
+
+		for _, server := range servers {
+			server.activeTasks = 2
+		}
+		if counts := selectionCounts(lb, algorithm, 3); counts[0] != 3 {
+			t.Errorf("picks by server %v with every server at capacity, want all rejected", counts)
+		}
+	})
+}
+
+// startSelectionTestSlowStart puts the server 6s into a one-minute slow start,
+// where it admits 19% of selections
+func startSelectionTestSlowStart(server *Server) {
+	server.slowStartFrom = selectionTestTime.Add(-6 * time.Second)
+	server.slowStartUntil = server.slowStartFrom.Add(time.Minute)
+}
+
+func TestSelectionRampsSlowStartServers(t *testing.T) {
+	forEachSelectionCase(t, func(t *testing.T, algorithm string, magcImminent bool) {
+		lb, servers := newSelectionTestBalancer(3, magcImminent)
+		startSelectionTestSlowStart(servers[2])
+
+		// A third of 300 selections is its full share; the ramp admits about 19
+		counts := selectionCounts(lb, algorithm, 300)
+		if counts[servers[2].ID] > 50 || counts[0] > 0 {
+			t.Errorf("picks by server %v, want at most 50 for server %d in slow start and none rejected", counts, servers[2].ID)
+		}
+	})
+}
+
+func TestSlowStartDecisionsReplay(t *testing.T) {
+	forEachSelectionCase(t, func(t *testing.T, algorithm string, magcImminent bool) {
+		lb, servers := newSelectionTestBalancer(3, magcImminent)
+		startSelectionTestSlowStart(servers[0])
+		startSelectionTestSlowStart(servers[2])
+		policy := lb.GetLoadBalancingPolicy()
+		policy.Algorithm = algorithm
+		policy.GCAware = true
+
+		for i := 0; i < 50; i++ {
+			record := lb.decisionSnapshot("task", policy, "", 0)
+			explain := lb.newSelectionExplanation(policy, "")
+			server, _ := lb.selectWithRetries("task", policy, explain, 0)
+			lb.recordDecision(record, explain, server)
+
+			decoded, err := decodeDecision(encodeDecision(*record))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result := ReplayDecision(decoded); !result.Match {
+				t.Fatalf("decision %d picked server %d, replay picked %d", i, record.ServerID, result.ServerID)
+			}
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -8,17 +9,25 @@ import (
 	"time"
 )
 
+// NewServer creates a server with the given memory limit and GC trigger
+// percentage (0-100), the old generation occupancy that starts a MaGC
+func NewServer(id int, memLimit int, gcPercentage float64) *Server {
+	return &Server{
+		ID:            id,
+		TaskStorage:   make([]string, 0),
+		memLimit:      memLimit,
+		gcPercentage:  gcPercentage / 100.0, // Convert percentage to decimal
+		Cost:          defaultServerCost,
+		CPUMultiplier: defaultCPUMultiplier,
+		adminState:    StateActive,
+	}
+}
+
 func (s *Server) Start() {
 	s.mu.Lock()
 	s.TaskStorage = make([]string, 0)
 	s.isCollectingGCTasks = false
 	s.usedMemory = 0
-	if s.memLimit == 0 {
-		s.memLimit = 100
-	}
-	if s.gcPercentage == 0 {
-		s.gcPercentage = 0.9 // 90%
-	}
 	s.mu.Unlock()
 }
 
@@ -27,6 +36,8 @@ func (s *Server) Configure(memLimit int, gcPercentage float64) {
 	defer s.mu.Unlock()
 	s.memLimit = memLimit
 	s.gcPercentage = gcPercentage / 100.0 // Convert percentage to decimal
+	s.refreshWeightLocked()
+	s.refreshGenerationsLocked()
 }
 
 // SetMemoryLimit sets the server's memory
@@ -34,6 +45,8 @@ func (s *Server) SetMemoryLimit(limit int) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.memLimit = limit
+	s.refreshWeightLocked()
+	s.refreshGenerationsLocked()
 }
 
 // SetGCPercentage sets the GC trigger percentage (0-100)
@@ -51,27 +64,51 @@ func (s *Server) GetConfiguration() (memLimit int, gcPercentage float64) {
 }
 
 func (s *Server) CollectGCTasks() {
+	magcStartTime, ok := s.beginMaGC()
+	if !ok {
+		return
+	}
+
+	gcDuration := s.calculateGCDuration()
+	time.Sleep(time.Duration(gcDuration) * time.Millisecond)
+
+	s.finishMaGC(magcStartTime)
+}
+
+// beginMaGC takes the server out of rotation for a MaGC and returns when it
+// started, reporting false if one is already in progress
+func (s *Server) beginMaGC() (time.Time, bool) {
 	s.mu.Lock()
 	if s.isCollectingGCTasks {
 		s.mu.Unlock()
-		return
+		return time.Time{}, false
 	}
 	s.isCollectingGCTasks = true
 
-	magcStartTime := time.Now()
+	magcStartTime := s.now()
+	s.gcStartedAt = magcStartTime
+	inFlight := s.activeTasks
 	s.mu.Unlock()
 
-	fmt.Printf("Server %d: Collecting GC tasks...\n", s.ID)
+	s.LoadBalancer.recordGCHit(inFlight)
 
-	gcDuration := s.calculateGCDuration()
-	time.Sleep(time.Duration(gcDuration) * time.Millisecond)
+	s.logf("Server %d: Collecting GC tasks...", s.ID)
+	s.LoadBalancer.onGCStart(s)
+	s.LoadBalancer.checkStandby()
+	return magcStartTime, true
+}
 
+// finishMaGC empties the heap after the MaGC begun at magcStartTime and
+// returns the server to rotation, once warmed up if configured
+func (s *Server) finishMaGC(magcStartTime time.Time) {
 	s.mu.Lock()
 
-	magcEndTime := time.Now()
+	magcEndTime := s.now()
 	s.MaGCDuration = magcEndTime.Sub(magcStartTime).Milliseconds()
 	s.LastMaGCTime = magcEndTime
 	s.GCCount++
+	s.recordGCEventLocked(magcStartTime, magcEndTime)
+	s.recordExecutionLocked(ExecutionTotals{GCPauses: 1, GCPauseMs: s.MaGCDuration})
 
 	// Reset memory state after GC
 	s.isCollectingGCTasks = false
@@ -80,10 +117,19 @@ func (s *Server) CollectGCTasks() {
 	s.YoungGenUsed = 0
 	s.OldGenUsed = 0
 
+	// Stay out of rotation until warmed up
+	warmup := s.LoadBalancer.warmupConfig()
+	s.warmingUp = warmup.enabled() && !s.replay
+
 	s.mu.Unlock()
 
-	fmt.Printf("Server %d: GC tasks collected (duration: %dms), ready for new tasks\n",
+	s.logf("Server %d: GC tasks collected (duration: %dms), ready for new tasks",
 		s.ID, s.MaGCDuration)
+	s.LoadBalancer.onGCEnd(s, magcEndTime.Sub(magcStartTime))
+
+	if warmup.enabled() && !s.replay {
+		s.warmUp(s.LoadBalancer.lifetime(), warmup)
+	}
 }
 
 // calculateGCDuration simulates realistic GC duration based on memory usage
@@ -110,40 +156,70 @@ func (s *Server) calculateGCDuration() int64 {
 	return duration
 }
 
+// MemoryUtilization returns the fraction of the memory limit currently in use
+func (s *Server) MemoryUtilization() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.memLimit == 0 {
+		return 0
+	}
+	return float64(s.usedMemory) / float64(s.memLimit)
+}
+
+// IsAvailable reports whether the server can take new tasks: it is not
+// collecting GC tasks and its administrative state is active
 func (s *Server) IsAvailable() bool {
 	s.mu.Lock()
-	time.Sleep(100 * time.Millisecond)
+	s.simulateLatency(100 * time.Millisecond)
 	defer s.mu.Unlock()
-	return !s.isCollectingGCTasks
+	return !s.isCollectingGCTasks && s.adminStateLocked() == StateActive
 }
 
+// CanHandleTaskSize reports whether a task of taskSize fits in free memory.
+// It never collects: the balancer's memory pressure policy decides whether a
+// failed fit starts a MaGC, see MemoryPressurePolicy.
 func (s *Server) CanHandleTaskSize(taskSize int) bool {
 	s.mu.Lock()
-	time.Sleep(100 * time.Millisecond)
+	defer s.mu.Unlock()
+	s.simulateLatency(100 * time.Millisecond)
 
-	if s.usedMemory+taskSize > s.memLimit {
-		s.mu.Unlock()      // Unlock before blocking GC operation
-		s.CollectGCTasks() // Remove 'go' to make it blocking
-		return false
-	}
-	s.mu.Unlock()
-	return true
+	return s.usedMemory+taskSize <= s.memLimit
 }
 
-func (s *Server) canHandleTask(input string) bool {
-	s.mu.Lock()
-	time.Sleep(100 * time.Millisecond)
-	taskSize := len(input)
-	if s.usedMemory+taskSize > s.memLimit {
-		s.mu.Unlock()      // Unlock before blocking GC operation
-		s.CollectGCTasks() // Remove 'go' to make it blocking
-		return false
+// simulateLatency sleeps to model a remote call, except on replay copies
+func (s *Server) simulateLatency(d time.Duration) {
+	if !s.replay {
+		time.Sleep(d)
 	}
-	s.mu.Unlock()
-	return true
 }
 
+// RequestTask submits the task in the interactive lane
 func (s *Server) RequestTask(input string) ServiceResponse {
+	return s.RequestTaskInLane(input, LaneInteractive)
+}
+
+// RequestTaskInLane submits the task in the given dispatch lane, see LaneBatch
+func (s *Server) RequestTaskInLane(input string, lane string) ServiceResponse {
+	return s.RequestTaskWithTimeout(input, lane, 0)
+}
+
+// RequestTaskWithTimeout is RequestTaskInLane for a task the worker gives up
+// on once it has run for timeout, see TaskStatusTimeout; 0 waits indefinitely
+func (s *Server) RequestTaskWithTimeout(input string, lane string, timeout time.Duration) ServiceResponse {
+	return s.requestTask(context.Background(), input, lane, timeout)
+}
+
+// requestTask is RequestTaskWithTimeout for a task the worker also stops once
+// ctx is cancelled, see TaskStatusCancelled. Outcomes are counted per server;
+// the load balancer counts each dispatched task once, see recordTaskOutcome.
+func (s *Server) requestTask(ctx context.Context, input string, lane string, timeout time.Duration) ServiceResponse {
+	start := s.now()
+
+	// Turn the task away up front rather than queueing unbounded work
+	if reason := s.acquireTaskSlot(lane); reason != "" {
+		return s.rejectAtCapacity(input, lane, reason, start)
+	}
+
 	// Add constant delay for server processing overhead
 	time.Sleep(300 * time.Millisecond)
 	resultChan := make(chan *Task, 1)
@@ -155,36 +231,120 @@ func (s *Server) RequestTask(input string) ServiceResponse {
 		ResultChan: resultChan,
 	}
 
-	go func(input string) {
-		if !s.IsAvailable() || !s.canHandleTask(input) {
-			resultChan <- &Task{
-				ID:     fmt.Sprintf("error-%d", rand.Intn(1000)),
-				Input:  input,
-				Output: "",
-				Status: "rejected",
+	s.LoadBalancer.onDispatch(input, s)
+
+	s.LoadBalancer.spawn(func() {
+		defer s.releaseTaskSlot(lane)
+
+		admittedAt := s.now()
+		reason := s.admitTask(len(input))
+		if reason == SkipInsufficientMemory {
+			s.LoadBalancer.allocationFailed(s, len(input))
+		}
+		if reason != "" {
+			rejected := &Task{
+				ID:       fmt.Sprintf("error-%d", rand.Intn(1000)),
+				Input:    input,
+				Output:   "",
+				Status:   "rejected",
+				Reason:   reason,
+				serverID: s.ID,
+			}
+			resultChan <- rejected
+			s.LoadBalancer.onComplete(s, rejected, s.now().Sub(start))
+			if reason == SkipUnavailable {
+				s.LoadBalancer.recordGCHit(1)
 			}
+			s.mu.Lock()
+			s.recordExecutionLocked(ExecutionTotals{TasksRejected: 1})
+			s.recordLaneOutcomeLocked(lane, false, 0)
+			s.recordTimelineLocked(TimelineRejected, "Rejected a %s task: %s", lane, reason)
+			s.mu.Unlock()
 			return
 		}
 
-		taskResult := s.handleTask(input)
+		execStart := s.now()
+		taskResult, finished := s.runTask(ctx, input, timeout, admittedAt)
+		taskResult.serverID, taskResult.startedAt, taskResult.finishedAt = s.ID, execStart, s.now()
+		if !finished {
+			resultChan <- &taskResult
+			s.LoadBalancer.onComplete(s, &taskResult, s.now().Sub(start))
+			s.mu.Lock()
+			if taskResult.Status == TaskStatusCancelled {
+				// Another attempt answered the task: nothing failed here
+				s.recordTimelineLocked(TimelineCancelled, "Cancelled a %s task: %s", lane, taskResult.Reason)
+			} else {
+				s.recordExecutionLocked(ExecutionTotals{TasksTimedOut: 1})
+				s.recordLaneOutcomeLocked(lane, false, 0)
+				s.recordTimelineLocked(TimelineTimedOut, "Cancelled a %s task: %s", lane, taskResult.Reason)
+			}
+			s.mu.Unlock()
+			return
+		}
 		resultChan <- &taskResult
+		elapsed := s.now().Sub(start)
+		s.LoadBalancer.onComplete(s, &taskResult, elapsed)
+		s.LoadBalancer.observeTaskDuration(len(input), elapsed)
 
 		s.mu.Lock()
-		memoryUsage := float64(s.usedMemory) / float64(s.memLimit)
-		gcThreshold := s.gcPercentage
+		s.recordExecutionLocked(ExecutionTotals{
+			TasksCompleted: 1,
+			BytesProcessed: int64(len(input)),
+			ExecTimeMs:     elapsed.Milliseconds(),
+		})
+		s.recordLaneOutcomeLocked(lane, true, elapsed)
+
+		collect := s.shouldCollectLocked()
 		s.mu.Unlock()
 
-		if memoryUsage >= gcThreshold {
-			go s.CollectGCTasks()
+		if collect {
+			s.LoadBalancer.spawn(s.CollectGCTasks)
 		}
-	}(input)
+	})
 
 	return resp
 }
 
-func hashSHA256(s string) string {
-	time.Sleep(time.Duration(rand.Intn(100)+500) * time.Millisecond)
+// rejectAtCapacity answers a task the server or its lane has no concurrency slot for
+func (s *Server) rejectAtCapacity(input string, lane string, reason string, start time.Time) ServiceResponse {
+	rejected := &Task{
+		ID:       fmt.Sprintf("error-%d", rand.Intn(1000)),
+		Input:    input,
+		Status:   "rejected",
+		Reason:   reason,
+		serverID: s.ID,
+	}
+	resultChan := make(chan *Task, 1)
+	resultChan <- rejected
 
+	s.LoadBalancer.onComplete(s, rejected, s.now().Sub(start))
+	s.mu.Lock()
+	s.recordExecutionLocked(ExecutionTotals{TasksRejected: 1})
+	s.recordLaneOutcomeLocked(lane, false, 0)
+	s.recordTimelineLocked(TimelineRejected, "Rejected a %s task: %s", lane, reason)
+	s.mu.Unlock()
+
+	return ServiceResponse{
+		Status:     "rejected",
+		Message:    rejected.Reason,
+		ResultChan: resultChan,
+	}
+}
+
+// simulateTaskWork sleeps for the 500-600ms a task takes to process,
+// reporting false if ctx was cancelled first
+func simulateTaskWork(ctx context.Context) bool {
+	timer := time.NewTimer(time.Duration(rand.Intn(100)+500) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func hashSHA256(s string) string {
 	hasher := sha256.New()
 	hasher.Write([]byte(s))
 	hashBytes := hasher.Sum(nil)
@@ -192,11 +352,28 @@ func hashSHA256(s string) string {
 	return hex.EncodeToString(hashBytes)
 }
 
-func (s *Server) handleTask(input string) Task {
+// admitTask reserves memory for a task of taskSize if the server can run it
+// now, or returns why it cannot. Availability, fit and the allocation are
+// settled under one lock, so concurrent tasks can neither overcommit the heap
+// nor start on a server that began collecting after they were checked.
+func (s *Server) admitTask(taskSize int) string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.simulateLatency(200 * time.Millisecond) // Availability and memory round trips
 
-	taskSize := len(input)
+	switch {
+	case s.isCollectingGCTasks || s.adminStateLocked() != StateActive:
+		return SkipUnavailable
+	case s.usedMemory+taskSize > s.memLimit:
+		return SkipInsufficientMemory
+	}
+	s.allocateLocked(taskSize)
+	return ""
+}
+
+// allocateLocked accounts a task's allocation in the heap and its
+// generations; callers hold s.mu
+func (s *Server) allocateLocked(taskSize int) {
 	s.usedMemory += taskSize
 
 	// Simulate generational heap behavior
@@ -221,32 +398,42 @@ func (s *Server) handleTask(input string) Task {
 	if s.OldGenUsed > s.OldGenMax {
 		s.OldGenUsed = s.OldGenMax
 	}
+}
 
+// handleTask runs a task whose memory admitTask reserved. The work runs
+// without s.mu and stops early when ctx is cancelled, reporting false; the
+// task is then neither stored nor billed, and its reservation is the caller's
+// to release.
+func (s *Server) handleTask(ctx context.Context, input string) (Task, bool) {
 	task := Task{
 		ID:        fmt.Sprintf("task-%d", rand.Intn(1000)),
 		Input:     input,
-		Output:    hashSHA256(input),
 		Status:    "completed",
-		CreatedAt: time.Now(),
+		CreatedAt: s.now(),
+	}
+	if !simulateTaskWork(ctx) {
+		return task, false
 	}
+	task.Output = hashSHA256(input)
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.TaskStorage = append(s.TaskStorage, task.ID)
-	return task
+	s.accrueCost()
+	return task, true
 }
 
-func (s *Server) Ping() map[string]interface{} {
+// Ping reports the server's status after a simulated network round trip
+func (s *Server) Ping() ServerStatus {
 	s.mu.Lock()
 	time.Sleep(100 * time.Millisecond)
 	defer s.mu.Unlock()
+	return s.pingResultLocked()
+}
 
-	return map[string]interface{}{
-		"server_id":        s.ID,
-		"status":           "online",
-		"is_available":     !s.isCollectingGCTasks,
-		"is_collecting_gc": s.isCollectingGCTasks,
-		"mem_used":         fmt.Sprintf("%.1f%%", float64(s.usedMemory)/float64(s.memLimit)*100),
-		"tasks_processed":  len(s.TaskStorage),
-		"task_ids":         s.TaskStorage,
-		"memory_usage":     fmt.Sprintf("%d/%d (%.1f%%)", s.usedMemory, s.memLimit, float64(s.usedMemory)/float64(s.memLimit)*100),
-	}
+// pingResult is Ping without the simulated round trip
+func (s *Server) pingResult() ServerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pingResultLocked()
 }
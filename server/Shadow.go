@@ -0,0 +1,164 @@
+package server
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// shadowDraws is how many random numbers a shadow selection is given; a pass
+// draws at most once and selection makes at most maxTaskRetries+1 passes
+const shadowDraws = 8
+
+// ShadowImpact estimates how the shadow policy's choices would have differed
+// from the live ones, from the servers' state at each decision
+type ShadowImpact struct {
+	// Chosen servers with a MaGC forecast within the live policy's threshold
+	LiveMaGCRisk   int64 `json:"live_magc_risk"`
+	ShadowMaGCRisk int64 `json:"shadow_magc_risk"`
+	// Mean memory utilization of the chosen servers where both policies placed the task
+	LiveUtilization   float64 `json:"live_utilization"`
+	ShadowUtilization float64 `json:"shadow_utilization"`
+	// Summed per-task cost of the chosen servers where both policies placed the task
+	LiveCost   float64 `json:"live_cost"`
+	ShadowCost float64 `json:"shadow_cost"`
+}
+
+// ShadowStats compares live selections with those of the shadow policy
+type ShadowStats struct {
+	Policy           LoadBalancingPolicy `json:"policy"`
+	Since            time.Time           `json:"since"`
+	Decisions        int64               `json:"decisions"`
+	Agreements       int64               `json:"agreements"`
+	Disagreements    int64               `json:"disagreements"`
+	AgreementRate    float64             `json:"agreement_rate"`
+	LiveRejections   int64               `json:"live_rejections"`   // Tasks only the shadow policy would have placed
+	ShadowRejections int64               `json:"shadow_rejections"` // Tasks only the live policy placed
+	Impact           ShadowImpact        `json:"impact"`
+}
+
+// shadowState accumulates the comparison for the configured shadow policy
+type shadowState struct {
+	mu    sync.Mutex
+	stats ShadowStats
+
+	bothPlaced        int64
+	liveUtilization   float64
+	shadowUtilization float64
+}
+
+// SetShadowPolicy makes every selection also compute, without using, the
+// server the given policy would have chosen. Statistics start over.
+func (l *LoadBalancer) SetShadowPolicy(policy LoadBalancingPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	l.shadow.Store(&shadowState{stats: ShadowStats{Policy: policy, Since: l.now()}})
+	l.RecordEvent("shadow_started", 0, "Shadowing decisions with policy %s (GC-aware: %v)", policy.Algorithm, policy.GCAware)
+	return nil
+}
+
+// ClearShadowPolicy stops shadowing decisions
+func (l *LoadBalancer) ClearShadowPolicy() {
+	if l.shadow.Swap(nil) != nil {
+		l.RecordEvent("shadow_stopped", 0, "Stopped shadowing decisions")
+	}
+}
+
+// ShadowStats returns the comparison with the shadow policy, or false when
+// decisions are not shadowed
+func (l *LoadBalancer) ShadowStats() (ShadowStats, bool) {
+	shadow := l.shadow.Load()
+	if shadow == nil {
+		return ShadowStats{}, false
+	}
+
+	shadow.mu.Lock()
+	defer shadow.mu.Unlock()
+	stats := shadow.stats
+	if stats.Decisions > 0 {
+		stats.AgreementRate = float64(stats.Agreements) / float64(stats.Decisions)
+	}
+	if shadow.bothPlaced > 0 {
+		stats.Impact.LiveUtilization = shadow.liveUtilization / float64(shadow.bothPlaced)
+		stats.Impact.ShadowUtilization = shadow.shadowUtilization / float64(shadow.bothPlaced)
+	}
+	return stats, true
+}
+
+// shadowSnapshot captures the selection inputs for the shadow policy, or
+// returns nil when decisions are not shadowed or the balancer is degraded. It
+// must run before the live selection moves the round-robin position and weights.
+func (l *LoadBalancer) shadowSnapshot(taskInput string, retries int) (*DecisionRecord, *shadowState) {
+	shadow := l.shadow.Load()
+	if shadow == nil || l.degraded() {
+		return nil, nil
+	}
+	return l.decisionSnapshot(taskInput, shadow.stats.Policy, "", retries), shadow
+}
+
+// compareShadow replays the snapshot under the shadow policy in the
+// background and compares its choice with the live one
+func (l *LoadBalancer) compareShadow(record *DecisionRecord, shadow *shadowState, live *Server, thresholdMs int64) {
+	record.Draws = make([]int, shadowDraws)
+	for i := range record.Draws {
+		record.Draws[i] = rand.Int()
+	}
+	liveID := 0
+	if live != nil {
+		liveID = live.ID
+	}
+
+	l.spawn(func() {
+		shadowID := ReplayDecision(*record).ServerID
+		states := make(map[int]DecisionServer, len(record.Servers))
+		for _, state := range record.Servers {
+			states[state.ID] = state
+		}
+
+		shadow.mu.Lock()
+		defer shadow.mu.Unlock()
+		stats := &shadow.stats
+		stats.Decisions++
+		if shadowID == liveID {
+			stats.Agreements++
+		} else {
+			stats.Disagreements++
+		}
+
+		liveChoice, liveOK := states[liveID]
+		shadowChoice, shadowOK := states[shadowID]
+		switch {
+		case !liveOK && shadowOK:
+			stats.LiveRejections++
+		case liveOK && !shadowOK:
+			stats.ShadowRejections++
+		}
+		if liveOK && liveChoice.magcWithin(thresholdMs) {
+			stats.Impact.LiveMaGCRisk++
+		}
+		if shadowOK && shadowChoice.magcWithin(thresholdMs) {
+			stats.Impact.ShadowMaGCRisk++
+		}
+		if liveOK && shadowOK {
+			shadow.bothPlaced++
+			shadow.liveUtilization += liveChoice.utilization()
+			shadow.shadowUtilization += shadowChoice.utilization()
+			stats.Impact.LiveCost += liveChoice.Cost
+			stats.Impact.ShadowCost += shadowChoice.Cost
+		}
+	})
+}
+
+// magcWithin reports whether the recorded forecast put a MaGC within thresholdMs
+func (s DecisionServer) magcWithin(thresholdMs int64) bool {
+	return s.HasForecast && s.ForecastInMs >= 0 && s.ForecastInMs <= thresholdMs
+}
+
+// utilization is the recorded share of memory in use
+func (s DecisionServer) utilization() float64 {
+	if s.MemLimit <= 0 {
+		return 0
+	}
+	return float64(s.UsedMemory) / float64(s.MemLimit)
+}
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// DefaultBatchMaxShare is the largest share of a batch one server takes when
+// the caller does not say
+const DefaultBatchMaxShare = 0.5
+
+// BatchPlacement reports how a batch was spread over servers and zones
+type BatchPlacement struct {
+	Tasks         int            `json:"tasks"`
+	MaxShare      float64        `json:"max_server_share"`
+	MaxPerServer  int            `json:"max_per_server"`
+	ZoneLimits    map[string]int `json:"zone_limits,omitempty"` // Most tasks placed per zone
+	PerServer     map[int]int    `json:"per_server"`
+	PerZone       map[string]int `json:"per_zone,omitempty"`
+	Overflow      int            `json:"overflow"` // Tasks placed past a limit because no server under it was eligible
+	Unplaced      int            `json:"unplaced"` // Tasks no server could take
+	DistinctZones int            `json:"distinct_zones"`
+}
+
+// BatchDispatch is the outcome of dispatching one task of a batch, in the same
+// form as DispatchLane's
+type BatchDispatch struct {
+	Server   *Server
+	Response ServiceResponse
+}
+
+// batchSpread caps how many tasks of one batch each server and zone takes, so
+// round-robin and GC skipping do not pile a batch onto the one clean server.
+// A batch is dispatched sequentially, so it needs no lock.
+type batchSpread struct {
+	placement BatchPlacement
+	limited   bool // A server was skipped for its share during the current selection
+}
+
+// newBatchSpread sizes per-server and per-zone limits for a batch of n tasks.
+// Zones get a share proportional to their number of servers.
+func (l *LoadBalancer) newBatchSpread(n int, maxShare float64) *batchSpread {
+	spread := &batchSpread{placement: BatchPlacement{
+		Tasks:        n,
+		MaxShare:     maxShare,
+		MaxPerServer: max(int(math.Ceil(float64(n)*maxShare)), 1),
+		PerServer:    make(map[int]int),
+	}}
+
+	servers := l.ListServers()
+	zoneServers := make(map[string]int)
+	for _, server := range servers {
+		if zone := server.Zone(); zone != "" {
+			zoneServers[zone]++
+		}
+	}
+	if len(zoneServers) > 0 {
+		spread.placement.ZoneLimits = make(map[string]int, len(zoneServers))
+		spread.placement.PerZone = make(map[string]int, len(zoneServers))
+		for zone, count := range zoneServers {
+			spread.placement.ZoneLimits[zone] = int(math.Ceil(float64(n*count) / float64(len(servers))))
+		}
+	}
+	return spread
+}
+
+// reason reports why the server already took its share of the batch, or ""
+func (b *batchSpread) reason(server *Server) string {
+	if b == nil {
+		return ""
+	}
+	if b.placement.PerServer[server.ID] >= b.placement.MaxPerServer {
+		b.limited = true
+		return SkipBatchShare
+	}
+	if zone := server.Zone(); zone != "" && b.placement.PerZone[zone] >= b.placement.ZoneLimits[zone] {
+		b.limited = true
+		return SkipZoneShare
+	}
+	return ""
+}
+
+// limitedSelection reports whether the selection just made skipped a server
+// for its share, and starts tracking the next one
+func (b *batchSpread) limitedSelection() bool {
+	if b == nil {
+		return false
+	}
+	limited := b.limited
+	b.limited = false
+	return limited
+}
+
+// place counts a task placed on the server; nil-safe for tasks outside a
+// batch and for rejected tasks
+func (b *batchSpread) place(server *Server) {
+	if b == nil || server == nil {
+		return
+	}
+	b.placement.PerServer[server.ID]++
+	if zone := server.Zone(); zone != "" {
+		if b.placement.PerZone[zone] == 0 {
+			b.placement.DistinctZones++
+		}
+		b.placement.PerZone[zone]++
+	}
+}
+
+// DispatchBatch dispatches tasks that arrived together, spreading them so no
+// server takes more than maxShare of the batch and zones share it by their
+// number of servers. When every server under its limit is ineligible, the
+// task goes wherever the policy puts it rather than being rejected. A
+// positive timeout overrides the policy's task_timeout_ms for every task.
+// Tasks still waiting for a server once ctx is done are rejected.
+func (l *LoadBalancer) DispatchBatch(ctx context.Context, taskInputs []string, lane string, maxShare float64, timeout time.Duration, explained bool) ([]BatchDispatch, BatchPlacement, error) {
+	if maxShare <= 0 || maxShare > 1 {
+		return nil, BatchPlacement{}, fmt.Errorf("max server share must be in (0, 1], got %g", maxShare)
+	}
+
+	spread := l.newBatchSpread(len(taskInputs), maxShare)
+	dispatches := make([]BatchDispatch, 0, len(taskInputs))
+	for _, taskInput := range taskInputs {
+		server, response := l.dispatch(ctx, taskInput, lane, explained, timeout, spread)
+		if server == nil {
+			spread.placement.Unplaced++
+		}
+		dispatches = append(dispatches, BatchDispatch{Server: server, Response: response})
+	}
+	return dispatches, spread.placement, nil
+}
+
+// SetZone sets the failure domain batches are spread across; empty means none
+func (s *Server) SetZone(zone string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zone = zone
+}
+
+// Zone returns the server's failure domain, empty when unset
+func (s *Server) Zone() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.zone
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultStandbyCooldownMs is how long an activated standby server serves
+	// at least when the config sets no cooldown
+	defaultStandbyCooldownMs = 30000
+
+	// maxStandbyCooldownMs bounds the standby cooldown to an hour
+	maxStandbyCooldownMs = 3600000
+)
+
+// StandbyConfig decides when servers in StateStandby take traffic. While
+// fewer than MinCleanServers active servers are GC-clean (neither collecting
+// nor warming up, and with no MaGC forecast within the policy threshold), one
+// standby server is activated per missing server. Each returns to standby
+// once it has served for the cooldown and the fleet no longer needs it, so
+// throughput holds through fleet-wide GC storms without paying for spares
+// the rest of the time.
+type StandbyConfig struct {
+	MinCleanServers int   `json:"min_clean_servers"` // 0 never activates standby servers
+	CooldownMs      int64 `json:"cooldown_ms"`       // Least time an activated standby server serves
+}
+
+// DefaultStandbyConfig never activates standby servers
+func DefaultStandbyConfig() StandbyConfig {
+	return StandbyConfig{CooldownMs: defaultStandbyCooldownMs}
+}
+
+// Validate checks the clean server threshold and the cooldown
+func (c StandbyConfig) Validate() error {
+	if c.MinCleanServers < 0 {
+		return fmt.Errorf("minimum clean servers must not be negative, got %d", c.MinCleanServers)
+	}
+	if c.CooldownMs < 0 || c.CooldownMs > maxStandbyCooldownMs {
+		return fmt.Errorf("standby cooldown must be between 0 and %dms, got %d", maxStandbyCooldownMs, c.CooldownMs)
+	}
+	return nil
+}
+
+// StandbyServer is one standby server, waiting or activated
+type StandbyServer struct {
+	ServerID    int        `json:"server_id"`
+	Activated   bool       `json:"activated"`
+	ActivatedAt *time.Time `json:"activated_at,omitempty"`
+}
+
+// StandbyStatus reports the standby config, how many active servers are
+// GC-clean, activated standby servers aside, and every standby server
+type StandbyStatus struct {
+	Config       StandbyConfig   `json:"config"`
+	CleanServers int             `json:"clean_servers"`
+	Servers      []StandbyServer `json:"servers"`
+}
+
+// SetStandbyConfig changes when standby servers are activated and applies it at once
+func (l *LoadBalancer) SetStandbyConfig(config StandbyConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	l.standby.Store(&config)
+	l.checkStandby()
+	return nil
+}
+
+// StandbyConfig returns when standby servers are activated
+func (l *LoadBalancer) StandbyConfig() StandbyConfig {
+	if config := l.standby.Load(); config != nil {
+		return *config
+	}
+	return DefaultStandbyConfig()
+}
+
+// StandbyStatus reports the standby servers and the clean server count they are activated by
+func (l *LoadBalancer) StandbyStatus() StandbyStatus {
+	threshold := l.getCurrentMaGCThreshold()
+	status := StandbyStatus{Config: l.StandbyConfig(), Servers: make([]StandbyServer, 0)}
+	for _, server := range l.ListServers() {
+		waiting, activatedAt := server.standbyRole()
+		switch {
+		case waiting:
+			status.Servers = append(status.Servers, StandbyServer{ServerID: server.ID})
+		case !activatedAt.IsZero():
+			status.Servers = append(status.Servers, StandbyServer{ServerID: server.ID, Activated: true, ActivatedAt: &activatedAt})
+		case server.gcClean(threshold):
+			status.CleanServers++
+		}
+	}
+	return status
+}
+
+// standbyRole reports whether the server waits in standby, or when GC
+// pressure activated it; zero if it is not a standby server
+func (s *Server) standbyRole() (bool, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.adminStateLocked() == StateStandby, s.standbyActivatedAt
+}
+
+// gcClean reports whether the server is active, neither collecting nor
+// warming up, and has no MaGC forecast within thresholdMs
+func (s *Server) gcClean(thresholdMs int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.adminStateLocked() != StateActive || s.isCollectingGCTasks || s.warmingUp {
+		return false
+	}
+	timeToMaGC, ok := s.timeToMaGCLocked()
+	return !ok || timeToMaGC.Milliseconds() > thresholdMs
+}
+
+// activateStandby moves a waiting standby server that is not collecting to active
+func (s *Server) activateStandby(now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.adminStateLocked() != StateStandby || s.isCollectingGCTasks {
+		return false
+	}
+	s.adminState = StateActive
+	s.standbyActivatedAt = now
+	return true
+}
+
+// deactivateStandby returns an activated standby server to standby, unless
+// an operator has changed its state since
+func (s *Server) deactivateStandby() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.adminStateLocked() != StateActive || s.standbyActivatedAt.IsZero() {
+		return false
+	}
+	s.adminState = StateStandby
+	s.standbyActivatedAt = time.Time{}
+	return true
+}
+
+// checkStandby activates standby servers while too few active servers are
+// GC-clean and returns them to standby once cooled down and no longer
+// needed. It runs on every MaGC start and state change and from the
+// housekeeping loop.
+func (l *LoadBalancer) checkStandby() {
+	if l == nil {
+		return
+	}
+	config := l.StandbyConfig()
+	threshold := l.getCurrentMaGCThreshold()
+
+	l.standbyMu.Lock()
+	defer l.standbyMu.Unlock()
+
+	type activation struct {
+		server *Server
+		at     time.Time
+	}
+	now := l.now()
+	clean, covering := 0, 0
+	var waiting []*Server
+	var activated []activation
+	for _, server := range l.ListServers() {
+		isWaiting, activatedAt := server.standbyRole()
+		switch {
+		case isWaiting:
+			waiting = append(waiting, server)
+		case !activatedAt.IsZero():
+			activated = append(activated, activation{server, activatedAt})
+			if server.gcClean(threshold) {
+				covering++
+			}
+		case server.gcClean(threshold):
+			clean++
+		}
+	}
+
+	// Activated standby servers that are collecting themselves do not cover
+	needed := max(config.MinCleanServers-clean, 0)
+	for _, server := range waiting {
+		if covering >= needed {
+			break
+		}
+		if server.activateStandby(now) {
+			covering++
+			activated = append(activated, activation{server, now})
+			l.RecordEvent("standby_activated", server.ID, "Standby server %d activated: %d of %d required servers GC-clean",
+				server.ID, clean, config.MinCleanServers)
+		}
+	}
+
+	// The longest-serving go back first
+	sort.Slice(activated, func(i, j int) bool { return activated[i].at.Before(activated[j].at) })
+	cooldown := time.Duration(config.CooldownMs) * time.Millisecond
+	remaining := len(activated)
+	for _, standby := range activated {
+		if remaining <= needed || now.Sub(standby.at) < cooldown {
+			break
+		}
+		if standby.server.deactivateStandby() {
+			remaining--
+			l.RecordEvent("standby_deactivated", standby.server.ID, "Standby server %d returned to standby: %d servers GC-clean",
+				standby.server.ID, clean)
+		}
+	}
+}
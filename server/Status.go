@@ -0,0 +1,89 @@
+package server
+
+import "fmt"
+
+// Field groups for partial status queries
+const (
+	StatusFieldState    = "state"    // Administrative state and availability
+	StatusFieldMem      = "mem"      // Memory and generation usage
+	StatusFieldGC       = "gc"       // MaGC in progress, count and last pause
+	StatusFieldForecast = "forecast" // Latest MaGC forecast
+	StatusFieldTasks    = "tasks"    // Processed, in-flight, concurrency limit and lanes
+)
+
+// StatusFields lists every field group accepted by Server.Status
+var StatusFields = []string{StatusFieldState, StatusFieldMem, StatusFieldGC, StatusFieldForecast, StatusFieldTasks}
+
+// IsValidStatusField reports whether name is a status field group
+func IsValidStatusField(name string) bool {
+	for _, field := range StatusFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Status reports the requested field groups. Unlike Ping it reads the
+// server's state directly, without the simulated round trip, so dashboards
+// can poll a few fields cheaply. Keys match those of Ping where they overlap.
+func (s *Server) Status(fields []string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := map[string]interface{}{"server_id": s.ID}
+	for _, field := range fields {
+		switch field {
+		case StatusFieldState:
+			status["admin_state"] = s.adminStateLocked()
+			status["is_available"] = !s.isCollectingGCTasks && s.adminStateLocked() == StateActive
+
+		case StatusFieldMem:
+			status["mem_used"] = fmt.Sprintf("%.1f%%", float64(s.usedMemory)/float64(s.memLimit)*100)
+			status["memory_usage"] = fmt.Sprintf("%d/%d (%.1f%%)", s.usedMemory, s.memLimit, float64(s.usedMemory)/float64(s.memLimit)*100)
+			status["young_gen_used"] = s.YoungGenUsed
+			status["young_gen_max"] = s.YoungGenMax
+			status["old_gen_used"] = s.OldGenUsed
+			status["old_gen_max"] = s.OldGenMax
+
+		case StatusFieldGC:
+			status["is_collecting_gc"] = s.isCollectingGCTasks
+			status["gc_count"] = s.GCCount
+			status["magc_duration_ms"] = s.MaGCDuration
+			status["gc_pressure"] = s.gcPressure.Score
+			if !s.LastMaGCTime.IsZero() {
+				status["last_magc_time"] = s.LastMaGCTime
+			}
+
+		case StatusFieldForecast:
+			forecast := map[string]interface{}(nil)
+			if timeToMaGC, ok := s.timeToMaGCLocked(); ok {
+				forecast = map[string]interface{}{
+					"predicted_time":  s.LastMaGCForecast.PredictedTime,
+					"time_to_magc_ms": timeToMaGC.Milliseconds(),
+					"confidence":      s.LastMaGCForecast.Confidence,
+				}
+			}
+			status["magc_forecast"] = forecast
+
+			longForecast := map[string]interface{}(nil)
+			if long := s.longForecastLocked(); long != nil {
+				longForecast = map[string]interface{}{
+					"next_magc":      long.NextMaGC,
+					"interval_ms":    long.IntervalMs,
+					"expected_magcs": len(long.PredictedTimes),
+					"horizon_end":    long.HorizonEnd,
+					"confidence":     long.Confidence,
+				}
+			}
+			status["long_magc_forecast"] = longForecast
+
+		case StatusFieldTasks:
+			status["tasks_processed"] = len(s.TaskStorage)
+			status["active_tasks"] = s.activeTasks
+			status["max_concurrent_tasks"] = s.maxConcurrentTasks
+			status["lanes"] = s.laneStatsLocked()
+		}
+	}
+	return status
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"slices"
+	"time"
+)
+
+// StatusSnapshot is the fleet's status as of its last refresh
+type StatusSnapshot struct {
+	UpdatedAt time.Time      `json:"updated_at"`
+	Servers   []ServerStatus `json:"servers"` // Ping results in pool order
+}
+
+// housekeepingInterval is how often the status snapshot is refreshed
+const housekeepingInterval = 2 * time.Second
+
+// refreshStatus rebuilds the status snapshot from every server's current
+// state, without the simulated ping latency
+func (l *LoadBalancer) refreshStatus() {
+	servers := l.ListServers()
+	snapshot := &StatusSnapshot{
+		UpdatedAt: l.now(),
+		Servers:   make([]ServerStatus, 0, len(servers)),
+	}
+	for _, server := range servers {
+		result := server.pingResult()
+		result.TaskIDs = slices.Clone(result.TaskIDs) // Detach from the live slice
+		snapshot.Servers = append(snapshot.Servers, result)
+	}
+	l.statusCache.Store(snapshot)
+}
+
+// CachedStatus returns the fleet status kept up to date by the housekeeping
+// loop and pool changes. It never pings servers, so it answers instantly but
+// may trail state changes by up to housekeepingInterval.
+func (l *LoadBalancer) CachedStatus() StatusSnapshot {
+	snapshot := l.statusCache.Load()
+	if snapshot == nil {
+		l.refreshStatus()
+		snapshot = l.statusCache.Load()
+	}
+	return *snapshot
+}
@@ -0,0 +1,313 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// decisionLogFile names the decision log Storage keeps in its directory
+	decisionLogFile = "decisions.log"
+
+	// taskTracesFile names the JSON lines file Storage keeps task traces in
+	taskTracesFile = "task-traces.jsonl"
+
+	// maxStoredLine bounds one line of a JSON lines file
+	maxStoredLine = 1 << 20
+)
+
+// Storage is the file-based Store. Documents and the policy are JSON files in
+// a directory. Each server's GC history and the task traces are JSON lines
+// files, compacted to the last maxStoredSnapshots and maxStoredTaskTraces
+// entries once they hold twice as many. Decisions go to a DecisionLog in the
+// same directory that `debug replay` reads.
+type Storage struct {
+	dir string
+
+	mu         sync.Mutex
+	lineCounts map[string]int // Lines in each JSON lines file, once counted
+	decisions  *DecisionLog
+}
+
+// NewStorage creates the directory if needed and returns a storage rooted there
+func NewStorage(dir string) (*Storage, error) {
+	if dir == "" {
+		return nil, errors.New("storage directory cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create storage directory: %w", err)
+	}
+	return &Storage{dir: dir, lineCounts: make(map[string]int)}, nil
+}
+
+// Dir returns the storage directory
+func (s *Storage) Dir() string {
+	return s.dir
+}
+
+// Save writes v as the named document, replacing it atomically
+func (s *Storage) Save(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+
+	path := filepath.Join(s.dir, name+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Load reads the named document into v. It reports false if the document
+// does not exist yet.
+func (s *Storage) Load(name string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, name+".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// List returns the names of the documents starting with prefix, sorted
+func (s *Storage) List(prefix string) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("list %s: %w", s.dir, err)
+	}
+
+	names := make([]string, 0)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if entry.IsDir() || !ok || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// historyPath is the file holding the server's GC history
+func (s *Storage) historyPath(serverID int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("gc-history-%d.jsonl", serverID))
+}
+
+// SaveSnapshot appends a GC snapshot to the server's history file
+func (s *Storage) SaveSnapshot(serverID int, snapshot GCSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLineLocked(s.historyPath(serverID), snapshot, maxStoredSnapshots)
+}
+
+// LoadHistory returns the server's snapshots taken in [from, to]
+func (s *Storage) LoadHistory(serverID int, from, to time.Time) ([]GCSnapshot, error) {
+	s.mu.Lock()
+	lines, err := readLines(s.historyPath(serverID))
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]GCSnapshot, 0, len(lines))
+	for _, line := range lines {
+		var snapshot GCSnapshot
+		if json.Unmarshal(line, &snapshot) == nil && inRange(snapshot.Timestamp, from, to) {
+			history = append(history, snapshot)
+		}
+	}
+	return history, nil
+}
+
+// SaveTaskTrace appends a task trace to the directory's trace file
+func (s *Storage) SaveTaskTrace(trace TaskTrace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendLineLocked(filepath.Join(s.dir, taskTracesFile), trace, maxStoredTaskTraces)
+}
+
+// LoadTaskTrace returns the most recent trace with the given task ID, or nil
+// if there is none
+func (s *Storage) LoadTaskTrace(id string) (*TaskTrace, error) {
+	s.mu.Lock()
+	lines, err := readLines(filepath.Join(s.dir, taskTracesFile))
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Matching the ID before decoding skips the work for every other line
+	needle, _ := json.Marshal(id)
+	for i := len(lines) - 1; i >= 0; i-- {
+		if !bytes.Contains(lines[i], needle) {
+			continue
+		}
+		var trace TaskTrace
+		if json.Unmarshal(lines[i], &trace) == nil && trace.ID == id {
+			return &trace, nil
+		}
+	}
+	return nil, nil
+}
+
+// appendLineLocked appends v as a JSON line to path, rewriting the file with
+// its last keep lines once it holds twice that many; callers hold s.mu
+func (s *Storage) appendLineLocked(path string, v interface{}, keep int) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", filepath.Base(path), err)
+	}
+	line = append(line, '\n')
+
+	count, counted := s.lineCounts[path]
+	if !counted {
+		lines, err := readLines(path)
+		if err != nil {
+			return err
+		}
+		count = len(lines)
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	_, err = file.Write(line)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	count++
+
+	if count > 2*keep {
+		if count, err = compactLines(path, keep); err != nil {
+			return err
+		}
+	}
+	s.lineCounts[path] = count
+	return nil
+}
+
+// readLines returns the non-empty lines of a JSON lines file; a missing file
+// has none. A final line cut short by a crash is returned as-is and fails to
+// decode.
+func readLines(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+
+	lines := make([][]byte, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStoredLine)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			lines = append(lines, bytes.Clone(scanner.Bytes()))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", filepath.Base(path), err)
+	}
+	return lines, nil
+}
+
+// compactLines rewrites a JSON lines file with its last keep lines and
+// returns how many it now holds
+func compactLines(path string, keep int) (int, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) > keep {
+		lines = lines[len(lines)-keep:]
+	}
+
+	var buf bytes.Buffer
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return 0, fmt.Errorf("compact %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("compact %s: %w", filepath.Base(path), err)
+	}
+	return len(lines), nil
+}
+
+// SavePolicy writes the policy now in effect as a document
+func (s *Storage) SavePolicy(policy LoadBalancingPolicy) error {
+	return s.Save(policyDocument, policy)
+}
+
+// LoadPolicy returns the saved policy, or nil if none was saved
+func (s *Storage) LoadPolicy() (*LoadBalancingPolicy, error) {
+	var policy LoadBalancingPolicy
+	found, err := s.Load(policyDocument, &policy)
+	if err != nil || !found {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SaveDecision appends a decision to the directory's decision log, opening
+// it on first use
+func (s *Storage) SaveDecision(record DecisionRecord) error {
+	s.mu.Lock()
+	if s.decisions == nil {
+		decisions, err := OpenDecisionLog(s.DecisionLogPath())
+		if err != nil {
+			s.mu.Unlock()
+			return err
+		}
+		s.decisions = decisions
+	}
+	decisions := s.decisions
+	s.mu.Unlock()
+
+	return decisions.Append(record)
+}
+
+// DecisionLogPath returns the decision log SaveDecision appends to
+func (s *Storage) DecisionLogPath() string {
+	return filepath.Join(s.dir, decisionLogFile)
+}
+
+// Close closes the decision log if one was opened
+func (s *Storage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.decisions == nil {
+		return nil
+	}
+	err := s.decisions.Close()
+	s.decisions = nil
+	return err
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxStoredSnapshots bounds the GC snapshots the in-tree stores keep per server
+	maxStoredSnapshots = 1000
+
+	// maxStoredDecisions bounds the decisions MemoryStore keeps
+	maxStoredDecisions = 10000
+
+	// maxStoredTaskTraces bounds the task traces the in-tree stores keep
+	maxStoredTaskTraces = 10000
+
+	// policyDocument names the document holding the load balancing policy
+	policyDocument = "policy"
+)
+
+// Store persists load balancer state that should survive restarts: named
+// documents (execution stats, family pins, run reports), GC history, the
+// load balancing policy, selection decisions and task traces. Storage keeps it in a
+// directory and MemoryStore in memory; other backends such as Postgres or
+// ClickHouse implement Store and are passed to WithStore.
+//
+// Methods are called concurrently, from dispatch and monitoring goroutines,
+// and should return quickly.
+type Store interface {
+	// Save writes v as the named document, replacing it
+	Save(name string, v interface{}) error
+	// Load reads the named document into v, reporting false if it does not exist
+	Load(name string, v interface{}) (bool, error)
+	// List returns the names of the documents starting with prefix, sorted
+	List(prefix string) ([]string, error)
+
+	// SaveSnapshot appends a GC snapshot to the server's history
+	SaveSnapshot(serverID int, snapshot GCSnapshot) error
+	// LoadHistory returns the server's snapshots taken in [from, to], oldest
+	// first; a zero from or to leaves that end of the range open
+	LoadHistory(serverID int, from, to time.Time) ([]GCSnapshot, error)
+
+	// SavePolicy records the load balancing policy now in effect
+	SavePolicy(policy LoadBalancingPolicy) error
+	// LoadPolicy returns the last saved policy, or nil if none was saved
+	LoadPolicy() (*LoadBalancingPolicy, error)
+
+	// SaveDecision appends a selection decision recorded for replay
+	SaveDecision(record DecisionRecord) error
+
+	// SaveTaskTrace appends the trace of a finished task
+	SaveTaskTrace(trace TaskTrace) error
+	// LoadTaskTrace returns the trace with the given task ID, or nil if
+	// there is none
+	LoadTaskTrace(id string) (*TaskTrace, error)
+}
+
+// inRange reports whether t lies in [from, to], treating zero bounds as open
+func inRange(t, from, to time.Time) bool {
+	return (from.IsZero() || !t.Before(from)) && (to.IsZero() || !t.After(to))
+}
+
+// MemoryStore is a Store that keeps everything in memory, for tests and
+// embedders that do not need state to outlive the process. Documents are kept
+// encoded, so loading one never aliases what was saved.
+type MemoryStore struct {
+	mu        sync.Mutex
+	documents map[string][]byte
+	history   map[int][]GCSnapshot
+	policy    *LoadBalancingPolicy
+	decisions []DecisionRecord
+	traces    []TaskTrace
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		documents: make(map[string][]byte),
+		history:   make(map[int][]GCSnapshot),
+	}
+}
+
+// Save writes v as the named document
+func (m *MemoryStore) Save(name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode %s: %w", name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.documents[name] = data
+	return nil
+}
+
+// Load reads the named document into v
+func (m *MemoryStore) Load(name string, v interface{}) (bool, error) {
+	m.mu.Lock()
+	data, ok := m.documents[name]
+	m.mu.Unlock()
+	if !ok {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("decode %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// List returns the names of the documents starting with prefix, sorted
+func (m *MemoryStore) List(prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0)
+	for name := range m.documents {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// SaveSnapshot appends a GC snapshot, keeping the last maxStoredSnapshots
+func (m *MemoryStore) SaveSnapshot(serverID int, snapshot GCSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := append(m.history[serverID], snapshot)
+	if len(history) > maxStoredSnapshots {
+		history = history[len(history)-maxStoredSnapshots:]
+	}
+	m.history[serverID] = history
+	return nil
+}
+
+// LoadHistory returns the server's snapshots taken in [from, to]
+func (m *MemoryStore) LoadHistory(serverID int, from, to time.Time) ([]GCSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	history := make([]GCSnapshot, 0)
+	for _, snapshot := range m.history[serverID] {
+		if inRange(snapshot.Timestamp, from, to) {
+			history = append(history, snapshot)
+		}
+	}
+	return history, nil
+}
+
+// SavePolicy records the policy now in effect
+func (m *MemoryStore) SavePolicy(policy LoadBalancingPolicy) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.policy = &policy
+	return nil
+}
+
+// LoadPolicy returns the last saved policy, or nil if none was saved
+func (m *MemoryStore) LoadPolicy() (*LoadBalancingPolicy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.policy == nil {
+		return nil, nil
+	}
+	policy := *m.policy
+	return &policy, nil
+}
+
+// SaveDecision appends a decision, keeping the last maxStoredDecisions
+func (m *MemoryStore) SaveDecision(record DecisionRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.decisions = append(m.decisions, record)
+	if len(m.decisions) > maxStoredDecisions {
+		m.decisions = m.decisions[len(m.decisions)-maxStoredDecisions:]
+	}
+	return nil
+}
+
+// Decisions returns the stored decisions in [from, to], oldest first
+func (m *MemoryStore) Decisions(from, to time.Time) []DecisionRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	records := make([]DecisionRecord, 0)
+	for _, record := range m.decisions {
+		if inRange(record.Time, from, to) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// SaveTaskTrace appends a task trace, keeping the last maxStoredTaskTraces
+func (m *MemoryStore) SaveTaskTrace(trace TaskTrace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.traces = append(m.traces, trace)
+	if len(m.traces) > maxStoredTaskTraces {
+		m.traces = m.traces[len(m.traces)-maxStoredTaskTraces:]
+	}
+	return nil
+}
+
+// LoadTaskTrace returns the trace with the given task ID, or nil if there is none
+func (m *MemoryStore) LoadTaskTrace(id string) (*TaskTrace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := len(m.traces) - 1; i >= 0; i-- {
+		if m.traces[i].ID == id {
+			trace := m.traces[i]
+			return &trace, nil
+		}
+	}
+	return nil, nil
+}
@@ -1,7 +1,10 @@
 package server
 
 import (
+	"context"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -10,7 +13,13 @@ type Task struct {
 	Input     string    `json:"input"`
 	Output    string    `json:"output"`
 	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"` // Why a rejected task was turned away or a task timed out
 	CreatedAt time.Time `json:"created_at"`
+
+	// Where and when the task ran, for its TaskTrace
+	serverID   int
+	startedAt  time.Time
+	finishedAt time.Time
 }
 
 // GCSnapshot represents a point-in-time GC and memory state
@@ -31,7 +40,8 @@ type GCSnapshot struct {
 // MaGCForecast represents a predicted Major GC event
 type MaGCForecast struct {
 	PredictedTime     time.Time `json:"predicted_time"`
-	Confidence        float64   `json:"confidence"`
+	Confidence        float64   `json:"confidence"`     // Calibrated against past outcomes
+	RawConfidence     float64   `json:"raw_confidence"` // From data quality alone
 	YoungGenThreshold int       `json:"young_gen_threshold"`
 	TimeToMaGC        int64     `json:"time_to_magc_ms"`
 	ForecastCreatedAt time.Time `json:"forecast_created_at"`
@@ -50,10 +60,32 @@ type ProgramFamily struct {
 
 // LoadBalancingPolicy defines the rules for load balancing
 type LoadBalancingPolicy struct {
-	Algorithm         string `json:"algorithm"` // RR, RAN, WRR, WRAN
+	Algorithm         string `json:"algorithm"` // RR, RAN, WRR, WRAN, COST
 	GCAware           bool   `json:"gc_aware"`
 	MaGCThreshold     int64  `json:"magc_threshold_ms"`
 	HistoryWindowSize int    `json:"history_window_size"`
+	// Responses from a server whose MaGC is forecast within this window carry
+	// an X-GC-Imminent header; 0 disables the header
+	GCImminentHeaderMs int64 `json:"gc_imminent_header_ms,omitempty"`
+	// How GC-aware RR and RAN choose among several GC-safe servers: order
+	// (default), confidence or pressure, see TiebreakConfidence
+	Tiebreaker string `json:"tiebreaker,omitempty"`
+	// What happens to servers with a MaGC predicted within the threshold:
+	// hard (default) skips them, soft penalizes them, see AvoidanceSoft
+	GCAvoidance string `json:"gc_avoidance,omitempty"`
+	// Forecasts less confident than this (0-1) are ignored when avoiding
+	// MaGCs, e.g. after a restart with little history; 0 trusts every forecast
+	ConfidenceFloor float64 `json:"confidence_floor,omitempty"`
+	// Tasks still running after this long are cancelled by the server worker
+	// and their memory released; 0 lets them run, a request may set its own
+	TaskTimeoutMs int64 `json:"task_timeout_ms,omitempty"`
+	// What happens to a task no server is eligible for: fail_fast (default),
+	// wait or block, see NoServerWait; both of the latter give up after
+	// NoServerWaitMs (default 2000), and at most BlockCapacity tasks (default
+	// 20) block at once
+	OnNoServer     string `json:"on_no_server,omitempty"`
+	NoServerWaitMs int64  `json:"no_server_wait_ms,omitempty"`
+	BlockCapacity  int    `json:"block_capacity,omitempty"`
 }
 
 // TRINI represents the TRINI adaptive system
@@ -64,6 +96,18 @@ type TRINI struct {
 	MonitorInterval  time.Duration             `json:"monitor_interval"`
 	AnalysisInterval time.Duration             `json:"analysis_interval"`
 	IsActive         bool                      `json:"is_active"`
+
+	// Bounds of the adaptive monitoring interval, see SetAdaptiveMonitoring;
+	// both zero keeps it fixed at MonitorInterval
+	MinMonitorInterval     time.Duration `json:"min_monitor_interval,omitempty"`
+	MaxMonitorInterval     time.Duration `json:"max_monitor_interval,omitempty"`
+	currentMonitorInterval time.Duration
+
+	lb       *LoadBalancer
+	ctx      context.Context    // From Start; the loops run under it while active
+	cancel   context.CancelFunc // Stops the running loops; nil while paused or stopped
+	toggleMu sync.Mutex         // Serializes Start, Stop and SetActive
+	wg       sync.WaitGroup
 }
 
 type Server struct {
@@ -73,22 +117,70 @@ type Server struct {
 	LoadBalancer        *LoadBalancer
 	TaskStorage         []string
 	isCollectingGCTasks bool
+	adminState          string    // Administrative state, see StateActive
+	standbyActivatedAt  time.Time // When GC pressure moved this standby server to active; zero otherwise
+	replay              bool      // Decision replay or Harness copy: no simulated latency or GC side effects
+	gcStartedAt         time.Time // Start of the MaGC in progress
 	usedMemory          int
 	memLimit            int
-	gcPercentage        float64 // GC trigger percentage (0.0-1.0)
+	gcPercentage        float64 // Old generation occupancy that triggers a MaGC (0.0-1.0)
+	maxConcurrentTasks  int     // Tasks allowed in flight at once; 0 is unlimited
+	activeTasks         int
+	lanes               map[string]*laneState // Per-lane load and outcomes, see LaneInteractive
+	interactiveShare    float64               // Share of the limit for the interactive lane; 0 is the default
+
+	// Traffic ramp after a rolling upgrade or warm-up, see StartRollingUpgrade
+	slowStartFrom  time.Time
+	slowStartUntil time.Time
+	warmingUp      bool   // Out of rotation running warm-up tasks after a MaGC, see WithWarmup
+	zone           string // Failure domain batches are spread across, see DispatchBatch
 
 	// TRINI GC-aware extensions
-	GCHistory        []GCSnapshot   `json:"gc_history"`
-	CurrentFamily    *ProgramFamily `json:"current_family"`
-	LastMaGCForecast *MaGCForecast  `json:"last_magc_forecast"`
-	YoungGenUsed     int            `json:"young_gen_used"`
-	OldGenUsed       int            `json:"old_gen_used"`
-	YoungGenMax      int            `json:"young_gen_max"`
-	OldGenMax        int            `json:"old_gen_max"`
-	GCCount          int            `json:"gc_count"`
-	LastMaGCTime     time.Time      `json:"last_magc_time"`
-	MaGCDuration     int64          `json:"magc_duration_ms"`
-	Weights          int            `json:"weights"` // For weighted algorithms
+	GCHistory        []GCSnapshot         `json:"gc_history"`
+	CurrentFamily    *ProgramFamily       `json:"current_family"`
+	familyPin        *FamilyPin           // Operator-chosen family, see LoadBalancer.PinFamily
+	LastMaGCForecast *MaGCForecast        `json:"last_magc_forecast"` // Short horizon, for selection
+	LongMaGCForecast *LongHorizonForecast `json:"long_magc_forecast"` // Long horizon, for autoscaling and GC scheduling
+	YoungGenUsed     int                  `json:"young_gen_used"`
+	OldGenUsed       int                  `json:"old_gen_used"`
+	YoungGenMax      int                  `json:"young_gen_max"`
+	OldGenMax        int                  `json:"old_gen_max"`
+	GCCount          int                  `json:"gc_count"`
+	LastMaGCTime     time.Time            `json:"last_magc_time"`
+	MaGCDuration     int64                `json:"magc_duration_ms"`
+	Weights          int                  `json:"weights"` // Remaining weighted round-robin budget
+
+	// Generation sizing: a young generation ratio of the memory limit, or absolute sizes
+	youngGenRatio      float64
+	generationOverride bool
+
+	// Capacity-derived weight for weighted algorithms
+	CPUMultiplier  float64 `json:"cpu_multiplier"` // Relative CPU capacity
+	weight         int
+	weightOverride bool
+
+	// Composite GC pressure, refreshed by the TRINI monitoring loop
+	gcPressure GCPressure
+
+	// Recent selections by the load balancer, for starvation detection
+	selections []selectionBucket
+	starved    bool
+
+	// Execution statistics: lifetime totals and one-minute buckets
+	execTotals  ExecutionTotals
+	execBuckets []StatsBucket
+
+	// GC analysis: completed MaGCs and scored forecasts
+	GCEvents           []GCEvent          `json:"-"`
+	ForecastOutcomes   []ForecastOutcome  `json:"-"`
+	trainingSequences  []TrainingSequence // Labeled snapshots before each MaGC, for export
+	timeline           []TimelineEntry    // Family changes, forecasts, selections and rejections, see Timeline
+	lastScoredForecast time.Time
+
+	// Cost-aware balancing
+	Cost        float64 `json:"cost"` // Relative cost/power score per processed task
+	tasksCosted int64
+	totalCost   float64
 }
 
 type LoadBalancer struct {
@@ -100,6 +192,115 @@ type LoadBalancer struct {
 	// TRINI extensions
 	TRINI         *TRINI              `json:"trini"`
 	CurrentPolicy LoadBalancingPolicy `json:"current_policy"`
+
+	// Runtime toggles for experimental behaviors
+	Flags *FeatureFlags `json:"-"`
+
+	// Learned task execution times used for forecast-aware placement
+	Durations *DurationEstimator `json:"-"`
+
+	// Optional persistence for state that should survive restarts
+	store Store
+
+	// Optional record of selection decisions for replay
+	decisions *DecisionLog
+
+	// When a task that does not fit triggers a MaGC, see MemoryPressurePolicy
+	memoryPressure atomic.Pointer[MemoryPressurePolicy]
+
+	// Objective task outcomes are measured against and the last hour of them
+	sloConfig    atomic.Pointer[SLOConfig]
+	sloMu        sync.Mutex
+	sloBuckets   []sloBucket
+	sloExhausted bool // As of the last checkSLO
+
+	// Optional external service consulted before the local algorithm
+	policyEngine *PolicyEngine
+
+	// Kill switch for the adaptive machinery, see ModeDegraded
+	mode atomic.Pointer[OperatingMode]
+
+	// Second policy evaluated alongside every selection, see SetShadowPolicy
+	shadow atomic.Pointer[shadowState]
+
+	// How soon a forecast MaGC must be due to be run early on an idle server
+	gcPrefetchWindow time.Duration
+
+	// Warm-up tasks and ramp after each MaGC
+	warmup WarmupConfig
+
+	// When standby servers are activated and deactivated, see StandbyConfig;
+	// standbyMu serializes the controller
+	standby   atomic.Pointer[StandbyConfig]
+	standbyMu sync.Mutex
+
+	// Configuration replication group this balancer belongs to, see RaftNode
+	raft atomic.Pointer[RaftNode]
+
+	clock  Clock
+	logger *log.Logger
+	ctx    context.Context // From Start; done once Stop is called
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// Task outcome counters
+	tasksSubmitted atomic.Int64
+	taskAttempts   atomic.Int64
+	tasksCompleted atomic.Int64
+	tasksRejected  atomic.Int64
+	tasksTimedOut  atomic.Int64
+	gcAvoided      atomic.Int64
+	gcHit          atomic.Int64
+
+	rejectionsMu sync.Mutex
+	rejections   map[string]int64 // Rejected tasks by reason
+
+	// Start of the run and what it is measured against, see RunReport
+	runMu         sync.Mutex
+	runStartedAt  time.Time
+	runBaseline   map[int]ExecutionTotals // Each server's totals when the run started
+	policyChanges []PolicyChange
+
+	eventsMu         sync.Mutex
+	events           []Event
+	eventCounts      map[string]int64 // Events recorded since start by type, for run reports
+	eventSubscribers map[chan Event]struct{}
+
+	rolloutMu sync.Mutex
+	rollout   *PolicyRollout
+
+	upgradeMu sync.Mutex
+	upgrade   *RollingUpgrade
+
+	// Task IDs and the traces of recently finished tasks, see TaskTrace
+	taskIDEpoch  int64
+	taskSeq      atomic.Int64
+	blockedTasks atomic.Int64 // Tasks waiting under NoServerBlock
+	tracesMu     sync.Mutex
+	traces       []TaskTrace
+
+	// Recent MaGCs run early to stagger correlated forecasts
+	desyncMu      sync.Mutex
+	desyncActions []Desynchronization
+
+	// Fleet status served without pinging servers
+	statusCache atomic.Pointer[StatusSnapshot]
+
+	// Callbacks registered by library users
+	hooksMu sync.RWMutex
+	hooks   []Hooks
+}
+
+// LoadBalancerStats aggregates task outcomes across the server pool
+type LoadBalancerStats struct {
+	TasksSubmitted   int64            `json:"tasks_submitted"`
+	TaskAttempts     int64            `json:"task_attempts"` // Executions sent to servers, hedges and retries included
+	TasksCompleted   int64            `json:"tasks_completed"`
+	TasksRejected    int64            `json:"tasks_rejected"`
+	TasksTimedOut    int64            `json:"tasks_timed_out"`
+	RejectionReasons map[string]int64 `json:"rejection_reasons"` // Rejected tasks by Reject* or Skip* reason
+	GCAvoided        int64            `json:"gc_avoided"`        // Servers skipped because they were in or forecast to start a MaGC
+	GCHit            int64            `json:"gc_hit"`            // Tasks in flight on, or sent to, a server when its MaGC started
 }
 
 type ServiceResponse struct {
@@ -107,4 +308,14 @@ type ServiceResponse struct {
 	Message    string     `json:"message"`
 	TaskResult *Task      `json:"task_result,omitempty"`
 	ResultChan chan *Task `json:"-"`
+	TaskID     string     `json:"task_id,omitempty"` // Set by the load balancer; look up its TaskTrace once it finishes
+	// How the policy's no-server behavior was applied, if selection first
+	// found no eligible server
+	NoServer *NoServerOutcome `json:"no_server,omitempty"`
+
+	// Set by DispatchExplained
+	Explanation *SelectionExplanation `json:"explanation,omitempty"`
+
+	// GC imminence window of the policy that placed the task (0 = no notice)
+	GCImminentWindow time.Duration `json:"-"`
 }
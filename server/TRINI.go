@@ -1,7 +1,8 @@
 package server
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"math"
 	"time"
 )
@@ -33,10 +34,11 @@ func (t *TRINI) initializeDefaultFamilies() {
 			"min_samples":       5,
 		},
 		Policy: LoadBalancingPolicy{
-			Algorithm:         "RR",
-			GCAware:           true,
-			MaGCThreshold:     1000, // 1 second
-			HistoryWindowSize: 20,
+			Algorithm:          "RR",
+			GCAware:            true,
+			MaGCThreshold:      1000, // 1 second
+			HistoryWindowSize:  20,
+			GCImminentHeaderMs: 1000,
 		},
 		ForecastWindowSize: 15,
 		MaGCThreshold:      1000,
@@ -53,10 +55,11 @@ func (t *TRINI) initializeDefaultFamilies() {
 			"min_samples":       5,
 		},
 		Policy: LoadBalancingPolicy{
-			Algorithm:         "WRR",
-			GCAware:           true,
-			MaGCThreshold:     3000, // 3 seconds
-			HistoryWindowSize: 30,
+			Algorithm:          "WRR",
+			GCAware:            true,
+			MaGCThreshold:      3000, // 3 seconds
+			HistoryWindowSize:  30,
+			GCImminentHeaderMs: 3000,
 		},
 		ForecastWindowSize: 25,
 		MaGCThreshold:      3000,
@@ -72,10 +75,11 @@ func (t *TRINI) initializeDefaultFamilies() {
 			"min_samples":       3,
 		},
 		Policy: LoadBalancingPolicy{
-			Algorithm:         "WRR",
-			GCAware:           true,
-			MaGCThreshold:     5000, // 5 seconds
-			HistoryWindowSize: 40,
+			Algorithm:          "WRR",
+			GCAware:            true,
+			MaGCThreshold:      5000, // 5 seconds
+			HistoryWindowSize:  40,
+			GCImminentHeaderMs: 5000,
 		},
 		ForecastWindowSize: 35,
 		MaGCThreshold:      5000,
@@ -106,54 +110,169 @@ func (t *TRINI) initializeDefaultFamilies() {
 	t.DefaultFamily = defaultFamily
 }
 
-// StartTRINI starts the TRINI monitoring and analysis loops
-func (lb *LoadBalancer) StartTRINI() {
-	if lb.TRINI == nil {
-		lb.TRINI = NewTRINI()
+// Start initializes the attached servers and, unless TRINI is paused,
+// launches the monitoring and analysis loops. The loops run until ctx is
+// cancelled, Stop is called or SetActive pauses them.
+func (t *TRINI) Start(ctx context.Context) error {
+	t.toggleMu.Lock()
+	defer t.toggleMu.Unlock()
+
+	t.mu.Lock()
+	if t.lb == nil {
+		t.mu.Unlock()
+		return errors.New("TRINI is not attached to a load balancer")
 	}
+	if t.ctx != nil {
+		t.mu.Unlock()
+		return errors.New("TRINI already started")
+	}
+	t.ctx = ctx
+	lb := t.lb
+	defaultFamily := t.DefaultFamily
+	t.mu.Unlock()
 
 	// Initialize servers with default family
-	for _, server := range lb.Servers {
-		server.initializeTRINI(lb.TRINI.DefaultFamily)
+	for _, server := range lb.ListServers() {
+		server.initializeTRINI(defaultFamily)
 	}
 
-	// Start monitoring loop
-	go lb.monitoringLoop()
+	t.mu.Lock()
+	if t.IsActive {
+		t.startLoopsLocked()
+	}
+	t.mu.Unlock()
+
+	lb.logf("🔍 TRINI GC-aware load balancing started")
+	return nil
+}
+
+// startLoopsLocked launches the monitoring and analysis loops under the
+// context given to Start; callers hold t.mu
+func (t *TRINI) startLoopsLocked() {
+	ctx, cancel := context.WithCancel(t.ctx)
+	t.cancel = cancel
 
-	// Start analysis loop
-	go lb.analysisLoop()
+	t.wg.Add(2)
+	go t.monitoringLoop(ctx)
+	go t.analysisLoop(ctx)
+}
+
+// Stop terminates the monitoring and analysis loops and waits for them to exit
+func (t *TRINI) Stop() {
+	t.toggleMu.Lock()
+	defer t.toggleMu.Unlock()
 
-	fmt.Println("🔍 TRINI GC-aware load balancing started")
+	t.mu.Lock()
+	cancel := t.cancel
+	t.cancel = nil
+	t.ctx = nil
+	t.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	t.wg.Wait()
+}
+
+// SetActive pauses or resumes GC-aware load balancing. Pausing stops the
+// monitoring and analysis loops and waits for them to exit; GC history,
+// forecasts and program families are kept, and resuming restarts the loops
+// on top of them. Before Start it only sets IsActive.
+func (t *TRINI) SetActive(active bool) {
+	t.toggleMu.Lock()
+	defer t.toggleMu.Unlock()
+
+	t.mu.Lock()
+	if t.IsActive == active {
+		t.mu.Unlock()
+		return
+	}
+	t.IsActive = active
+
+	var cancel context.CancelFunc
+	switch {
+	case !active:
+		cancel, t.cancel = t.cancel, nil
+	case t.ctx != nil && t.cancel == nil:
+		t.startLoopsLocked()
+	}
+	lb := t.lb
+	t.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		t.wg.Wait()
+	}
+
+	if lb == nil {
+		return
+	}
+	if active {
+		lb.RecordEvent("trini_resumed", 0, "TRINI resumed; monitoring and analysis loops restarted")
+	} else {
+		lb.RecordEvent("trini_paused", 0, "TRINI paused; monitoring and analysis loops stopped, history and families kept")
+	}
 }
 
 // monitoringLoop periodically collects GC data from servers
-func (lb *LoadBalancer) monitoringLoop() {
-	ticker := time.NewTicker(lb.TRINI.MonitorInterval)
-	defer ticker.Stop()
+func (t *TRINI) monitoringLoop(ctx context.Context) {
+	defer t.wg.Done()
+
+	timer := time.NewTimer(t.CurrentMonitorInterval())
+	defer timer.Stop()
 
-	for range ticker.C {
-		if !lb.TRINI.IsActive {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		timer.Reset(t.adaptMonitorInterval())
+
+		// Degraded mode sheds snapshot collection along with forecasts
+		if !t.IsActive || t.lb.degraded() {
 			continue
 		}
 
-		for _, server := range lb.Servers {
-			go server.collectGCSnapshot()
+		for _, server := range t.lb.ListServers() {
+			t.wg.Add(1)
+			go func(s *Server) {
+				defer t.wg.Done()
+				s.collectGCSnapshot()
+				s.updateGCPressure()
+			}(server)
 		}
+
+		// Forecasts only exist while TRINI is active
+		t.lb.desyncForecasts()
+		t.lb.prefetchGC()
 	}
 }
 
 // analysisLoop periodically analyzes GC patterns and updates program families
-func (lb *LoadBalancer) analysisLoop() {
-	ticker := time.NewTicker(lb.TRINI.AnalysisInterval)
+func (t *TRINI) analysisLoop(ctx context.Context) {
+	defer t.wg.Done()
+
+	ticker := time.NewTicker(t.AnalysisInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		if !lb.TRINI.IsActive {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !t.IsActive || t.lb.degraded() {
 			continue
 		}
 
-		for _, server := range lb.Servers {
-			go server.analyzeAndAdapt(lb.TRINI)
+		for _, server := range t.lb.ListServers() {
+			t.wg.Add(1)
+			go func(s *Server) {
+				defer t.wg.Done()
+				s.analyzeAndAdapt(t)
+			}(server)
 		}
 	}
 }
@@ -165,18 +284,17 @@ func (s *Server) initializeTRINI(defaultFamily *ProgramFamily) {
 
 	s.GCHistory = make([]GCSnapshot, 0, 100)
 	s.CurrentFamily = defaultFamily
-	s.YoungGenMax = s.memLimit / 2 // Assume 50% for young generation
-	s.OldGenMax = s.memLimit / 2   // Assume 50% for old generation
-	s.Weights = 1                  // Default weight for weighted algorithms
+	s.refreshGenerationsLocked()
+	s.refreshWeightLocked()
+	s.Weights = s.weight // Runtime budget for weighted round-robin
 }
 
-// collectGCSnapshot captures current GC and memory state
+// collectGCSnapshot captures current GC and memory state and appends it to
+// the load balancer's store, if any
 func (s *Server) collectGCSnapshot() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	snapshot := GCSnapshot{
-		Timestamp:      time.Now(),
+		Timestamp:      s.now(),
 		YoungGenUsed:   s.YoungGenUsed,
 		OldGenUsed:     s.OldGenUsed,
 		YoungGenMax:    s.YoungGenMax,
@@ -194,6 +312,13 @@ func (s *Server) collectGCSnapshot() {
 	if len(s.GCHistory) > 100 {
 		s.GCHistory = s.GCHistory[1:]
 	}
+	s.mu.Unlock()
+
+	if s.LoadBalancer != nil && s.LoadBalancer.store != nil {
+		if err := s.LoadBalancer.store.SaveSnapshot(s.ID, snapshot); err != nil {
+			s.logf("⚠️ Could not store GC snapshot: %v", err)
+		}
+	}
 }
 
 // analyzeAndAdapt analyzes GC patterns and adapts program family if needed
@@ -202,31 +327,46 @@ func (s *Server) analyzeAndAdapt(trini *TRINI) {
 	gcHistory := make([]GCSnapshot, len(s.GCHistory))
 	copy(gcHistory, s.GCHistory)
 	currentFamily := s.CurrentFamily
+	_, pinned := s.familyPinLocked()
 	s.mu.Unlock()
 
 	if len(gcHistory) < 3 {
 		return // Need minimum samples for analysis
 	}
 
-	// Evaluate current family suitability
-	if !s.evaluateCurrentFamily(gcHistory, currentFamily) {
+	// Evaluate current family suitability, unless an operator pinned it or
+	// the replication leader classifies it
+	if !pinned && !s.LoadBalancer.followsLeader() && !s.evaluateCurrentFamily(gcHistory, currentFamily) {
 		// Find better family
 		newFamily := s.findBestFamily(gcHistory, trini)
 		if newFamily != nil && newFamily.ID != currentFamily.ID {
 			s.mu.Lock()
 			s.CurrentFamily = newFamily
+			s.recordTimelineLocked(TimelineFamily, "Adapted from program family '%s' to '%s'", currentFamily.ID, newFamily.ID)
 			s.mu.Unlock()
-			fmt.Printf("Server %d: Adapted to program family '%s'\n", s.ID, newFamily.Name)
+			s.logf("Server %d: Adapted to program family '%s'", s.ID, newFamily.Name)
 		}
 	}
 
 	// Generate MaGC forecast
-	forecast := s.generateMaGCForecast(gcHistory)
+	forecast := s.generateMaGCForecast(gcHistory, trini.lb.Flags.Enabled(FlagForecastCalibration))
 	if forecast != nil {
 		s.mu.Lock()
+		s.scoreExpiredForecastLocked(s.now())
 		s.LastMaGCForecast = forecast
+		s.recordTimelineLocked(TimelineForecast, "MaGC forecast in %dms (confidence %.0f%%)", forecast.TimeToMaGC, forecast.Confidence*100)
 		s.mu.Unlock()
 	}
+
+	// Generate the long-horizon forecast from the MaGC cadence, dropping it
+	// at once while the forecaster is turned off
+	s.mu.Lock()
+	if !trini.lb.Flags.Enabled(FlagLongHorizonForecast) {
+		s.LongMaGCForecast = nil
+	} else if long := s.generateLongHorizonForecastLocked(s.now()); long != nil {
+		s.LongMaGCForecast = long
+	}
+	s.mu.Unlock()
 }
 
 // evaluateCurrentFamily checks if current family still suits the server
@@ -334,8 +474,9 @@ func (s *Server) findBestFamily(history []GCSnapshot, trini *TRINI) *ProgramFami
 	return trini.DefaultFamily
 }
 
-// generateMaGCForecast implements the MaGA algorithm for MaGC prediction
-func (s *Server) generateMaGCForecast(history []GCSnapshot) *MaGCForecast {
+// generateMaGCForecast implements the MaGA algorithm for MaGC prediction,
+// calibrating its confidence against past outcomes when calibrate is set
+func (s *Server) generateMaGCForecast(history []GCSnapshot, calibrate bool) *MaGCForecast {
 	if len(history) < 5 {
 		return nil // Need minimum samples for forecasting
 	}
@@ -356,7 +497,7 @@ func (s *Server) generateMaGCForecast(history []GCSnapshot) *MaGCForecast {
 	// Get recent history window
 	recentHistory := history[len(history)-windowSize:]
 
-	// Step 1: Forecast YoungGen threshold when OldGen exhaustion occurs
+	// Step 1: Forecast YoungGen threshold when OldGen reaches the MaGC trigger
 	youngGenThreshold := s.forecastYoungGenThreshold(recentHistory)
 	if youngGenThreshold <= 0 {
 		return nil
@@ -368,19 +509,27 @@ func (s *Server) generateMaGCForecast(history []GCSnapshot) *MaGCForecast {
 		return nil
 	}
 
-	// Calculate confidence based on data quality
-	confidence := s.calculateForecastConfidence(recentHistory)
+	// Calculate confidence based on data quality, then calibrate it against
+	// how often similar forecasts came true
+	rawConfidence := s.calculateForecastConfidence(recentHistory)
+	confidence := rawConfidence
+	if calibrate {
+		s.mu.Lock()
+		confidence = s.calibrateConfidenceLocked(rawConfidence)
+		s.mu.Unlock()
+	}
 
 	return &MaGCForecast{
-		PredictedTime:     time.Now().Add(time.Duration(timeToMaGC) * time.Millisecond),
+		PredictedTime:     s.now().Add(time.Duration(timeToMaGC) * time.Millisecond),
 		Confidence:        confidence,
+		RawConfidence:     rawConfidence,
 		YoungGenThreshold: youngGenThreshold,
 		TimeToMaGC:        timeToMaGC,
-		ForecastCreatedAt: time.Now(),
+		ForecastCreatedAt: s.now(),
 	}
 }
 
-// forecastYoungGenThreshold predicts YoungGen memory when OldGen exhaustion occurs
+// forecastYoungGenThreshold predicts YoungGen memory when the MaGC trigger is reached
 func (s *Server) forecastYoungGenThreshold(history []GCSnapshot) int {
 	if len(history) < 3 {
 		return 0
@@ -409,9 +558,8 @@ func (s *Server) forecastYoungGenThreshold(history []GCSnapshot) int {
 	a := (n*sumXY - sumX*sumY) / denominator
 	b := (sumY - a*sumX) / n
 
-	// Predict YoungGen when OldGen reaches 90% capacity
-	oldGenThreshold := float64(s.OldGenMax) * 0.9
-	youngGenThreshold := a*oldGenThreshold + b
+	// Predict YoungGen when OldGen reaches the occupancy that triggers a MaGC
+	youngGenThreshold := a*s.oldGenTrigger() + b
 
 	if youngGenThreshold < 0 {
 		youngGenThreshold = 0
@@ -453,7 +601,7 @@ func (s *Server) forecastTimeToMaGC(history []GCSnapshot, youngGenThreshold int)
 
 	// Predict time when YoungGen reaches threshold
 	predictedTime := a*float64(youngGenThreshold) + b
-	currentTime := float64(time.Now().Sub(baseTime).Milliseconds())
+	currentTime := float64(s.now().Sub(baseTime).Milliseconds())
 
 	timeToMaGC := int64(predictedTime - currentTime)
 
@@ -475,7 +623,7 @@ func (s *Server) calculateForecastConfidence(history []GCSnapshot) float64 {
 
 	// Reduce confidence if data is old
 	latestSnapshot := history[len(history)-1]
-	timeSinceLatest := time.Since(latestSnapshot.Timestamp)
+	timeSinceLatest := s.now().Sub(latestSnapshot.Timestamp)
 	if timeSinceLatest > 30*time.Second {
 		baseConfidence *= 0.5
 	}
@@ -483,22 +631,37 @@ func (s *Server) calculateForecastConfidence(history []GCSnapshot) float64 {
 	return baseConfidence
 }
 
+// forecastValidity is how long a MaGC forecast is trusted after it was made
+const forecastValidity = 30 * time.Second
+
 // IsMaGCPredicted checks if a MaGC is predicted within the threshold
 func (s *Server) IsMaGCPredicted(thresholdMs int64) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	timeToMaGC, ok := s.timeToMaGCLocked()
+	return ok && timeToMaGC.Milliseconds() <= thresholdMs
+}
+
+// TimeToMaGC returns how long until the forecast MaGC. It reports false if
+// there is no valid forecast or the predicted time has passed.
+func (s *Server) TimeToMaGC() (time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timeToMaGCLocked()
+}
+
+// timeToMaGCLocked implements TimeToMaGC; callers hold s.mu
+func (s *Server) timeToMaGCLocked() (time.Duration, bool) {
 	if s.LastMaGCForecast == nil {
-		return false
+		return 0, false
 	}
 
 	// Check if forecast is still valid (not too old)
-	if time.Since(s.LastMaGCForecast.ForecastCreatedAt) > 30*time.Second {
-		return false
+	if s.now().Sub(s.LastMaGCForecast.ForecastCreatedAt) > forecastValidity {
+		return 0, false
 	}
 
-	// Check if MaGC is predicted within threshold
-	timeToMaGC := time.Until(s.LastMaGCForecast.PredictedTime).Milliseconds()
-
-	return timeToMaGC >= 0 && timeToMaGC <= thresholdMs
+	timeToMaGC := s.LastMaGCForecast.PredictedTime.Sub(s.now())
+	return timeToMaGC, timeToMaGC >= 0
 }
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// TaskStatusTimeout is the Task.Status of a task the server worker gave up on
+// after it ran past its execution timeout
+const TaskStatusTimeout = "timeout"
+
+// TaskStatusCancelled is the Task.Status of a hedged attempt the load balancer
+// cancelled because another attempt of the same task answered first
+const TaskStatusCancelled = "cancelled"
+
+// runTask processes the task, cancelling the handler once it has run for
+// timeout, 0 waiting indefinitely, or once ctx is cancelled. A cancelled
+// task's memory reservation, made no earlier than admittedAt, is released
+// before runTask returns, so the caller frees its concurrency slot only once
+// the handler has stopped. Reports false when the task was cancelled.
+func (s *Server) runTask(ctx context.Context, input string, timeout time.Duration, admittedAt time.Time) (Task, bool) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	task, finished := s.handleTask(ctx, input)
+	if finished {
+		return task, true
+	}
+
+	s.mu.Lock()
+	s.releaseTaskLocked(len(input), admittedAt)
+	s.mu.Unlock()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		s.logf("Server %d: another attempt answered task '%s' first, cancelled it", s.ID, input)
+		return Task{
+			ID:        fmt.Sprintf("cancelled-%d", rand.Intn(1000)),
+			Input:     input,
+			Status:    TaskStatusCancelled,
+			Reason:    "Another attempt of the task answered first",
+			CreatedAt: s.now(),
+		}, false
+	}
+	s.logf("⏱️ Server %d: task '%s' exceeded its %v execution timeout, cancelled it", s.ID, input, timeout)
+	return Task{
+		ID:        fmt.Sprintf("timeout-%d", rand.Intn(1000)),
+		Input:     input,
+		Status:    TaskStatusTimeout,
+		Reason:    fmt.Sprintf("Task exceeded its %v execution timeout", timeout),
+		CreatedAt: s.now(),
+	}, false
+}
+
+// releaseTaskLocked undoes the allocation of a task whose result was
+// abandoned; callers hold s.mu. A cancelled task was never stored, so storage
+// is left alone. Promotion cannot be told apart afterwards, so the young
+// generation is credited first. Nothing is left to release when a MaGC has
+// emptied the heap since the task was admitted.
+func (s *Server) releaseTaskLocked(size int, admittedAt time.Time) {
+	if s.LastMaGCTime.After(admittedAt) {
+		return
+	}
+
+	s.usedMemory = max(s.usedMemory-size, 0)
+	young := min(int(float64(size)*0.8), s.YoungGenUsed)
+	s.YoungGenUsed -= young
+	s.OldGenUsed = max(s.OldGenUsed-(size-young), 0)
+}
+
+// recordTaskTimeout counts a task whose answering attempt ran past its execution timeout
+func (l *LoadBalancer) recordTaskTimeout() {
+	if l == nil {
+		return
+	}
+	l.tasksTimedOut.Add(1)
+	l.recordSLOOutcome(true, true, false)
+}
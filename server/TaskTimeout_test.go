@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+	"time"
+)
+
+func TestTaskTimeoutReleasesServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		timeout    time.Duration
+		wantStatus string
+		wantMemory int
+		wantStored int
+	}{
+		{"past the timeout", 50 * time.Millisecond, TaskStatusTimeout, 0, 0},
+		{"without a timeout", 0, "completed", len("payload"), 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewServer(1, 100, 80)
+			lb := NewLoadBalancer(WithServers(server), WithLogger(log.New(io.Discard, "", 0)))
+
+			// Every ID a task can draw, so a timeout releasing another task's entry shows
+			for id := 0; id < 1000; id++ {
+				server.TaskStorage = append(server.TaskStorage, fmt.Sprintf("task-%d", id))
+			}
+
+			_, resp := lb.DispatchLaneWithTimeout(context.Background(), "payload", LaneInteractive, false, tt.timeout)
+			task := <-resp.ResultChan
+			if task.Status != tt.wantStatus {
+				t.Fatalf("task %s (%s), want %s", task.Status, task.Reason, tt.wantStatus)
+			}
+			lb.wg.Wait()
+
+			server.mu.Lock()
+			used, stored := server.usedMemory, len(server.TaskStorage)-1000
+			server.mu.Unlock()
+			if active := server.ActiveTasks(); active != 0 {
+				t.Errorf("%d concurrency slots still held", active)
+			}
+			if used != tt.wantMemory || stored != tt.wantStored {
+				t.Errorf("server holds %d bytes and %d stored tasks, want %d and %d",
+					used, stored, tt.wantMemory, tt.wantStored)
+			}
+			var timedOut int64
+			if tt.wantStatus == TaskStatusTimeout {
+				timedOut = 1
+			}
+			if got := lb.Stats().TasksTimedOut; got != timedOut {
+				t.Errorf("balancer counted %d timed out tasks, want %d", got, timedOut)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxTaskTraces bounds the traces kept in memory for quick lookup; older ones
+// are only found in the store
+const maxTaskTraces = 1000
+
+// TaskTrace links one task's selection decision to its outcome, so it can be
+// checked whether GC-aware placement kept the task clear of MaGC pauses
+type TaskTrace struct {
+	ID          string    `json:"id"` // Task ID returned to the client
+	Lane        string    `json:"lane"`
+	TaskSize    int       `json:"task_size"`
+	SubmittedAt time.Time `json:"submitted_at"`
+	Status      string    `json:"status"` // completed, rejected or timeout
+	Reason      string    `json:"reason,omitempty"`
+	ServerID    int       `json:"server_id,omitempty"` // Server that produced the outcome, after any retry or hedge
+	// Selection decision, including every server's MaGC forecast at the time
+	Decision          *SelectionExplanation `json:"decision"`
+	QueueWaitMs       int64                 `json:"queue_wait_ms"` // Submission until the server started executing the task
+	ExecutionMs       int64                 `json:"execution_ms"`
+	TotalMs           int64                 `json:"total_ms"`
+	GCDuringExecution bool                  `json:"gc_during_execution"` // A MaGC on the server overlapped the execution
+	NoServer          *NoServerOutcome      `json:"no_server,omitempty"` // Set if selection first found no eligible server
+}
+
+// newTaskID returns a task ID unique across restarts of the load balancer
+func (l *LoadBalancer) newTaskID() string {
+	return fmt.Sprintf("task-%d-%d", l.taskIDEpoch, l.taskSeq.Add(1))
+}
+
+// traceTask completes a trace with the task's outcome and keeps it in memory
+// and the store. A nil result is a task whose result never arrived.
+func (l *LoadBalancer) traceTask(trace TaskTrace, result *Task) {
+	now := l.now()
+	trace.TotalMs = now.Sub(trace.SubmittedAt).Milliseconds()
+	switch {
+	case result == nil:
+		trace.Status = TaskStatusTimeout
+		trace.Reason = "No result"
+	default:
+		trace.Status = result.Status
+		trace.Reason = result.Reason
+		if result.serverID != 0 {
+			trace.ServerID = result.serverID
+		}
+	}
+
+	if result != nil && !result.startedAt.IsZero() {
+		trace.QueueWaitMs = result.startedAt.Sub(trace.SubmittedAt).Milliseconds()
+		trace.ExecutionMs = result.finishedAt.Sub(result.startedAt).Milliseconds()
+		if server := l.GetServerByID(trace.ServerID); server != nil {
+			trace.GCDuringExecution = server.pausedBetween(result.startedAt, result.finishedAt)
+		}
+	}
+
+	l.tracesMu.Lock()
+	l.traces = append(l.traces, trace)
+	if len(l.traces) > maxTaskTraces {
+		l.traces = l.traces[len(l.traces)-maxTaskTraces:]
+	}
+	l.tracesMu.Unlock()
+
+	if l.store != nil {
+		if err := l.store.SaveTaskTrace(trace); err != nil {
+			l.logf("⚠️ Could not store trace of task %s: %v", trace.ID, err)
+		}
+	}
+}
+
+// TaskTrace returns the trace of a finished task, from memory or the store.
+// It returns nil without an error if the task is unknown or still running.
+func (l *LoadBalancer) TaskTrace(id string) (*TaskTrace, error) {
+	l.tracesMu.Lock()
+	for i := len(l.traces) - 1; i >= 0; i-- {
+		if l.traces[i].ID == id {
+			trace := l.traces[i]
+			l.tracesMu.Unlock()
+			return &trace, nil
+		}
+	}
+	l.tracesMu.Unlock()
+
+	if l.store == nil {
+		return nil, nil
+	}
+	return l.store.LoadTaskTrace(id)
+}
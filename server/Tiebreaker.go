@@ -0,0 +1,53 @@
+package server
+
+// Tiebreakers decide between servers an algorithm considers equally good
+const (
+	TiebreakOrder      = "order"      // Keep the algorithm's own order or random draw (default)
+	TiebreakConfidence = "confidence" // Prefer the most confident "no imminent MaGC" outlook, then the least loaded server
+	TiebreakPressure   = "pressure"   // Prefer the lowest GC pressure score, then the least loaded server
+)
+
+// IsValidTiebreaker reports whether name is a supported tiebreaker; empty means TiebreakOrder
+func IsValidTiebreaker(name string) bool {
+	return name == "" || name == TiebreakOrder || name == TiebreakConfidence || name == TiebreakPressure
+}
+
+// breaksTies reports whether the tiebreaker compares candidates instead of
+// keeping the algorithm's own choice
+func breaksTies(tiebreaker string) bool {
+	return tiebreaker == TiebreakConfidence || tiebreaker == TiebreakPressure
+}
+
+// gcOutlook is the forecast confidence that no MaGC lands within thresholdMs.
+// Servers without a valid forecast have no outlook and score 0.
+func (s *Server) gcOutlook(thresholdMs int64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timeToMaGC, ok := s.timeToMaGCLocked()
+	if !ok || timeToMaGC.Milliseconds() <= thresholdMs {
+		return 0
+	}
+	return s.LastMaGCForecast.Confidence
+}
+
+// breakTie returns the index of the candidate with the best GC outlook, or the
+// lowest GC pressure with TiebreakPressure, then the lowest memory utilization.
+// Remaining ties go to the earliest candidate, so callers pass candidates in
+// the algorithm's own preference order.
+func breakTie(candidates []*Server, thresholdMs int64, tiebreaker string) int {
+	best, bestOutlook, bestUtil := -1, 0.0, 0.0
+	for i, server := range candidates {
+		var outlook float64
+		if tiebreaker == TiebreakPressure {
+			outlook = 100 - server.GCPressure().Score
+		} else {
+			outlook = server.gcOutlook(thresholdMs)
+		}
+		util := server.MemoryUtilization()
+		if best < 0 || outlook > bestOutlook || (outlook == bestOutlook && util < bestUtil) {
+			best, bestOutlook, bestUtil = i, outlook, util
+		}
+	}
+	return best
+}
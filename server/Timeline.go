@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxTimelineEntries bounds the per-server log of family changes, forecasts,
+// selections and rejections; GC events and load balancer events have their own
+const maxTimelineEntries = 1000
+
+// Timeline entry kinds; load balancer events about the server keep their
+// event type, e.g. server_state or family_pinned
+const (
+	TimelineGCStarted   = "gc_started"
+	TimelineGCCompleted = "gc_completed"
+	TimelineFamily      = "family_changed"
+	TimelineForecast    = "forecast_updated"
+	TimelineSelected    = "selected"
+	TimelineRejected    = "rejected"
+	TimelineTimedOut    = "timed_out"
+	TimelineCancelled   = "cancelled" // A hedged attempt another attempt beat
+)
+
+// TimelineEntry is one thing that happened to a server
+type TimelineEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+}
+
+// ServerTimeline is one page of a server's chronological timeline
+type ServerTimeline struct {
+	ServerID   int             `json:"server_id"`
+	Total      int             `json:"total"` // Entries in the requested range
+	Offset     int             `json:"offset"`
+	Entries    []TimelineEntry `json:"entries"`
+	NextOffset *int            `json:"next_offset,omitempty"` // Offset of the next page, if any
+}
+
+// recordTimelineLocked appends an entry to the server's timeline; callers hold s.mu
+func (s *Server) recordTimelineLocked(kind string, format string, args ...interface{}) {
+	s.timeline = append(s.timeline, TimelineEntry{
+		Timestamp: s.now(),
+		Kind:      kind,
+		Message:   fmt.Sprintf(format, args...),
+	})
+	if len(s.timeline) > maxTimelineEntries {
+		s.timeline = s.timeline[len(s.timeline)-maxTimelineEntries:]
+	}
+}
+
+// Timeline merges the server's MaGCs, family changes, forecast updates,
+// selections, rejections and load balancer events between from and to (zero
+// leaves that end open) into one chronological feed, and returns limit
+// entries starting at offset
+func (l *LoadBalancer) Timeline(server *Server, from, to time.Time, offset, limit int) ServerTimeline {
+	server.mu.Lock()
+	entries := make([]TimelineEntry, 0, len(server.timeline)+2*len(server.GCEvents)+1)
+	entries = append(entries, server.timeline...)
+	for _, event := range server.GCEvents {
+		entries = append(entries,
+			TimelineEntry{Timestamp: event.StartTime, Kind: TimelineGCStarted, Message: "MaGC started"},
+			TimelineEntry{Timestamp: event.EndTime, Kind: TimelineGCCompleted, Message: fmt.Sprintf("MaGC completed after %dms", event.DurationMs)},
+		)
+	}
+	if server.isCollectingGCTasks {
+		entries = append(entries, TimelineEntry{Timestamp: server.gcStartedAt, Kind: TimelineGCStarted, Message: "MaGC started, in progress"})
+	}
+	server.mu.Unlock()
+
+	for _, event := range l.Events(0) {
+		if event.ServerID == server.ID {
+			entries = append(entries, TimelineEntry{Timestamp: event.Timestamp, Kind: event.Type, Message: event.Message})
+		}
+	}
+
+	inRange := entries[:0]
+	for _, entry := range entries {
+		if !from.IsZero() && entry.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && entry.Timestamp.After(to) {
+			continue
+		}
+		inRange = append(inRange, entry)
+	}
+	sort.SliceStable(inRange, func(i, j int) bool {
+		return inRange[i].Timestamp.Before(inRange[j].Timestamp)
+	})
+
+	timeline := ServerTimeline{
+		ServerID: server.ID,
+		Total:    len(inRange),
+		Offset:   offset,
+		Entries:  make([]TimelineEntry, 0),
+	}
+	if offset >= len(inRange) {
+		return timeline
+	}
+	end := min(offset+limit, len(inRange))
+	timeline.Entries = append(timeline.Entries, inRange[offset:end]...)
+	if end < len(inRange) {
+		timeline.NextOffset = &end
+	}
+	return timeline
+}
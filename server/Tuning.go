@@ -0,0 +1,119 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// minTuningGCs is how many MaGCs a calibration must observe across the
+	// fleet before its measurements replace the current policy's values
+	minTuningGCs = 2
+
+	// tuningHistoryCycles is how many MaGC cycles the history window should span
+	tuningHistoryCycles = 3
+	minHistoryWindow    = 10
+	maxHistoryWindow    = 100
+
+	// tuningThresholdStep rounds recommended thresholds up to a readable value
+	tuningThresholdStep = int64(100)
+
+	// unevenPauseRatio is how much more of its time one server may spend in
+	// MaGC pauses than another before capacity weighting is recommended
+	unevenPauseRatio = 2.0
+)
+
+// PolicyRecommendation is a policy tuned to GC behavior measured during a
+// calibration, with the reasoning behind each changed setting
+type PolicyRecommendation struct {
+	Policy      LoadBalancingPolicy `json:"policy"`
+	GCsObserved int                 `json:"gcs_observed"`
+	Reasons     []string            `json:"reasons"`
+}
+
+// RecommendPolicy derives a MaGC threshold, history window and algorithm from
+// per-server GC summaries covering a calibration workload and the median
+// task latency it saw. Other settings are kept from current. With too few MaGCs
+// observed the current values are kept and the reasons say so.
+func RecommendPolicy(current LoadBalancingPolicy, summaries []ServerGCSummary, taskLatency time.Duration) PolicyRecommendation {
+	recommendation := PolicyRecommendation{Policy: current}
+
+	forecasts, totalAbsErrorMs := 0, 0.0
+	for _, summary := range summaries {
+		recommendation.GCsObserved += summary.GCCount
+		forecasts += summary.ForecastsEvaluated
+		totalAbsErrorMs += summary.MeanAbsErrorMs * float64(summary.ForecastsEvaluated)
+	}
+	if recommendation.GCsObserved < minTuningGCs {
+		recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf(
+			"Only %d MaGCs observed, at least %d are needed; keeping the current settings (run a longer or heavier calibration)",
+			recommendation.GCsObserved, minTuningGCs))
+		return recommendation
+	}
+	recommendation.Policy.GCAware = true
+
+	// A task collides with a MaGC that starts before it completes, and
+	// forecasts are off by about their mean error either way
+	thresholdMs := taskLatency.Milliseconds()
+	if forecasts > 0 {
+		thresholdMs += int64(totalAbsErrorMs / float64(forecasts))
+	}
+	thresholdMs = (thresholdMs + tuningThresholdStep - 1) / tuningThresholdStep * tuningThresholdStep
+	recommendation.Policy.MaGCThreshold = min(max(thresholdMs, tuningThresholdStep), maxMaGCThreshold)
+	if forecasts > 0 {
+		recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf(
+			"MaGC threshold %dms: median task latency %dms plus mean forecast error %.0fms over %d forecasts",
+			recommendation.Policy.MaGCThreshold, taskLatency.Milliseconds(), totalAbsErrorMs/float64(forecasts), forecasts))
+	} else {
+		recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf(
+			"MaGC threshold %dms: median task latency %dms (no forecasts were scored yet)",
+			recommendation.Policy.MaGCThreshold, taskLatency.Milliseconds()))
+	}
+
+	// The history window spans a few MaGC cycles of snapshots
+	snapshots, cycles := 0, 0
+	for _, summary := range summaries {
+		if summary.GCCount > 0 {
+			snapshots += summary.SnapshotCount
+			cycles += summary.GCCount
+		}
+	}
+	perCycle := float64(snapshots) / float64(cycles)
+	recommendation.Policy.HistoryWindowSize = min(max(int(perCycle*tuningHistoryCycles+0.5), minHistoryWindow), maxHistoryWindow)
+	recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf(
+		"History window %d: about %.1f snapshots per MaGC cycle, covering %d cycles",
+		recommendation.Policy.HistoryWindowSize, perCycle, tuningHistoryCycles))
+
+	// Capacity weighting pays off when some servers pause much more than
+	// others; a single MaGC says too little about a server's share to compare
+	least, most, compared := 0.0, 0.0, 0
+	for _, summary := range summaries {
+		window := summary.WindowEnd.Sub(summary.WindowStart).Milliseconds()
+		if summary.GCCount < minTuningGCs || window <= 0 {
+			continue
+		}
+		share := float64(summary.TotalPauseMs) / float64(window)
+		if compared == 0 || share < least {
+			least = share
+		}
+		most = max(most, share)
+		compared++
+	}
+	switch {
+	case compared < 2:
+		recommendation.Policy.Algorithm = "RR"
+		recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf(
+			"Algorithm RR: fewer than 2 servers had %d MaGCs to compare their pause time by", minTuningGCs))
+	case most >= least*unevenPauseRatio:
+		recommendation.Policy.Algorithm = "WRR"
+		recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf(
+			"Algorithm WRR: servers spent between %.1f%% and %.1f%% of the calibration in MaGC pauses; capacity weights send less to the smaller servers that collect most",
+			least*100, most*100))
+	default:
+		recommendation.Policy.Algorithm = "RR"
+		recommendation.Reasons = append(recommendation.Reasons, fmt.Sprintf(
+			"Algorithm RR: servers spent %.1f%%-%.1f%% of the calibration in MaGC pauses, within %gx of each other",
+			least*100, most*100, unevenPauseRatio))
+	}
+	return recommendation
+}
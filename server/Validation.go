@@ -0,0 +1,168 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+)
+
+// maxMaGCThreshold bounds how far ahead a policy may avoid predicted MaGCs
+const maxMaGCThreshold = int64(60000)
+
+// MaxTaskTimeoutMs bounds a task's execution timeout to the 5s the HTTP API
+// waits for a task, so the server gives up on a task no later than its client
+const MaxTaskTimeoutMs = int64(5000)
+
+// maxNoServerWaitMs bounds how long a task may wait or block for a server,
+// leaving it time to run before the HTTP API stops waiting at MaxTaskTimeoutMs
+const maxNoServerWaitMs = int64(3000)
+
+// validAlgorithms lists the load balancing algorithms a policy may use
+var validAlgorithms = []string{"RR", "RAN", "WRR", "WRAN", AlgorithmCost}
+
+// IsValidAlgorithm reports whether name is a supported load balancing algorithm
+func IsValidAlgorithm(name string) bool {
+	for _, algorithm := range validAlgorithms {
+		if algorithm == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the policy for unknown algorithms and unusable thresholds
+func (p LoadBalancingPolicy) Validate() error {
+	if !IsValidAlgorithm(p.Algorithm) {
+		return fmt.Errorf("invalid algorithm %q: use RR, RAN, WRR, WRAN or COST", p.Algorithm)
+	}
+	if p.MaGCThreshold < 0 || p.MaGCThreshold > maxMaGCThreshold {
+		return fmt.Errorf("magc_threshold_ms must be between 0 and %d", maxMaGCThreshold)
+	}
+	if p.GCAware && p.MaGCThreshold == 0 {
+		return errors.New("magc_threshold_ms must be positive for GC-aware policies")
+	}
+	if p.HistoryWindowSize < 0 {
+		return errors.New("history_window_size cannot be negative")
+	}
+	if p.GCImminentHeaderMs < 0 || p.GCImminentHeaderMs > maxMaGCThreshold {
+		return fmt.Errorf("gc_imminent_header_ms must be between 0 and %d", maxMaGCThreshold)
+	}
+	if !IsValidTiebreaker(p.Tiebreaker) {
+		return fmt.Errorf("invalid tiebreaker %q: use order, confidence or pressure", p.Tiebreaker)
+	}
+	if !IsValidGCAvoidance(p.GCAvoidance) {
+		return fmt.Errorf("invalid gc_avoidance %q: use hard or soft", p.GCAvoidance)
+	}
+	if p.ConfidenceFloor < 0 || p.ConfidenceFloor > 1 {
+		return errors.New("confidence_floor must be between 0 and 1")
+	}
+	if p.TaskTimeoutMs < 0 || p.TaskTimeoutMs > MaxTaskTimeoutMs {
+		return fmt.Errorf("task_timeout_ms must be between 0 and %d", MaxTaskTimeoutMs)
+	}
+	if !IsValidNoServerBehavior(p.OnNoServer) {
+		return fmt.Errorf("invalid on_no_server %q: use fail_fast, wait or block", p.OnNoServer)
+	}
+	if p.NoServerWaitMs < 0 || p.NoServerWaitMs > maxNoServerWaitMs {
+		return fmt.Errorf("no_server_wait_ms must be between 0 and %d", maxNoServerWaitMs)
+	}
+	if p.BlockCapacity < 0 {
+		return errors.New("block_capacity cannot be negative")
+	}
+	return nil
+}
+
+// validateServerConfig checks a memory limit and GC trigger fraction (0.0-1.0)
+func validateServerConfig(memLimit int, gcFraction float64) error {
+	if memLimit <= 0 {
+		return fmt.Errorf("memory limit must be positive, got %d", memLimit)
+	}
+	if gcFraction <= 0 || gcFraction > 1 {
+		return fmt.Errorf("GC percentage must be between 0 and 100, got %.1f", gcFraction*100)
+	}
+	return nil
+}
+
+// Validate checks the server's memory and GC configuration
+func (s *Server) Validate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ID <= 0 {
+		return fmt.Errorf("server ID must be positive, got %d", s.ID)
+	}
+	if err := validateServerConfig(s.memLimit, s.gcPercentage); err != nil {
+		return fmt.Errorf("server %d: %w", s.ID, err)
+	}
+	return nil
+}
+
+// Validate checks TRINI's intervals and program family policies
+func (t *TRINI) Validate() error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var errs []error
+	if t.MonitorInterval <= 0 {
+		errs = append(errs, errors.New("TRINI monitor interval must be positive"))
+	}
+	if t.AnalysisInterval <= 0 {
+		errs = append(errs, errors.New("TRINI analysis interval must be positive"))
+	}
+	if t.AnalysisInterval > 0 && t.AnalysisInterval < t.MonitorInterval {
+		errs = append(errs, fmt.Errorf("TRINI analysis interval (%s) must not be shorter than the monitor interval (%s)",
+			t.AnalysisInterval, t.MonitorInterval))
+	}
+	if t.MinMonitorInterval != 0 || t.MaxMonitorInterval != 0 {
+		if t.MinMonitorInterval <= 0 || t.MinMonitorInterval > t.MonitorInterval || t.MaxMonitorInterval < t.MonitorInterval {
+			errs = append(errs, fmt.Errorf("TRINI monitor interval bounds (%s-%s) must include the monitor interval (%s)",
+				t.MinMonitorInterval, t.MaxMonitorInterval, t.MonitorInterval))
+		}
+		if t.AnalysisInterval > 0 && t.MaxMonitorInterval > t.AnalysisInterval {
+			errs = append(errs, fmt.Errorf("TRINI maximum monitor interval (%s) must not exceed the analysis interval (%s)",
+				t.MaxMonitorInterval, t.AnalysisInterval))
+		}
+	}
+	if t.DefaultFamily == nil {
+		errs = append(errs, errors.New("TRINI has no default program family"))
+	}
+	for id, family := range t.ProgramFamilies {
+		if err := family.Policy.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("program family %q: %w", id, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks the whole balancer configuration and reports every problem found
+func (l *LoadBalancer) Validate() error {
+	var errs []error
+
+	servers := l.ListServers()
+	if len(servers) == 0 {
+		errs = append(errs, errors.New("no servers configured"))
+	}
+	seen := make(map[int]bool)
+	for _, server := range servers {
+		if err := server.Validate(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if seen[server.ID] {
+			errs = append(errs, fmt.Errorf("duplicate server ID %d", server.ID))
+		}
+		seen[server.ID] = true
+	}
+
+	if err := l.GetLoadBalancingPolicy().Validate(); err != nil {
+		errs = append(errs, fmt.Errorf("policy: %w", err))
+	}
+	if l.TRINI != nil {
+		if err := l.TRINI.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid load balancer configuration: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WarmupConfig sends a server synthetic tasks after each MaGC, before it
+// rejoins rotation, so client tasks do not pay for its cold caches
+type WarmupConfig struct {
+	Tasks int           // Synthetic tasks run while out of rotation; 0 skips them
+	Ramp  time.Duration // Slow start after the warm-up tasks; 0 rejoins at full share
+}
+
+// Validate reports whether the warm-up config is usable
+func (c WarmupConfig) Validate() error {
+	if c.Tasks < 0 {
+		return fmt.Errorf("warm-up tasks must not be negative, got %d", c.Tasks)
+	}
+	if c.Ramp < 0 {
+		return fmt.Errorf("warm-up ramp must not be negative, got %v", c.Ramp)
+	}
+	return nil
+}
+
+// enabled reports whether MaGCs are followed by a warm-up
+func (c WarmupConfig) enabled() bool {
+	return c.Tasks > 0 || c.Ramp > 0
+}
+
+// warmupConfig returns the configured warm-up; nil-safe for detached servers
+func (l *LoadBalancer) warmupConfig() WarmupConfig {
+	if l == nil {
+		return WarmupConfig{}
+	}
+	return l.warmup
+}
+
+// isWarmingUp reports whether the server is running warm-up tasks after a MaGC
+func (s *Server) isWarmingUp() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.warmingUp
+}
+
+// warmUp runs the warm-up tasks on a server that has just finished a MaGC and
+// was kept out of rotation, then returns it to rotation with slow start. The
+// tasks stop early once ctx is done, so stopping the balancer does not wait
+// for them.
+func (s *Server) warmUp(ctx context.Context, config WarmupConfig) {
+	start := s.now()
+	s.logf("🔥 Server %d: running %d warm-up tasks before rejoining rotation", s.ID, config.Tasks)
+	done := 0
+	for ; done < config.Tasks; done++ {
+		// Same processing overhead and work as a client task, without
+		// allocating, storing or billing it
+		if !sleepCtx(ctx, 300*time.Millisecond) || !simulateTaskWork(ctx) {
+			break
+		}
+		hashSHA256(fmt.Sprintf("warmup-%d-%d", s.ID, done))
+	}
+
+	s.mu.Lock()
+	s.warmingUp = false
+	s.recordExecutionLocked(ExecutionTotals{WarmupTasks: int64(done)})
+	s.mu.Unlock()
+	if ctx.Err() != nil {
+		s.logf("Server %d: warm-up stopped after %d of %d tasks", s.ID, done, config.Tasks)
+		return
+	}
+	if config.Ramp > 0 {
+		s.startSlowStart(config.Ramp)
+	}
+
+	s.LoadBalancer.RecordEvent("warmup_completed", s.ID, "Server %d warmed up with %d tasks in %v, ramping over %v",
+		s.ID, config.Tasks, s.now().Sub(start).Round(time.Millisecond), config.Ramp)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	defaultCPUMultiplier = 1.0
+	maxCPUMultiplier     = 64.0
+	maxServerWeight      = 100
+
+	// weightMemoryUnit is the memory limit worth one weight point at a CPU multiplier of 1
+	weightMemoryUnit = 50
+)
+
+// ServerWeight reports how a server's weight for WRR/WRAN was determined
+type ServerWeight struct {
+	ServerID       int     `json:"server_id"`
+	Weight         int     `json:"weight"`
+	CapacityWeight int     `json:"capacity_weight"`
+	CPUMultiplier  float64 `json:"cpu_multiplier"`
+	Override       bool    `json:"override"` // Weight was set explicitly instead of derived from capacity
+}
+
+// capacityWeight derives a weight from the memory limit scaled by the CPU multiplier
+func capacityWeight(memLimit int, cpuMultiplier float64) int {
+	weight := int(math.Round(float64(memLimit) / weightMemoryUnit * cpuMultiplier))
+	if weight < 1 {
+		return 1
+	}
+	if weight > maxServerWeight {
+		return maxServerWeight
+	}
+	return weight
+}
+
+// refreshWeightLocked recomputes the capacity weight unless it was overridden; callers hold s.mu
+func (s *Server) refreshWeightLocked() {
+	if s.CPUMultiplier == 0 {
+		s.CPUMultiplier = defaultCPUMultiplier
+	}
+	if !s.weightOverride {
+		s.weight = capacityWeight(s.memLimit, s.CPUMultiplier)
+	}
+}
+
+// SetWeight overrides the capacity-derived weight used by weighted algorithms
+func (s *Server) SetWeight(weight int) error {
+	if weight < 1 || weight > maxServerWeight {
+		return fmt.Errorf("weight must be between 1 and %d", maxServerWeight)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weight = weight
+	s.weightOverride = true
+	s.Weights = weight
+	return nil
+}
+
+// SetCPUMultiplier sets the relative CPU capacity used to derive the weight
+func (s *Server) SetCPUMultiplier(multiplier float64) error {
+	if multiplier <= 0 || multiplier > maxCPUMultiplier {
+		return fmt.Errorf("cpu multiplier must be greater than 0 and at most %g", maxCPUMultiplier)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.CPUMultiplier = multiplier
+	s.refreshWeightLocked()
+	s.Weights = s.weight
+	return nil
+}
+
+// ResetWeight drops any override and derives the weight from capacity again
+func (s *Server) ResetWeight() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.weightOverride = false
+	s.refreshWeightLocked()
+	s.Weights = s.weight
+}
+
+// GetWeight returns the configured weight (not the WRR runtime budget)
+func (s *Server) GetWeight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.weight
+}
+
+// WeightInfo returns the server's weight configuration
+func (s *Server) WeightInfo() ServerWeight {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ServerWeight{
+		ServerID:       s.ID,
+		Weight:         s.weight,
+		CapacityWeight: capacityWeight(s.memLimit, s.CPUMultiplier),
+		CPUMultiplier:  s.CPUMultiplier,
+		Override:       s.weightOverride,
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"golang_lb/client"
+	"golang_lb/server"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTuneDuration and defaultTuneRate give a calibration enough tasks
+	// to fill the default servers' memory a few times over, at the autopilot's rate
+	defaultTuneDuration = 30 * time.Second
+	defaultTuneRate     = 2.0
+	maxTuneDuration     = 10 * time.Minute
+
+	// tuneDrainTimeout bounds how long a calibration waits for its last tasks;
+	// slower ones count as not completed
+	tuneDrainTimeout = 10 * time.Second
+
+	// Calibration tasks are sized like the autopilot's
+	minTuneTaskSize = 5
+	maxTuneTaskSize = 20
+)
+
+// tuneOptions controls the calibration workload and whether its recommendation is applied
+type tuneOptions struct {
+	duration time.Duration
+	rate     float64 // Tasks submitted per second
+	apply    bool
+}
+
+// calibration is what the calibration workload observed
+type calibration struct {
+	submitted int
+	completed int
+	started   time.Time
+	elapsed   time.Duration // From the first submission until every task finished or was given up on
+	latency   time.Duration // Median latency of the completed tasks, so those stalled by a MaGC do not skew it
+}
+
+// parseTune reads tune's [--duration 30s] [--rate 2] [--apply] options
+func parseTune(args []string) (tuneOptions, error) {
+	var opts tuneOptions
+	fs := flag.NewFlagSet("tune", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.DurationVar(&opts.duration, "duration", defaultTuneDuration, "How long to run the calibration workload")
+	fs.Float64Var(&opts.rate, "rate", defaultTuneRate, "Calibration tasks per second")
+	fs.BoolVar(&opts.apply, "apply", false, "Apply the recommended policy")
+	if err := fs.Parse(args); err != nil {
+		return opts, fmt.Errorf("usage: tune [--duration 30s] [--rate 2] [--apply]")
+	}
+
+	if opts.duration <= 0 || opts.duration > maxTuneDuration {
+		return opts, fmt.Errorf("invalid duration %v: use up to %v", opts.duration, maxTuneDuration)
+	}
+	if opts.rate <= 0 || opts.rate > 1000 {
+		return opts, fmt.Errorf("invalid rate %g: use more than 0, up to 1000 tasks per second", opts.rate)
+	}
+	return opts, nil
+}
+
+// calibrate submits randomly sized tasks at opts.rate for opts.duration, each
+// from its own goroutine, and waits up to tuneDrainTimeout for them to finish.
+// submit returns an error for a task that did not complete, or whose ctx was
+// cancelled first.
+func calibrate(ctx context.Context, opts tuneOptions, submit func(ctx context.Context, input string) error) (calibration, error) {
+	var (
+		result    calibration
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies []time.Duration
+	)
+
+	taskCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result.started = time.Now()
+	deadline := result.started.Add(opts.duration)
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.rate))
+	defer ticker.Stop()
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return result, errors.New("calibration interrupted")
+		case <-ticker.C:
+		}
+
+		size := minTuneTaskSize + rand.Intn(maxTuneTaskSize-minTuneTaskSize+1)
+		input := make([]byte, size)
+		for i := range input {
+			input[i] = byte('a' + rand.Intn(26))
+		}
+
+		result.submitted++
+		wg.Add(1)
+		go func(input string) {
+			defer wg.Done()
+			submitted := time.Now()
+			if err := submit(taskCtx, input); err != nil {
+				return
+			}
+			mu.Lock()
+			latencies = append(latencies, time.Since(submitted))
+			mu.Unlock()
+		}(string(input))
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(tuneDrainTimeout):
+		cancel()
+		<-finished
+	}
+
+	result.elapsed = time.Since(result.started)
+	result.completed = len(latencies)
+	if result.completed > 0 {
+		slices.Sort(latencies)
+		result.latency = latencies[result.completed/2]
+	}
+	return result, nil
+}
+
+// printTuneReport shows what the calibration measured per server and the
+// recommended policy next to the current one
+func printTuneReport(w io.Writer, result calibration, summaries []server.ServerGCSummary, current server.LoadBalancingPolicy, recommendation server.PolicyRecommendation) {
+	fmt.Fprintf(w, "\n🎛️  Calibration: %d/%d tasks completed in %v, median latency %dms\n",
+		result.completed, result.submitted, result.elapsed.Round(time.Second), result.latency.Milliseconds())
+	for _, summary := range summaries {
+		fmt.Fprintf(w, "   Server %d: %d MaGCs (%.1f/min), avg pause %.0fms, max %dms",
+			summary.ServerID, summary.GCCount, summary.GCPerMinute, summary.AvgPauseMs, summary.MaxPauseMs)
+		if summary.ForecastsEvaluated > 0 {
+			fmt.Fprintf(w, ", forecasts %d/%d accurate", summary.ForecastsAccurate, summary.ForecastsEvaluated)
+		}
+		fmt.Fprintln(w)
+	}
+
+	policy := recommendation.Policy
+	fmt.Fprintln(w, "\n🔧 Recommended Policy (current → recommended):")
+	fmt.Fprintf(w, "   Algorithm: %s → %s\n", current.Algorithm, policy.Algorithm)
+	fmt.Fprintf(w, "   GC-Aware: %t → %t\n", current.GCAware, policy.GCAware)
+	fmt.Fprintf(w, "   MaGC Threshold: %dms → %dms\n", current.MaGCThreshold, policy.MaGCThreshold)
+	fmt.Fprintf(w, "   History Window: %d → %d\n", current.HistoryWindowSize, policy.HistoryWindowSize)
+	for _, reason := range recommendation.Reasons {
+		fmt.Fprintf(w, "   • %s\n", reason)
+	}
+}
+
+// applyRecommendation sets the recommended policy through set unless it
+// matches the current one, and reports what happened
+func applyRecommendation(current server.LoadBalancingPolicy, recommendation server.PolicyRecommendation, apply bool, set func(server.LoadBalancingPolicy) error) error {
+	switch {
+	case recommendation.Policy == current:
+		fmt.Println("✅ The current policy already matches the recommendation")
+	case !apply:
+		fmt.Println("💡 Run tune again with --apply to apply it")
+	default:
+		if err := recommendation.Policy.Validate(); err != nil {
+			return fmt.Errorf("invalid recommended policy: %w", err)
+		}
+		if err := set(recommendation.Policy); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Policy set to %s (threshold %dms, history window %d)\n",
+			recommendation.Policy.Algorithm, recommendation.Policy.MaGCThreshold, recommendation.Policy.HistoryWindowSize)
+	}
+	return nil
+}
+
+// handleTune calibrates the in-process load balancer and recommends a policy
+func handleTune(lb *server.LoadBalancer, args []string) {
+	opts, err := parseTune(args)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	fmt.Printf("🎛️  Calibrating for %v at %g tasks/s...\n", opts.duration, opts.rate)
+	result, err := calibrate(context.Background(), opts, func(ctx context.Context, input string) error {
+		srv, response := lb.Dispatch(input)
+		if srv == nil {
+			return errors.New("no available server")
+		}
+		select {
+		case task := <-response.ResultChan:
+			if task == nil || task.Status != "completed" {
+				return errors.New("task not completed")
+			}
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+
+	summaries, err := lb.CompareServers(nil, time.Since(result.started))
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	current := lb.GetLoadBalancingPolicy()
+	recommendation := server.RecommendPolicy(current, summaries, result.latency)
+	printTuneReport(os.Stdout, result, summaries, current, recommendation)
+	err = applyRecommendation(current, recommendation, opts.apply, func(policy server.LoadBalancingPolicy) error {
+		lb.SetLoadBalancingPolicy(policy)
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+	}
+}
+
+// runClientTune calibrates a remote backend server and recommends a policy
+func runClientTune(ctx context.Context, c *client.Client, args []string) error {
+	opts, err := parseTune(args)
+	if err != nil {
+		return err
+	}
+	status, err := c.TRINIStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("🎛️  Calibrating for %v at %g tasks/s...\n", opts.duration, opts.rate)
+	result, err := calibrate(ctx, opts, func(ctx context.Context, input string) error {
+		response, err := c.SubmitTask(ctx, input)
+		if err != nil {
+			return err
+		}
+		if response.Status != client.TaskCompleted {
+			return errors.New(response.Message)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Slack keeps the first tasks in the window should the request wait on a busy server
+	summaries, err := c.CompareServers(ctx, time.Since(result.started)+tuneDrainTimeout)
+	if err != nil {
+		return err
+	}
+	current := policyFromInfo(status.CurrentPolicy)
+	recommendation := server.RecommendPolicy(current, summaries, result.latency)
+	printTuneReport(os.Stdout, result, summaries, current, recommendation)
+	return applyRecommendation(current, recommendation, opts.apply, func(policy server.LoadBalancingPolicy) error {
+		return c.SetPolicy(ctx, policy)
+	})
+}
+
+// policyFromInfo rebuilds a policy from what TRINI status reports of it
+func policyFromInfo(info client.PolicySummary) server.LoadBalancingPolicy {
+	return server.LoadBalancingPolicy{
+		Algorithm:          info.Algorithm,
+		GCAware:            info.GCAware,
+		MaGCThreshold:      info.MaGCThreshold,
+		HistoryWindowSize:  info.HistoryWindow,
+		GCImminentHeaderMs: info.GCImminentHeaderMs,
+		Tiebreaker:         info.Tiebreaker,
+		GCAvoidance:        info.GCAvoidance,
+		ConfidenceFloor:    info.ConfidenceFloor,
+		TaskTimeoutMs:      info.TaskTimeoutMs,
+		OnNoServer:         info.OnNoServer,
+		NoServerWaitMs:     info.NoServerWaitMs,
+		BlockCapacity:      info.BlockCapacity,
+	}
+}
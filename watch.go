@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultWatchInterval is how often --watch re-renders without a duration
+	defaultWatchInterval = time.Second
+
+	// minWatchInterval keeps --watch from hammering the load balancer
+	minWatchInterval = 100 * time.Millisecond
+
+	clearScreen   = "\033[H\033[2J"
+	highlightLine = "\033[1;33m" // Bold yellow for lines that changed since the last render
+	resetStyle    = "\033[0m"
+)
+
+// parseWatch removes a --watch [interval] (or -watch, --watch=interval)
+// option from args and reports whether it was present and how often to
+// re-render
+func parseWatch(args []string) ([]string, time.Duration, bool, error) {
+	rest := make([]string, 0, len(args))
+	interval, watching := defaultWatchInterval, false
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--watch" && name != "-watch" {
+			rest = append(rest, args[i])
+			continue
+		}
+
+		watching = true
+		if !hasValue && i+1 < len(args) {
+			if _, err := time.ParseDuration(args[i+1]); err == nil {
+				value, hasValue = args[i+1], true
+				i++
+			}
+		}
+		if !hasValue {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < minWatchInterval {
+			return nil, 0, false, fmt.Errorf("invalid watch interval %q: use a duration of at least %v", value, minWatchInterval)
+		}
+		interval = parsed
+	}
+	return rest, interval, watching, nil
+}
+
+// watch clears the terminal and re-renders every interval until ctx is done,
+// highlighting lines that changed since the previous render. A render error is
+// shown in place of the output rather than ending the watch.
+func watch(ctx context.Context, title, hint string, interval time.Duration, render func(w io.Writer) error) {
+	var previous []string
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		var buf bytes.Buffer
+		if err := render(&buf); err != nil {
+			buf.Reset()
+			fmt.Fprintf(&buf, "❌ %v\n", err)
+		}
+		lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+		var screen strings.Builder
+		screen.WriteString(clearScreen)
+		fmt.Fprintf(&screen, "Every %v: %s    %s\n%s\n\n", interval, title, time.Now().Format(time.TimeOnly), hint)
+		for i, line := range lines {
+			if previous != nil && (i >= len(previous) || previous[i] != line) {
+				line = highlightLine + line + resetStyle
+			}
+			screen.WriteString(line + "\n")
+		}
+		os.Stdout.WriteString(screen.String())
+		previous = lines
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}